@@ -357,4 +357,28 @@ func (s *testRegionCacheSuite) TestPriorityQueue(c *C) {
 	c.Assert(pq.items, HasLen, 0)
 	c.Assert(pq.Peek(), IsNil)
 	c.Assert(pq.Tail(), IsNil)
+
+	// case5 shrink the queue, and the lowest elements should be removed
+	pq = NewPriorityQueue(3)
+	pq.Put(1, testData[1])
+	pq.Put(2, testData[2])
+	pq.Put(3, testData[3])
+	removed := pq.SetCapacity(1)
+	c.Assert(removed, HasLen, 2)
+	c.Assert(removed[0].Value, Equals, testData[3])
+	c.Assert(removed[1].Value, Equals, testData[2])
+	c.Assert(pq.Len(), Equals, 1)
+	c.Assert(pq.Peek().Value, Equals, testData[1])
+	c.Assert(pq.Put(2, testData[2]), IsFalse)
+	c.Assert(pq.SetCapacity(2), HasLen, 0)
+	c.Assert(pq.Put(2, testData[2]), IsTrue)
+
+	// case6 the elements with the same priority are all kept
+	pq = NewPriorityQueue(2)
+	c.Assert(pq.Put(1, testData[1]), IsTrue)
+	c.Assert(pq.Put(1, testData[2]), IsTrue)
+	c.Assert(pq.Put(1, testData[3]), IsFalse)
+	c.Assert(pq.Len(), Equals, 2)
+	c.Assert(pq.Get(1), NotNil)
+	c.Assert(pq.Get(2), NotNil)
 }
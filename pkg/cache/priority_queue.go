@@ -49,12 +49,13 @@ func (pq *PriorityQueue) Put(priority int, value PriorityQueueItem) bool {
 	if !ok {
 		entry = &Entry{Priority: priority, Value: value}
 		if pq.Len() >= pq.capacity {
-			min := pq.btree.Min()
-			// avoid to capacity equal 0
-			if min == nil || !min.Less(entry) {
+			min, _ := pq.btree.Min().(*Entry)
+			// avoid to capacity equal 0, and keep the existing entry if the
+			// priorities are the same
+			if min == nil || min.Priority <= priority {
 				return false
 			}
-			pq.Remove(min.(*Entry).Value.ID())
+			pq.Remove(min.Value.ID())
 		}
 	} else {
 		// delete before update
@@ -68,6 +69,18 @@ func (pq *PriorityQueue) Put(priority int, value PriorityQueueItem) bool {
 	return true
 }
 
+// SetCapacity changes the capacity of the queue, and removes the entries with
+// the lowest priority if the queue holds more entries. It returns the removed entries.
+func (pq *PriorityQueue) SetCapacity(capacity int) (removed []*Entry) {
+	pq.capacity = capacity
+	for pq.Len() > pq.capacity {
+		min := pq.btree.Min().(*Entry)
+		pq.Remove(min.Value.ID())
+		removed = append(removed, min)
+	}
+	return
+}
+
 // Get find entry by id from queue
 func (pq *PriorityQueue) Get(id uint64) *Entry {
 	return pq.items[id]
@@ -120,9 +133,13 @@ type Entry struct {
 	Value    PriorityQueueItem
 }
 
-// Less return true if the entry has smaller priority
+// Less return true if the entry has smaller priority. The entries with the
+// same priority are ordered by ID, so that they are all kept in the btree.
 func (r *Entry) Less(other btree.Item) bool {
 	left := r.Priority
 	right := other.(*Entry).Priority
+	if left == right {
+		return r.Value.ID() > other.(*Entry).Value.ID()
+	}
 	return left > right
 }
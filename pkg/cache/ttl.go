@@ -39,6 +39,8 @@ type ttlCache struct {
 }
 
 // NewTTL returns a new TTL cache.
+// If gcInterval is not positive, no GC goroutine is started, and the expired
+// items are only invisible until they are overwritten or removed.
 func newTTL(ctx context.Context, gcInterval time.Duration, duration time.Duration) *ttlCache {
 	c := &ttlCache{
 		ctx:        ctx,
@@ -47,7 +49,9 @@ func newTTL(ctx context.Context, gcInterval time.Duration, duration time.Duratio
 		gcInterval: gcInterval,
 	}
 
-	go c.doGC()
+	if gcInterval > 0 {
+		go c.doGC()
+	}
 	return c
 }
 
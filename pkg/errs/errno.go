@@ -81,6 +81,7 @@ var (
 	ErrSchedulerNotFound                = errors.Normalize("scheduler not found", errors.RFCCodeText("PD:scheduler:ErrSchedulerNotFound"))
 	ErrScheduleConfigNotExist           = errors.Normalize("the config does not exist", errors.RFCCodeText("PD:scheduler:ErrScheduleConfigNotExist"))
 	ErrSchedulerConfig                  = errors.Normalize("wrong scheduler config %s", errors.RFCCodeText("PD:scheduler:ErrSchedulerConfig"))
+	ErrSchedulerExclusive               = errors.Normalize("scheduler can not run together with %s", errors.RFCCodeText("PD:scheduler:ErrSchedulerExclusive"))
 	ErrCacheOverflow                    = errors.Normalize("cache overflow", errors.RFCCodeText("PD:scheduler:ErrCacheOverflow"))
 	ErrInternalGrowth                   = errors.Normalize("unknown interval growth type error", errors.RFCCodeText("PD:scheduler:ErrInternalGrowth"))
 	ErrSchedulerCreateFuncNotRegistered = errors.Normalize("create func of %v is not registered", errors.RFCCodeText("PD:scheduler:ErrSchedulerCreateFuncNotRegistered"))
@@ -88,7 +89,10 @@ var (
 
 // checker errors
 var (
-	ErrCheckerNotFound = errors.Normalize("checker not found", errors.RFCCodeText("PD:checker:ErrCheckerNotFound"))
+	ErrCheckerNotFound       = errors.Normalize("checker not found", errors.RFCCodeText("PD:checker:ErrCheckerNotFound"))
+	ErrCheckerRegionNotFound = errors.Normalize("region %v not found", errors.RFCCodeText("PD:checker:ErrCheckerRegionNotFound"))
+	ErrCheckerCannotDisable  = errors.Normalize("checker %v cannot be disabled", errors.RFCCodeText("PD:checker:ErrCheckerCannotDisable"))
+	ErrCheckerTraceRunning   = errors.Normalize("decision trace is already running", errors.RFCCodeText("PD:checker:ErrCheckerTraceRunning"))
 )
 
 // placement errors
@@ -26,21 +26,184 @@ func (mc *Cluster) SetMaxMergeRegionSize(v int) {
 	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.MaxMergeRegionSize = uint64(v) })
 }
 
+// SetRegionSplitSize updates the RegionSplitSize configuration.
+func (mc *Cluster) SetRegionSplitSize(v int) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.RegionSplitSize = uint64(v) })
+}
+
 // SetMaxMergeRegionKeys updates the MaxMergeRegionKeys configuration.
 func (mc *Cluster) SetMaxMergeRegionKeys(v int) {
 	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.MaxMergeRegionKeys = uint64(v) })
 }
 
+// SetRegionOperatorHistorySize updates the RegionOperatorHistorySize configuration.
+func (mc *Cluster) SetRegionOperatorHistorySize(v int) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.RegionOperatorHistorySize = uint64(v) })
+}
+
+// SetEmergencyUnderReplicatedRatio updates the EmergencyUnderReplicatedRatio configuration.
+func (mc *Cluster) SetEmergencyUnderReplicatedRatio(v float64) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.EmergencyUnderReplicatedRatio = v })
+}
+
+// SetEmergencyReplicaLimitMultiplier updates the EmergencyReplicaLimitMultiplier configuration.
+func (mc *Cluster) SetEmergencyReplicaLimitMultiplier(v float64) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.EmergencyReplicaLimitMultiplier = v })
+}
+
+// SetPriorityRangeReservedRatio updates the PriorityRangeReservedRatio configuration.
+func (mc *Cluster) SetPriorityRangeReservedRatio(v float64) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.PriorityRangeReservedRatio = v })
+}
+
+// SetMergeTargetRegionsPerStore updates the MergeTargetRegionsPerStore configuration.
+func (mc *Cluster) SetMergeTargetRegionsPerStore(v uint64) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.MergeTargetRegionsPerStore = v })
+}
+
+// SetMaxMergeScheduleLimit updates the MaxMergeScheduleLimit configuration.
+func (mc *Cluster) SetMaxMergeScheduleLimit(v uint64) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.MaxMergeScheduleLimit = v })
+}
+
+// SetEnableWaitingListPersistence updates the EnableWaitingListPersistence configuration.
+func (mc *Cluster) SetEnableWaitingListPersistence(v bool) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.EnableWaitingListPersistence = v })
+}
+
+// SetStoreBudgetShare updates the StoreBudgetShare configuration.
+func (mc *Cluster) SetStoreBudgetShare(v float64) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.StoreBudgetShare = v })
+}
+
+// SetEnableQuerySplit updates the EnableQuerySplit configuration.
+func (mc *Cluster) SetEnableQuerySplit(v bool) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.EnableQuerySplit = v })
+}
+
+// SetQuerySplitThreshold updates the QuerySplitThreshold configuration.
+func (mc *Cluster) SetQuerySplitThreshold(v float64) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.QuerySplitThreshold = v })
+}
+
+// SetQuerySplitMinKeys updates the QuerySplitMinKeys configuration.
+func (mc *Cluster) SetQuerySplitMinKeys(v uint64) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.QuerySplitMinKeys = v })
+}
+
+// SetMaxSplitsPerScan updates the MaxSplitsPerScan configuration.
+func (mc *Cluster) SetMaxSplitsPerScan(v uint64) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.MaxSplitsPerScan = v })
+}
+
+// SetMaxMergeFanin updates the MaxMergeFanin configuration.
+func (mc *Cluster) SetMaxMergeFanin(v int) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.MaxMergeFanin = uint64(v) })
+}
+
 // SetSplitMergeInterval updates the SplitMergeInterval configuration.
 func (mc *Cluster) SetSplitMergeInterval(v time.Duration) {
 	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.SplitMergeInterval = typeutil.NewDuration(v) })
 }
 
+// SetRegionWaitingListSize updates the RegionWaitingListSize configuration.
+func (mc *Cluster) SetRegionWaitingListSize(v int) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.RegionWaitingListSize = uint64(v) })
+}
+
+// SetPriorityQueueSize updates the PriorityQueueSize configuration.
+func (mc *Cluster) SetPriorityQueueSize(v int) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.PriorityQueueSize = uint64(v) })
+}
+
+// SetHotRegionRollingWindowSize updates the HotRegionRollingWindowSize configuration.
+func (mc *Cluster) SetHotRegionRollingWindowSize(v int) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.HotRegionRollingWindowSize = uint64(v) })
+}
+
+// SetHotRegionTransitionHysteresis updates the HotRegionTransitionHysteresis configuration.
+func (mc *Cluster) SetHotRegionTransitionHysteresis(v time.Duration) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.HotRegionTransitionHysteresis = typeutil.NewDuration(v) })
+}
+
+// SetCheckerSnapshotRate updates the CheckerSnapshotRate configuration.
+func (mc *Cluster) SetCheckerSnapshotRate(v float64) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.CheckerSnapshotRate = v })
+}
+
+// SetMaxWaitingRegionBackoff updates the MaxWaitingRegionBackoff configuration.
+func (mc *Cluster) SetMaxWaitingRegionBackoff(v time.Duration) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.MaxWaitingRegionBackoff = typeutil.NewDuration(v) })
+}
+
+// SetMergeSplitCooldown updates the MergeSplitCooldown configuration.
+func (mc *Cluster) SetMergeSplitCooldown(v time.Duration) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.MergeSplitCooldown = typeutil.NewDuration(v) })
+}
+
+// SetSlowStoreThresholds updates the SlowStoreEvictThreshold and SlowStoreRecoverThreshold configurations.
+func (mc *Cluster) SetSlowStoreThresholds(evict, recover uint64) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) {
+		s.SlowStoreEvictThreshold = evict
+		s.SlowStoreRecoverThreshold = recover
+	})
+}
+
+// SetSlowStoreRecoveryDuration updates the SlowStoreRecoveryDuration configuration.
+func (mc *Cluster) SetSlowStoreRecoveryDuration(v time.Duration) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.SlowStoreRecoveryDuration = typeutil.NewDuration(v) })
+}
+
+// SetCheckRegionPolicy updates the CheckRegionPolicy configuration.
+func (mc *Cluster) SetCheckRegionPolicy(v string) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.CheckRegionPolicy = v })
+}
+
+// SetSkipCheckersLabel updates the SkipCheckersLabel configuration.
+func (mc *Cluster) SetSkipCheckersLabel(v string) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.SkipCheckersLabel = v })
+}
+
+// SetMergeDirectionPolicy updates the MergeDirectionPolicy configuration.
+func (mc *Cluster) SetMergeDirectionPolicy(v string) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.MergeDirectionPolicy = v })
+}
+
 // SetEnableOneWayMerge updates the EnableOneWayMerge configuration.
 func (mc *Cluster) SetEnableOneWayMerge(v bool) {
 	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.EnableOneWayMerge = v })
 }
 
+// SetEnableReadOnlyCluster updates the EnableReadOnlyCluster configuration.
+func (mc *Cluster) SetEnableReadOnlyCluster(v bool) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.EnableReadOnlyCluster = v })
+}
+
+// SetReadOnlyAllowDuplicatePeer updates the ReadOnlyAllowDuplicatePeer configuration.
+func (mc *Cluster) SetReadOnlyAllowDuplicatePeer(v bool) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.ReadOnlyAllowDuplicatePeer = v })
+}
+
+// SetReadOnlyAllowJointState updates the ReadOnlyAllowJointState configuration.
+func (mc *Cluster) SetReadOnlyAllowJointState(v bool) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.ReadOnlyAllowJointState = v })
+}
+
+// SetEnableAllPeersDownRecovery updates the EnableAllPeersDownRecovery configuration.
+func (mc *Cluster) SetEnableAllPeersDownRecovery(v bool) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.EnableAllPeersDownRecovery = v })
+}
+
+// SetEnableEmptyRegionMergePriority updates the EnableEmptyRegionMergePriority configuration.
+func (mc *Cluster) SetEnableEmptyRegionMergePriority(v bool) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.EnableEmptyRegionMergePriority = v })
+}
+
+// SetEnableHotRegionMergeGuard updates the EnableHotRegionMergeGuard configuration.
+func (mc *Cluster) SetEnableHotRegionMergeGuard(v bool) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.EnableHotRegionMergeGuard = v })
+}
+
 // SetMaxSnapshotCount updates the MaxSnapshotCount configuration.
 func (mc *Cluster) SetMaxSnapshotCount(v int) {
 	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.MaxSnapshotCount = uint64(v) })
@@ -61,6 +224,46 @@ func (mc *Cluster) SetEnableLocationReplacement(v bool) {
 	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.EnableLocationReplacement = v })
 }
 
+// SetEnableWeightedReplicaScheduling updates the EnableWeightedReplicaScheduling configuration.
+func (mc *Cluster) SetEnableWeightedReplicaScheduling(v bool) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.EnableWeightedReplicaScheduling = v })
+}
+
+// SetDownPeerGracePeriod updates the DownPeerGracePeriod configuration.
+func (mc *Cluster) SetDownPeerGracePeriod(v time.Duration) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.DownPeerGracePeriod = typeutil.NewDuration(v) })
+}
+
+// SetCheckerOperatorDedupTTL updates the CheckerOperatorDedupTTL configuration.
+func (mc *Cluster) SetCheckerOperatorDedupTTL(v time.Duration) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.CheckerOperatorDedupTTL = typeutil.NewDuration(v) })
+}
+
+// SetPlacementRulesRampDuration updates the PlacementRulesRampDuration configuration.
+func (mc *Cluster) SetPlacementRulesRampDuration(v time.Duration) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.PlacementRulesRampDuration = typeutil.NewDuration(v) })
+}
+
+// SetMaxRuleLearnersPerRegion updates the MaxRuleLearnersPerRegion configuration.
+func (mc *Cluster) SetMaxRuleLearnersPerRegion(v int) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.MaxRuleLearnersPerRegion = uint64(v) })
+}
+
+// SetReplicaMinFreeSpace updates the ReplicaMinFreeSpace configuration.
+func (mc *Cluster) SetReplicaMinFreeSpace(v uint64) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.ReplicaMinFreeSpace = typeutil.ByteSize(v) })
+}
+
+// SetReplicaMinFreeRatio updates the ReplicaMinFreeRatio configuration.
+func (mc *Cluster) SetReplicaMinFreeRatio(v float64) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.ReplicaMinFreeRatio = v })
+}
+
+// SetLearnerCatchUpDuration updates the LearnerCatchUpDuration configuration.
+func (mc *Cluster) SetLearnerCatchUpDuration(v time.Duration) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.LearnerCatchUpDuration = typeutil.NewDuration(v) })
+}
+
 // SetEnableRemoveDownReplica updates the EnableRemoveDownReplica configuration.
 func (mc *Cluster) SetEnableRemoveDownReplica(v bool) {
 	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.EnableRemoveDownReplica = v })
@@ -96,6 +299,11 @@ func (mc *Cluster) SetRegionScheduleLimit(v int) {
 	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.RegionScheduleLimit = uint64(v) })
 }
 
+// SetReplicaScheduleLimit updates the ReplicaScheduleLimit configuration.
+func (mc *Cluster) SetReplicaScheduleLimit(v int) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.ReplicaScheduleLimit = uint64(v) })
+}
+
 // SetMergeScheduleLimit updates the MergeScheduleLimit configuration.
 func (mc *Cluster) SetMergeScheduleLimit(v int) {
 	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.MergeScheduleLimit = uint64(v) })
@@ -61,7 +61,7 @@ func NewCluster(ctx context.Context, opts *config.PersistOptions) *Cluster {
 	clus := &Cluster{
 		BasicCluster:     core.NewBasicCluster(),
 		IDAllocator:      mockid.NewIDAllocator(),
-		HotStat:          statistics.NewHotStat(ctx, mockQuit),
+		HotStat:          statistics.NewHotStat(ctx, mockQuit, opts),
 		PersistOptions:   opts,
 		suspectRegions:   map[uint64]struct{}{},
 		disabledFeatures: make(map[versioninfo.Feature]struct{}),
@@ -116,6 +116,11 @@ func (mc *Cluster) IsRegionHot(region *core.RegionInfo) bool {
 	return mc.HotCache.IsRegionHot(region, mc.GetHotRegionCacheHitsThreshold())
 }
 
+// HasHotPeer returns true if any peer of the region is hot.
+func (mc *Cluster) HasHotPeer(region *core.RegionInfo) bool {
+	return mc.HotCache.IsRegionHot(region, 1)
+}
+
 // RegionReadStats returns hot region's read stats.
 // The result only includes peers that are hot enough.
 func (mc *Cluster) RegionReadStats() map[uint64][]*statistics.HotPeerStat {
@@ -129,6 +134,11 @@ func (mc *Cluster) RegionWriteStats() map[uint64][]*statistics.HotPeerStat {
 	return mc.HotCache.RegionStats(statistics.WriteFlow, mc.GetHotRegionCacheHitsThreshold())
 }
 
+// AllRegionWriteStats returns the write stats of all peers in the hot cache.
+func (mc *Cluster) AllRegionWriteStats() map[uint64][]*statistics.HotPeerStat {
+	return mc.HotCache.RegionStats(statistics.WriteFlow, 0)
+}
+
 // HotRegionsFromStore picks hot regions in specify store.
 func (mc *Cluster) HotRegionsFromStore(store uint64, kind statistics.FlowKind) []*core.RegionInfo {
 	stats := mc.HotCache.HotRegionsFromStore(store, kind, mc.GetHotRegionCacheHitsThreshold())
@@ -67,6 +67,34 @@ func (r *MedianFilter) Set(n float64) {
 	r.count = 1
 }
 
+// Resize changes the window size. The most recent data points which fit in
+// the new window are kept, so that the median doesn't change abruptly.
+func (r *MedianFilter) Resize(size int) {
+	if size <= 0 || uint64(size) == r.size {
+		return
+	}
+	kept := r.Records()
+	if len(kept) > size {
+		kept = kept[len(kept)-size:]
+	}
+	records := make([]float64, size)
+	copy(records, kept)
+	r.records, r.size, r.count = records, uint64(size), uint64(len(kept))
+}
+
+// Records returns the data points in the window, the oldest first.
+func (r *MedianFilter) Records() []float64 {
+	kept := r.count
+	if kept > r.size {
+		kept = r.size
+	}
+	records := make([]float64, kept)
+	for i := uint64(0); i < kept; i++ {
+		records[i] = r.records[(r.count-kept+i)%r.size]
+	}
+	return records
+}
+
 // GetInstantaneous returns the value just added.
 func (r *MedianFilter) GetInstantaneous() float64 {
 	return r.instantaneous
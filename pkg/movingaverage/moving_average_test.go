@@ -91,6 +91,48 @@ func (t *testMovingAvg) TestMedianFilter(c *C) {
 	checkSet(c, mf, data, expected)
 }
 
+func (t *testMovingAvg) TestMedianFilterResize(c *C) {
+	mf := NewMedianFilter(5)
+	for _, x := range []float64{1, 2, 3, 100, 200, 300, 400} {
+		mf.Add(x)
+	}
+	// The window holds 3, 100, 200, 300, 400.
+	c.Assert(mf.Get(), Equals, 200.0)
+
+	// Shrinking keeps the most recent data points.
+	mf.Resize(3)
+	c.Assert(mf.Get(), Equals, 300.0)
+	mf.Add(0)
+	c.Assert(mf.Get(), Equals, 300.0)
+
+	// Enlarging keeps all data points and makes room for more.
+	mf.Resize(5)
+	c.Assert(mf.Get(), Equals, 300.0)
+	mf.Add(0)
+	mf.Add(0)
+	c.Assert(mf.Get(), Equals, 0.0)
+
+	// Resizing a filter with fewer data points than the window keeps all of them.
+	mf = NewMedianFilter(5)
+	mf.Add(1)
+	mf.Add(3)
+	mf.Resize(10)
+	c.Assert(mf.Get(), Equals, 2.0)
+}
+
+func (t *testMovingAvg) TestMedianFilterRecords(c *C) {
+	mf := NewMedianFilter(3)
+	c.Assert(mf.Records(), HasLen, 0)
+	mf.Add(1)
+	mf.Add(2)
+	c.Assert(mf.Records(), DeepEquals, []float64{1, 2})
+	mf.Add(3)
+	mf.Add(4)
+	c.Assert(mf.Records(), DeepEquals, []float64{2, 3, 4})
+	mf.Set(5)
+	c.Assert(mf.Records(), DeepEquals, []float64{5})
+}
+
 type testCase struct {
 	ma       MovingAvg
 	expected []float64
@@ -57,6 +57,20 @@ func (t *TimeMedian) Set(avg float64) {
 	t.mf.Set(avg)
 }
 
+// Resize changes the size of the median filter, keeping the recent averages.
+func (t *TimeMedian) Resize(mfSize int) {
+	if mfSize <= 0 || mfSize == t.mfSize {
+		return
+	}
+	t.mf.Resize(mfSize)
+	t.mfSize = mfSize
+}
+
+// Records returns the averages of the recent periods in the window, the oldest first.
+func (t *TimeMedian) Records() []float64 {
+	return t.mf.Records()
+}
+
 // GetFilledPeriod returns filled period.
 func (t *TimeMedian) GetFilledPeriod() int { // it is unrelated with mfSize
 	return t.aotSize
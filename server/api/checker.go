@@ -15,11 +15,17 @@
 package api
 
 import (
+	"bytes"
+	"encoding/hex"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/tikv/pd/pkg/apiutil"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
 	"github.com/unrolled/render"
 )
 
@@ -53,6 +59,10 @@ func (c *checkerHandler) PauseOrResume(w http.ResponseWriter, r *http.Request) {
 	}
 
 	name := mux.Vars(r)["name"]
+	if _, err := schedule.ParseCheckerType(name); err != nil {
+		c.r.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	t, ok := input["delay"]
 	if !ok {
 		c.r.JSON(w, http.StatusBadRequest, "missing pause time")
@@ -79,10 +89,15 @@ func (c *checkerHandler) PauseOrResume(w http.ResponseWriter, r *http.Request) {
 // @Param name path string true "The name of the scheduler."
 // @Produce json
 // @Success 200 {string} string "Pause or resume the scheduler successfully."
+// @Failure 400 {string} string "The checker is not found."
 // @Failure 500 {string} string "PD server failed to proceed the request."
 // @Router /checker/{name} [get]
 func (c *checkerHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
 	name := mux.Vars(r)["name"]
+	if _, err := schedule.ParseCheckerType(name); err != nil {
+		c.r.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	isPaused, err := c.IsCheckerPaused(name)
 	if err != nil {
 		c.r.JSON(w, http.StatusInternalServerError, err.Error())
@@ -93,3 +108,259 @@ func (c *checkerHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
 	}
 	c.r.JSON(w, http.StatusOK, output)
 }
+
+// @Tags checker
+// @Summary List all checkers and whether they are enabled or paused.
+// @Produce json
+// @Success 200 {array} schedule.CheckerStatus
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /checkers [get]
+func (c *checkerHandler) List(w http.ResponseWriter, r *http.Request) {
+	status, err := c.ListCheckers()
+	if err != nil {
+		c.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.r.JSON(w, http.StatusOK, status)
+}
+
+// @Tags checker
+// @Summary Get the pause and enabled states of all checkers, which can be restored later.
+// @Produce json
+// @Success 200 {object} schedule.CheckersState
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /checkers/state [get]
+func (c *checkerHandler) ExportState(w http.ResponseWriter, r *http.Request) {
+	state, err := c.ExportCheckerState()
+	if err != nil {
+		c.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.r.JSON(w, http.StatusOK, state)
+}
+
+// @Tags checker
+// @Summary Restore the pause and enabled states of the checkers. The checkers whose pause has expired are resumed.
+// @Accept json
+// @Param body body schedule.CheckersState true "The states of the checkers."
+// @Produce json
+// @Success 200 {string} string "Restore the checker states successfully."
+// @Failure 400 {string} string "Bad format request."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /checkers/state [post]
+func (c *checkerHandler) ImportState(w http.ResponseWriter, r *http.Request) {
+	var state schedule.CheckersState
+	if err := apiutil.ReadJSONRespondError(c.r, w, r.Body, &state); err != nil {
+		return
+	}
+	if err := state.Validate(); err != nil {
+		c.r.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := c.ImportCheckerState(&state); err != nil {
+		c.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.r.JSON(w, http.StatusOK, "Restore the checker states successfully.")
+}
+
+// @Tags checker
+// @Summary List the regions in the waiting list of the checkers, the longest waiting first.
+// @Produce json
+// @Success 200 {array} schedule.WaitingRegionInfo
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /checkers/waiting-regions [get]
+func (c *checkerHandler) ListWaitingRegions(w http.ResponseWriter, r *http.Request) {
+	infos, err := c.GetWaitingRegionInfos()
+	if err != nil {
+		c.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.r.JSON(w, http.StatusOK, infos)
+}
+
+// priorityRange is a priority key range of the checkers, whose keys are in hex format.
+type priorityRange struct {
+	StartKey string `json:"start-key"`
+	EndKey   string `json:"end-key"`
+}
+
+// @Tags checker
+// @Summary List the priority key ranges of the checkers.
+// @Produce json
+// @Success 200 {array} priorityRange
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /checkers/priority-ranges [get]
+func (c *checkerHandler) GetPriorityRanges(w http.ResponseWriter, r *http.Request) {
+	ranges, err := c.Handler.GetPriorityRanges()
+	if err != nil {
+		c.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	output := make([]priorityRange, 0, len(ranges))
+	for _, kr := range ranges {
+		output = append(output, priorityRange{
+			StartKey: hex.EncodeToString(kr.StartKey),
+			EndKey:   hex.EncodeToString(kr.EndKey),
+		})
+	}
+	c.r.JSON(w, http.StatusOK, output)
+}
+
+// @Tags checker
+// @Summary Replace the priority key ranges of the checkers. The regions overlapping them are checked and fixed first.
+// @Accept json
+// @Param body body []priorityRange true "The key ranges in hex format, an empty array clears them."
+// @Produce json
+// @Success 200 {string} string "Set the priority key ranges successfully."
+// @Failure 400 {string} string "Bad format request."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /checkers/priority-ranges [post]
+func (c *checkerHandler) SetPriorityRanges(w http.ResponseWriter, r *http.Request) {
+	var input []priorityRange
+	if err := apiutil.ReadJSONRespondError(c.r, w, r.Body, &input); err != nil {
+		return
+	}
+	ranges := make([]core.KeyRange, 0, len(input))
+	for _, pr := range input {
+		startKey, err := hex.DecodeString(pr.StartKey)
+		if err != nil {
+			c.r.JSON(w, http.StatusBadRequest, "start-key is not in hex format")
+			return
+		}
+		endKey, err := hex.DecodeString(pr.EndKey)
+		if err != nil {
+			c.r.JSON(w, http.StatusBadRequest, "end-key is not in hex format")
+			return
+		}
+		if len(endKey) > 0 && bytes.Compare(startKey, endKey) >= 0 {
+			c.r.JSON(w, http.StatusBadRequest, "start-key should be less than end-key")
+			return
+		}
+		ranges = append(ranges, core.KeyRange{StartKey: startKey, EndKey: endKey})
+	}
+	if err := c.Handler.SetPriorityRanges(ranges); err != nil {
+		c.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.r.JSON(w, http.StatusOK, "Set the priority key ranges successfully.")
+}
+
+// lowChurnWindow is the low-churn window declared by an external job.
+type lowChurnWindow struct {
+	// Duration is the length of the window from now, such as "30m". A
+	// non-positive duration ends the window.
+	Duration string     `json:"duration,omitempty"`
+	Active   bool       `json:"active"`
+	Until    *time.Time `json:"until,omitempty"`
+}
+
+// @Tags checker
+// @Summary Get the low-churn window, in which only the urgent operators are produced.
+// @Produce json
+// @Success 200 {object} lowChurnWindow
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /checkers/low-churn-window [get]
+func (c *checkerHandler) GetLowChurnWindow(w http.ResponseWriter, r *http.Request) {
+	until, err := c.Handler.GetLowChurnWindow()
+	if err != nil {
+		c.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	output := lowChurnWindow{}
+	if !until.IsZero() {
+		output.Active, output.Until = true, &until
+	}
+	c.r.JSON(w, http.StatusOK, output)
+}
+
+// @Tags checker
+// @Summary Declare a low-churn window from now, in which the merge and balance operators are not produced while the unhealthy regions are still fixed. The window expires by itself.
+// @Accept json
+// @Param body body lowChurnWindow true "The duration of the window, such as 30m, a non-positive one ends the window."
+// @Produce json
+// @Success 200 {object} lowChurnWindow
+// @Failure 400 {string} string "Bad format request."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /checkers/low-churn-window [post]
+func (c *checkerHandler) SetLowChurnWindow(w http.ResponseWriter, r *http.Request) {
+	var input lowChurnWindow
+	if err := apiutil.ReadJSONRespondError(c.r, w, r.Body, &input); err != nil {
+		return
+	}
+	if input.Duration == "" {
+		c.r.JSON(w, http.StatusBadRequest, "missing duration")
+		return
+	}
+	d, err := time.ParseDuration(input.Duration)
+	if err != nil {
+		c.r.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := c.Handler.SetLowChurnWindow(d); err != nil {
+		c.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.GetLowChurnWindow(w, r)
+}
+
+// defaultDecisionTraceSeconds is how long the decision trace runs if it is not
+// given in the request.
+const defaultDecisionTraceSeconds = 60
+
+// flushWriter flushes every write to the client, so that the records of the
+// decision trace are streamed.
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if f, ok := fw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return n, err
+}
+
+// @Tags checker
+// @Summary Stream what the checkers decide about every region they check as newline delimited JSON.
+// @Param seconds query integer false "How long the trace runs." default(60)
+// @Param dry_run query bool false "Drop the operators created by the checkers." default(false)
+// @Produce json
+// @Success 200 {array} schedule.DecisionTraceRecord
+// @Failure 400 {string} string "Bad format request."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /checkers/trace [post]
+func (c *checkerHandler) TraceDecisions(w http.ResponseWriter, r *http.Request) {
+	seconds := defaultDecisionTraceSeconds
+	if secondsStr := r.URL.Query().Get("seconds"); secondsStr != "" {
+		var err error
+		seconds, err = strconv.Atoi(secondsStr)
+		if err != nil || seconds <= 0 {
+			c.r.JSON(w, http.StatusBadRequest, "seconds should be a positive integer")
+			return
+		}
+	}
+	var dryRun bool
+	if dryRunStr := r.URL.Query().Get("dry_run"); dryRunStr != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			c.r.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	if err := c.Handler.StartDecisionTrace(flushWriter{w: w}, dryRun); err != nil {
+		c.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	timer := time.NewTimer(time.Duration(seconds) * time.Second)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-r.Context().Done():
+	}
+	// The response is written by the checkers until the trace stops.
+	c.Handler.StopDecisionTrace()
+}
@@ -22,6 +22,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/schedule"
 )
 
 var _ = Suite(&testCheckerSuite{})
@@ -61,11 +62,143 @@ func (s *testCheckerSuite) TestAPI(c *C) {
 		{name: "merge"},
 		{name: "joint-state"},
 		{name: "priority"},
+		{name: "duplicate-peer"},
+		{name: "all-peers-down"},
 	}
 	for _, ca := range cases {
 		s.testGetStatus(ca.name, c)
 		s.testPauseOrResume(ca.name, c)
 	}
+	s.testList(len(cases), c)
+	s.testWaitingRegions(c)
+	s.testPriorityRanges(c)
+	s.testLowChurnWindow(c)
+	s.testDecisionTrace(c)
+	s.testExportImportState(c)
+}
+
+func (s *testCheckerSuite) testList(count int, c *C) {
+	handler := s.svr.GetHandler()
+	url := fmt.Sprintf("%s%s/api/v1/checkers", s.svr.GetAddr(), apiPrefix)
+
+	err := handler.PauseOrResumeChecker("merge", 30)
+	c.Assert(err, IsNil)
+	var status []schedule.CheckerStatus
+	err = readJSON(testDialClient, url, &status)
+	c.Assert(err, IsNil)
+	c.Assert(status, HasLen, count)
+	for _, st := range status {
+		c.Assert(st.Paused, Equals, st.Name == "merge")
+	}
+	err = handler.PauseOrResumeChecker("merge", 0)
+	c.Assert(err, IsNil)
+}
+
+func (s *testCheckerSuite) testWaitingRegions(c *C) {
+	url := fmt.Sprintf("%s%s/api/v1/checkers/waiting-regions", s.svr.GetAddr(), apiPrefix)
+	var infos []*schedule.WaitingRegionInfo
+	err := readJSON(testDialClient, url, &infos)
+	c.Assert(err, IsNil)
+	c.Assert(infos, HasLen, 0)
+}
+
+func (s *testCheckerSuite) testPriorityRanges(c *C) {
+	url := fmt.Sprintf("%s%s/api/v1/checkers/priority-ranges", s.svr.GetAddr(), apiPrefix)
+	var ranges []priorityRange
+	err := readJSON(testDialClient, url, &ranges)
+	c.Assert(err, IsNil)
+	c.Assert(ranges, HasLen, 0)
+
+	input := []priorityRange{{StartKey: "7461", EndKey: "7462"}, {StartKey: "7463", EndKey: ""}}
+	data, err := json.Marshal(input)
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, url, data)
+	c.Assert(err, IsNil)
+	err = readJSON(testDialClient, url, &ranges)
+	c.Assert(err, IsNil)
+	c.Assert(ranges, DeepEquals, input)
+
+	// bad keys are rejected and the ranges are kept.
+	for _, bad := range [][]priorityRange{
+		{{StartKey: "zz", EndKey: ""}},
+		{{StartKey: "7462", EndKey: "7461"}},
+	} {
+		data, err = json.Marshal(bad)
+		c.Assert(err, IsNil)
+		err = postJSON(testDialClient, url, data)
+		c.Assert(err, NotNil)
+	}
+	err = readJSON(testDialClient, url, &ranges)
+	c.Assert(err, IsNil)
+	c.Assert(ranges, DeepEquals, input)
+
+	// an empty array clears the ranges.
+	err = postJSON(testDialClient, url, []byte("[]"))
+	c.Assert(err, IsNil)
+	err = readJSON(testDialClient, url, &ranges)
+	c.Assert(err, IsNil)
+	c.Assert(ranges, HasLen, 0)
+}
+
+func (s *testCheckerSuite) testLowChurnWindow(c *C) {
+	url := fmt.Sprintf("%s%s/api/v1/checkers/low-churn-window", s.svr.GetAddr(), apiPrefix)
+	var window lowChurnWindow
+	err := readJSON(testDialClient, url, &window)
+	c.Assert(err, IsNil)
+	c.Assert(window.Active, IsFalse)
+	c.Assert(window.Until, IsNil)
+
+	err = postJSON(testDialClient, url, []byte(`{"duration": "10m"}`))
+	c.Assert(err, IsNil)
+	err = readJSON(testDialClient, url, &window)
+	c.Assert(err, IsNil)
+	c.Assert(window.Active, IsTrue)
+	c.Assert(window.Until.After(time.Now().Add(9*time.Minute)), IsTrue)
+
+	// bad durations are rejected.
+	for _, bad := range []string{`{}`, `{"duration": "ten"}`} {
+		err = postJSON(testDialClient, url, []byte(bad))
+		c.Assert(err, NotNil)
+	}
+
+	// a zero duration ends the window.
+	err = postJSON(testDialClient, url, []byte(`{"duration": "0s"}`))
+	c.Assert(err, IsNil)
+	window = lowChurnWindow{}
+	err = readJSON(testDialClient, url, &window)
+	c.Assert(err, IsNil)
+	c.Assert(window.Active, IsFalse)
+}
+
+func (s *testCheckerSuite) testExportImportState(c *C) {
+	handler := s.svr.GetHandler()
+	url := fmt.Sprintf("%s%s/api/v1/checkers/state", s.svr.GetAddr(), apiPrefix)
+	c.Assert(handler.PauseOrResumeChecker("split", 60), IsNil)
+	var saved schedule.CheckersState
+	err := readJSON(testDialClient, url, &saved)
+	c.Assert(err, IsNil)
+	c.Assert(saved.Checkers, HasLen, 9)
+	data, err := json.Marshal(saved)
+	c.Assert(err, IsNil)
+
+	c.Assert(handler.PauseOrResumeChecker("split", 0), IsNil)
+	c.Assert(handler.PauseOrResumeChecker("merge", 60), IsNil)
+	err = postJSON(testDialClient, url, data)
+	c.Assert(err, IsNil)
+	var state schedule.CheckersState
+	err = readJSON(testDialClient, url, &state)
+	c.Assert(err, IsNil)
+	c.Assert(state, DeepEquals, saved)
+	paused, err := handler.IsCheckerPaused("split")
+	c.Assert(err, IsNil)
+	c.Assert(paused, IsTrue)
+
+	// The invalid states are rejected.
+	err = postJSON(testDialClient, url, []byte(`{"checkers":[{"name":"joint-state","enabled":false}]}`))
+	c.Assert(err, NotNil)
+	err = postJSON(testDialClient, url, []byte(`{"checkers":[{"name":"unknown","enabled":true}]}`))
+	c.Assert(err, NotNil)
+	c.Assert(handler.PauseOrResumeChecker("split", 0), IsNil)
 }
 
 func (s *testCheckerSuite) testErrCases(c *C) {
@@ -95,6 +228,9 @@ func (s *testCheckerSuite) testErrCases(c *C) {
 	c.Assert(err, IsNil)
 	err = postJSON(testDialClient, s.urlPrefix+"/"+name, pauseArgs)
 	c.Assert(err, NotNil)
+	resp := make(map[string]interface{})
+	err = readJSON(testDialClient, s.urlPrefix+"/"+name, &resp)
+	c.Assert(err, NotNil)
 }
 
 func (s *testCheckerSuite) testGetStatus(name string, c *C) {
@@ -161,3 +297,13 @@ func (s *testCheckerSuite) testPauseOrResume(name string, c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(isPaused, IsFalse)
 }
+
+func (s *testCheckerSuite) testDecisionTrace(c *C) {
+	url := fmt.Sprintf("%s%s/api/v1/checkers/trace", s.svr.GetAddr(), apiPrefix)
+	for _, query := range []string{"?seconds=0", "?seconds=1&dry_run=maybe"} {
+		c.Assert(postJSON(testDialClient, url+query, nil), NotNil)
+	}
+	c.Assert(postJSON(testDialClient, url+"?seconds=1&dry_run=true", nil), IsNil)
+	// The trace stops once the request finishes.
+	c.Assert(postJSON(testDialClient, url+"?seconds=1", nil), IsNil)
+}
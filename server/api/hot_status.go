@@ -40,6 +40,7 @@ type HotStoreStats struct {
 	KeysReadStats   map[uint64]float64 `json:"keys-read-rate,omitempty"`
 	QueryWriteStats map[uint64]float64 `json:"query-write-rate,omitempty"`
 	QueryReadStats  map[uint64]float64 `json:"query-read-rate,omitempty"`
+	QueryStats      map[uint64]float64 `json:"query-rate,omitempty"`
 }
 
 // HistoryHotRegionsRequest wrap request condition from tidb.
@@ -147,6 +148,7 @@ func (h *hotStatusHandler) GetHotStores(w http.ResponseWriter, r *http.Request)
 		KeysReadStats:   make(map[uint64]float64),
 		QueryWriteStats: make(map[uint64]float64),
 		QueryReadStats:  make(map[uint64]float64),
+		QueryStats:      make(map[uint64]float64),
 	}
 	stores, _ := h.GetStores()
 	storesLoads := h.GetStoresLoads()
@@ -164,6 +166,7 @@ func (h *hotStatusHandler) GetHotStores(w http.ResponseWriter, r *http.Request)
 			stats.KeysReadStats[id] = loads[statistics.StoreReadKeys]
 			stats.QueryWriteStats[id] = loads[statistics.StoreWriteQuery]
 			stats.QueryReadStats[id] = loads[statistics.StoreReadQuery]
+			stats.QueryStats[id] = loads[statistics.StoreReadQuery] + loads[statistics.StoreWriteQuery]
 		}
 	}
 	h.rd.JSON(w, http.StatusOK, stats)
@@ -70,6 +70,15 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	checkerHandler := newCheckerHandler(svr, rd)
 	apiRouter.HandleFunc("/checker/{name}", checkerHandler.PauseOrResume).Methods("POST")
 	apiRouter.HandleFunc("/checker/{name}", checkerHandler.GetStatus).Methods("GET")
+	apiRouter.HandleFunc("/checkers", checkerHandler.List).Methods("GET")
+	apiRouter.HandleFunc("/checkers/state", checkerHandler.ExportState).Methods("GET")
+	apiRouter.HandleFunc("/checkers/state", checkerHandler.ImportState).Methods("POST")
+	apiRouter.HandleFunc("/checkers/waiting-regions", checkerHandler.ListWaitingRegions).Methods("GET")
+	apiRouter.HandleFunc("/checkers/priority-ranges", checkerHandler.GetPriorityRanges).Methods("GET")
+	apiRouter.HandleFunc("/checkers/priority-ranges", checkerHandler.SetPriorityRanges).Methods("POST")
+	apiRouter.HandleFunc("/checkers/low-churn-window", checkerHandler.GetLowChurnWindow).Methods("GET")
+	apiRouter.HandleFunc("/checkers/low-churn-window", checkerHandler.SetLowChurnWindow).Methods("POST")
+	apiRouter.HandleFunc("/checkers/trace", checkerHandler.TraceDecisions).Methods("POST")
 
 	schedulerHandler := newSchedulerHandler(svr, rd)
 	apiRouter.HandleFunc("/schedulers", schedulerHandler.List).Methods("GET")
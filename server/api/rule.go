@@ -420,6 +420,7 @@ func (h *ruleHandler) GetAllGroupBundles(w http.ResponseWriter, r *http.Request)
 // @Tags rule
 // @Summary Update all rules and groups configuration.
 // @Param partial query bool false "if partially update rules" default(false)
+// @Param simulate query bool false "if only estimate the effect of the rules on the regions without updating them" default(false)
 // @Produce json
 // @Success 200 {string} string "Update rules and groups successfully."
 // @Failure 400 {string} string "The input is invalid."
@@ -437,6 +438,20 @@ func (h *ruleHandler) SetAllGroupBundles(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	_, partial := r.URL.Query()["partial"]
+	if _, simulate := r.URL.Query()["simulate"]; simulate {
+		cluster.GetRuleManager().SetKeyType(h.svr.GetConfig().PDServerCfg.KeyType)
+		simulation, err := cluster.SimulatePlacementRules(groups, !partial)
+		if err != nil {
+			if errs.ErrRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) {
+				h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			} else {
+				h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+		h.rd.JSON(w, http.StatusOK, simulation)
+		return
+	}
 	if err := cluster.GetRuleManager().SetKeyType(h.svr.GetConfig().PDServerCfg.KeyType).
 		SetAllGroupBundles(groups, !partial); err != nil {
 		if errs.ErrRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) {
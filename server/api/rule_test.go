@@ -24,6 +24,7 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/schedule/checker"
 	"github.com/tikv/pd/server/schedule/placement"
 )
 
@@ -818,6 +819,44 @@ func (s *testRuleSuite) TestBundleBadRequest(c *C) {
 	}
 }
 
+func (s *testRuleSuite) TestSimulateBundles(c *C) {
+	b1 := placement.GroupBundle{
+		ID: "pd",
+		Rules: []*placement.Rule{
+			{GroupID: "pd", ID: "default", Role: "voter", Count: 3},
+		},
+	}
+	b2 := placement.GroupBundle{
+		ID: "foo",
+		Rules: []*placement.Rule{
+			{GroupID: "foo", ID: "bar", Role: "learner", Count: 1},
+		},
+	}
+	data, err := json.Marshal([]placement.GroupBundle{b2})
+	c.Assert(err, IsNil)
+	var simulation checker.RuleSimulation
+	err = postJSON(testDialClient, s.urlPrefix+"/placement-rule?simulate=true&partial=true", data, func(res []byte, code int) {
+		c.Assert(code, Equals, http.StatusOK)
+		c.Assert(json.Unmarshal(res, &simulation), IsNil)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(simulation.Regions, Equals, s.svr.GetRaftCluster().GetRegionCount())
+
+	// The rules are unchanged.
+	var bundles []placement.GroupBundle
+	err = readJSON(testDialClient, s.urlPrefix+"/placement-rule", &bundles)
+	c.Assert(err, IsNil)
+	c.Assert(bundles, HasLen, 1)
+	compareBundle(c, bundles[0], b1)
+
+	// The invalid rules are rejected.
+	b2.Rules[0].Count = 0
+	data, err = json.Marshal([]placement.GroupBundle{b2})
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, s.urlPrefix+"/placement-rule?simulate=true", data)
+	c.Assert(err, NotNil)
+}
+
 func compareBundle(c *C, b1, b2 placement.GroupBundle) {
 	c.Assert(b1.ID, Equals, b2.ID)
 	c.Assert(b1.Index, Equals, b2.Index)
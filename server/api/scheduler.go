@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pingcap/errors"
@@ -134,6 +135,16 @@ func (h *schedulerHandler) Post(w http.ResponseWriter, r *http.Request) {
 			h.r.JSON(w, http.StatusInternalServerError, err.Error())
 			return
 		}
+	case schedulers.BalanceRegionSizeName:
+		if err := h.AddBalanceRegionSizeScheduler(); err != nil {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	case schedulers.BalanceLearnerName:
+		if err := h.AddBalanceLearnerScheduler(); err != nil {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
 	case schedulers.LabelName:
 		if err := h.AddLabelScheduler(); err != nil {
 			h.r.JSON(w, http.StatusInternalServerError, err.Error())
@@ -164,6 +175,26 @@ func (h *schedulerHandler) Post(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+	case schedulers.ColocateLeaderName:
+		var args []string
+		collector := func(v string) {
+			args = append(args, v)
+		}
+		// the scheduler works on the whole key space without a range.
+		if _, ok := input["start_key"]; ok {
+			if err := collectEscapeStringOption("start_key", input, collector); err != nil {
+				h.r.JSON(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			if err := collectEscapeStringOption("end_key", input, collector); err != nil {
+				h.r.JSON(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+		if err := h.AddColocateLeaderScheduler(args...); err != nil {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
 	case schedulers.GrantLeaderName:
 		h.addEvictOrGrant(w, input, schedulers.GrantLeaderName)
 	case schedulers.EvictLeaderName:
@@ -193,11 +224,49 @@ func (h *schedulerHandler) Post(w http.ResponseWriter, r *http.Request) {
 			h.r.JSON(w, http.StatusInternalServerError, err.Error())
 			return
 		}
+	case schedulers.ReadQueryHotName:
+		margin := 0.1
+		m, ok := input["margin"].(float64)
+		if ok {
+			margin = m
+		}
+		if err := h.AddReadQueryHotScheduler(margin); err != nil {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	case schedulers.BalanceZoneRegionName:
+		labelKey, _ := input["label-key"].(string)
+		if err := h.AddBalanceZoneRegionScheduler(labelKey); err != nil {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	case schedulers.PreventiveSplitName:
+		lowRatio, highRatio := 0.8, 1.5
+		if r, ok := input["low-ratio"].(float64); ok {
+			lowRatio = r
+		}
+		if r, ok := input["high-ratio"].(float64); ok {
+			highRatio = r
+		}
+		if err := h.AddPreventiveSplitScheduler(lowRatio, highRatio); err != nil {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
 	case schedulers.EvictSlowStoreName:
 		if err := h.AddEvictSlowStoreScheduler(); err != nil {
 			h.r.JSON(w, http.StatusInternalServerError, err.Error())
 			return
 		}
+	case schedulers.DrainStoreName:
+		storeID, ok := input["store_id"].(float64)
+		if !ok {
+			h.r.JSON(w, http.StatusBadRequest, "missing store id")
+			return
+		}
+		if err := h.AddDrainStoreScheduler(uint64(storeID)); err != nil {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
 	default:
 		h.r.JSON(w, http.StatusBadRequest, "unknown scheduler")
 		return
@@ -206,10 +275,13 @@ func (h *schedulerHandler) Post(w http.ResponseWriter, r *http.Request) {
 	h.r.JSON(w, http.StatusOK, "The scheduler is created.")
 }
 
-func (h *schedulerHandler) redirectSchedulerUpdate(name string, storeID float64) error {
+func (h *schedulerHandler) redirectSchedulerUpdate(name string, storeID float64, timeout string) error {
 	input := make(map[string]interface{})
 	input["name"] = name
 	input["store_id"] = storeID
+	if timeout != "" {
+		input["timeout"] = timeout
+	}
 	updateURL := fmt.Sprintf("%s/%s/%s/config", h.GetAddr(), schedulerConfigPrefix, name)
 	body, err := json.Marshal(input)
 	if err != nil {
@@ -224,6 +296,16 @@ func (h *schedulerHandler) addEvictOrGrant(w http.ResponseWriter, input map[stri
 		h.r.JSON(w, http.StatusBadRequest, "missing store id")
 		return
 	}
+	// Only the evict-leader-scheduler supports to stop evicting a store after a timeout.
+	var timeout time.Duration
+	timeoutStr, _ := input["timeout"].(string)
+	if timeoutStr != "" {
+		var err error
+		if timeout, err = time.ParseDuration(timeoutStr); name != schedulers.EvictLeaderName || err != nil || timeout <= 0 {
+			h.r.JSON(w, http.StatusBadRequest, "invalid timeout")
+			return
+		}
+	}
 	if exist, err := h.Handler.IsSchedulerExisted(name); !exist {
 		if err != nil && !errors.ErrorEqual(err, errs.ErrSchedulerNotFound.FastGenByArgs()) {
 			h.r.JSON(w, http.StatusInternalServerError, err.Error())
@@ -231,7 +313,7 @@ func (h *schedulerHandler) addEvictOrGrant(w http.ResponseWriter, input map[stri
 		}
 		switch name {
 		case schedulers.EvictLeaderName:
-			err = h.AddEvictLeaderScheduler(uint64(storeID))
+			err = h.AddEvictLeaderScheduler(uint64(storeID), timeout)
 		case schedulers.GrantLeaderName:
 			err = h.AddGrantLeaderScheduler(uint64(storeID))
 		}
@@ -240,7 +322,7 @@ func (h *schedulerHandler) addEvictOrGrant(w http.ResponseWriter, input map[stri
 			return
 		}
 	} else {
-		if err := h.redirectSchedulerUpdate(name, storeID); err != nil {
+		if err := h.redirectSchedulerUpdate(name, storeID, timeoutStr); err != nil {
 			h.r.JSON(w, http.StatusInternalServerError, err.Error())
 			return
 		}
@@ -107,6 +107,55 @@ func (s *testScheduleSuite) TestOriginAPI(c *C) {
 	c.Assert(r.StatusCode, Equals, 404)
 }
 
+func (s *testScheduleSuite) TestInvalidTimeout(c *C) {
+	for _, input := range []map[string]interface{}{
+		{"name": "evict-leader-scheduler", "store_id": 1, "timeout": "1x"},
+		{"name": "evict-leader-scheduler", "store_id": 1, "timeout": "-1m"},
+		// Only the evict-leader-scheduler supports the timeout.
+		{"name": "grant-leader-scheduler", "store_id": 1, "timeout": "1m"},
+	} {
+		body, err := json.Marshal(input)
+		c.Assert(err, IsNil)
+		c.Assert(postJSON(testDialClient, s.urlPrefix, body), NotNil)
+	}
+	c.Assert(s.svr.GetRaftCluster().GetSchedulers(), HasLen, 0)
+}
+
+func (s *testScheduleSuite) TestReAddEvictLeaderStore(c *C) {
+	post := func(url string, input map[string]interface{}) error {
+		body, err := json.Marshal(input)
+		c.Assert(err, IsNil)
+		return postJSON(testDialClient, url, body)
+	}
+	listURL := fmt.Sprintf("%s%s%s/%s/list", s.svr.GetAddr(), apiPrefix, server.SchedulerConfigHandlerPath, "evict-leader-scheduler")
+	updateURL := fmt.Sprintf("%s%s%s/%s/config", s.svr.GetAddr(), apiPrefix, server.SchedulerConfigHandlerPath, "evict-leader-scheduler")
+	checkDeadline := func(has bool) {
+		resp := make(map[string]interface{})
+		c.Assert(readJSON(testDialClient, listURL, &resp), IsNil)
+		c.Assert(resp["store-id-ranges"], HasLen, 1)
+		deadlines, _ := resp["store-id-deadline"].(map[string]interface{})
+		_, ok := deadlines["1"]
+		c.Assert(ok, Equals, has)
+	}
+
+	c.Assert(post(s.urlPrefix, map[string]interface{}{"name": "evict-leader-scheduler", "store_id": 1, "timeout": "1h"}), IsNil)
+	checkDeadline(true)
+	// Re-adding the store without a timeout drops its deadline.
+	c.Assert(post(updateURL, map[string]interface{}{"store_id": 1}), IsNil)
+	checkDeadline(false)
+	// Re-adding it with a timeout sets the deadline again.
+	c.Assert(post(updateURL, map[string]interface{}{"store_id": 1, "timeout": "1h"}), IsNil)
+	checkDeadline(true)
+	// The invalid config is rejected.
+	c.Assert(post(updateURL, map[string]interface{}{"timeout": "1h"}), NotNil)
+	c.Assert(post(updateURL, map[string]interface{}{"store_id": 1, "timeout": "-1m"}), NotNil)
+	checkDeadline(true)
+
+	_, err := doDelete(testDialClient, fmt.Sprintf("%s/%s", s.urlPrefix, "evict-leader-scheduler-1"))
+	c.Assert(err, IsNil)
+	c.Assert(s.svr.GetRaftCluster().GetSchedulers(), HasLen, 0)
+}
+
 func (s *testScheduleSuite) TestAPI(c *C) {
 	type arg struct {
 		opt   string
@@ -17,6 +17,7 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"sync"
@@ -104,8 +105,11 @@ type RaftCluster struct {
 	changedRegions chan *core.RegionInfo
 
 	labelLevelStats *statistics.LabelStatistics
-	regionStats     *statistics.RegionStatistics
-	hotStat         *statistics.HotStat
+	// replicaSpreadStats records how the voters are spread over the location labels.
+	replicaSpreadStats *statistics.ReplicaSpreadStatistics
+	storeFlowStats     *statistics.StoreFlowStats
+	regionStats        *statistics.RegionStatistics
+	hotStat            *statistics.HotStat
 
 	coordinator      *coordinator
 	suspectRegions   *cache.TTLUint64 // suspectRegions are regions that may need fix
@@ -207,7 +211,9 @@ func (c *RaftCluster) InitCluster(id id.Allocator, opt *config.PersistOptions, s
 	c.storage = storage
 	c.id = id
 	c.labelLevelStats = statistics.NewLabelStatistics()
-	c.hotStat = statistics.NewHotStat(c.ctx, c.quit)
+	c.replicaSpreadStats = statistics.NewReplicaSpreadStatistics()
+	c.storeFlowStats = statistics.NewStoreFlowStats()
+	c.hotStat = statistics.NewHotStat(c.ctx, c.quit, opt)
 	c.prepareChecker = newPrepareChecker()
 	c.changedRegions = make(chan *core.RegionInfo, defaultChangedRegionsLimit)
 	c.suspectRegions = cache.NewIDTTL(c.ctx, time.Minute, 3*time.Minute)
@@ -609,16 +615,16 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 	storage := c.storage
 	coreCluster := c.core
 	hotStat := c.hotStat
+	storeFlowStats := c.storeFlowStats
 	c.RUnlock()
 
 	origin, err := coreCluster.PreCheckPutRegion(region)
 	if err != nil {
 		return err
 	}
+	interval := storeFlowStats.Observe(region)
 	hotStat.CheckWriteAsync(statistics.NewCheckExpiredItemTask(region))
 	hotStat.CheckReadAsync(statistics.NewCheckExpiredItemTask(region))
-	reportInterval := region.GetInterval()
-	interval := reportInterval.GetEndTimestamp() - reportInterval.GetStartTimestamp()
 	for _, peer := range region.GetPeers() {
 		peerInfo := core.NewPeerInfo(peer, region.GetWriteLoads(), interval)
 		hotStat.CheckWriteAsync(statistics.NewCheckPeerTask(peerInfo, region))
@@ -653,6 +659,8 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 				c.regionStats.ClearDefunctRegion(item.GetID())
 			}
 			c.labelLevelStats.ClearDefunctRegion(item.GetID())
+			c.replicaSpreadStats.ClearDefunctRegion(item.GetID())
+			c.storeFlowStats.ClearDefunctRegion(item.GetID())
 		}
 
 		// Update related stores.
@@ -680,9 +688,16 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 	}
 
 	changedRegions := c.changedRegions
+	co := c.coordinator
 
 	c.Unlock()
 
+	// A region covering the overlapped regions of its own has merged them, and
+	// it is not split by size for a while to avoid splitting it back at once.
+	if origin != nil && len(overlaps) > 0 && co != nil {
+		co.checkers.GetSplitChecker().RecordRegionMerge([]uint64{region.GetID()})
+	}
+
 	if storage != nil {
 		// If there are concurrent heartbeats from the same region, the last write will win even if
 		// writes to storage in the critical area. So don't use mutex to protect it.
@@ -829,6 +844,11 @@ func (c *RaftCluster) GetStoreRegionCount(storeID uint64) int {
 	return c.core.GetStoreRegionCount(storeID)
 }
 
+// GetStoreLearnerCount returns the number of learners for a given store.
+func (c *RaftCluster) GetStoreLearnerCount(storeID uint64) int {
+	return c.core.GetStoreLearnerCount(storeID)
+}
+
 // GetAverageRegionSize returns the average region approximate size.
 func (c *RaftCluster) GetAverageRegionSize() int64 {
 	return c.core.GetAverageRegionSize()
@@ -888,6 +908,14 @@ func (c *RaftCluster) IsRegionHot(region *core.RegionInfo) bool {
 	return hotStat.IsRegionHot(region, c.opt.GetHotRegionCacheHitsThreshold())
 }
 
+// HasHotPeer returns true if any peer of the region is hot.
+func (c *RaftCluster) HasHotPeer(region *core.RegionInfo) bool {
+	c.RLock()
+	hotStat := c.hotStat
+	c.RUnlock()
+	return hotStat.IsRegionHot(region, 1)
+}
+
 // GetAdjacentRegions returns regions' information that are adjacent with the specific region ID.
 func (c *RaftCluster) GetAdjacentRegions(region *core.RegionInfo) (*core.RegionInfo, *core.RegionInfo) {
 	return c.core.GetAdjacentRegions(region)
@@ -1259,6 +1287,7 @@ func (c *RaftCluster) collectMetrics() {
 	statsMap.Collect()
 
 	c.coordinator.collectSchedulerMetrics()
+	c.coordinator.collectCheckerMetrics()
 	c.coordinator.collectHotSpotMetrics()
 	c.collectClusterMetrics()
 	c.collectHealthStatus()
@@ -1269,6 +1298,7 @@ func (c *RaftCluster) resetMetrics() {
 	statsMap.Reset()
 
 	c.coordinator.resetSchedulerMetrics()
+	c.coordinator.resetCheckerMetrics()
 	c.coordinator.resetHotSpotMetrics()
 	c.resetClusterMetrics()
 	c.resetHealthStatus()
@@ -1345,8 +1375,43 @@ func (c *RaftCluster) updateRegionsLabelLevelStats(regions []*core.RegionInfo) {
 	c.Lock()
 	defer c.Unlock()
 	for _, region := range regions {
-		c.labelLevelStats.Observe(region, c.getRegionStoresLocked(region), c.opt.GetLocationLabels())
+		stores, labels := c.getRegionStoresLocked(region), c.opt.GetLocationLabels()
+		c.labelLevelStats.Observe(region, stores, labels)
+		c.replicaSpreadStats.Observe(region, stores, labels)
+	}
+}
+
+// GetReplicaSpreads returns how the voters of the regions are spread over the
+// values of each location label, and the regions whose voters are not spread
+// as evenly as the stores allow.
+func (c *RaftCluster) GetReplicaSpreads() []*statistics.LabelSpread {
+	c.RLock()
+	defer c.RUnlock()
+	var stores []*core.StoreInfo
+	for _, store := range c.core.GetStores() {
+		if !store.IsTombstone() {
+			stores = append(stores, store)
+		}
 	}
+	return c.replicaSpreadStats.GetLabelSpreads(stores, c.opt.GetLocationLabels())
+}
+
+// GetStoreFlowByKind returns the total flow of the leaders on the store for each
+// region statistics kind of the flow, which is updated by region heartbeats.
+func (c *RaftCluster) GetStoreFlowByKind(storeID uint64, kind statistics.FlowKind) map[statistics.RegionStatKind]float64 {
+	c.RLock()
+	defer c.RUnlock()
+	return c.storeFlowStats.GetStoreFlowByKind(storeID, kind)
+}
+
+// GetHotStores returns the stores which are hot in the flow by the flow of
+// their leaders. The thresholds are read from the config on each call.
+func (c *RaftCluster) GetHotStores(kind statistics.FlowKind) []uint64 {
+	c.RLock()
+	defer c.RUnlock()
+	c.storeFlowStats.SetHotStoreThresholds(statistics.WriteFlow, c.opt.GetHotStoreWriteThresholds())
+	c.storeFlowStats.SetHotStoreThresholds(statistics.ReadFlow, c.opt.GetHotStoreReadThresholds())
+	return c.storeFlowStats.GetHotStores(kind)
 }
 
 func (c *RaftCluster) getRegionStoresLocked(region *core.RegionInfo) []*core.StoreInfo {
@@ -1484,6 +1549,18 @@ func (c *RaftCluster) RegionWriteStats() map[uint64][]*statistics.HotPeerStat {
 	return c.hotStat.RegionStats(statistics.WriteFlow, c.GetOpts().GetHotRegionCacheHitsThreshold())
 }
 
+// AllRegionWriteStats returns the write stats of all peers in the hot cache,
+// including the ones that are not hot enough yet.
+func (c *RaftCluster) AllRegionWriteStats() map[uint64][]*statistics.HotPeerStat {
+	return c.hotStat.RegionStats(statistics.WriteFlow, 0)
+}
+
+// GetHotRegionsByKind returns the hot regions whose rate of any region
+// statistics kind of the flow reaches minRate.
+func (c *RaftCluster) GetHotRegionsByKind(kind statistics.FlowKind, minRate float64) []*statistics.HotRegionRate {
+	return c.hotStat.GetHotRegionsByKind(kind, minRate)
+}
+
 // TODO: remove me.
 // only used in test.
 //nolint:unused
@@ -1506,6 +1583,17 @@ func (c *RaftCluster) GetRuleManager() *placement.RuleManager {
 	return c.ruleManager
 }
 
+// SimulatePlacementRules estimates the effect on the regions of resetting the
+// placement rules by the groups like SetAllGroupBundles, without changing the
+// rules or creating any operator.
+func (c *RaftCluster) SimulatePlacementRules(groups []placement.GroupBundle, override bool) (*checker.RuleSimulation, error) {
+	ruleManager, err := c.GetRuleManager().CloneWithGroupBundles(groups, override)
+	if err != nil {
+		return nil, err
+	}
+	return checker.SimulateRules(c, ruleManager, c.GetRegions()), nil
+}
+
 // GetRegionLabeler returns the region labeler.
 func (c *RaftCluster) GetRegionLabeler() *labeler.RegionLabeler {
 	c.RLock()
@@ -1657,6 +1745,89 @@ func (c *RaftCluster) PauseOrResumeChecker(name string, t int64) error {
 	return c.coordinator.pauseOrResumeChecker(name, t)
 }
 
+// SetCheckerEnabled enables or disables checker, which is persisted.
+func (c *RaftCluster) SetCheckerEnabled(name string, enabled bool) error {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.setCheckerEnabled(name, enabled)
+}
+
+// ExportCheckerState returns the pause and enabled states of all checkers.
+func (c *RaftCluster) ExportCheckerState() (*schedule.CheckersState, error) {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.exportCheckerState()
+}
+
+// ImportCheckerState restores the pause and enabled states of the checkers,
+// which are persisted.
+func (c *RaftCluster) ImportCheckerState(state *schedule.CheckersState) error {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.importCheckerState(state)
+}
+
+// ListCheckers returns the status of all checkers.
+func (c *RaftCluster) ListCheckers() ([]schedule.CheckerStatus, error) {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.listCheckers()
+}
+
+// GetWaitingRegionInfos returns the regions in the waiting list of the checkers.
+func (c *RaftCluster) GetWaitingRegionInfos() ([]*schedule.WaitingRegionInfo, error) {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.getWaitingRegionInfos()
+}
+
+// SetPriorityRanges replaces the priority key ranges of the checkers.
+func (c *RaftCluster) SetPriorityRanges(ranges []core.KeyRange) error {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.setPriorityRanges(ranges)
+}
+
+// GetPriorityRanges returns the priority key ranges of the checkers.
+func (c *RaftCluster) GetPriorityRanges() ([]core.KeyRange, error) {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.getPriorityRanges()
+}
+
+// SetLowChurnWindow declares a low-churn window of the duration from now, in
+// which only the urgent operators are produced. A non-positive duration ends
+// the window.
+func (c *RaftCluster) SetLowChurnWindow(d time.Duration) error {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.setLowChurnWindow(d)
+}
+
+// GetLowChurnWindow returns the time when the low-churn window ends, or the
+// zero time if there is no window in effect.
+func (c *RaftCluster) GetLowChurnWindow() (time.Time, error) {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.getLowChurnWindow()
+}
+
+// StartDecisionTrace makes the checkers write their decisions about every region
+// they check to w, and drop the operators if dryRun is true.
+func (c *RaftCluster) StartDecisionTrace(w io.Writer, dryRun bool) error {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.startDecisionTrace(w, dryRun)
+}
+
+// StopDecisionTrace stops the decision trace of the checkers and returns the
+// number of the records written.
+func (c *RaftCluster) StopDecisionTrace() (uint64, error) {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.stopDecisionTrace()
+}
+
 // IsCheckerPaused returns if checker is paused
 func (c *RaftCluster) IsCheckerPaused(name string) (bool, error) {
 	c.RLock()
@@ -411,6 +411,24 @@ func (s *testClusterInfoSuite) TestRegionHeartbeatHotStat(c *C) {
 	c.Assert(stats[4], HasLen, 1)
 }
 
+func (s *testClusterInfoSuite) TestGetHotStores(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cluster := newTestRaftCluster(s.ctx, mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+	leader := &metapb.Peer{Id: 1, StoreId: 1}
+	region := core.NewRegionInfo(&metapb.Region{Id: 1, Peers: []*metapb.Peer{leader}}, leader,
+		core.SetReportInterval(10), core.SetWrittenBytes(3000))
+	c.Assert(cluster.processRegionHeartbeat(region), IsNil)
+	c.Assert(cluster.GetHotStores(statistics.WriteFlow), HasLen, 0)
+
+	// The thresholds take effect without any store heartbeat.
+	cfg := opt.GetScheduleConfig().Clone()
+	cfg.HotStoreWriteThresholds = []float64{200, 0, 0}
+	opt.SetScheduleConfig(cfg)
+	c.Assert(cluster.GetHotStores(statistics.WriteFlow), DeepEquals, []uint64{1})
+	c.Assert(cluster.GetHotStores(statistics.ReadFlow), HasLen, 0)
+}
+
 func (s *testClusterInfoSuite) TestRegionHeartbeat(c *C) {
 	_, opt, err := newTestScheduleConfig()
 	c.Assert(err, IsNil)
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"sync"
 	"sync/atomic"
@@ -48,6 +49,10 @@ const (
 	maxLoadConfigRetries      = 10
 
 	patrolScanRegionLimit = 128 // It takes about 14 minutes to iterate 1 million regions.
+	// persistWaitingListInterval is the interval of saving the waiting list of
+	// the checkers, and maxPersistedWaitingRegions bounds the regions saved.
+	persistWaitingListInterval = time.Minute
+	maxPersistedWaitingRegions = 10000
 	// PluginLoad means action for load plugin
 	PluginLoad = "PluginLoad"
 	// PluginUnload means action for unload plugin
@@ -99,7 +104,9 @@ func (c *coordinator) patrolRegions() {
 	defer timer.Stop()
 
 	log.Info("coordinator starts patrol regions")
+	c.loadWaitingList()
 	start := time.Now()
+	lastPersist := start
 	var key []byte
 	for {
 		select {
@@ -110,6 +117,12 @@ func (c *coordinator) patrolRegions() {
 			return
 		}
 
+		if time.Since(lastPersist) >= persistWaitingListInterval {
+			c.persistWaitingList()
+			lastPersist = time.Now()
+		}
+
+		c.checkers.RefreshStoreBudget()
 		// Check priority regions first.
 		c.checkPriorityRegions()
 		// Check suspect regions first.
@@ -126,13 +139,18 @@ func (c *coordinator) patrolRegions() {
 			continue
 		}
 
+		checkRegions := make([]*core.RegionInfo, 0, len(regions))
 		for _, region := range regions {
-			// Skips the region if there is already a pending operator.
-			if c.opController.GetOperator(region.GetID()) != nil {
+			// Skips the region if there is already a pending operator, unless
+			// the region may need a more urgent one.
+			if op := c.opController.GetOperator(region.GetID()); op != nil && !c.checkers.MayPreempt(region, op) {
 				continue
 			}
-
-			ops := c.checkers.CheckRegion(region)
+			checkRegions = append(checkRegions, region)
+		}
+		results := c.checkers.CheckRegions(checkRegions)
+		for _, region := range checkRegions {
+			ops := results[region.GetID()]
 
 			key = region.GetEndKey()
 			if len(ops) == 0 {
@@ -152,6 +170,11 @@ func (c *coordinator) patrolRegions() {
 		if len(key) == 0 {
 			patrolCheckRegionsGauge.Set(time.Since(start).Seconds())
 			start = time.Now()
+			c.checkers.ResetSplitBudget()
+			c.checkers.PruneReplicaMismatch()
+			c.checkers.UpdateReplicaEmergency()
+			c.checkers.PruneConvergence()
+			c.checkers.PrunePendingPromotions()
 		}
 		failpoint.Inject("break-patrol", func() {
 			failpoint.Break()
@@ -235,9 +258,8 @@ func (c *coordinator) checkSuspectKeyRanges() {
 }
 
 func (c *coordinator) checkWaitingRegions() {
-	items := c.checkers.GetWaitingRegions()
-	regionListGauge.WithLabelValues("waiting_list").Set(float64(len(items)))
-	for _, item := range items {
+	regionListGauge.WithLabelValues("waiting_list").Set(float64(len(c.checkers.GetWaitingRegions())))
+	for _, item := range c.checkers.GetReadyWaitingRegions() {
 		id := item.Key
 		region := c.cluster.GetRegion(id)
 		if region == nil {
@@ -279,6 +301,37 @@ func (c *coordinator) drivePushOperator() {
 	}
 }
 
+// loadWaitingList seeds the waiting list of the checkers with the regions saved
+// by the previous leader. It is best-effort, since the regions are only the
+// candidates to check again.
+func (c *coordinator) loadWaitingList() {
+	if !c.cluster.GetOpts().IsWaitingListPersistenceEnabled() {
+		return
+	}
+	regionIDs, err := c.cluster.storage.LoadWaitingRegions()
+	if err != nil {
+		log.Warn("failed to load the waiting list of the checkers", errs.ZapError(err))
+		return
+	}
+	if len(regionIDs) > maxPersistedWaitingRegions {
+		regionIDs = regionIDs[:maxPersistedWaitingRegions]
+	}
+	seeded := c.checkers.SeedWaitingList(regionIDs)
+	log.Info("seed the waiting list of the checkers", zap.Int("saved", len(regionIDs)), zap.Int("seeded", seeded))
+}
+
+// persistWaitingList saves the IDs of the regions in the waiting list of the
+// checkers, so that the next leader can seed its waiting list with them. The
+// stale regions are harmless, so a failure is only logged.
+func (c *coordinator) persistWaitingList() {
+	if !c.cluster.GetOpts().IsWaitingListPersistenceEnabled() {
+		return
+	}
+	if err := c.cluster.storage.SaveWaitingRegions(c.checkers.GetWaitingRegionIDs(maxPersistedWaitingRegions)); err != nil {
+		log.Warn("failed to save the waiting list of the checkers", errs.ZapError(err))
+	}
+}
+
 func (c *coordinator) run() {
 	ticker := time.NewTicker(runSchedulerCheckInterval)
 	defer ticker.Stop()
@@ -521,6 +574,20 @@ func (c *coordinator) resetSchedulerMetrics() {
 	schedulerStatusGauge.Reset()
 }
 
+func (c *coordinator) collectCheckerMetrics() {
+	for checkerType, stats := range c.checkers.GetCheckerStats() {
+		checkerStatusGauge.WithLabelValues(checkerType, "produced").Set(float64(stats.Produced))
+		checkerStatusGauge.WithLabelValues(checkerType, "blocked").Set(float64(stats.Blocked))
+	}
+	regionListGauge.WithLabelValues("stuck_joint_list").Set(float64(len(c.checkers.GetStuckJointRegions())))
+	regionListGauge.WithLabelValues("duplicate_peer_list").Set(float64(len(c.checkers.GetDuplicatePeerRegions())))
+	regionListGauge.WithLabelValues("all_peers_down_list").Set(float64(len(c.checkers.GetAllPeersDownRegions())))
+}
+
+func (c *coordinator) resetCheckerMetrics() {
+	checkerStatusGauge.Reset()
+}
+
 func (c *coordinator) collectHotSpotMetrics() {
 	c.RLock()
 	// Collects hot write region metrics.
@@ -614,6 +681,11 @@ func (c *coordinator) addScheduler(scheduler schedule.Scheduler, args ...string)
 	if _, ok := c.schedulers[scheduler.GetName()]; ok {
 		return errs.ErrSchedulerExisted.FastGenByArgs()
 	}
+	for name, s := range c.schedulers {
+		if schedulers.IsExclusive(scheduler.GetType(), s.GetType()) {
+			return errs.ErrSchedulerExclusive.FastGenByArgs(name)
+		}
+	}
 
 	s := newScheduleController(c, scheduler)
 	if err := s.Prepare(c.cluster); err != nil {
@@ -802,6 +874,113 @@ func (c *coordinator) pauseOrResumeChecker(name string, t int64) error {
 	return nil
 }
 
+func (c *coordinator) setCheckerEnabled(name string, enabled bool) error {
+	c.Lock()
+	defer c.Unlock()
+	if c.cluster == nil {
+		return errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	if err := c.checkers.SetCheckerEnabled(name, enabled); err != nil {
+		return err
+	}
+	return c.cluster.opt.Persist(c.cluster.storage)
+}
+
+func (c *coordinator) getWaitingRegionInfos() ([]*schedule.WaitingRegionInfo, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if c.cluster == nil {
+		return nil, errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	return c.checkers.GetWaitingRegionInfos(), nil
+}
+
+func (c *coordinator) setPriorityRanges(ranges []core.KeyRange) error {
+	c.Lock()
+	defer c.Unlock()
+	if c.cluster == nil {
+		return errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	c.checkers.SetPriorityRanges(ranges)
+	return nil
+}
+
+func (c *coordinator) getPriorityRanges() ([]core.KeyRange, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if c.cluster == nil {
+		return nil, errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	return c.checkers.GetPriorityRanges(), nil
+}
+
+func (c *coordinator) setLowChurnWindow(d time.Duration) error {
+	c.Lock()
+	defer c.Unlock()
+	if c.cluster == nil {
+		return errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	c.checkers.SetLowChurnWindow(d)
+	return nil
+}
+
+func (c *coordinator) getLowChurnWindow() (time.Time, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if c.cluster == nil {
+		return time.Time{}, errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	return c.checkers.LowChurnUntil(), nil
+}
+
+func (c *coordinator) startDecisionTrace(w io.Writer, dryRun bool) error {
+	c.RLock()
+	defer c.RUnlock()
+	if c.cluster == nil {
+		return errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	return c.checkers.StartDecisionTrace(w, dryRun)
+}
+
+func (c *coordinator) stopDecisionTrace() (uint64, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if c.cluster == nil {
+		return 0, errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	return c.checkers.StopDecisionTrace()
+}
+
+func (c *coordinator) listCheckers() ([]schedule.CheckerStatus, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if c.cluster == nil {
+		return nil, errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	return c.checkers.ListCheckers(), nil
+}
+
+func (c *coordinator) exportCheckerState() (*schedule.CheckersState, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if c.cluster == nil {
+		return nil, errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	return c.checkers.ExportState(), nil
+}
+
+func (c *coordinator) importCheckerState(state *schedule.CheckersState) error {
+	c.Lock()
+	defer c.Unlock()
+	if c.cluster == nil {
+		return errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	if err := c.checkers.ImportState(state); err != nil {
+		return err
+	}
+	return c.cluster.opt.Persist(c.cluster.storage)
+}
+
 func (c *coordinator) isCheckerPaused(name string) (bool, error) {
 	c.RLock()
 	defer c.RUnlock()
@@ -820,6 +999,7 @@ type scheduleController struct {
 	schedule.Scheduler
 	cluster      *RaftCluster
 	opController *schedule.OperatorController
+	checkers     *schedule.CheckerController
 	nextInterval time.Duration
 	ctx          context.Context
 	cancel       context.CancelFunc
@@ -833,6 +1013,7 @@ func newScheduleController(c *coordinator, s schedule.Scheduler) *scheduleContro
 		Scheduler:    s,
 		cluster:      c.cluster,
 		opController: c.opController,
+		checkers:     c.checkers,
 		nextInterval: s.GetMinInterval(),
 		ctx:          ctx,
 		cancel:       cancel,
@@ -865,8 +1046,12 @@ func (s *scheduleController) GetInterval() time.Duration {
 	return s.nextInterval
 }
 
-// AllowSchedule returns if a scheduler is allowed to schedule.
+// AllowSchedule returns if a scheduler is allowed to schedule. The schedulers
+// which only balance the cluster are held back during a low-churn window.
 func (s *scheduleController) AllowSchedule() bool {
+	if s.checkers != nil && s.checkers.IsLowChurn() && schedulers.IsLowChurnSensitive(s.GetType()) {
+		return false
+	}
 	return s.Scheduler.IsScheduleAllowed(s.cluster) && !s.IsPaused()
 }
 
@@ -22,10 +22,12 @@ import (
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/eraftpb"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/mock/mockhbstream"
 	"github.com/tikv/pd/pkg/testutil"
 	"github.com/tikv/pd/pkg/typeutil"
@@ -383,6 +385,37 @@ func (s *testCoordinatorSuite) TestCheckerIsBusy(c *C) {
 	s.checkRegion(c, tc, co, num, 0)
 }
 
+func (s *testCoordinatorSuite) TestMergeDelaysSplit(c *C) {
+	tc, co, cleanup := prepare(func(cfg *config.ScheduleConfig) {
+		cfg.RegionSplitSize = 100
+		cfg.MergeSplitCooldown = typeutil.NewDuration(time.Hour)
+	}, nil, nil, c)
+	defer cleanup()
+	tc.RaftCluster.coordinator = co
+	c.Assert(tc.addRegionStore(1, 0), IsNil)
+
+	leader := &metapb.Peer{Id: 11, StoreId: 1}
+	newRegion := func(id uint64, start, end string, version uint64) *core.RegionInfo {
+		meta := &metapb.Region{
+			Id:          id,
+			StartKey:    []byte(start),
+			EndKey:      []byte(end),
+			Peers:       []*metapb.Peer{leader},
+			RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: version},
+		}
+		return core.NewRegionInfo(meta, leader, core.SetApproximateSize(1000), core.SetApproximateKeys(100000))
+	}
+	c.Assert(tc.processRegionHeartbeat(newRegion(1, "", "m", 1)), IsNil)
+	c.Assert(tc.processRegionHeartbeat(newRegion(2, "m", "", 1)), IsNil)
+	splitChecker := co.checkers.GetSplitChecker()
+	c.Assert(splitChecker.Check(tc.GetRegion(1)), NotNil)
+
+	// The region merging region 2 is not split by size during the cooldown.
+	c.Assert(tc.processRegionHeartbeat(newRegion(1, "", "", 2)), IsNil)
+	c.Assert(tc.GetRegion(2), IsNil)
+	c.Assert(splitChecker.Check(tc.GetRegion(1)), IsNil)
+}
+
 func (s *testCoordinatorSuite) TestReplica(c *C) {
 	tc, co, cleanup := prepare(func(cfg *config.ScheduleConfig) {
 		// Turn off balance.
@@ -670,6 +703,23 @@ func (s *testCoordinatorSuite) TestAddScheduler(c *C) {
 	waitNoResponse(c, stream)
 }
 
+func (s *testCoordinatorSuite) TestAddExclusiveScheduler(c *C) {
+	_, co, cleanup := prepare(nil, nil, func(co *coordinator) { co.run() }, c)
+	defer cleanup()
+
+	oc := co.opController
+	brs, err := schedule.CreateScheduler(schedulers.BalanceRegionSizeType, oc, core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(schedulers.BalanceRegionSizeType, []string{"", ""}))
+	c.Assert(err, IsNil)
+	// The balance region scheduler is added by default.
+	c.Assert(errors.ErrorEqual(co.addScheduler(brs), errs.ErrSchedulerExclusive.FastGenByArgs(schedulers.BalanceRegionName)), IsTrue)
+	c.Assert(co.removeScheduler(schedulers.BalanceRegionName), IsNil)
+	c.Assert(co.addScheduler(brs), IsNil)
+
+	br, err := schedule.CreateScheduler(schedulers.BalanceRegionType, oc, core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(schedulers.BalanceRegionType, []string{"", ""}))
+	c.Assert(err, IsNil)
+	c.Assert(co.addScheduler(br), NotNil)
+}
+
 func (s *testCoordinatorSuite) TestPersistScheduler(c *C) {
 	tc, co, cleanup := prepare(nil, nil, func(co *coordinator) { co.run() }, c)
 	hbStreams := co.hbStreams
@@ -767,6 +817,54 @@ func (s *testCoordinatorSuite) TestPersistScheduler(c *C) {
 	c.Assert(co.schedulers, HasLen, 3)
 }
 
+func (s *testCoordinatorSuite) TestPersistDisabledChecker(c *C) {
+	tc, co, cleanup := prepare(nil, nil, nil, c)
+	defer cleanup()
+
+	c.Assert(co.setCheckerEnabled("merge", false), IsNil)
+	c.Assert(co.setCheckerEnabled("joint-state", false), NotNil)
+	_, newOpt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	c.Assert(newOpt.Reload(tc.storage), IsNil)
+	c.Assert(newOpt.IsCheckerDisabled("merge"), IsTrue)
+	c.Assert(newOpt.IsCheckerDisabled("joint-state"), IsFalse)
+}
+
+func (s *testCoordinatorSuite) TestPersistWaitingList(c *C) {
+	tc, co, cleanup := prepare(func(cfg *config.ScheduleConfig) {
+		cfg.EnableWaitingListPersistence = true
+	}, nil, nil, c)
+	defer cleanup()
+
+	c.Assert(tc.addRegionStore(1, 1), IsNil)
+	c.Assert(tc.addRegionStore(2, 1), IsNil)
+	c.Assert(tc.addLeaderRegion(1, 1, 2), IsNil)
+	c.Assert(tc.addLeaderRegion(2, 1, 2), IsNil)
+	co.checkers.AddWaitingRegion(tc.GetRegion(1))
+	co.persistWaitingList()
+	regionIDs, err := tc.storage.LoadWaitingRegions()
+	c.Assert(err, IsNil)
+	c.Assert(regionIDs, DeepEquals, []uint64{1})
+
+	// The regions which no longer exist are skipped.
+	co.checkers.FlushWaitingList()
+	c.Assert(tc.storage.SaveWaitingRegions([]uint64{2, 100}), IsNil)
+	co.loadWaitingList()
+	c.Assert(co.checkers.GetWaitingRegionIDs(10), DeepEquals, []uint64{2})
+
+	// Nothing is saved or loaded once disabled.
+	co.checkers.FlushWaitingList()
+	cfg := tc.GetOpts().GetScheduleConfig()
+	cfg.EnableWaitingListPersistence = false
+	tc.GetOpts().SetScheduleConfig(cfg)
+	co.loadWaitingList()
+	c.Assert(co.checkers.GetWaitingRegions(), HasLen, 0)
+	co.persistWaitingList()
+	regionIDs, err = tc.storage.LoadWaitingRegions()
+	c.Assert(err, IsNil)
+	c.Assert(regionIDs, DeepEquals, []uint64{2, 100})
+}
+
 func (s *testCoordinatorSuite) TestRemoveScheduler(c *C) {
 	tc, co, cleanup := prepare(func(cfg *config.ScheduleConfig) {
 		cfg.ReplicaScheduleLimit = 0
@@ -1173,6 +1271,13 @@ func (s *testScheduleControllerSuite) TestController(c *C) {
 		c.Assert(oc.AddWaitingOperator(op6), Equals, 1)
 		c.Assert(oc.RemoveOperator(op6), IsTrue)
 	}
+
+	// the balance schedulers are held back during a low-churn window.
+	c.Assert(sc.AllowSchedule(), IsTrue)
+	co.checkers.SetLowChurnWindow(time.Minute)
+	c.Assert(sc.AllowSchedule(), IsFalse)
+	co.checkers.SetLowChurnWindow(0)
+	c.Assert(sc.AllowSchedule(), IsTrue)
 }
 
 func (s *testScheduleControllerSuite) TestInterval(c *C) {
@@ -72,6 +72,14 @@ var (
 			Help:      "Current state of the cluster",
 		}, []string{"state"})
 
+	checkerStatusGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "checker",
+			Name:      "status",
+			Help:      "Number of operators produced or blocked by the checker.",
+		}, []string{"type", "event"})
+
 	regionListGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "pd",
@@ -90,4 +98,5 @@ func init() {
 	prometheus.MustRegister(clusterStateCPUGauge)
 	prometheus.MustRegister(clusterStateCurrent)
 	prometheus.MustRegister(regionListGauge)
+	prometheus.MustRegister(checkerStatusGauge)
 }
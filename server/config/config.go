@@ -33,6 +33,7 @@ import (
 	"github.com/tikv/pd/pkg/grpcutil"
 	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/pkg/metricutil"
+	"github.com/tikv/pd/pkg/slice"
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/core/storelimit"
 	"github.com/tikv/pd/server/versioninfo"
@@ -358,6 +359,26 @@ func adjustSchedulers(v *SchedulerConfigs, defValue SchedulerConfigs) {
 	}
 }
 
+// adjustCheckerOrder uses the default order if the order is not set. The
+// checkers missing in the order, which may be set before they are added, are
+// put right after the joint-state checker.
+func adjustCheckerOrder(v *[]string) {
+	if len(*v) == 0 {
+		*v = append(*v, DefaultCheckerOrder...)
+		return
+	}
+	var missing []string
+	for _, name := range DefaultCheckerOrder {
+		if slice.NoneOf(*v, func(i int) bool { return (*v)[i] == name }) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	*v = append(append((*v)[:1:1], missing...), (*v)[1:]...)
+}
+
 func adjustPath(p *string) {
 	absPath, err := filepath.Abs(*p)
 	if err == nil {
@@ -623,6 +644,24 @@ func (c *Config) configFromFile(path string) (*toml.MetaData, error) {
 	return &meta, errors.WithStack(err)
 }
 
+// The policies of choosing the adjacent region to merge into.
+const (
+	// MergeDirectionSmallerSize merges a region into the smaller one of its adjacent regions.
+	MergeDirectionSmallerSize = "adjacent-smaller-size"
+	// MergeDirectionLeft merges a region into its previous region if possible.
+	MergeDirectionLeft = "left"
+	// MergeDirectionRight merges a region into its next region if possible.
+	MergeDirectionRight = "right"
+)
+
+// The policies of choosing the operators returned by checking a region.
+const (
+	// CheckRegionFirstChecker returns the operators of the first checker which creates any.
+	CheckRegionFirstChecker = "first-checker"
+	// CheckRegionMostUrgent runs all checkers and returns the most urgent operator.
+	CheckRegionMostUrgent = "most-urgent"
+)
+
 // ScheduleConfig is the schedule configuration.
 type ScheduleConfig struct {
 	// If the snapshot count of one store is greater than this value,
@@ -634,18 +673,72 @@ type ScheduleConfig struct {
 	// it will try to merge with adjacent regions.
 	MaxMergeRegionSize uint64 `toml:"max-merge-region-size" json:"max-merge-region-size"`
 	MaxMergeRegionKeys uint64 `toml:"max-merge-region-keys" json:"max-merge-region-keys"`
+	// MaxMergeFanin is the max number of regions merged away at once when the merge
+	// checker finds a run of adjacent small regions. The regions are merged in
+	// disjoint pairs, so that the run is halved each round. 1 means merging a
+	// single pair of regions at a time.
+	MaxMergeFanin uint64 `toml:"max-merge-fanin" json:"max-merge-fanin"`
+	// RegionSplitSize is the expected size (in MiB) of the regions split by the split checker.
+	// If a region is at least twice as large, it is split into two by TiKV with the approximate size,
+	// and the parts which are still too large are split again by the following checks.
+	// 0 means the split checker doesn't split regions by size.
+	RegionSplitSize uint64 `toml:"region-split-size" json:"region-split-size"`
 	// SplitMergeInterval is the minimum interval time to permit merge after split.
+	// The merge checker skips a region which splits within the interval, and
+	// it merges nothing within the interval after it starts.
 	SplitMergeInterval typeutil.Duration `toml:"split-merge-interval" json:"split-merge-interval"`
+	// MergeSplitCooldown is the minimum time a region must wait after it merges before it can be split by size.
+	// If it is not set, SplitMergeInterval is used.
+	MergeSplitCooldown typeutil.Duration `toml:"merge-split-cooldown" json:"merge-split-cooldown"`
 	// EnableOneWayMerge is the option to enable one way merge. This means a Region can only be merged into the next region of it.
 	EnableOneWayMerge bool `toml:"enable-one-way-merge" json:"enable-one-way-merge,string"`
 	// EnableCrossTableMerge is the option to enable cross table merge. This means two Regions can be merged with different table IDs.
 	// This option only works when key type is "table".
 	EnableCrossTableMerge bool `toml:"enable-cross-table-merge" json:"enable-cross-table-merge,string"`
+	// MergeDirectionPolicy is the option to choose which adjacent region a region is merged into.
+	// There are some policies supported: ["adjacent-smaller-size", "left", "right"], default: "adjacent-smaller-size"
+	MergeDirectionPolicy string `toml:"merge-direction-policy" json:"merge-direction-policy"`
+	// EnableHotRegionMergeGuard is the option to prevent merging a region once it has been hot,
+	// even if it has not been hot for long enough.
+	EnableHotRegionMergeGuard bool `toml:"enable-hot-region-merge-guard" json:"enable-hot-region-merge-guard,string"`
+	// EnableEmptyRegionMergePriority is the option to merge the empty regions ahead of the
+	// other small regions, even into a target region larger than the merge target size limit.
+	EnableEmptyRegionMergePriority bool `toml:"enable-empty-region-merge-priority" json:"enable-empty-region-merge-priority,string"`
 	// PatrolRegionInterval is the interval for scanning region during patrol.
 	PatrolRegionInterval typeutil.Duration `toml:"patrol-region-interval" json:"patrol-region-interval"`
+	// RegionWaitingListSize is the max number of regions kept in the waiting list
+	// of the checkers. It takes effect when the checkers are created.
+	RegionWaitingListSize uint64 `toml:"region-waiting-list-size" json:"region-waiting-list-size"`
+	// EnableWaitingListPersistence is the option to save the IDs of the regions
+	// in the waiting list periodically, so that a new PD leader checks them
+	// first instead of finding them again by a full scan.
+	EnableWaitingListPersistence bool `toml:"enable-waiting-list-persistence" json:"enable-waiting-list-persistence,string"`
+	// PriorityQueueSize is the max number of regions kept in the priority queue of
+	// the checkers. The regions lacking the fewest replicas are evicted when it is
+	// full, and they are put back when they are checked again.
+	PriorityQueueSize uint64 `toml:"priority-queue-size" json:"priority-queue-size"`
+	// RegionOperatorHistorySize is the number of the latest operators recorded for
+	// each region by the checkers. 0 means not recording the operators.
+	RegionOperatorHistorySize uint64 `toml:"region-operator-history-size" json:"region-operator-history-size"`
+	// MaxWaitingRegionBackoff is the max time a region in the waiting list waits before it is checked again.
+	// The wait time doubles each time the region is blocked, starting from PatrolRegionInterval.
+	MaxWaitingRegionBackoff typeutil.Duration `toml:"max-waiting-region-backoff" json:"max-waiting-region-backoff"`
 	// MaxStoreDownTime is the max duration after which
 	// a store will be considered to be down if it hasn't reported heartbeats.
 	MaxStoreDownTime typeutil.Duration `toml:"max-store-down-time" json:"max-store-down-time"`
+	// DownPeerGracePeriod is how long a peer must have been down, as reported
+	// by the region leader, before the replica checker or the rule checker
+	// replaces it. 0 means a down peer is replaced as soon as its store has
+	// been down for MaxStoreDownTime.
+	DownPeerGracePeriod typeutil.Duration `toml:"down-peer-grace-period" json:"down-peer-grace-period"`
+	// SlowStoreEvictThreshold is the slow score a store must reach before the
+	// evict-slow-store-scheduler evicts its leaders.
+	SlowStoreEvictThreshold uint64 `toml:"slow-store-evict-threshold" json:"slow-store-evict-threshold"`
+	// SlowStoreRecoverThreshold is the slow score an evicted store must fall to before it is considered recovered.
+	SlowStoreRecoverThreshold uint64 `toml:"slow-store-recover-threshold" json:"slow-store-recover-threshold"`
+	// SlowStoreRecoveryDuration is how long an evicted store must stay recovered
+	// before leaders are allowed back. 0 means leaders are allowed back at once.
+	SlowStoreRecoveryDuration typeutil.Duration `toml:"slow-store-recovery-duration" json:"slow-store-recovery-duration"`
 	// LeaderScheduleLimit is the max coexist leader schedules.
 	LeaderScheduleLimit uint64 `toml:"leader-schedule-limit" json:"leader-schedule-limit"`
 	// LeaderSchedulePolicy is the option to balance leader, there are some policies supported: ["count", "size"], default: "count"
@@ -656,17 +749,80 @@ type ScheduleConfig struct {
 	ReplicaScheduleLimit uint64 `toml:"replica-schedule-limit" json:"replica-schedule-limit"`
 	// MergeScheduleLimit is the max coexist merge schedules.
 	MergeScheduleLimit uint64 `toml:"merge-schedule-limit" json:"merge-schedule-limit"`
+	// MergeTargetRegionsPerStore is the number of the regions per store beyond
+	// which the merge schedule limit used by the checkers is scaled up by how
+	// far the regions exceed it, so that an over-fragmented cluster merges
+	// faster. 0 means disabled.
+	MergeTargetRegionsPerStore uint64 `toml:"merge-target-regions-per-store" json:"merge-target-regions-per-store"`
+	// MaxMergeScheduleLimit caps the scaled merge schedule limit. It never
+	// lowers the merge schedule limit.
+	MaxMergeScheduleLimit uint64 `toml:"max-merge-schedule-limit" json:"max-merge-schedule-limit"`
+	// EmergencyUnderReplicatedRatio is the ratio of the under-replicated regions
+	// to all regions at or above which the replica schedule limit used by the
+	// checkers is raised, until the ratio drops below it. The ratio is checked
+	// each time the checkers complete a scan of the regions. 0 means disabled.
+	EmergencyUnderReplicatedRatio float64 `toml:"emergency-under-replicated-ratio" json:"emergency-under-replicated-ratio"`
+	// EmergencyReplicaLimitMultiplier is the multiplier applied to the replica
+	// schedule limit used by the checkers in the emergency.
+	EmergencyReplicaLimitMultiplier float64 `toml:"emergency-replica-limit-multiplier" json:"emergency-replica-limit-multiplier"`
+	// PriorityRangeReservedRatio is the ratio of the replica schedule limit
+	// reserved for the regions in the priority key ranges of the checkers. It
+	// only takes effect while any priority key range is set.
+	PriorityRangeReservedRatio float64 `toml:"priority-range-reserved-ratio" json:"priority-range-reserved-ratio"`
+	// StoreBudgetShare is the max ratio of the replica schedule limit the
+	// operators of the checkers touching a store can take, beyond which they
+	// only take the spare budget. The operators fixing the down peers are not
+	// bounded by it. 0 means disabled.
+	StoreBudgetShare float64 `toml:"store-budget-share" json:"store-budget-share"`
+	// MaxSplitsPerScan is the max number of split operators created by the split
+	// checker during a full scan of the regions. The regions which need to split
+	// beyond it are put into the waiting list. 0 means no limit.
+	MaxSplitsPerScan uint64 `toml:"max-splits-per-scan" json:"max-splits-per-scan"`
+	// EnableQuerySplit is the option to make the split checker split the regions
+	// whose query rate exceeds the QuerySplitThreshold into two, however small
+	// they are, so that the queries spread across more regions. The split key is
+	// decided by TiKV with the approximate size, not by where the queries land.
+	EnableQuerySplit bool `toml:"enable-query-split" json:"enable-query-split,string"`
+	// QuerySplitThreshold is the read and write query rate of a region above
+	// which it is split if EnableQuerySplit is on.
+	QuerySplitThreshold float64 `toml:"query-split-threshold" json:"query-split-threshold"`
+	// QuerySplitMinKeys is the min number of keys of either part of a region
+	// split by its query rate.
+	QuerySplitMinKeys uint64 `toml:"query-split-min-keys" json:"query-split-min-keys"`
 	// HotRegionScheduleLimit is the max coexist hot region schedules.
 	HotRegionScheduleLimit uint64 `toml:"hot-region-schedule-limit" json:"hot-region-schedule-limit"`
 	// HotRegionCacheHitThreshold is the cache hits threshold of the hot region.
 	// If the number of times a region hits the hot cache is greater than this
 	// threshold, it is considered a hot region.
 	HotRegionCacheHitsThreshold uint64 `toml:"hot-region-cache-hits-threshold" json:"hot-region-cache-hits-threshold"`
+	// HotRegionQueryThreshold is the query rate above which a region is always considered
+	// as hot, no matter how small its byte and key rates are. 0 means it is disabled.
+	HotRegionQueryThreshold float64 `toml:"hot-region-query-threshold" json:"hot-region-query-threshold"`
+	// HotRegionRollingWindowSize is the number of the recent averages of the flow
+	// which the load of a hot peer is the median of. A larger window ignores more
+	// transient spikes, and a smaller one reacts faster.
+	HotRegionRollingWindowSize uint64 `toml:"hot-region-rolling-window-size" json:"hot-region-rolling-window-size"`
+	// HotRegionTransitionHysteresis is how long a region needs to stay hot or cold
+	// before it is reported to turn hot or cold, so that a region flapping around
+	// the threshold is not reported again and again. 0 reports it at once.
+	HotRegionTransitionHysteresis typeutil.Duration `toml:"hot-region-transition-hysteresis" json:"hot-region-transition-hysteresis"`
+	// HotStoreWriteThresholds are the write byte, key and query rates of the leaders
+	// on a store, reaching any of which makes the store write-hot. 0 disables a dimension.
+	HotStoreWriteThresholds []float64 `toml:"hot-store-write-thresholds" json:"hot-store-write-thresholds"`
+	// HotStoreReadThresholds are the read byte, key and query rates of the leaders
+	// on a store, reaching any of which makes the store read-hot. 0 disables a dimension.
+	HotStoreReadThresholds []float64 `toml:"hot-store-read-thresholds" json:"hot-store-read-thresholds"`
 	// StoreBalanceRate is the maximum of balance rate for each store.
 	// WARN: StoreBalanceRate is deprecated.
 	StoreBalanceRate float64 `toml:"store-balance-rate" json:"store-balance-rate,omitempty"`
 	// StoreLimit is the limit of scheduling for stores.
 	StoreLimit map[uint64]StoreLimitConfig `toml:"store-limit" json:"store-limit"`
+	// StoreSnapshotLimit is the max number of running operators which send snapshots to a store.
+	// The checkers won't create more such operators for the store. A missing store or 0 means unlimited.
+	StoreSnapshotLimit map[uint64]uint64 `toml:"store-snapshot-limit" json:"store-snapshot-limit"`
+	// CheckerSnapshotRate is the max number of operators which send snapshots that the
+	// replica and rule checkers create per second in total. 0 means unlimited.
+	CheckerSnapshotRate float64 `toml:"checker-snapshot-rate" json:"checker-snapshot-rate"`
 	// TolerantSizeRatio is the ratio of buffer size for balance scheduler.
 	TolerantSizeRatio float64 `toml:"tolerant-size-ratio" json:"tolerant-size-ratio"`
 	//
@@ -719,10 +875,64 @@ type ScheduleConfig struct {
 	EnableRemoveExtraReplica bool `toml:"enable-remove-extra-replica" json:"enable-remove-extra-replica,string"`
 	// EnableLocationReplacement is the option to enable replica checker to move replica to a better location.
 	EnableLocationReplacement bool `toml:"enable-location-replacement" json:"enable-location-replacement,string"`
+	// EnableWeightedReplicaScheduling is the option to make replica checker prefer the store
+	// with the highest available capacity ratio when adding a replica.
+	EnableWeightedReplicaScheduling bool `toml:"enable-weighted-replica-scheduling" json:"enable-weighted-replica-scheduling,string"`
+	// ReplicaMinFreeSpace is the available space below which a store is never picked by
+	// replica checker to add a peer to. 0 means it is disabled.
+	ReplicaMinFreeSpace typeutil.ByteSize `toml:"replica-min-free-space" json:"replica-min-free-space"`
+	// ReplicaMinFreeRatio is the available ratio below which a store is never picked by
+	// replica checker to add a peer to. 0 means it is disabled.
+	ReplicaMinFreeRatio float64 `toml:"replica-min-free-ratio" json:"replica-min-free-ratio"`
+	// LearnerCatchUpDuration is how long a learner must stay neither pending nor down
+	// before the learner checker promotes it. 0 means a learner is promoted as soon
+	// as it can be.
+	LearnerCatchUpDuration typeutil.Duration `toml:"learner-catch-up-duration" json:"learner-catch-up-duration"`
+	// CheckerOperatorDedupTTL is how long the checkers skip an operator which is the
+	// same as the one produced for the region recently, which is presumably still in
+	// flight. 0 means the operators are never skipped, which is the default.
+	CheckerOperatorDedupTTL typeutil.Duration `toml:"checker-operator-dedup-ttl" json:"checker-operator-dedup-ttl"`
+	// PlacementRulesRampDuration is how long the part of the replica schedule limit the
+	// rule checker can take grows from 0 to all after the placement rules are enabled,
+	// so that the regions are not taken over by the rule checker all at once.
+	// 0 means the rule checker takes over at once, which is the default.
+	PlacementRulesRampDuration typeutil.Duration `toml:"placement-rules-ramp-duration" json:"placement-rules-ramp-duration"`
+	// MaxRuleLearnersPerRegion is the max number of the learners in transition, which are
+	// catching up or are to be promoted, a region can have before the rule checker adds
+	// another learner to it. The region waits in the waiting list at the limit.
+	// 0 means no limit, which is the default.
+	MaxRuleLearnersPerRegion uint64 `toml:"max-rule-learners-per-region" json:"max-rule-learners-per-region"`
 	// EnableDebugMetrics is the option to enable debug metrics.
 	EnableDebugMetrics bool `toml:"enable-debug-metrics" json:"enable-debug-metrics,string"`
 	// EnableJointConsensus is the option to enable using joint consensus as a operator step.
 	EnableJointConsensus bool `toml:"enable-joint-consensus" json:"enable-joint-consensus,string"`
+	// EnableReadOnlyCluster is the option to make the checkers only log the operators
+	// they would create, instead of returning them.
+	EnableReadOnlyCluster bool `toml:"enable-read-only-cluster" json:"enable-read-only-cluster,string"`
+	// ReadOnlyAllowJointState is the option to still return the operators which leave
+	// the joint state when the cluster is read-only.
+	ReadOnlyAllowJointState bool `toml:"read-only-allow-joint-state" json:"read-only-allow-joint-state,string"`
+	// ReadOnlyAllowDuplicatePeer is the option to still return the operators which
+	// remove the duplicate peers of a region on a store when the cluster is read-only.
+	ReadOnlyAllowDuplicatePeer bool `toml:"read-only-allow-duplicate-peer" json:"read-only-allow-duplicate-peer,string"`
+	// EnableAllPeersDownRecovery is the option to plan recreating a peer by unsafe
+	// recovery for the regions whose peers are all down, which may lose data.
+	// The regions are left alone by default, and so are they when the cluster is read-only.
+	EnableAllPeersDownRecovery bool `toml:"enable-all-peers-down-recovery" json:"enable-all-peers-down-recovery,string"`
+	// CheckerOrder is the order in which the checkers check a region.
+	// The joint-state checker must always be the first one.
+	CheckerOrder []string `toml:"checker-order" json:"checker-order"`
+	// SkipCheckersLabel is the key of the region label which makes the checkers skip the region.
+	// The value of the label is "all" or a comma-separated list of checker names, like "rule,merge".
+	// The joint-state checker never skips a region.
+	SkipCheckersLabel string `toml:"skip-checkers-label" json:"skip-checkers-label"`
+	// CheckRegionPolicy is the option to choose which operators are returned by checking a region.
+	// It can be "first-checker" or "most-urgent".
+	CheckRegionPolicy string `toml:"check-region-policy" json:"check-region-policy"`
+	// DisabledCheckers are the checkers which never check any region, unlike the
+	// paused ones, they keep disabled until enabled again.
+	// The joint-state checker cannot be disabled.
+	DisabledCheckers []string `toml:"disabled-checkers" json:"disabled-checkers"`
 
 	// Schedulers support for loading customized schedulers
 	Schedulers SchedulerConfigs `toml:"schedulers" json:"schedulers-v2"` // json v2 is for the sake of compatible upgrade
@@ -755,9 +965,21 @@ func (c *ScheduleConfig) Clone() *ScheduleConfig {
 			storeLimit[k] = v
 		}
 	}
+	var storeSnapshotLimit map[uint64]uint64
+	if c.StoreSnapshotLimit != nil {
+		storeSnapshotLimit = make(map[uint64]uint64, len(c.StoreSnapshotLimit))
+		for k, v := range c.StoreSnapshotLimit {
+			storeSnapshotLimit[k] = v
+		}
+	}
 	cfg := *c
 	cfg.StoreLimit = storeLimit
+	cfg.StoreSnapshotLimit = storeSnapshotLimit
 	cfg.Schedulers = schedulers
+	cfg.CheckerOrder = append(c.CheckerOrder[:0:0], c.CheckerOrder...)
+	cfg.DisabledCheckers = append(c.DisabledCheckers[:0:0], c.DisabledCheckers...)
+	cfg.HotStoreWriteThresholds = append(c.HotStoreWriteThresholds[:0:0], c.HotStoreWriteThresholds...)
+	cfg.HotStoreReadThresholds = append(c.HotStoreReadThresholds[:0:0], c.HotStoreReadThresholds...)
 	cfg.SchedulersPayload = nil
 	return &cfg
 }
@@ -768,13 +990,26 @@ const (
 	defaultMaxPendingPeerCount       = 64
 	defaultMaxMergeRegionSize        = 20
 	defaultMaxMergeRegionKeys        = 200000
+	defaultMaxMergeFanin             = 1
 	defaultSplitMergeInterval        = 1 * time.Hour
 	defaultPatrolRegionInterval      = 10 * time.Millisecond
+	defaultMaxWaitingRegionBackoff   = 10 * time.Second
+	defaultRegionWaitingListSize     = 1000
+	defaultPriorityQueueSize         = 1280
+	defaultRegionOperatorHistorySize = 5
 	defaultMaxStoreDownTime          = 30 * time.Minute
+	defaultSlowStoreEvictThreshold   = 100
+	defaultSlowStoreRecoverThreshold = 1
 	defaultLeaderScheduleLimit       = 4
 	defaultRegionScheduleLimit       = 2048
 	defaultReplicaScheduleLimit      = 64
 	defaultMergeScheduleLimit        = 8
+	defaultMaxMergeScheduleLimit     = 64
+	defaultMaxSplitsPerScan          = 10000
+	defaultQuerySplitThreshold       = 3000
+	defaultQuerySplitMinKeys         = 1000
+	defaultEmergencyLimitMultiplier  = 4
+	defaultPriorityRangeReserved     = 0.25
 	defaultHotRegionScheduleLimit    = 4
 	defaultTolerantSizeRatio         = 0
 	defaultLowSpaceRatio             = 0.8
@@ -783,13 +1018,22 @@ const (
 	// defaultHotRegionCacheHitsThreshold is the low hit number threshold of the
 	// hot region.
 	defaultHotRegionCacheHitsThreshold = 3
+	defaultHotRegionRollingWindowSize  = 5
 	defaultSchedulerMaxWaitingOperator = 5
 	defaultLeaderSchedulePolicy        = "count"
+	defaultMergeDirectionPolicy        = MergeDirectionSmallerSize
+	defaultSkipCheckersLabel           = "skip-checkers"
+	defaultCheckRegionPolicy           = CheckRegionFirstChecker
 	defaultStoreLimitMode              = "manual"
 	defaultEnableJointConsensus        = true
 	defaultEnableCrossTableMerge       = true
+	defaultEnableHotRegionMergeGuard   = true
 	defaultHotRegionsWriteInterval     = 10 * time.Minute
 	defaultHotRegionsResevervedDays    = 7
+
+	// defaultHotRegionTransitionHysteresis is how long a region stays hot or
+	// cold before it is reported.
+	defaultHotRegionTransitionHysteresis = time.Minute
 )
 
 func (c *ScheduleConfig) adjust(meta *configMetaData, reloading bool) error {
@@ -805,9 +1049,24 @@ func (c *ScheduleConfig) adjust(meta *configMetaData, reloading bool) error {
 	if !meta.IsDefined("max-merge-region-keys") {
 		adjustUint64(&c.MaxMergeRegionKeys, defaultMaxMergeRegionKeys)
 	}
+	if !meta.IsDefined("max-merge-fanin") {
+		adjustUint64(&c.MaxMergeFanin, defaultMaxMergeFanin)
+	}
 	adjustDuration(&c.SplitMergeInterval, defaultSplitMergeInterval)
 	adjustDuration(&c.PatrolRegionInterval, defaultPatrolRegionInterval)
+	adjustDuration(&c.MaxWaitingRegionBackoff, defaultMaxWaitingRegionBackoff)
+	if !meta.IsDefined("region-waiting-list-size") {
+		adjustUint64(&c.RegionWaitingListSize, defaultRegionWaitingListSize)
+	}
+	if !meta.IsDefined("priority-queue-size") {
+		adjustUint64(&c.PriorityQueueSize, defaultPriorityQueueSize)
+	}
+	if !meta.IsDefined("region-operator-history-size") {
+		adjustUint64(&c.RegionOperatorHistorySize, defaultRegionOperatorHistorySize)
+	}
 	adjustDuration(&c.MaxStoreDownTime, defaultMaxStoreDownTime)
+	adjustUint64(&c.SlowStoreEvictThreshold, defaultSlowStoreEvictThreshold)
+	adjustUint64(&c.SlowStoreRecoverThreshold, defaultSlowStoreRecoverThreshold)
 	if !meta.IsDefined("leader-schedule-limit") {
 		adjustUint64(&c.LeaderScheduleLimit, defaultLeaderScheduleLimit)
 	}
@@ -820,12 +1079,32 @@ func (c *ScheduleConfig) adjust(meta *configMetaData, reloading bool) error {
 	if !meta.IsDefined("merge-schedule-limit") {
 		adjustUint64(&c.MergeScheduleLimit, defaultMergeScheduleLimit)
 	}
+	if !meta.IsDefined("max-merge-schedule-limit") {
+		adjustUint64(&c.MaxMergeScheduleLimit, defaultMaxMergeScheduleLimit)
+	}
+	if !meta.IsDefined("max-splits-per-scan") {
+		adjustUint64(&c.MaxSplitsPerScan, defaultMaxSplitsPerScan)
+	}
+	adjustFloat64(&c.QuerySplitThreshold, defaultQuerySplitThreshold)
+	if !meta.IsDefined("query-split-min-keys") {
+		adjustUint64(&c.QuerySplitMinKeys, defaultQuerySplitMinKeys)
+	}
+	adjustFloat64(&c.EmergencyReplicaLimitMultiplier, defaultEmergencyLimitMultiplier)
+	if !meta.IsDefined("priority-range-reserved-ratio") {
+		adjustFloat64(&c.PriorityRangeReservedRatio, defaultPriorityRangeReserved)
+	}
 	if !meta.IsDefined("hot-region-schedule-limit") {
 		adjustUint64(&c.HotRegionScheduleLimit, defaultHotRegionScheduleLimit)
 	}
 	if !meta.IsDefined("hot-region-cache-hits-threshold") {
 		adjustUint64(&c.HotRegionCacheHitsThreshold, defaultHotRegionCacheHitsThreshold)
 	}
+	if !meta.IsDefined("hot-region-rolling-window-size") {
+		adjustUint64(&c.HotRegionRollingWindowSize, defaultHotRegionRollingWindowSize)
+	}
+	if !meta.IsDefined("hot-region-transition-hysteresis") {
+		adjustDuration(&c.HotRegionTransitionHysteresis, defaultHotRegionTransitionHysteresis)
+	}
 	if !meta.IsDefined("tolerant-size-ratio") {
 		adjustFloat64(&c.TolerantSizeRatio, defaultTolerantSizeRatio)
 	}
@@ -835,6 +1114,11 @@ func (c *ScheduleConfig) adjust(meta *configMetaData, reloading bool) error {
 	if !meta.IsDefined("leader-schedule-policy") {
 		adjustString(&c.LeaderSchedulePolicy, defaultLeaderSchedulePolicy)
 	}
+	if !meta.IsDefined("merge-direction-policy") {
+		adjustString(&c.MergeDirectionPolicy, defaultMergeDirectionPolicy)
+	}
+	adjustString(&c.SkipCheckersLabel, defaultSkipCheckersLabel)
+	adjustString(&c.CheckRegionPolicy, defaultCheckRegionPolicy)
 	if !meta.IsDefined("store-limit-mode") {
 		adjustString(&c.StoreLimitMode, defaultStoreLimitMode)
 	}
@@ -844,6 +1128,9 @@ func (c *ScheduleConfig) adjust(meta *configMetaData, reloading bool) error {
 	if !meta.IsDefined("enable-cross-table-merge") {
 		c.EnableCrossTableMerge = defaultEnableCrossTableMerge
 	}
+	if !meta.IsDefined("enable-hot-region-merge-guard") {
+		c.EnableHotRegionMergeGuard = defaultEnableHotRegionMergeGuard
+	}
 	adjustFloat64(&c.LowSpaceRatio, defaultLowSpaceRatio)
 	adjustFloat64(&c.HighSpaceRatio, defaultHighSpaceRatio)
 
@@ -854,6 +1141,14 @@ func (c *ScheduleConfig) adjust(meta *configMetaData, reloading bool) error {
 
 	adjustSchedulers(&c.Schedulers, DefaultSchedulers)
 
+	adjustCheckerOrder(&c.CheckerOrder)
+	if len(c.HotStoreWriteThresholds) == 0 {
+		c.HotStoreWriteThresholds = append(c.HotStoreWriteThresholds, defaultHotStoreWriteThresholds...)
+	}
+	if len(c.HotStoreReadThresholds) == 0 {
+		c.HotStoreReadThresholds = append(c.HotStoreReadThresholds, defaultHotStoreReadThresholds...)
+	}
+
 	for k, b := range c.migrateConfigurationMap() {
 		v, err := c.parseDeprecatedFlag(meta, k, *b[0], *b[1])
 		if err != nil {
@@ -931,6 +1226,85 @@ func (c *ScheduleConfig) Validate() error {
 	if c.TolerantSizeRatio < 0 {
 		return errors.New("tolerant-size-ratio should be nonnegative")
 	}
+	if c.HotRegionQueryThreshold < 0 {
+		return errors.New("hot-region-query-threshold should be nonnegative")
+	}
+	if c.HotRegionRollingWindowSize == 0 {
+		return errors.New("hot-region-rolling-window-size should be positive")
+	}
+	if c.HotRegionTransitionHysteresis.Duration < 0 {
+		return errors.New("hot-region-transition-hysteresis should be nonnegative")
+	}
+	if err := validateHotStoreThresholds("hot-store-write-thresholds", c.HotStoreWriteThresholds); err != nil {
+		return err
+	}
+	if err := validateHotStoreThresholds("hot-store-read-thresholds", c.HotStoreReadThresholds); err != nil {
+		return err
+	}
+	if c.MergeSplitCooldown.Duration < 0 {
+		return errors.New("merge-split-cooldown should be nonnegative")
+	}
+	if c.SlowStoreRecoverThreshold >= c.SlowStoreEvictThreshold {
+		return errors.New("slow-store-recover-threshold should be less than slow-store-evict-threshold")
+	}
+	if c.SlowStoreRecoveryDuration.Duration < 0 {
+		return errors.New("slow-store-recovery-duration should be nonnegative")
+	}
+	if c.DownPeerGracePeriod.Duration < 0 {
+		return errors.New("down-peer-grace-period should be nonnegative")
+	}
+	if c.LearnerCatchUpDuration.Duration < 0 {
+		return errors.New("learner-catch-up-duration should be nonnegative")
+	}
+	if c.ReplicaMinFreeRatio < 0 || c.ReplicaMinFreeRatio >= 1 {
+		return errors.New("replica-min-free-ratio should be in [0, 1)")
+	}
+	if c.CheckerOperatorDedupTTL.Duration < 0 {
+		return errors.New("checker-operator-dedup-ttl should be nonnegative")
+	}
+	if c.PlacementRulesRampDuration.Duration < 0 {
+		return errors.New("placement-rules-ramp-duration should be nonnegative")
+	}
+	switch c.MergeDirectionPolicy {
+	case MergeDirectionSmallerSize, MergeDirectionLeft, MergeDirectionRight:
+	default:
+		return errors.Errorf("unknown merge-direction-policy %s", c.MergeDirectionPolicy)
+	}
+	switch c.CheckRegionPolicy {
+	case CheckRegionFirstChecker, CheckRegionMostUrgent:
+	default:
+		return errors.Errorf("unknown check-region-policy %s", c.CheckRegionPolicy)
+	}
+	if c.RegionWaitingListSize == 0 {
+		return errors.New("region-waiting-list-size should be positive")
+	}
+	if c.PriorityQueueSize == 0 {
+		return errors.New("priority-queue-size should be positive")
+	}
+	if c.MaxWaitingRegionBackoff.Duration < 0 {
+		return errors.New("max-waiting-region-backoff should be nonnegative")
+	}
+	if c.MaxMergeFanin == 0 {
+		return errors.New("max-merge-fanin should be positive")
+	}
+	if c.CheckerSnapshotRate < 0 {
+		return errors.New("checker-snapshot-rate should be nonnegative")
+	}
+	if c.EmergencyUnderReplicatedRatio < 0 || c.EmergencyUnderReplicatedRatio > 1 {
+		return errors.New("emergency-under-replicated-ratio should between 0 and 1")
+	}
+	if c.QuerySplitThreshold < 0 {
+		return errors.New("query-split-threshold should be nonnegative")
+	}
+	if c.EmergencyReplicaLimitMultiplier < 1 {
+		return errors.New("emergency-replica-limit-multiplier should be at least 1")
+	}
+	if c.PriorityRangeReservedRatio < 0 || c.PriorityRangeReservedRatio > 1 {
+		return errors.New("priority-range-reserved-ratio should between 0 and 1")
+	}
+	if c.StoreBudgetShare < 0 || c.StoreBudgetShare > 1 {
+		return errors.New("store-budget-share should between 0 and 1")
+	}
 	if c.LowSpaceRatio < 0 || c.LowSpaceRatio > 1 {
 		return errors.New("low-space-ratio should between 0 and 1")
 	}
@@ -945,6 +1319,61 @@ func (c *ScheduleConfig) Validate() error {
 			return errors.Errorf("create func of %v is not registered, maybe misspelled", scheduleConfig.Type)
 		}
 	}
+	if err := validateDisabledCheckers(c.DisabledCheckers); err != nil {
+		return err
+	}
+	return validateCheckerOrder(c.CheckerOrder)
+}
+
+// validateHotStoreThresholds checks that the thresholds are the nonnegative
+// byte, key and query rates.
+func validateHotStoreThresholds(name string, thresholds []float64) error {
+	if len(thresholds) != 3 {
+		return errors.Errorf("%s should contain the byte, key and query rates", name)
+	}
+	for _, threshold := range thresholds {
+		if threshold < 0 {
+			return errors.Errorf("%s should be nonnegative", name)
+		}
+	}
+	return nil
+}
+
+// validateDisabledCheckers checks that the disabled checkers are known and
+// don't include the joint-state checker.
+func validateDisabledCheckers(names []string) error {
+	for _, name := range names {
+		if slice.NoneOf(DefaultCheckerOrder, func(i int) bool { return DefaultCheckerOrder[i] == name }) {
+			return errors.Errorf("unknown checker %s in disabled-checkers", name)
+		}
+		if name == DefaultCheckerOrder[0] {
+			return errors.Errorf("checker %s cannot be disabled", name)
+		}
+	}
+	return nil
+}
+
+// validateCheckerOrder checks that the order contains every checker exactly
+// once and starts with the joint-state checker.
+func validateCheckerOrder(order []string) error {
+	seen := make(map[string]struct{}, len(order))
+	for _, name := range order {
+		if slice.NoneOf(DefaultCheckerOrder, func(i int) bool { return DefaultCheckerOrder[i] == name }) {
+			return errors.Errorf("unknown checker %s in checker-order", name)
+		}
+		if _, ok := seen[name]; ok {
+			return errors.Errorf("checker %s is duplicated in checker-order", name)
+		}
+		seen[name] = struct{}{}
+	}
+	for _, name := range DefaultCheckerOrder {
+		if _, ok := seen[name]; !ok {
+			return errors.Errorf("checker %s is missing in checker-order", name)
+		}
+	}
+	if order[0] != DefaultCheckerOrder[0] {
+		return errors.Errorf("checker-order should start with %s", DefaultCheckerOrder[0])
+	}
 	return nil
 }
 
@@ -991,6 +1420,20 @@ type SchedulerConfig struct {
 	ArgsPayload string   `toml:"args-payload" json:"args-payload"`
 }
 
+// DefaultCheckerOrder is the default order in which the checkers check a region.
+// The rule checker only works when placement rules are enabled, while the
+// learner and replica checkers only work when they are disabled.
+var DefaultCheckerOrder = []string{"joint-state", "all-peers-down", "duplicate-peer", "split", "rule", "learner", "replica", "merge"}
+
+var (
+	// defaultHotStoreWriteThresholds are the default write byte, key and query
+	// rates of a write-hot store.
+	defaultHotStoreWriteThresholds = []float64{32 * 1024 * 1024, 128 * 1024, 32 * 1024}
+	// defaultHotStoreReadThresholds are the default read byte, key and query
+	// rates of a read-hot store.
+	defaultHotStoreReadThresholds = []float64{128 * 1024 * 1024, 512 * 1024, 64 * 1024}
+)
+
 // DefaultSchedulers are the schedulers be created by default.
 // If these schedulers are not in the persistent configuration, they
 // will be created automatically when reloading.
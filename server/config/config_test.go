@@ -475,6 +475,107 @@ hot-regions-write-interval= "30m"
 	c.Assert(cfg.Schedule.HotRegionsResevervedDays, Equals, int64(30))
 }
 
+func (s *testConfigSuite) TestCheckerOrderConfig(c *C) {
+	cfgData := `
+[schedule]
+checker-order = ["joint-state", "merge", "split", "rule", "learner", "replica"]
+`
+	cfg := NewConfig()
+	meta, err := toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), IsNil)
+	// the checkers missing in the order are put right after the joint-state checker.
+	c.Assert(cfg.Schedule.CheckerOrder, DeepEquals, []string{"joint-state", "all-peers-down", "duplicate-peer", "merge", "split", "rule", "learner", "replica"})
+
+	// use the default order if not set.
+	cfg = NewConfig()
+	c.Assert(cfg.Adjust(nil, false), IsNil)
+	c.Assert(cfg.Schedule.CheckerOrder, DeepEquals, DefaultCheckerOrder)
+
+	invalidOrders := [][]string{
+		{"split", "joint-state", "all-peers-down", "duplicate-peer", "rule", "learner", "replica", "merge"},
+		{"joint-state", "all-peers-down", "duplicate-peer", "split", "rule", "learner", "replica"},
+		{"joint-state", "all-peers-down", "duplicate-peer", "split", "rule", "learner", "replica", "merge", "merge"},
+		{"joint-state", "all-peers-down", "duplicate-peer", "split", "rule", "learner", "replica", "unknown"},
+	}
+	for _, order := range invalidOrders {
+		cfg.Schedule.CheckerOrder = order
+		c.Assert(cfg.Schedule.Validate(), NotNil)
+	}
+}
+
+func (s *testConfigSuite) TestDisabledCheckersConfig(c *C) {
+	cfgData := `
+[schedule]
+disabled-checkers = ["merge"]
+`
+	cfg := NewConfig()
+	meta, err := toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), IsNil)
+	c.Assert(cfg.Schedule.DisabledCheckers, DeepEquals, []string{"merge"})
+
+	for _, names := range [][]string{{"joint-state"}, {"unknown"}} {
+		cfg.Schedule.DisabledCheckers = names
+		c.Assert(cfg.Schedule.Validate(), NotNil)
+	}
+
+	cfg.Schedule.DisabledCheckers = nil
+	opt := NewPersistOptions(cfg)
+	opt.SetCheckerDisabled("split", true)
+	opt.SetCheckerDisabled("merge", true)
+	c.Assert(opt.IsCheckerDisabled("split"), IsTrue)
+	c.Assert(opt.IsCheckerDisabled("merge"), IsTrue)
+	opt.SetCheckerDisabled("split", false)
+	c.Assert(opt.IsCheckerDisabled("split"), IsFalse)
+	c.Assert(opt.GetScheduleConfig().DisabledCheckers, DeepEquals, []string{"merge"})
+}
+
+func (s *testConfigSuite) TestRegionWaitingListSize(c *C) {
+	cfg := NewConfig()
+	c.Assert(cfg.Adjust(nil, false), IsNil)
+	c.Assert(cfg.Schedule.RegionWaitingListSize, Equals, uint64(defaultRegionWaitingListSize))
+
+	cfgData := `
+[schedule]
+region-waiting-list-size = 0
+`
+	cfg = NewConfig()
+	meta, err := toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), NotNil)
+}
+
+func (s *testConfigSuite) TestMergeDirectionPolicy(c *C) {
+	cfg := NewConfig()
+	c.Assert(cfg.Adjust(nil, false), IsNil)
+	c.Assert(cfg.Schedule.MergeDirectionPolicy, Equals, MergeDirectionSmallerSize)
+	for _, policy := range []string{MergeDirectionLeft, MergeDirectionRight} {
+		cfg.Schedule.MergeDirectionPolicy = policy
+		c.Assert(cfg.Schedule.Validate(), IsNil)
+	}
+	cfg.Schedule.MergeDirectionPolicy = "up"
+	c.Assert(cfg.Schedule.Validate(), NotNil)
+}
+
+func (s *testConfigSuite) TestHotStoreThresholds(c *C) {
+	cfgData := `
+[schedule]
+hot-store-write-thresholds = [1024.0, 0.0, 0.0]
+`
+	cfg := NewConfig()
+	meta, err := toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), IsNil)
+	c.Assert(cfg.Schedule.HotStoreWriteThresholds, DeepEquals, []float64{1024, 0, 0})
+	c.Assert(cfg.Schedule.HotStoreReadThresholds, DeepEquals, defaultHotStoreReadThresholds)
+
+	for _, thresholds := range [][]float64{{1024, 0}, {1024, -1, 0}} {
+		cfg.Schedule.HotStoreReadThresholds = thresholds
+		c.Assert(cfg.Schedule.Validate(), NotNil)
+	}
+}
+
 func (s *testConfigSuite) TestConfigClone(c *C) {
 	cfg := &Config{}
 	cfg.Adjust(nil, false)
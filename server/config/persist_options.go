@@ -228,11 +228,24 @@ func (o *PersistOptions) GetMaxMergeRegionKeys() uint64 {
 	return o.getTTLUintOr(maxMergeRegionKeysKey, o.GetScheduleConfig().MaxMergeRegionKeys)
 }
 
+// GetRegionSplitSize returns the expected size of the regions split by size.
+func (o *PersistOptions) GetRegionSplitSize() uint64 {
+	return o.GetScheduleConfig().RegionSplitSize
+}
+
 // GetSplitMergeInterval returns the interval between finishing split and starting to merge.
 func (o *PersistOptions) GetSplitMergeInterval() time.Duration {
 	return o.GetScheduleConfig().SplitMergeInterval.Duration
 }
 
+// GetMergeSplitCooldown returns the minimum time a region must wait after it merges before it can be split by size.
+func (o *PersistOptions) GetMergeSplitCooldown() time.Duration {
+	if cooldown := o.GetScheduleConfig().MergeSplitCooldown.Duration; cooldown > 0 {
+		return cooldown
+	}
+	return o.GetSplitMergeInterval()
+}
+
 // SetSplitMergeInterval to set the interval between finishing split and starting to merge. It's only used to test.
 func (o *PersistOptions) SetSplitMergeInterval(splitMergeInterval time.Duration) {
 	v := o.GetScheduleConfig().Clone()
@@ -265,6 +278,33 @@ func (o *PersistOptions) SetStoreLimit(storeID uint64, typ storelimit.Type, rate
 	o.SetScheduleConfig(v)
 }
 
+// SetStoreSnapshotLimit sets the max number of running operators which send snapshots to the store.
+// 0 means unlimited.
+func (o *PersistOptions) SetStoreSnapshotLimit(storeID uint64, limit uint64) {
+	v := o.GetScheduleConfig().Clone()
+	if limit == 0 {
+		delete(v.StoreSnapshotLimit, storeID)
+	} else {
+		if v.StoreSnapshotLimit == nil {
+			v.StoreSnapshotLimit = make(map[uint64]uint64)
+		}
+		v.StoreSnapshotLimit[storeID] = limit
+	}
+	o.SetScheduleConfig(v)
+}
+
+// GetCheckerSnapshotRate returns the max number of operators which send snapshots
+// that the replica and rule checkers create per second.
+func (o *PersistOptions) GetCheckerSnapshotRate() float64 {
+	return o.GetScheduleConfig().CheckerSnapshotRate
+}
+
+// GetStoreSnapshotLimit returns the max number of running operators which send snapshots to the store.
+// 0 means unlimited.
+func (o *PersistOptions) GetStoreSnapshotLimit(storeID uint64) uint64 {
+	return o.GetScheduleConfig().StoreSnapshotLimit[storeID]
+}
+
 // SetAllStoresLimit sets all store limit for a given type and rate.
 func (o *PersistOptions) SetAllStoresLimit(typ storelimit.Type, ratePerMin float64) {
 	v := o.GetScheduleConfig().Clone()
@@ -286,6 +326,71 @@ func (o *PersistOptions) SetAllStoresLimit(typ storelimit.Type, ratePerMin float
 	o.SetScheduleConfig(v)
 }
 
+// GetMergeTargetRegionsPerStore returns the number of the regions per store
+// beyond which the merge schedule limit of the checkers is scaled up.
+func (o *PersistOptions) GetMergeTargetRegionsPerStore() uint64 {
+	return o.GetScheduleConfig().MergeTargetRegionsPerStore
+}
+
+// GetMaxMergeScheduleLimit returns the cap of the scaled merge schedule limit.
+func (o *PersistOptions) GetMaxMergeScheduleLimit() uint64 {
+	return o.GetScheduleConfig().MaxMergeScheduleLimit
+}
+
+// GetEmergencyUnderReplicatedRatio returns the ratio of the under-replicated
+// regions at or above which the replica schedule limit of the checkers is raised.
+func (o *PersistOptions) GetEmergencyUnderReplicatedRatio() float64 {
+	return o.GetScheduleConfig().EmergencyUnderReplicatedRatio
+}
+
+// GetEmergencyReplicaLimitMultiplier returns the multiplier applied to the
+// replica schedule limit of the checkers in the emergency.
+func (o *PersistOptions) GetEmergencyReplicaLimitMultiplier() float64 {
+	return o.GetScheduleConfig().EmergencyReplicaLimitMultiplier
+}
+
+// GetPriorityRangeReservedRatio returns the ratio of the replica schedule limit
+// reserved for the regions in the priority key ranges.
+func (o *PersistOptions) GetPriorityRangeReservedRatio() float64 {
+	return o.GetScheduleConfig().PriorityRangeReservedRatio
+}
+
+// GetMaxSplitsPerScan returns the max number of split operators created by the split checker during a full scan.
+func (o *PersistOptions) GetMaxSplitsPerScan() uint64 {
+	return o.GetScheduleConfig().MaxSplitsPerScan
+}
+
+// IsWaitingListPersistenceEnabled returns if the regions in the waiting list are saved for the next PD leader.
+func (o *PersistOptions) IsWaitingListPersistenceEnabled() bool {
+	return o.GetScheduleConfig().EnableWaitingListPersistence
+}
+
+// GetStoreBudgetShare returns the max ratio of the replica schedule limit the
+// operators of the checkers touching a store can take.
+func (o *PersistOptions) GetStoreBudgetShare() float64 {
+	return o.GetScheduleConfig().StoreBudgetShare
+}
+
+// IsQuerySplitEnabled returns if the split checker splits the regions by their query rate.
+func (o *PersistOptions) IsQuerySplitEnabled() bool {
+	return o.GetScheduleConfig().EnableQuerySplit
+}
+
+// GetQuerySplitThreshold returns the query rate of a region above which it is split.
+func (o *PersistOptions) GetQuerySplitThreshold() float64 {
+	return o.GetScheduleConfig().QuerySplitThreshold
+}
+
+// GetQuerySplitMinKeys returns the min number of keys of either part of a region split by its query rate.
+func (o *PersistOptions) GetQuerySplitMinKeys() uint64 {
+	return o.GetScheduleConfig().QuerySplitMinKeys
+}
+
+// GetMaxMergeFanin returns the max number of regions merged away at once in a run of small regions.
+func (o *PersistOptions) GetMaxMergeFanin() int {
+	return int(o.GetScheduleConfig().MaxMergeFanin)
+}
+
 // IsOneWayMergeEnabled returns if a region can only be merged into the next region of it.
 func (o *PersistOptions) IsOneWayMergeEnabled() bool {
 	return o.GetScheduleConfig().EnableOneWayMerge
@@ -296,11 +401,61 @@ func (o *PersistOptions) IsCrossTableMergeEnabled() bool {
 	return o.GetScheduleConfig().EnableCrossTableMerge
 }
 
+// IsHotRegionMergeGuardEnabled returns if the regions which have been hot are prevented from merging.
+func (o *PersistOptions) IsHotRegionMergeGuardEnabled() bool {
+	return o.GetScheduleConfig().EnableHotRegionMergeGuard
+}
+
+// IsEmptyRegionMergePriorityEnabled returns if the empty regions are merged ahead of the other small regions.
+func (o *PersistOptions) IsEmptyRegionMergePriorityEnabled() bool {
+	return o.GetScheduleConfig().EnableEmptyRegionMergePriority
+}
+
 // GetPatrolRegionInterval returns the interval of patrolling region.
 func (o *PersistOptions) GetPatrolRegionInterval() time.Duration {
 	return o.GetScheduleConfig().PatrolRegionInterval.Duration
 }
 
+// GetRegionOperatorHistorySize returns the number of the latest operators recorded for each region.
+func (o *PersistOptions) GetRegionOperatorHistorySize() int {
+	return int(o.GetScheduleConfig().RegionOperatorHistorySize)
+}
+
+// GetRegionWaitingListSize returns the max number of regions in the waiting list.
+func (o *PersistOptions) GetRegionWaitingListSize() int {
+	return int(o.GetScheduleConfig().RegionWaitingListSize)
+}
+
+// GetPriorityQueueSize returns the max number of regions in the priority queue.
+func (o *PersistOptions) GetPriorityQueueSize() int {
+	return int(o.GetScheduleConfig().PriorityQueueSize)
+}
+
+// GetMaxWaitingRegionBackoff returns the max backoff of a region in the waiting list.
+func (o *PersistOptions) GetMaxWaitingRegionBackoff() time.Duration {
+	return o.GetScheduleConfig().MaxWaitingRegionBackoff.Duration
+}
+
+// GetDownPeerGracePeriod returns how long a peer must have been down before it is replaced.
+func (o *PersistOptions) GetDownPeerGracePeriod() time.Duration {
+	return o.GetScheduleConfig().DownPeerGracePeriod.Duration
+}
+
+// GetSlowStoreEvictThreshold returns the slow score from which the leaders of a store are evicted.
+func (o *PersistOptions) GetSlowStoreEvictThreshold() uint64 {
+	return o.GetScheduleConfig().SlowStoreEvictThreshold
+}
+
+// GetSlowStoreRecoverThreshold returns the slow score below which an evicted store is considered recovered.
+func (o *PersistOptions) GetSlowStoreRecoverThreshold() uint64 {
+	return o.GetScheduleConfig().SlowStoreRecoverThreshold
+}
+
+// GetSlowStoreRecoveryDuration returns how long an evicted store must stay recovered before leaders are allowed back.
+func (o *PersistOptions) GetSlowStoreRecoveryDuration() time.Duration {
+	return o.GetScheduleConfig().SlowStoreRecoveryDuration.Duration
+}
+
 // GetMaxStoreDownTime returns the max down time of a store.
 func (o *PersistOptions) GetMaxStoreDownTime() time.Duration {
 	return o.GetScheduleConfig().MaxStoreDownTime.Duration
@@ -426,6 +581,11 @@ func (o *PersistOptions) GetSchedulerMaxWaitingOperator() uint64 {
 	return o.getTTLUintOr(schedulerMaxWaitingOperatorKey, o.GetScheduleConfig().SchedulerMaxWaitingOperator)
 }
 
+// GetMergeDirectionPolicy returns the policy of choosing the adjacent region to merge into.
+func (o *PersistOptions) GetMergeDirectionPolicy() string {
+	return o.GetScheduleConfig().MergeDirectionPolicy
+}
+
 // GetLeaderSchedulePolicy is to get leader schedule policy.
 func (o *PersistOptions) GetLeaderSchedulePolicy() core.SchedulePolicy {
 	return core.StringToSchedulePolicy(o.GetScheduleConfig().LeaderSchedulePolicy)
@@ -483,6 +643,26 @@ func (o *PersistOptions) IsLocationReplacementEnabled() bool {
 	return o.GetScheduleConfig().EnableLocationReplacement
 }
 
+// IsWeightedReplicaSchedulingEnabled returns if replica checker selects target stores by available capacity ratio.
+func (o *PersistOptions) IsWeightedReplicaSchedulingEnabled() bool {
+	return o.GetScheduleConfig().EnableWeightedReplicaScheduling
+}
+
+// GetReplicaMinFreeSpace returns the available space below which replica checker doesn't add a peer to a store.
+func (o *PersistOptions) GetReplicaMinFreeSpace() uint64 {
+	return uint64(o.GetScheduleConfig().ReplicaMinFreeSpace)
+}
+
+// GetReplicaMinFreeRatio returns the available ratio below which replica checker doesn't add a peer to a store.
+func (o *PersistOptions) GetReplicaMinFreeRatio() float64 {
+	return o.GetScheduleConfig().ReplicaMinFreeRatio
+}
+
+// GetLearnerCatchUpDuration returns how long a learner must stay healthy before it is promoted by learner checker.
+func (o *PersistOptions) GetLearnerCatchUpDuration() time.Duration {
+	return o.GetScheduleConfig().LearnerCatchUpDuration.Duration
+}
+
 // IsDebugMetricsEnabled returns if debug metrics is enabled.
 func (o *PersistOptions) IsDebugMetricsEnabled() bool {
 	return o.GetScheduleConfig().EnableDebugMetrics
@@ -493,22 +673,129 @@ func (o *PersistOptions) IsUseJointConsensus() bool {
 	return o.GetScheduleConfig().EnableJointConsensus
 }
 
+// IsReadOnlyClusterEnabled returns if the checkers should not return any operator.
+func (o *PersistOptions) IsReadOnlyClusterEnabled() bool {
+	return o.GetScheduleConfig().EnableReadOnlyCluster
+}
+
+// IsReadOnlyJointStateAllowed returns if the operators leaving the joint state are
+// still returned when the cluster is read-only.
+func (o *PersistOptions) IsReadOnlyJointStateAllowed() bool {
+	return o.GetScheduleConfig().ReadOnlyAllowJointState
+}
+
+// IsReadOnlyDuplicatePeerAllowed returns if the operators removing the duplicate
+// peers are still returned when the cluster is read-only.
+func (o *PersistOptions) IsReadOnlyDuplicatePeerAllowed() bool {
+	return o.GetScheduleConfig().ReadOnlyAllowDuplicatePeer
+}
+
+// IsAllPeersDownRecoveryEnabled returns if a peer is planned to be recreated for
+// the regions whose peers are all down.
+func (o *PersistOptions) IsAllPeersDownRecoveryEnabled() bool {
+	return o.GetScheduleConfig().EnableAllPeersDownRecovery
+}
+
 // IsTraceRegionFlow returns if the region flow is tracing.
 // If the accuracy cannot reach 0.1 MB, it is considered not.
 func (o *PersistOptions) IsTraceRegionFlow() bool {
 	return o.GetPDServerConfig().FlowRoundByDigit <= maxTraceFlowRoundByDigit
 }
 
+// GetCheckerOperatorDedupTTL returns how long the checkers skip an operator which is the same as a recent one.
+func (o *PersistOptions) GetCheckerOperatorDedupTTL() time.Duration {
+	return o.GetScheduleConfig().CheckerOperatorDedupTTL.Duration
+}
+
+// GetPlacementRulesRampDuration returns how long the rule checker takes to ramp up after the placement rules are enabled.
+func (o *PersistOptions) GetPlacementRulesRampDuration() time.Duration {
+	return o.GetScheduleConfig().PlacementRulesRampDuration.Duration
+}
+
+// GetMaxRuleLearnersPerRegion returns the max number of the learners in transition a region can have before the rule checker adds another learner.
+func (o *PersistOptions) GetMaxRuleLearnersPerRegion() int {
+	return int(o.GetScheduleConfig().MaxRuleLearnersPerRegion)
+}
+
 // GetHotRegionCacheHitsThreshold is a threshold to decide if a region is hot.
 func (o *PersistOptions) GetHotRegionCacheHitsThreshold() int {
 	return int(o.GetScheduleConfig().HotRegionCacheHitsThreshold)
 }
 
+// GetHotRegionRollingWindowSize returns the number of the averages which the load of a hot peer is the median of.
+func (o *PersistOptions) GetHotRegionRollingWindowSize() int {
+	return int(o.GetScheduleConfig().HotRegionRollingWindowSize)
+}
+
+// GetHotRegionTransitionHysteresis returns how long a region needs to stay hot or cold before it is reported.
+func (o *PersistOptions) GetHotRegionTransitionHysteresis() time.Duration {
+	return o.GetScheduleConfig().HotRegionTransitionHysteresis.Duration
+}
+
+// GetHotRegionQueryThreshold is a threshold to decide if a region is hot by its query rate.
+func (o *PersistOptions) GetHotRegionQueryThreshold() float64 {
+	return o.GetScheduleConfig().HotRegionQueryThreshold
+}
+
+// GetHotStoreWriteThresholds returns the write byte, key and query rates of a write-hot store.
+func (o *PersistOptions) GetHotStoreWriteThresholds() []float64 {
+	return o.GetScheduleConfig().HotStoreWriteThresholds
+}
+
+// GetHotStoreReadThresholds returns the read byte, key and query rates of a read-hot store.
+func (o *PersistOptions) GetHotStoreReadThresholds() []float64 {
+	return o.GetScheduleConfig().HotStoreReadThresholds
+}
+
 // GetStoresLimit gets the stores' limit.
 func (o *PersistOptions) GetStoresLimit() map[uint64]StoreLimitConfig {
 	return o.GetScheduleConfig().StoreLimit
 }
 
+// GetSkipCheckersLabel returns the key of the region label which makes the checkers skip a region.
+func (o *PersistOptions) GetSkipCheckersLabel() string {
+	return o.GetScheduleConfig().SkipCheckersLabel
+}
+
+// GetCheckRegionPolicy returns the policy of choosing the operators returned by checking a region.
+func (o *PersistOptions) GetCheckRegionPolicy() string {
+	return o.GetScheduleConfig().CheckRegionPolicy
+}
+
+// IsCheckerDisabled returns true if the checker is disabled.
+func (o *PersistOptions) IsCheckerDisabled(name string) bool {
+	for _, disabled := range o.GetScheduleConfig().DisabledCheckers {
+		if disabled == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCheckerDisabled disables or enables the checker.
+func (o *PersistOptions) SetCheckerDisabled(name string, disabled bool) {
+	v := o.GetScheduleConfig().Clone()
+	names := v.DisabledCheckers[:0]
+	for _, n := range v.DisabledCheckers {
+		if n != name {
+			names = append(names, n)
+		}
+	}
+	if disabled {
+		names = append(names, name)
+	}
+	v.DisabledCheckers = names
+	o.SetScheduleConfig(v)
+}
+
+// GetCheckerOrder returns the order in which the checkers check a region.
+func (o *PersistOptions) GetCheckerOrder() []string {
+	if order := o.GetScheduleConfig().CheckerOrder; len(order) > 0 {
+		return order
+	}
+	return DefaultCheckerOrder
+}
+
 // GetSchedulers gets the scheduler configurations.
 func (o *PersistOptions) GetSchedulers() SchedulerConfigs {
 	return o.GetScheduleConfig().Schedulers
@@ -614,6 +901,8 @@ func (o *PersistOptions) adjustScheduleCfg(scheduleCfg *ScheduleConfig) {
 			scheduleCfg.Schedulers = append(scheduleCfg.Schedulers, ps)
 		}
 	}
+	// In case we add new checkers.
+	adjustCheckerOrder(&scheduleCfg.CheckerOrder)
 	scheduleCfg.MigrateDeprecatedFlags()
 }
 
@@ -242,6 +242,13 @@ func (bc *BasicCluster) GetStoreRegionCount(storeID uint64) int {
 	return bc.Regions.GetStoreLeaderCount(storeID) + bc.Regions.GetStoreFollowerCount(storeID) + bc.Regions.GetStoreLearnerCount(storeID)
 }
 
+// GetStoreLearnerCount get the total count of a store's learner RegionInfo.
+func (bc *BasicCluster) GetStoreLearnerCount(storeID uint64) int {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Regions.GetStoreLearnerCount(storeID)
+}
+
 // GetStoreLeaderCount get the total count of a store's leader RegionInfo.
 func (bc *BasicCluster) GetStoreLeaderCount(storeID uint64) int {
 	bc.RLock()
@@ -424,6 +431,7 @@ type RegionSetInformer interface {
 	RandPendingRegion(storeID uint64, ranges []KeyRange, opts ...RegionOption) *RegionInfo
 	GetAverageRegionSize() int64
 	GetStoreRegionCount(storeID uint64) int
+	GetStoreLearnerCount(storeID uint64) int
 	GetRegion(id uint64) *RegionInfo
 	GetAdjacentRegions(region *RegionInfo) (*RegionInfo, *RegionInfo)
 	ScanRegions(startKey, endKey []byte, limit int) []*RegionInfo
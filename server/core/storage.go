@@ -48,6 +48,7 @@ const (
 	componentPath              = "component"
 	customScheduleConfigPath   = "scheduler_config"
 	encryptionKeysPath         = "encryption_keys"
+	waitingRegionsPath         = "checker/waiting_regions"
 	gcWorkerServiceSafePointID = "gc_worker"
 )
 
@@ -357,6 +358,28 @@ func (s *Storage) LoadReplicationStatus(mode string, status interface{}) (bool,
 	return true, nil
 }
 
+// SaveWaitingRegions stores the IDs of the regions in the waiting list of the checkers.
+func (s *Storage) SaveWaitingRegions(regionIDs []uint64) error {
+	value, err := json.Marshal(regionIDs)
+	if err != nil {
+		return errs.ErrJSONMarshal.Wrap(err).GenWithStackByArgs()
+	}
+	return s.Save(waitingRegionsPath, string(value))
+}
+
+// LoadWaitingRegions loads the IDs of the regions in the waiting list of the checkers.
+func (s *Storage) LoadWaitingRegions() ([]uint64, error) {
+	v, err := s.Load(waitingRegionsPath)
+	if err != nil || v == "" {
+		return nil, err
+	}
+	var regionIDs []uint64
+	if err := json.Unmarshal([]byte(v), &regionIDs); err != nil {
+		return nil, errs.ErrJSONUnmarshal.Wrap(err).GenWithStackByArgs()
+	}
+	return regionIDs, nil
+}
+
 // SaveComponent stores marshallable components to the componentPath.
 func (s *Storage) SaveComponent(component interface{}) error {
 	value, err := json.Marshal(component)
@@ -184,6 +184,18 @@ func (s *testKVSuite) TestLoadRegionsExceedRangeLimit(c *C) {
 	}
 }
 
+func (s *testKVSuite) TestWaitingRegions(c *C) {
+	storage := NewStorage(kv.NewMemoryKV())
+	regionIDs, err := storage.LoadWaitingRegions()
+	c.Assert(err, IsNil)
+	c.Assert(regionIDs, HasLen, 0)
+
+	c.Assert(storage.SaveWaitingRegions([]uint64{3, 1, 2}), IsNil)
+	regionIDs, err = storage.LoadWaitingRegions()
+	c.Assert(err, IsNil)
+	c.Assert(regionIDs, DeepEquals, []uint64{3, 1, 2})
+}
+
 func (s *testKVSuite) TestLoadGCSafePoint(c *C) {
 	storage := NewStorage(kv.NewMemoryKV())
 	testData := []uint64{0, 1, 2, 233, 2333, 23333333333, math.MaxUint64}
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"net/http"
 	"path"
 	"strconv"
@@ -155,6 +156,103 @@ func (h *Handler) IsCheckerPaused(name string) (bool, error) {
 	return rc.IsCheckerPaused(name)
 }
 
+// ListCheckers returns the status of all checkers.
+func (h *Handler) ListCheckers() ([]schedule.CheckerStatus, error) {
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		return nil, err
+	}
+	return rc.ListCheckers()
+}
+
+// ExportCheckerState returns the pause and enabled states of all checkers.
+func (h *Handler) ExportCheckerState() (*schedule.CheckersState, error) {
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		return nil, err
+	}
+	return rc.ExportCheckerState()
+}
+
+// ImportCheckerState restores the pause and enabled states of the checkers.
+func (h *Handler) ImportCheckerState(state *schedule.CheckersState) error {
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		return err
+	}
+	return rc.ImportCheckerState(state)
+}
+
+// GetWaitingRegionInfos returns the regions in the waiting list of the checkers.
+func (h *Handler) GetWaitingRegionInfos() ([]*schedule.WaitingRegionInfo, error) {
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		return nil, err
+	}
+	return rc.GetWaitingRegionInfos()
+}
+
+// SetPriorityRanges replaces the priority key ranges of the checkers. The
+// regions overlapping them are checked and fixed before the others.
+func (h *Handler) SetPriorityRanges(ranges []core.KeyRange) error {
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		return err
+	}
+	return rc.SetPriorityRanges(ranges)
+}
+
+// GetPriorityRanges returns the priority key ranges of the checkers.
+func (h *Handler) GetPriorityRanges() ([]core.KeyRange, error) {
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		return nil, err
+	}
+	return rc.GetPriorityRanges()
+}
+
+// SetLowChurnWindow declares a low-churn window of the duration from now, for
+// an external job such as a backup to keep the cluster quiet while it runs.
+// The merge and balance operators are not produced in the window, while the
+// unhealthy regions are still fixed. A non-positive duration ends the window.
+func (h *Handler) SetLowChurnWindow(d time.Duration) error {
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		return err
+	}
+	return rc.SetLowChurnWindow(d)
+}
+
+// GetLowChurnWindow returns the time when the low-churn window ends, or the
+// zero time if there is no window in effect.
+func (h *Handler) GetLowChurnWindow() (time.Time, error) {
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return rc.GetLowChurnWindow()
+}
+
+// StartDecisionTrace makes the checkers write their decisions about every region
+// they check to w as newline delimited JSON, and drop the operators if dryRun is true.
+func (h *Handler) StartDecisionTrace(w io.Writer, dryRun bool) error {
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		return err
+	}
+	return rc.StartDecisionTrace(w, dryRun)
+}
+
+// StopDecisionTrace stops the decision trace of the checkers and returns the
+// number of the records written.
+func (h *Handler) StopDecisionTrace() (uint64, error) {
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		return 0, err
+	}
+	return rc.StopDecisionTrace()
+}
+
 // GetStores returns all stores in the cluster.
 func (h *Handler) GetStores() ([]*core.StoreInfo, error) {
 	rc := h.s.GetRaftCluster()
@@ -269,6 +367,19 @@ func (h *Handler) PauseOrResumeChecker(name string, t int64) error {
 	return err
 }
 
+// SetCheckerEnabled enables or disables checker. A disabled checker keeps
+// disabled after PD restarts.
+func (h *Handler) SetCheckerEnabled(name string, enabled bool) error {
+	c, err := h.GetRaftCluster()
+	if err != nil {
+		return err
+	}
+	if err = c.SetCheckerEnabled(name, enabled); err != nil {
+		log.Error("can not set checker enabled", zap.String("checker-name", name), zap.Bool("enabled", enabled), errs.ZapError(err))
+	}
+	return err
+}
+
 // AddBalanceLeaderScheduler adds a balance-leader-scheduler.
 func (h *Handler) AddBalanceLeaderScheduler() error {
 	return h.AddScheduler(schedulers.BalanceLeaderType)
@@ -279,6 +390,16 @@ func (h *Handler) AddBalanceRegionScheduler() error {
 	return h.AddScheduler(schedulers.BalanceRegionType)
 }
 
+// AddBalanceRegionSizeScheduler adds a balance-region-size-scheduler.
+func (h *Handler) AddBalanceRegionSizeScheduler() error {
+	return h.AddScheduler(schedulers.BalanceRegionSizeType)
+}
+
+// AddBalanceLearnerScheduler adds a balance-learner-scheduler.
+func (h *Handler) AddBalanceLearnerScheduler() error {
+	return h.AddScheduler(schedulers.BalanceLearnerType)
+}
+
 // AddBalanceHotRegionScheduler adds a balance-hot-region-scheduler.
 func (h *Handler) AddBalanceHotRegionScheduler() error {
 	return h.AddScheduler(schedulers.HotRegionType)
@@ -289,6 +410,12 @@ func (h *Handler) AddLabelScheduler() error {
 	return h.AddScheduler(schedulers.LabelType)
 }
 
+// AddColocateLeaderScheduler adds a colocate-leader-scheduler, which colocates
+// the leaders of the adjacent regions in the given key ranges.
+func (h *Handler) AddColocateLeaderScheduler(args ...string) error {
+	return h.AddScheduler(schedulers.ColocateLeaderType, args...)
+}
+
 // AddScatterRangeScheduler adds a balance-range-leader-scheduler
 func (h *Handler) AddScatterRangeScheduler(args ...string) error {
 	return h.AddScheduler(schedulers.ScatterRangeType, args...)
@@ -299,9 +426,14 @@ func (h *Handler) AddGrantLeaderScheduler(storeID uint64) error {
 	return h.AddScheduler(schedulers.GrantLeaderType, strconv.FormatUint(storeID, 10))
 }
 
-// AddEvictLeaderScheduler adds an evict-leader-scheduler.
-func (h *Handler) AddEvictLeaderScheduler(storeID uint64) error {
-	return h.AddScheduler(schedulers.EvictLeaderType, strconv.FormatUint(storeID, 10))
+// AddEvictLeaderScheduler adds an evict-leader-scheduler. If timeout is not 0,
+// the store is no longer evicted once it holds no leader or the timeout fires.
+func (h *Handler) AddEvictLeaderScheduler(storeID uint64, timeout time.Duration) error {
+	args := []string{strconv.FormatUint(storeID, 10)}
+	if timeout > 0 {
+		args = append(args, timeout.String())
+	}
+	return h.AddScheduler(schedulers.EvictLeaderType, args...)
 }
 
 // AddShuffleLeaderScheduler adds a shuffle-leader-scheduler.
@@ -319,11 +451,34 @@ func (h *Handler) AddShuffleHotRegionScheduler(limit uint64) error {
 	return h.AddScheduler(schedulers.ShuffleHotRegionType, strconv.FormatUint(limit, 10))
 }
 
+// AddReadQueryHotScheduler adds a read-query-hot-scheduler.
+func (h *Handler) AddReadQueryHotScheduler(margin float64) error {
+	return h.AddScheduler(schedulers.ReadQueryHotType, strconv.FormatFloat(margin, 'f', -1, 64))
+}
+
+// AddBalanceZoneRegionScheduler adds a balance-zone-region-scheduler.
+func (h *Handler) AddBalanceZoneRegionScheduler(labelKey string) error {
+	return h.AddScheduler(schedulers.BalanceZoneRegionType, labelKey)
+}
+
+// AddPreventiveSplitScheduler adds a preventive-split-scheduler, which splits
+// the regions whose write load ratio to the hot threshold is in [lowRatio, highRatio].
+func (h *Handler) AddPreventiveSplitScheduler(lowRatio, highRatio float64) error {
+	return h.AddScheduler(schedulers.PreventiveSplitType,
+		strconv.FormatFloat(lowRatio, 'f', -1, 64), strconv.FormatFloat(highRatio, 'f', -1, 64))
+}
+
 // AddEvictSlowStoreScheduler adds a evict-slow-store-scheduler.
 func (h *Handler) AddEvictSlowStoreScheduler() error {
 	return h.AddScheduler(schedulers.EvictSlowStoreType)
 }
 
+// AddDrainStoreScheduler adds a drain-store-scheduler, which moves all peers
+// out of the store and removes itself then.
+func (h *Handler) AddDrainStoreScheduler(storeID uint64) error {
+	return h.AddScheduler(schedulers.DrainStoreType, strconv.FormatUint(storeID, 10))
+}
+
 // AddRandomMergeScheduler adds a random-merge-scheduler.
 func (h *Handler) AddRandomMergeScheduler() error {
 	return h.AddScheduler(schedulers.RandomMergeType)
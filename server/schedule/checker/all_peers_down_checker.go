@@ -0,0 +1,186 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/opt"
+	"go.uber.org/zap"
+)
+
+// AllPeersDownChecker finds the regions whose voters are all on the down
+// stores. Such a region has lost its quorum for good, and none of the other
+// checkers can help it, since there is no leader to carry out an operator.
+//
+// The regions are left alone by default. If the recovery is enabled, a store is
+// picked to recreate a peer of the region on by unsafe recovery, which may lose
+// the data not yet applied, and the region is marked for manual review. Since
+// there is no leader to send an operator to, the peer is not created by PD
+// itself but by the unsafe recovery tools following the plan. No recovery is
+// planned when the cluster is read-only.
+type AllPeersDownChecker struct {
+	PauseController
+	cluster opt.Cluster
+
+	mu sync.Mutex
+	// regions are the regions found with all peers down.
+	regions map[uint64]*AllPeersDownRegion
+}
+
+// AllPeersDownRegion is a region whose voters are all down.
+type AllPeersDownRegion struct {
+	RegionID uint64         `json:"region-id"`
+	Peers    []*metapb.Peer `json:"peers"`
+	// RecoveryStore is the store picked to recreate a peer of the region on,
+	// or 0 if the recovery is not enabled or there is no store to pick.
+	RecoveryStore uint64 `json:"recovery-store,omitempty"`
+	// NeedsReview is true if a recovery is planned for the region, which must
+	// be reviewed by hand since it may lose data.
+	NeedsReview bool `json:"needs-review"`
+}
+
+// NewAllPeersDownChecker creates an all peers down checker.
+func NewAllPeersDownChecker(cluster opt.Cluster) *AllPeersDownChecker {
+	return &AllPeersDownChecker{
+		cluster: cluster,
+		regions: make(map[uint64]*AllPeersDownRegion),
+	}
+}
+
+// GetType returns AllPeersDownChecker's type.
+func (c *AllPeersDownChecker) GetType() string {
+	return "all-peers-down-checker"
+}
+
+// Check returns true if the voters of the region are all down, and records the
+// region. The recovery is planned for the region if it is enabled.
+func (c *AllPeersDownChecker) Check(region *core.RegionInfo) bool {
+	checkerCounter.WithLabelValues("all_peers_down_checker", "check").Inc()
+	if c.IsPaused() {
+		checkerCounter.WithLabelValues("all_peers_down_checker", "paused").Inc()
+		return false
+	}
+	if !c.isAllPeersDown(region) {
+		c.forget(region.GetID())
+		return false
+	}
+	checkerCounter.WithLabelValues("all_peers_down_checker", "all-peers-down").Inc()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.regions[region.GetID()]
+	if !ok {
+		r = &AllPeersDownRegion{RegionID: region.GetID(), Peers: region.GetPeers()}
+		c.regions[region.GetID()] = r
+		log.Error("all peers of region are down, which can not be fixed by the checkers",
+			zap.Uint64("region-id", region.GetID()),
+			zap.Stringer("region", core.RegionToHexMeta(region.GetMeta())))
+	}
+	if !c.cluster.GetOpts().IsAllPeersDownRecoveryEnabled() || r.NeedsReview {
+		return true
+	}
+	if c.cluster.GetOpts().IsReadOnlyClusterEnabled() {
+		checkerCounter.WithLabelValues("all_peers_down_checker", "read-only").Inc()
+		return true
+	}
+	storeID := c.selectRecoveryStore(region)
+	if storeID == 0 {
+		checkerCounter.WithLabelValues("all_peers_down_checker", "no-recovery-store").Inc()
+		log.Warn("no store to recreate a peer of region whose peers are all down",
+			zap.Uint64("region-id", region.GetID()))
+		return true
+	}
+	r.RecoveryStore, r.NeedsReview = storeID, true
+	checkerCounter.WithLabelValues("all_peers_down_checker", "recovery-planned").Inc()
+	log.Warn("plan to recreate a peer of region whose peers are all down by unsafe recovery, which may lose data and must be reviewed by hand",
+		zap.Uint64("region-id", region.GetID()),
+		zap.Uint64("store-id", storeID),
+		zap.Stringer("region", core.RegionToHexMeta(region.GetMeta())))
+	return true
+}
+
+// CheckDryRun returns true if the voters of the region are all down, without
+// recording the region or planning the recovery.
+func (c *AllPeersDownChecker) CheckDryRun(region *core.RegionInfo) bool {
+	return c.isAllPeersDown(region)
+}
+
+// isAllPeersDown returns true if the region has voters and they are all on the
+// stores which are down, tombstone or gone.
+func (c *AllPeersDownChecker) isAllPeersDown(region *core.RegionInfo) bool {
+	voters := region.GetVoters()
+	if len(voters) == 0 {
+		return false
+	}
+	maxDownTime := c.cluster.GetOpts().GetMaxStoreDownTime()
+	for _, peer := range voters {
+		store := c.cluster.GetStore(peer.GetStoreId())
+		if store != nil && !store.IsTombstone() && store.DownTime() < maxDownTime {
+			return false
+		}
+	}
+	return true
+}
+
+// selectRecoveryStore returns the up store with the fewest regions which holds
+// no peer of the region, or 0 if there is none.
+func (c *AllPeersDownChecker) selectRecoveryStore(region *core.RegionInfo) uint64 {
+	var target *core.StoreInfo
+	for _, store := range c.cluster.GetStores() {
+		if !store.IsUp() || store.IsDisconnected() || region.GetStorePeer(store.GetID()) != nil {
+			continue
+		}
+		if target == nil || store.GetRegionCount() < target.GetRegionCount() {
+			target = store
+		}
+	}
+	if target == nil {
+		return 0
+	}
+	return target.GetID()
+}
+
+func (c *AllPeersDownChecker) forget(regionID uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.regions[regionID]; ok {
+		log.Info("region whose peers are all down recovers", zap.Uint64("region-id", regionID))
+		delete(c.regions, regionID)
+	}
+}
+
+// GetAllPeersDownRegions returns the regions which are found with all peers
+// down sorted by the region ID. The regions which no longer exist are dropped.
+func (c *AllPeersDownChecker) GetAllPeersDownRegions() []*AllPeersDownRegion {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	regions := make([]*AllPeersDownRegion, 0, len(c.regions))
+	for id, r := range c.regions {
+		if c.cluster.GetRegion(id) == nil {
+			delete(c.regions, id)
+			continue
+		}
+		copied := *r
+		regions = append(regions, &copied)
+	}
+	sort.Slice(regions, func(i, j int) bool {
+		return regions[i].RegionID < regions[j].RegionID
+	})
+	return regions
+}
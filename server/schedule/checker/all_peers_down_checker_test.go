@@ -0,0 +1,102 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"context"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/mock/mockcluster"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+)
+
+var _ = Suite(&testAllPeersDownCheckerSuite{})
+
+type testAllPeersDownCheckerSuite struct {
+	cluster *mockcluster.Cluster
+	apdc    *AllPeersDownChecker
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+func (s *testAllPeersDownCheckerSuite) SetUpTest(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.cluster = mockcluster.NewCluster(s.ctx, config.NewTestOptions())
+	s.apdc = NewAllPeersDownChecker(s.cluster)
+	for id := uint64(1); id <= 5; id++ {
+		s.cluster.PutStoreWithLabels(id)
+	}
+}
+
+func (s *testAllPeersDownCheckerSuite) TearDownTest(c *C) {
+	s.cancel()
+}
+
+func (s *testAllPeersDownCheckerSuite) TestAllPeersDown(c *C) {
+	s.cluster.AddLeaderRegion(1, 1, 2, 3)
+	s.cluster.SetStoreDown(1)
+	s.cluster.SetStoreDown(2)
+	c.Assert(s.apdc.Check(s.cluster.GetRegion(1)), IsFalse)
+
+	// The learners don't count.
+	region := s.cluster.GetRegion(1)
+	learner := &metapb.Peer{Id: 100, StoreId: 4, Role: metapb.PeerRole_Learner}
+	s.cluster.PutRegion(region.Clone(core.WithAddPeer(learner)))
+	s.cluster.SetStoreDown(3)
+	c.Assert(s.apdc.Check(s.cluster.GetRegion(1)), IsTrue)
+	regions := s.apdc.GetAllPeersDownRegions()
+	c.Assert(regions, HasLen, 1)
+	c.Assert(regions[0].RegionID, Equals, uint64(1))
+	c.Assert(regions[0].Peers, HasLen, 4)
+	// The region is left alone by default.
+	c.Assert(regions[0].RecoveryStore, Equals, uint64(0))
+	c.Assert(regions[0].NeedsReview, IsFalse)
+
+	// The region is forgotten once a store comes back.
+	s.cluster.PutStoreWithLabels(3)
+	c.Assert(s.apdc.Check(s.cluster.GetRegion(1)), IsFalse)
+	c.Assert(s.apdc.GetAllPeersDownRegions(), HasLen, 0)
+}
+
+func (s *testAllPeersDownCheckerSuite) TestRecovery(c *C) {
+	s.cluster.AddLeaderRegion(1, 1, 2, 3)
+	s.cluster.UpdateRegionCount(4, 10)
+	s.cluster.UpdateRegionCount(5, 5)
+	for id := uint64(1); id <= 3; id++ {
+		s.cluster.SetStoreDown(id)
+	}
+	s.cluster.SetEnableAllPeersDownRecovery(true)
+	// No recovery is planned when the cluster is read-only.
+	s.cluster.SetEnableReadOnlyCluster(true)
+	c.Assert(s.apdc.Check(s.cluster.GetRegion(1)), IsTrue)
+	c.Assert(s.apdc.GetAllPeersDownRegions()[0].NeedsReview, IsFalse)
+	s.cluster.SetEnableReadOnlyCluster(false)
+	c.Assert(s.apdc.Check(s.cluster.GetRegion(1)), IsTrue)
+	regions := s.apdc.GetAllPeersDownRegions()
+	c.Assert(regions, HasLen, 1)
+	c.Assert(regions[0].RecoveryStore, Equals, uint64(5))
+	c.Assert(regions[0].NeedsReview, IsTrue)
+
+	// The plan is kept until the region is reviewed.
+	s.cluster.UpdateRegionCount(4, 1)
+	c.Assert(s.apdc.Check(s.cluster.GetRegion(1)), IsTrue)
+	c.Assert(s.apdc.GetAllPeersDownRegions()[0].RecoveryStore, Equals, uint64(5))
+
+	// The regions which no longer exist are dropped.
+	s.cluster.RemoveRegion(s.cluster.GetRegion(1))
+	c.Assert(s.apdc.GetAllPeersDownRegions(), HasLen, 0)
+}
@@ -19,19 +19,59 @@ import (
 	"time"
 )
 
-// PauseController sets and stores delay time in checkers.
+// PauseController sets and stores delay time in checkers. It also holds the
+// clock of the checker, which is the real clock if not set.
 type PauseController struct {
 	delayUntil int64
+	clock      Clock
+}
+
+// SetClock sets the clock used by the checker. It should be called before the
+// checker is used.
+func (c *PauseController) SetClock(clock Clock) {
+	c.clock = clock
+}
+
+func (c *PauseController) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock.Now()
 }
 
 // IsPaused check if checker is paused
 func (c *PauseController) IsPaused() bool {
 	delayUntil := atomic.LoadInt64(&c.delayUntil)
-	return time.Now().Unix() < delayUntil
+	return c.now().Unix() < delayUntil
+}
+
+// RemainingDelay returns how many seconds the checker is still paused for.
+func (c *PauseController) RemainingDelay() int64 {
+	delay := atomic.LoadInt64(&c.delayUntil) - c.now().Unix()
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// PausedUntil returns the unix time in seconds until which the checker is
+// paused, which is 0 if the checker is not paused.
+func (c *PauseController) PausedUntil() int64 {
+	delayUntil := atomic.LoadInt64(&c.delayUntil)
+	if c.now().Unix() >= delayUntil {
+		return 0
+	}
+	return delayUntil
+}
+
+// PauseUntil pauses the checker until the unix time in seconds. The checker
+// is resumed if the time has passed.
+func (c *PauseController) PauseUntil(delayUntil int64) {
+	atomic.StoreInt64(&c.delayUntil, delayUntil)
 }
 
 // PauseOrResume pause or resume the checker
 func (c *PauseController) PauseOrResume(t int64) {
-	delayUntil := time.Now().Unix() + t
+	delayUntil := c.now().Unix() + t
 	atomic.StoreInt64(&c.delayUntil, delayUntil)
 }
@@ -0,0 +1,148 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"go.uber.org/zap"
+)
+
+// DuplicatePeerChecker ensures the peers of a region are on distinct stores.
+// A store holding more than one peer of a region only happens after a
+// corrupted membership change, and the extra peers are removed.
+type DuplicatePeerChecker struct {
+	PauseController
+	cluster opt.Cluster
+
+	mu sync.Mutex
+	// regions are the regions found with duplicate peers.
+	regions map[uint64]struct{}
+}
+
+// NewDuplicatePeerChecker creates a duplicate peer checker.
+func NewDuplicatePeerChecker(cluster opt.Cluster) *DuplicatePeerChecker {
+	return &DuplicatePeerChecker{
+		cluster: cluster,
+		regions: make(map[uint64]struct{}),
+	}
+}
+
+// GetType returns DuplicatePeerChecker's type.
+func (c *DuplicatePeerChecker) GetType() string {
+	return "duplicate-peer-checker"
+}
+
+// Check removes one of the duplicate peers of the region if any.
+func (c *DuplicatePeerChecker) Check(region *core.RegionInfo) *operator.Operator {
+	checkerCounter.WithLabelValues("duplicate_peer_checker", "check").Inc()
+	if c.IsPaused() {
+		checkerCounter.WithLabelValues("duplicate_peer_checker", "paused").Inc()
+		return nil
+	}
+	peer := duplicatePeerToRemove(region)
+	if peer == nil {
+		c.forget(region.GetID())
+		return nil
+	}
+	c.record(region.GetID())
+	log.Warn("region has more than one peer on a store",
+		zap.Uint64("region-id", region.GetID()),
+		zap.Uint64("store-id", peer.GetStoreId()),
+		zap.Stringer("region", core.RegionToHexMeta(region.GetMeta())))
+	checkerCounter.WithLabelValues("duplicate_peer_checker", "duplicate-peer").Inc()
+	op, err := operator.CreateRemoveDuplicatePeerOperator("remove-duplicate-peer", region, peer)
+	if err != nil {
+		checkerCounter.WithLabelValues("duplicate_peer_checker", "create-operator-fail").Inc()
+		log.Debug("fail to create remove duplicate peer operator", errs.ZapError(err))
+		return nil
+	}
+	checkerCounter.WithLabelValues("duplicate_peer_checker", "new-operator").Inc()
+	op.SetPriorityLevel(core.HighPriority)
+	return op
+}
+
+// duplicatePeerToRemove returns one of the peers to remove from a store which
+// holds more than one peer of the region, or nil if there is none. The leader
+// is kept, and so are the voters before the learners, and the older peers
+// before the newer ones.
+func duplicatePeerToRemove(region *core.RegionInfo) *metapb.Peer {
+	peers := region.GetPeers()
+	kept := make(map[uint64]*metapb.Peer, len(peers))
+	var toRemove *metapb.Peer
+	for _, peer := range peers {
+		other, ok := kept[peer.GetStoreId()]
+		if !ok {
+			kept[peer.GetStoreId()] = peer
+			continue
+		}
+		if keepBefore(region, peer, other) {
+			kept[peer.GetStoreId()], peer = peer, other
+		}
+		if toRemove == nil {
+			toRemove = peer
+		}
+	}
+	return toRemove
+}
+
+// keepBefore returns true if the peer is kept before the other peer on the same store.
+func keepBefore(region *core.RegionInfo, peer, other *metapb.Peer) bool {
+	leaderID := region.GetLeader().GetId()
+	if peer.GetId() == leaderID || other.GetId() == leaderID {
+		return peer.GetId() == leaderID
+	}
+	if core.IsLearner(peer) != core.IsLearner(other) {
+		return !core.IsLearner(peer)
+	}
+	return peer.GetId() < other.GetId()
+}
+
+func (c *DuplicatePeerChecker) record(regionID uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.regions[regionID] = struct{}{}
+}
+
+func (c *DuplicatePeerChecker) forget(regionID uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.regions, regionID)
+}
+
+// GetDuplicatePeerRegions returns the IDs of the regions which are found with
+// more than one peer on a store.
+func (c *DuplicatePeerChecker) GetDuplicatePeerRegions() []uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ids := make([]uint64, 0, len(c.regions))
+	for id := range c.regions {
+		// the region could have been fixed or merged without being checked.
+		if region := c.cluster.GetRegion(id); region == nil || duplicatePeerToRemove(region) == nil {
+			delete(c.regions, id)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"context"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/mock/mockcluster"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+var _ = Suite(&testDuplicatePeerCheckerSuite{})
+
+type testDuplicatePeerCheckerSuite struct {
+	cluster *mockcluster.Cluster
+	dpc     *DuplicatePeerChecker
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+func (s *testDuplicatePeerCheckerSuite) SetUpTest(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.cluster = mockcluster.NewCluster(s.ctx, config.NewTestOptions())
+	s.dpc = NewDuplicatePeerChecker(s.cluster)
+	for id := uint64(1); id <= 3; id++ {
+		s.cluster.PutStoreWithLabels(id)
+	}
+}
+
+func (s *testDuplicatePeerCheckerSuite) TearDownTest(c *C) {
+	s.cancel()
+}
+
+func (s *testDuplicatePeerCheckerSuite) TestRemoveDuplicatePeer(c *C) {
+	type testCase struct {
+		Peers   []*metapb.Peer // first is leader
+		OpSteps []operator.OpStep
+	}
+	cases := []testCase{
+		{
+			[]*metapb.Peer{
+				{Id: 101, StoreId: 1},
+				{Id: 102, StoreId: 2},
+				{Id: 103, StoreId: 3},
+			},
+			nil,
+		},
+		// the newer peer is removed.
+		{
+			[]*metapb.Peer{
+				{Id: 101, StoreId: 1},
+				{Id: 102, StoreId: 2},
+				{Id: 103, StoreId: 2},
+			},
+			[]operator.OpStep{
+				operator.RemovePeer{FromStore: 2, PeerID: 103, IsDuplicate: true},
+			},
+		},
+		// the learner is removed before the voter.
+		{
+			[]*metapb.Peer{
+				{Id: 101, StoreId: 1},
+				{Id: 102, StoreId: 2, Role: metapb.PeerRole_Learner},
+				{Id: 103, StoreId: 2},
+			},
+			[]operator.OpStep{
+				operator.RemovePeer{FromStore: 2, PeerID: 102, IsDuplicate: true},
+			},
+		},
+		// the leader is kept.
+		{
+			[]*metapb.Peer{
+				{Id: 103, StoreId: 1},
+				{Id: 101, StoreId: 1},
+				{Id: 102, StoreId: 2},
+			},
+			[]operator.OpStep{
+				operator.RemovePeer{FromStore: 1, PeerID: 101, IsDuplicate: true},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		region := core.NewRegionInfo(&metapb.Region{Id: 1, Peers: tc.Peers}, tc.Peers[0])
+		op := s.dpc.Check(region)
+		if tc.OpSteps == nil {
+			c.Assert(op, IsNil)
+			continue
+		}
+		c.Assert(op, NotNil)
+		c.Assert(op.GetPriorityLevel(), Equals, core.HighPriority)
+		c.Assert(op.Len(), Equals, len(tc.OpSteps))
+		for i := range tc.OpSteps {
+			c.Assert(op.Step(i), DeepEquals, tc.OpSteps[i])
+		}
+	}
+}
+
+func (s *testDuplicatePeerCheckerSuite) TestTransferLeaderBeforeRemove(c *C) {
+	peers := []*metapb.Peer{
+		{Id: 101, StoreId: 1},
+		{Id: 102, StoreId: 2},
+		{Id: 103, StoreId: 2},
+	}
+	// the checker keeps the leader.
+	region := core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers}, peers[1])
+	c.Assert(duplicatePeerToRemove(region).GetId(), Equals, uint64(103))
+
+	// the leader is transferred to another store before it is removed.
+	op, err := operator.CreateRemoveDuplicatePeerOperator("test", region, peers[1])
+	c.Assert(err, IsNil)
+	c.Assert(op.Kind()&operator.OpLeader, Not(Equals), operator.OpKind(0))
+	c.Assert(op.Len(), Equals, 2)
+	c.Assert(op.Step(0), DeepEquals, operator.TransferLeader{FromStore: 2, ToStore: 1})
+	c.Assert(op.Step(1), DeepEquals, operator.RemovePeer{FromStore: 2, PeerID: 102, IsDuplicate: true})
+
+	// the step is finished once the peer is removed, though the store still
+	// holds the other peer.
+	removed := core.NewRegionInfo(&metapb.Region{Id: 1, Peers: []*metapb.Peer{peers[0], peers[2]}}, peers[0])
+	c.Assert(op.Step(1).IsFinish(region), IsFalse)
+	c.Assert(op.Step(1).IsFinish(removed), IsTrue)
+	c.Assert(op.Step(1).CheckSafety(region), NotNil)
+}
+
+func (s *testDuplicatePeerCheckerSuite) TestDuplicatePeerRegions(c *C) {
+	peers := []*metapb.Peer{
+		{Id: 101, StoreId: 1},
+		{Id: 102, StoreId: 2},
+		{Id: 103, StoreId: 2},
+	}
+	region := core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers}, peers[0])
+	s.cluster.PutRegion(region)
+	c.Assert(s.dpc.GetDuplicatePeerRegions(), HasLen, 0)
+	c.Assert(s.dpc.Check(region), NotNil)
+	c.Assert(s.dpc.GetDuplicatePeerRegions(), DeepEquals, []uint64{1})
+
+	// the region is forgotten once it is fixed, even if it is not checked again.
+	fixed := core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers[:2]}, peers[0])
+	s.cluster.PutRegion(fixed)
+	c.Assert(s.dpc.GetDuplicatePeerRegions(), HasLen, 0)
+}
@@ -15,6 +15,10 @@
 package checker
 
 import (
+	"sort"
+	"sync"
+	"time"
+
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/core"
@@ -22,19 +26,36 @@ import (
 	"github.com/tikv/pd/server/schedule/opt"
 )
 
+// defaultStuckJointStateThreshold is the duration after which a region that
+// keeps producing leave-joint operators is considered to be stuck.
+const defaultStuckJointStateThreshold = 10 * time.Minute
+
 // JointStateChecker ensures region is in joint state will leave.
 type JointStateChecker struct {
 	PauseController
 	cluster opt.Cluster
+
+	mu sync.Mutex
+	// jointSince records when the checker began to create leave-joint
+	// operators for each region.
+	jointSince     map[uint64]time.Time
+	stuckThreshold time.Duration
 }
 
 // NewJointStateChecker creates a joint state checker.
 func NewJointStateChecker(cluster opt.Cluster) *JointStateChecker {
 	return &JointStateChecker{
-		cluster: cluster,
+		cluster:        cluster,
+		jointSince:     make(map[uint64]time.Time),
+		stuckThreshold: defaultStuckJointStateThreshold,
 	}
 }
 
+// GetType returns JointStateChecker's type.
+func (c *JointStateChecker) GetType() string {
+	return "joint-state-checker"
+}
+
 // Check verifies a region's role, creating an Operator if need.
 func (c *JointStateChecker) Check(region *core.RegionInfo) *operator.Operator {
 	checkerCounter.WithLabelValues("joint_state_checker", "check").Inc()
@@ -43,6 +64,7 @@ func (c *JointStateChecker) Check(region *core.RegionInfo) *operator.Operator {
 		return nil
 	}
 	if !core.IsInJointState(region.GetPeers()...) {
+		c.forget(region.GetID())
 		return nil
 	}
 	op, err := operator.CreateLeaveJointStateOperator("leave-joint-state", c.cluster, region)
@@ -56,6 +78,48 @@ func (c *JointStateChecker) Check(region *core.RegionInfo) *operator.Operator {
 			checkerCounter.WithLabelValues("joint_state_checker", "transfer-leader").Inc()
 		}
 		op.SetPriorityLevel(core.HighPriority)
+		if c.record(region.GetID()) {
+			checkerCounter.WithLabelValues("joint_state_checker", "stuck").Inc()
+		}
 	}
 	return op
 }
+
+// record records that a leave-joint operator is created for the region,
+// and returns true if the region has been stuck in joint state.
+func (c *JointStateChecker) record(regionID uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	since, ok := c.jointSince[regionID]
+	if !ok {
+		c.jointSince[regionID] = c.now()
+		return false
+	}
+	return c.now().Sub(since) > c.stuckThreshold
+}
+
+func (c *JointStateChecker) forget(regionID uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.jointSince, regionID)
+}
+
+// GetStuckJointRegions returns the IDs of the regions which have kept
+// producing leave-joint operators for longer than the threshold.
+func (c *JointStateChecker) GetStuckJointRegions() []uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var ids []uint64
+	for id, since := range c.jointSince {
+		// the region could have been merged or have left joint state without being checked.
+		if region := c.cluster.GetRegion(id); region == nil || !core.IsInJointState(region.GetPeers()...) {
+			delete(c.jointSince, id)
+			continue
+		}
+		if c.now().Sub(since) > c.stuckThreshold {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -16,6 +16,7 @@ package checker
 
 import (
 	"context"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -166,3 +167,38 @@ func (s *testJointStateCheckerSuite) checkSteps(c *C, op *operator.Operator, ste
 		}
 	}
 }
+
+func (s *testJointStateCheckerSuite) TestStuckJointRegions(c *C) {
+	jsc := s.jsc
+	clock := NewManualClock(time.Now())
+	jsc.SetClock(clock)
+	newRegion := func(role metapb.PeerRole) *core.RegionInfo {
+		peers := []*metapb.Peer{
+			{Id: 101, StoreId: 1, Role: metapb.PeerRole_Voter},
+			{Id: 102, StoreId: 2, Role: metapb.PeerRole_Voter},
+			{Id: 103, StoreId: 3, Role: role},
+		}
+		return core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers}, peers[0])
+	}
+	jointRegion, normalRegion := newRegion(metapb.PeerRole_IncomingVoter), newRegion(metapb.PeerRole_Voter)
+	s.cluster.PutRegion(jointRegion)
+
+	c.Assert(jsc.Check(jointRegion), NotNil)
+	c.Assert(jsc.GetStuckJointRegions(), HasLen, 0)
+
+	// the region keeps producing leave-joint operators.
+	jsc.stuckThreshold = 0
+	clock.Advance(time.Millisecond)
+	c.Assert(jsc.Check(jointRegion), NotNil)
+	c.Assert(jsc.GetStuckJointRegions(), DeepEquals, []uint64{1})
+
+	// the region leaves joint state.
+	c.Assert(jsc.Check(normalRegion), IsNil)
+	c.Assert(jsc.GetStuckJointRegions(), HasLen, 0)
+
+	// the stale record is dropped once the region is not in joint state.
+	c.Assert(jsc.Check(jointRegion), NotNil)
+	s.cluster.PutRegion(normalRegion)
+	c.Assert(jsc.GetStuckJointRegions(), HasLen, 0)
+	c.Assert(jsc.jointSince, HasLen, 0)
+}
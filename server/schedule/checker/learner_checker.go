@@ -15,6 +15,11 @@
 package checker
 
 import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/core"
@@ -22,32 +27,223 @@ import (
 	"github.com/tikv/pd/server/schedule/opt"
 )
 
+// The reasons why a learner is not promoted yet.
+const (
+	// PromotionSnapshotIncomplete means the learner is still catching up, such
+	// as applying the snapshot.
+	PromotionSnapshotIncomplete = "snapshot-incomplete"
+	// PromotionLearnerDown means the learner has not responded for a while.
+	PromotionLearnerDown = "learner-down"
+	// PromotionBuildFailed means the promote operator can not be built.
+	PromotionBuildFailed = "build-failed"
+	// PromotionCatchingUp means the learner can be promoted, but it has not
+	// stayed so for the learner catch up duration yet.
+	PromotionCatchingUp = "catching-up"
+)
+
 // LearnerChecker ensures region has a learner will be promoted.
 type LearnerChecker struct {
 	PauseController
 	cluster opt.Cluster
+
+	mu sync.Mutex
+	// pending are the learners which failed to be promoted the last time their
+	// regions were checked, keyed by the region ID.
+	pending map[uint64][]*PendingPromotion
+	// healthySince records since when each learner of a region has been able
+	// to be promoted, keyed by the region ID and then the peer ID. It is only
+	// recorded if the learner catch up duration is set.
+	healthySince map[uint64]map[uint64]time.Time
+}
+
+// PendingPromotion is a learner which is to be promoted by the checker but is
+// not promoted yet.
+type PendingPromotion struct {
+	RegionID uint64 `json:"region-id"`
+	PeerID   uint64 `json:"peer-id"`
+	StoreID  uint64 `json:"store-id"`
+	Reason   string `json:"reason"`
+	// Error is the error of building the promote operator, only set if the
+	// reason is build-failed.
+	Error string `json:"error,omitempty"`
+	// Since is when the learner was first found not promoted.
+	Since time.Time `json:"since"`
 }
 
 // NewLearnerChecker creates a learner checker.
 func NewLearnerChecker(cluster opt.Cluster) *LearnerChecker {
 	return &LearnerChecker{
-		cluster: cluster,
+		cluster:      cluster,
+		pending:      make(map[uint64][]*PendingPromotion),
+		healthySince: make(map[uint64]map[uint64]time.Time),
 	}
 }
 
+// GetType returns LearnerChecker's type.
+func (l *LearnerChecker) GetType() string {
+	return "learner-checker"
+}
+
 // Check verifies a region's role, creating an Operator if need.
 func (l *LearnerChecker) Check(region *core.RegionInfo) *operator.Operator {
 	if l.IsPaused() {
 		checkerCounter.WithLabelValues("learner_checker", "paused").Inc()
 		return nil
 	}
+	op, pending, healthySince := l.check(region)
+	l.record(region.GetID(), pending, healthySince)
+	return op
+}
+
+// CheckDryRun is like Check, but it doesn't record the learners which are not
+// promoted, so that the state of the checker is left untouched.
+func (l *LearnerChecker) CheckDryRun(region *core.RegionInfo) *operator.Operator {
+	op, _, _ := l.check(region)
+	return op
+}
+
+func (l *LearnerChecker) check(region *core.RegionInfo) (*operator.Operator, []*PendingPromotion, map[uint64]time.Time) {
+	catchUp := l.cluster.GetOpts().GetLearnerCatchUpDuration()
+	now := l.now()
+	var pending []*PendingPromotion
+	healthySince := make(map[uint64]time.Time)
 	for _, p := range region.GetLearners() {
 		op, err := operator.CreatePromoteLearnerOperator("promote-learner", l.cluster, region, p)
 		if err != nil {
 			log.Debug("fail to create promote learner operator", errs.ZapError(err))
+			pending = append(pending, newPendingPromotion(region, p, err))
+			continue
+		}
+		if catchUp > 0 {
+			since := l.getHealthySince(region.GetID(), p.GetId(), now)
+			healthySince[p.GetId()] = since
+			if now.Sub(since) < catchUp {
+				checkerCounter.WithLabelValues("learner_checker", "learner-not-caught-up").Inc()
+				pending = append(pending, &PendingPromotion{
+					RegionID: region.GetID(),
+					PeerID:   p.GetId(),
+					StoreID:  p.GetStoreId(),
+					Reason:   PromotionCatchingUp,
+				})
+				continue
+			}
+		}
+		return op, pending, healthySince
+	}
+	return nil, pending, healthySince
+}
+
+// getHealthySince returns since when the learner has been able to be promoted,
+// which is now if it is not recorded.
+func (l *LearnerChecker) getHealthySince(regionID, peerID uint64, now time.Time) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if since, ok := l.healthySince[regionID][peerID]; ok {
+		return since
+	}
+	return now
+}
+
+func newPendingPromotion(region *core.RegionInfo, peer *metapb.Peer, err error) *PendingPromotion {
+	p := &PendingPromotion{
+		RegionID: region.GetID(),
+		PeerID:   peer.GetId(),
+		StoreID:  peer.GetStoreId(),
+	}
+	switch {
+	case region.GetPendingPeer(peer.GetId()) != nil:
+		p.Reason = PromotionSnapshotIncomplete
+	case region.GetDownPeer(peer.GetId()) != nil:
+		p.Reason = PromotionLearnerDown
+	default:
+		p.Reason, p.Error = PromotionBuildFailed, err.Error()
+	}
+	return p
+}
+
+// record replaces the pending promotions and the healthy learners of the
+// region with the ones found by the latest check. A pending learner keeps the
+// time it was first found.
+func (l *LearnerChecker) record(regionID uint64, pending []*PendingPromotion, healthySince map[uint64]time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(healthySince) == 0 {
+		delete(l.healthySince, regionID)
+	} else {
+		l.healthySince[regionID] = healthySince
+	}
+	if len(pending) == 0 {
+		delete(l.pending, regionID)
+		return
+	}
+	now := l.now()
+	for _, p := range pending {
+		p.Since = now
+		for _, old := range l.pending[regionID] {
+			if old.PeerID == p.PeerID {
+				p.Since = old.Since
+				break
+			}
+		}
+	}
+	l.pending[regionID] = pending
+}
+
+// GetPendingPromotions returns the learners which the checker intends to
+// promote but failed to the last time their regions were checked, sorted by
+// the region ID. The regions which no longer exist or no longer have the
+// learners are dropped.
+func (l *LearnerChecker) GetPendingPromotions() []*PendingPromotion {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var promotions []*PendingPromotion
+	for id, pending := range l.pending {
+		region := l.cluster.GetRegion(id)
+		if region == nil {
+			delete(l.pending, id)
 			continue
 		}
-		return op
+		for _, p := range pending {
+			if learner := region.GetStoreLearner(p.StoreID); learner == nil || learner.GetId() != p.PeerID {
+				continue
+			}
+			copied := *p
+			promotions = append(promotions, &copied)
+		}
+	}
+	sort.Slice(promotions, func(i, j int) bool {
+		if promotions[i].RegionID != promotions[j].RegionID {
+			return promotions[i].RegionID < promotions[j].RegionID
+		}
+		return promotions[i].PeerID < promotions[j].PeerID
+	})
+	return promotions
+}
+
+// Prune drops the records of the regions which no longer exist.
+func (l *LearnerChecker) Prune() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for id := range l.pending {
+		if l.cluster.GetRegion(id) == nil {
+			delete(l.pending, id)
+		}
+	}
+	for id := range l.healthySince {
+		if l.cluster.GetRegion(id) == nil {
+			delete(l.healthySince, id)
+		}
+	}
+}
+
+// WillPromote returns true if the learner of the region on the store is to be
+// promoted by the checker once the region is checked, so that the schedulers
+// should not move it away. A learner which has not caught up can't be promoted.
+func (l *LearnerChecker) WillPromote(region *core.RegionInfo, storeID uint64) bool {
+	learner := region.GetStoreLearner(storeID)
+	if learner == nil {
+		return false
 	}
-	return nil
+	_, err := operator.CreatePromoteLearnerOperator("promote-learner", l.cluster, region, learner)
+	return err == nil
 }
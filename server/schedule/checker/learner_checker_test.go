@@ -16,9 +16,11 @@ package checker
 
 import (
 	"context"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/mock/mockcluster"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
@@ -71,3 +73,117 @@ func (s *testLearnerCheckerSuite) TestPromoteLearner(c *C) {
 	op = lc.Check(region)
 	c.Assert(op, IsNil)
 }
+
+func (s *testLearnerCheckerSuite) TestWillPromote(c *C) {
+	region := core.NewRegionInfo(
+		&metapb.Region{
+			Id: 1,
+			Peers: []*metapb.Peer{
+				{Id: 101, StoreId: 1},
+				{Id: 102, StoreId: 2},
+				{Id: 103, StoreId: 3, Role: metapb.PeerRole_Learner},
+			},
+		}, &metapb.Peer{Id: 101, StoreId: 1})
+	c.Assert(s.lc.WillPromote(region, 3), IsTrue)
+	// Only the learners are promoted.
+	c.Assert(s.lc.WillPromote(region, 2), IsFalse)
+	c.Assert(s.lc.WillPromote(region, 4), IsFalse)
+
+	// The learner which is catching up is not promoted.
+	region = region.Clone(core.WithPendingPeers([]*metapb.Peer{region.GetPeer(103)}))
+	c.Assert(s.lc.WillPromote(region, 3), IsFalse)
+}
+
+func (s *testLearnerCheckerSuite) TestPendingPromotions(c *C) {
+	clock := NewManualClock(time.Now())
+	s.lc.SetClock(clock)
+	region := core.NewRegionInfo(
+		&metapb.Region{
+			Id: 1,
+			Peers: []*metapb.Peer{
+				{Id: 101, StoreId: 1},
+				{Id: 102, StoreId: 2, Role: metapb.PeerRole_Learner},
+				{Id: 103, StoreId: 3, Role: metapb.PeerRole_Learner},
+			},
+		}, &metapb.Peer{Id: 101, StoreId: 1})
+	region = region.Clone(
+		core.WithPendingPeers([]*metapb.Peer{region.GetPeer(102)}),
+		core.WithDownPeers([]*pdpb.PeerStats{{Peer: region.GetPeer(103), DownSeconds: 100}}),
+	)
+	s.cluster.PutRegion(region)
+	c.Assert(s.lc.Check(region), IsNil)
+	since := clock.Now()
+	promotions := s.lc.GetPendingPromotions()
+	c.Assert(promotions, HasLen, 2)
+	c.Assert(promotions[0].PeerID, Equals, uint64(102))
+	c.Assert(promotions[0].Reason, Equals, PromotionSnapshotIncomplete)
+	c.Assert(promotions[1].PeerID, Equals, uint64(103))
+	c.Assert(promotions[1].Reason, Equals, PromotionLearnerDown)
+
+	// The learner keeps the time it was first found.
+	clock.Advance(time.Minute)
+	region = region.Clone(core.WithDownPeers(nil))
+	s.cluster.PutRegion(region)
+	op := s.lc.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Step(0).(operator.PromoteLearner).ToStore, Equals, uint64(3))
+	promotions = s.lc.GetPendingPromotions()
+	c.Assert(promotions, HasLen, 1)
+	c.Assert(promotions[0].PeerID, Equals, uint64(102))
+	c.Assert(promotions[0].Since.Equal(since), IsTrue)
+
+	// The learner which is no longer a learner is dropped.
+	s.cluster.PutRegion(region.Clone(core.WithRemoveStorePeer(2)))
+	c.Assert(s.lc.GetPendingPromotions(), HasLen, 0)
+
+	// The region is forgotten once all its learners can be promoted.
+	region = region.Clone(core.WithPendingPeers(nil))
+	s.cluster.PutRegion(region)
+	c.Assert(s.lc.Check(region), NotNil)
+	c.Assert(s.lc.GetPendingPromotions(), HasLen, 0)
+	c.Assert(s.lc.pending, HasLen, 0)
+}
+
+func (s *testLearnerCheckerSuite) TestLearnerCatchUp(c *C) {
+	clock := NewManualClock(time.Now())
+	s.lc.SetClock(clock)
+	s.cluster.SetLearnerCatchUpDuration(time.Minute)
+	region := core.NewRegionInfo(
+		&metapb.Region{
+			Id: 1,
+			Peers: []*metapb.Peer{
+				{Id: 101, StoreId: 1},
+				{Id: 102, StoreId: 2},
+				{Id: 103, StoreId: 3, Role: metapb.PeerRole_Learner},
+			},
+		}, &metapb.Peer{Id: 101, StoreId: 1})
+	pending := region.Clone(core.WithPendingPeers([]*metapb.Peer{region.GetPeer(103)}))
+	s.cluster.PutRegion(region)
+
+	// The learner must stay healthy for the catch up duration.
+	c.Assert(s.lc.Check(region), IsNil)
+	promotions := s.lc.GetPendingPromotions()
+	c.Assert(promotions, HasLen, 1)
+	c.Assert(promotions[0].Reason, Equals, PromotionCatchingUp)
+	clock.Advance(time.Minute / 2)
+	c.Assert(s.lc.CheckDryRun(region), IsNil)
+	c.Assert(s.lc.Check(region), IsNil)
+
+	// It waits for the whole duration again once it falls behind.
+	c.Assert(s.lc.Check(pending), IsNil)
+	c.Assert(s.lc.Check(region), IsNil)
+	clock.Advance(time.Minute / 2)
+	c.Assert(s.lc.Check(region), IsNil)
+	clock.Advance(time.Minute / 2)
+	c.Assert(s.lc.CheckDryRun(region), NotNil)
+	op := s.lc.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Step(0).(operator.PromoteLearner).ToStore, Equals, uint64(3))
+
+	// The records of the removed region are pruned.
+	c.Assert(s.lc.healthySince, HasLen, 1)
+	s.cluster.RemoveRegion(region)
+	s.lc.Prune()
+	c.Assert(s.lc.healthySince, HasLen, 0)
+	c.Assert(s.lc.pending, HasLen, 0)
+}
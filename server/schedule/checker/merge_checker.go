@@ -30,10 +30,16 @@ import (
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/opt"
 	"github.com/tikv/pd/server/schedule/placement"
+	"go.uber.org/zap"
 )
 
 const maxTargetRegionSize = 500
 
+// EmptyRegionMergeDesc is the description of the operators merging an empty
+// region, which are preferred to the other merge operators if the empty region
+// merge priority is enabled.
+const EmptyRegionMergeDesc = "merge-empty-region"
+
 // When a region has label `merge_option=deny`, skip merging the region.
 // If label value is `allow` or other value, it will be treated as `allow`.
 const (
@@ -41,144 +47,337 @@ const (
 	mergeOptionValueDeny = "deny"
 )
 
+// MergeBoundaryDetector decides whether two adjacent regions may be merged by
+// their keys, where left is the region before right. It lets the clusters with
+// custom key encodings decide the boundaries which must not be merged across.
+type MergeBoundaryDetector func(opts *config.PersistOptions, left, right *core.RegionInfo) bool
+
 // MergeChecker ensures region to merge with adjacent region when size is small
 type MergeChecker struct {
 	PauseController
-	cluster    opt.Cluster
-	opts       *config.PersistOptions
-	splitCache *cache.TTLUint64
-	startTime  time.Time // it's used to judge whether server recently start.
+	cluster          opt.Cluster
+	opts             *config.PersistOptions
+	splitCache       *cache.TTLUint64
+	startTime        time.Time // it's used to judge whether server recently start.
+	boundaryDetector MergeBoundaryDetector
+	dryRun           bool
 }
 
 // NewMergeChecker creates a merge checker.
 func NewMergeChecker(ctx context.Context, cluster opt.Cluster) *MergeChecker {
 	opts := cluster.GetOpts()
 	splitCache := cache.NewIDTTL(ctx, time.Minute, opts.GetSplitMergeInterval())
+	return newMergeChecker(cluster, splitCache)
+}
+
+// NewSyncMergeChecker creates a merge checker which runs no background goroutine,
+// so that its checks are purely synchronous. The expired split records are not
+// garbage collected, which is fine for tests.
+func NewSyncMergeChecker(cluster opt.Cluster) *MergeChecker {
+	opts := cluster.GetOpts()
+	splitCache := cache.NewIDTTL(context.Background(), 0, opts.GetSplitMergeInterval())
+	return newMergeChecker(cluster, splitCache)
+}
+
+func newMergeChecker(cluster opt.Cluster, splitCache *cache.TTLUint64) *MergeChecker {
+	opts := cluster.GetOpts()
 	return &MergeChecker{
-		cluster:    cluster,
-		opts:       opts,
-		splitCache: splitCache,
-		startTime:  time.Now(),
+		cluster:          cluster,
+		opts:             opts,
+		splitCache:       splitCache,
+		startTime:        time.Now(),
+		boundaryDetector: DefaultMergeBoundaryDetector,
 	}
 }
 
+// DryRun returns a checker which shares the state of the checker, but records
+// no metrics, so that checking a region with it leaves nothing behind. It is
+// never paused.
+func (m *MergeChecker) DryRun() *MergeChecker {
+	d := newMergeChecker(m.cluster, m.splitCache)
+	d.clock = m.clock
+	d.startTime = m.startTime
+	d.boundaryDetector = m.boundaryDetector
+	d.dryRun = true
+	return d
+}
+
+func (m *MergeChecker) count(name string) {
+	if !m.dryRun {
+		checkerCounter.WithLabelValues("merge_checker", name).Inc()
+	}
+}
+
+// SetBoundaryDetector replaces the detector deciding whether two adjacent
+// regions may be merged by their keys. DefaultMergeBoundaryDetector is used if
+// it is nil. It should be called before the checker is used.
+func (m *MergeChecker) SetBoundaryDetector(detector MergeBoundaryDetector) {
+	if detector == nil {
+		detector = DefaultMergeBoundaryDetector
+	}
+	m.boundaryDetector = detector
+}
+
+// SetClock sets the clock used by the checker, which also restarts the split
+// merge interval after the checker starts.
+func (m *MergeChecker) SetClock(clock Clock) {
+	m.PauseController.SetClock(clock)
+	m.startTime = m.now()
+}
+
 // GetType return MergeChecker's type
 func (m *MergeChecker) GetType() string {
 	return "merge-checker"
 }
 
 // RecordRegionSplit put the recently split region into cache. MergeChecker
-// will skip check it for a while.
+// will skip check it until the split merge interval expires.
 func (m *MergeChecker) RecordRegionSplit(regionIDs []uint64) {
+	interval := m.opts.GetSplitMergeInterval()
 	for _, regionID := range regionIDs {
-		m.splitCache.PutWithTTL(regionID, nil, m.opts.GetSplitMergeInterval())
+		m.splitCache.PutWithTTL(regionID, m.now().Add(interval), interval)
+	}
+}
+
+// recentlySplit returns true if the region is split within the split merge interval.
+func (m *MergeChecker) recentlySplit(regionID uint64) bool {
+	v, ok := m.splitCache.Get(regionID)
+	if !ok {
+		return false
 	}
+	expireTime, ok := v.(time.Time)
+	return !ok || m.now().Before(expireTime)
 }
 
 // Check verifies a region's replicas, creating an Operator if need.
 func (m *MergeChecker) Check(region *core.RegionInfo) []*operator.Operator {
-	checkerCounter.WithLabelValues("merge_checker", "check").Inc()
+	m.count("check")
 
 	if m.IsPaused() {
-		checkerCounter.WithLabelValues("merge_checker", "paused").Inc()
+		m.count("paused")
 		return nil
 	}
 
 	expireTime := m.startTime.Add(m.opts.GetSplitMergeInterval())
-	if time.Now().Before(expireTime) {
-		checkerCounter.WithLabelValues("merge_checker", "recently-start").Inc()
+	if m.now().Before(expireTime) {
+		m.count("recently-start")
 		return nil
 	}
 
-	if m.splitCache.Exists(region.GetID()) {
-		checkerCounter.WithLabelValues("merge_checker", "recently-split").Inc()
+	if reason := m.checkSource(region); reason != "" {
+		m.count(reason)
+		if reason == "recently-split" && !m.dryRun {
+			splitMergeThrashCounter.WithLabelValues("merge_checker").Inc()
+		}
 		return nil
 	}
 
+	prev, next := m.cluster.GetAdjacentRegions(region)
+
+	// try the preferred direction first, and fall back to pick the smaller
+	// adjacent region if the region can't be merged in that direction.
+	target := m.pickPreferredTarget(region, prev, next)
+	if target == nil {
+		if m.checkTarget(region, next) {
+			target = next
+		}
+		if !m.opts.IsOneWayMergeEnabled() && m.checkTarget(region, prev) { // allow a region can be merged by two ways.
+			if target == nil || prev.GetApproximateSize() < next.GetApproximateSize() { // pick smaller
+				target = prev
+			}
+		}
+	}
+
+	if target == nil {
+		m.count("no-target")
+		return nil
+	}
+
+	// Merging an empty region doesn't make the target larger, so the size
+	// of the target doesn't matter.
+	desc := "merge-region"
+	if m.isPreferredEmptyRegion(region) {
+		m.count("empty-region")
+		desc = EmptyRegionMergeDesc
+	} else if target.GetApproximateSize() > maxTargetRegionSize {
+		m.count("target-too-large")
+		return nil
+	}
+
+	log.Debug("try to merge region",
+		logutil.ZapRedactStringer("from", core.RegionToHexMeta(region.GetMeta())),
+		logutil.ZapRedactStringer("to", core.RegionToHexMeta(target.GetMeta())))
+	ops, err := operator.CreateMergeRegionOperator(desc, m.cluster, region, target, operator.OpMerge)
+	if err != nil {
+		log.Warn("create merge region operator failed", errs.ZapError(err))
+		return nil
+	}
+	m.count("new-operator")
+	if region.GetApproximateSize() > target.GetApproximateSize() ||
+		region.GetApproximateKeys() > target.GetApproximateKeys() {
+		m.count("larger-source")
+	}
+	if fanin := m.opts.GetMaxMergeFanin(); fanin > 1 {
+		ops = append(ops, m.extendMergeRun(region, target, fanin-1)...)
+	}
+	return ops
+}
+
+// extendMergeRun returns the merge operators of at most n more disjoint pairs of
+// adjacent small regions beyond the target, in the same direction as the region
+// is merged into the target. The run stops at the first pair which can't be
+// merged right away, and the total size of the run never exceeds the max target
+// region size, so that the merged regions can still be merged in the next round.
+func (m *MergeChecker) extendMergeRun(region, target *core.RegionInfo, n int) []*operator.Operator {
+	adjacent := func(r *core.RegionInfo) *core.RegionInfo {
+		prev, next := m.cluster.GetAdjacentRegions(r)
+		if bytes.Equal(region.GetEndKey(), target.GetStartKey()) {
+			return next
+		}
+		return prev
+	}
+	var ops []*operator.Operator
+	size := region.GetApproximateSize() + target.GetApproximateSize()
+	last := target
+	for i := 0; i < n; i++ {
+		source := adjacent(last)
+		if source == nil || m.checkSource(source) != "" || !allowMerge(m.cluster, last, source, m.boundaryDetector) {
+			break
+		}
+		dest := adjacent(source)
+		if !m.checkTarget(source, dest) || !isPeerAligned(source, dest) {
+			break
+		}
+		size += source.GetApproximateSize() + dest.GetApproximateSize()
+		if dest.GetApproximateSize() > maxTargetRegionSize || size > maxTargetRegionSize {
+			break
+		}
+		pairOps, err := operator.CreateMergeRegionOperator("merge-region", m.cluster, source, dest, operator.OpMerge)
+		if err != nil {
+			log.Warn("create merge region operator failed", errs.ZapError(err))
+			break
+		}
+		log.Debug("try to merge region in the run",
+			logutil.ZapRedactStringer("from", core.RegionToHexMeta(source.GetMeta())),
+			logutil.ZapRedactStringer("to", core.RegionToHexMeta(dest.GetMeta())))
+		m.count("merge-run")
+		ops = append(ops, pairOps...)
+		last = dest
+	}
+	return ops
+}
+
+// checkSource returns why the region can't be merged into an adjacent region,
+// or an empty string if it can. The region checked by Check and the sources of
+// the pairs in a merge run share it.
+func (m *MergeChecker) checkSource(region *core.RegionInfo) string {
+	if m.recentlySplit(region.GetID()) {
+		return "recently-split"
+	}
 	// when pd just started, it will load region meta from etcd
 	// but the size for these loaded region info is 0
 	// pd don't know the real size of one region until the first heartbeat of the region
 	// thus here when size is 0, just skip.
 	if region.GetApproximateSize() == 0 {
-		checkerCounter.WithLabelValues("merge_checker", "skip").Inc()
-		return nil
+		return "skip"
 	}
-
 	// region is not small enough
 	if region.GetApproximateSize() > int64(m.opts.GetMaxMergeRegionSize()) ||
 		region.GetApproximateKeys() > int64(m.opts.GetMaxMergeRegionKeys()) {
-		checkerCounter.WithLabelValues("merge_checker", "no-need").Inc()
-		return nil
+		return "no-need"
 	}
-
 	// skip region has down peers or pending peers or learner peers
 	if !opt.IsRegionHealthy(m.cluster, region) {
-		checkerCounter.WithLabelValues("merge_checker", "special-peer").Inc()
-		return nil
+		return "special-peer"
 	}
-
 	if !opt.IsRegionReplicated(m.cluster, region) {
-		checkerCounter.WithLabelValues("merge_checker", "abnormal-replica").Inc()
-		return nil
+		return "abnormal-replica"
+	}
+	if isMergeDenied(m.cluster, region) {
+		return "merge-denied"
 	}
-
 	// skip hot region
-	if m.cluster.IsRegionHot(region) {
-		checkerCounter.WithLabelValues("merge_checker", "hot-region").Inc()
-		return nil
+	if m.isHotRegion(region) {
+		log.Debug("skip merging hot region", zap.Uint64("region-id", region.GetID()))
+		return "hot-region"
 	}
+	return ""
+}
 
-	prev, next := m.cluster.GetAdjacentRegions(region)
-
-	var target *core.RegionInfo
-	if m.checkTarget(region, next) {
-		target = next
+// isPeerAligned returns true if the two regions have peers on the same stores,
+// so that they can be merged without moving any peer.
+func isPeerAligned(region, adjacent *core.RegionInfo) bool {
+	stores, adjacentStores := region.GetStoreIds(), adjacent.GetStoreIds()
+	if len(stores) != len(adjacentStores) {
+		return false
 	}
-	if !m.opts.IsOneWayMergeEnabled() && m.checkTarget(region, prev) { // allow a region can be merged by two ways.
-		if target == nil || prev.GetApproximateSize() < next.GetApproximateSize() { // pick smaller
-			target = prev
+	for id := range stores {
+		if _, ok := adjacentStores[id]; !ok {
+			return false
 		}
 	}
+	return true
+}
 
-	if target == nil {
-		checkerCounter.WithLabelValues("merge_checker", "no-target").Inc()
-		return nil
-	}
-
-	if target.GetApproximateSize() > maxTargetRegionSize {
-		checkerCounter.WithLabelValues("merge_checker", "target-too-large").Inc()
-		return nil
+// pickPreferredTarget returns the adjacent region in the direction chosen by
+// the merge direction policy, or nil if it is not mergeable.
+func (m *MergeChecker) pickPreferredTarget(region, prev, next *core.RegionInfo) *core.RegionInfo {
+	switch m.opts.GetMergeDirectionPolicy() {
+	case config.MergeDirectionLeft:
+		if !m.opts.IsOneWayMergeEnabled() && m.checkTarget(region, prev) {
+			return prev
+		}
+	case config.MergeDirectionRight:
+		if m.checkTarget(region, next) {
+			return next
+		}
 	}
+	return nil
+}
 
-	log.Debug("try to merge region",
-		logutil.ZapRedactStringer("from", core.RegionToHexMeta(region.GetMeta())),
-		logutil.ZapRedactStringer("to", core.RegionToHexMeta(target.GetMeta())))
-	ops, err := operator.CreateMergeRegionOperator("merge-region", m.cluster, region, target, operator.OpMerge)
-	if err != nil {
-		log.Warn("create merge region operator failed", errs.ZapError(err))
-		return nil
+func (m *MergeChecker) checkTarget(region, adjacent *core.RegionInfo) bool {
+	if adjacent == nil || m.recentlySplit(adjacent.GetID()) {
+		return false
 	}
-	checkerCounter.WithLabelValues("merge_checker", "new-operator").Inc()
-	if region.GetApproximateSize() > target.GetApproximateSize() ||
-		region.GetApproximateKeys() > target.GetApproximateKeys() {
-		checkerCounter.WithLabelValues("merge_checker", "larger-source").Inc()
+	if m.isHotRegion(adjacent) {
+		log.Debug("skip merging into hot region", zap.Uint64("region-id", region.GetID()), zap.Uint64("target-region-id", adjacent.GetID()))
+		return false
 	}
-	return ops
+	return allowMerge(m.cluster, region, adjacent, m.boundaryDetector) && opt.IsRegionHealthy(m.cluster, adjacent) &&
+		opt.IsRegionReplicated(m.cluster, adjacent)
 }
 
-func (m *MergeChecker) checkTarget(region, adjacent *core.RegionInfo) bool {
-	return adjacent != nil && !m.splitCache.Exists(adjacent.GetID()) && !m.cluster.IsRegionHot(adjacent) &&
-		AllowMerge(m.cluster, region, adjacent) && opt.IsRegionHealthy(m.cluster, adjacent) &&
-		opt.IsRegionReplicated(m.cluster, adjacent)
+// isPreferredEmptyRegion returns true if the region is empty and the empty
+// regions are merged ahead of the other small regions. The region loaded from
+// the storage whose size is unknown yet is not empty.
+func (m *MergeChecker) isPreferredEmptyRegion(region *core.RegionInfo) bool {
+	return m.opts.IsEmptyRegionMergePriorityEnabled() &&
+		region.GetApproximateSize() > 0 &&
+		region.GetApproximateSize() <= core.EmptyRegionApproximateSize &&
+		region.GetApproximateKeys() == 0
+}
+
+// isHotRegion returns true if the region is hot. If the hot region merge guard
+// is enabled, a region which has just become hot is also treated as hot.
+func (m *MergeChecker) isHotRegion(region *core.RegionInfo) bool {
+	return m.cluster.IsRegionHot(region) ||
+		(m.opts.IsHotRegionMergeGuardEnabled() && m.cluster.HasHotPeer(region))
 }
 
 // AllowMerge returns true if two regions can be merged according to the key type.
 func AllowMerge(cluster opt.Cluster, region *core.RegionInfo, adjacent *core.RegionInfo) bool {
+	return allowMerge(cluster, region, adjacent, DefaultMergeBoundaryDetector)
+}
+
+func allowMerge(cluster opt.Cluster, region *core.RegionInfo, adjacent *core.RegionInfo, detector MergeBoundaryDetector) bool {
 	var start, end []byte
+	var left, right *core.RegionInfo
 	if bytes.Equal(region.GetEndKey(), adjacent.GetStartKey()) && len(region.GetEndKey()) != 0 {
 		start, end = region.GetStartKey(), adjacent.GetEndKey()
+		left, right = region, adjacent
 	} else if bytes.Equal(adjacent.GetEndKey(), region.GetStartKey()) && len(adjacent.GetEndKey()) != 0 {
 		start, end = adjacent.GetStartKey(), region.GetEndKey()
+		left, right = adjacent, region
 	} else {
 		return false
 	}
@@ -201,24 +400,37 @@ func AllowMerge(cluster opt.Cluster, region *core.RegionInfo, adjacent *core.Reg
 		if len(l.GetSplitKeys(start, end)) > 0 {
 			return false
 		}
-		if l.GetRegionLabel(region, mergeOptionLabel) == mergeOptionValueDeny || l.GetRegionLabel(adjacent, mergeOptionLabel) == mergeOptionValueDeny {
-			return false
-		}
 	}
+	if isMergeDenied(cluster, region) || isMergeDenied(cluster, adjacent) {
+		return false
+	}
+
+	return detector(cluster.GetOpts(), left, right)
+}
+
+// isMergeDenied returns true if the region has the label `merge_option=deny`.
+func isMergeDenied(cluster opt.Cluster, region *core.RegionInfo) bool {
+	cl, ok := cluster.(interface{ GetRegionLabeler() *labeler.RegionLabeler })
+	return ok && cl.GetRegionLabeler().GetRegionLabel(region, mergeOptionLabel) == mergeOptionValueDeny
+}
 
-	policy := cluster.GetOpts().GetKeyType()
+// DefaultMergeBoundaryDetector is the MergeBoundaryDetector for TiDB, which
+// doesn't merge the regions of different tables unless the key type is raw or
+// txn, or cross table merge is enabled.
+func DefaultMergeBoundaryDetector(opts *config.PersistOptions, left, right *core.RegionInfo) bool {
+	policy := opts.GetKeyType()
 	switch policy {
 	case core.Table:
-		if cluster.GetOpts().IsCrossTableMergeEnabled() {
+		if opts.IsCrossTableMergeEnabled() {
 			return true
 		}
-		return isTableIDSame(region, adjacent)
+		return isTableIDSame(left, right)
 	case core.Raw:
 		return true
 	case core.Txn:
 		return true
 	default:
-		return isTableIDSame(region, adjacent)
+		return isTableIDSame(left, right)
 	}
 }
 
@@ -15,6 +15,7 @@
 package checker
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"testing"
@@ -30,6 +31,7 @@ import (
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/opt"
 	"github.com/tikv/pd/server/schedule/placement"
+	"github.com/tikv/pd/server/statistics"
 	"github.com/tikv/pd/server/versioninfo"
 	"go.uber.org/goleak"
 )
@@ -228,6 +230,80 @@ func (s *testMergeCheckerSuite) TestBasic(c *C) {
 	c.Assert(ops, IsNil)
 }
 
+func (s *testMergeCheckerSuite) TestHotRegionMergeGuard(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The suite context may have been canceled, so the hot cache needs a new one.
+	s.cluster.HotStat = statistics.NewHotStat(ctx, nil, nil)
+	s.cluster.SetSplitMergeInterval(0)
+	c.Assert(s.mc.Check(s.regions[2]), NotNil)
+
+	// The region has just become hot.
+	region := s.regions[2].Clone(core.SetWrittenBytes(100*1024*1024), core.SetReportInterval(statistics.RegionHeartBeatReportInterval))
+	for i := 0; i < s.cluster.HotCache.GetFilledPeriod(statistics.WriteFlow); i++ {
+		for _, item := range s.cluster.CheckRegionWrite(region) {
+			s.cluster.HotCache.Update(item)
+		}
+	}
+	c.Assert(s.cluster.IsRegionHot(region), IsFalse)
+	c.Assert(s.cluster.HasHotPeer(region), IsTrue)
+	c.Assert(s.mc.Check(s.regions[2]), IsNil)
+
+	s.cluster.SetEnableHotRegionMergeGuard(false)
+	c.Assert(s.mc.Check(s.regions[2]), NotNil)
+}
+
+func (s *testMergeCheckerSuite) TestEmptyRegionMergePriority(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.cluster.HotStat = statistics.NewHotStat(ctx, nil, nil)
+	s.cluster.SetSplitMergeInterval(0)
+	s.regions[1] = s.regions[1].Clone(core.SetApproximateSize(600))
+	s.cluster.PutRegion(s.regions[1])
+	// The target is too large to merge into.
+	c.Assert(s.mc.Check(s.regions[2]), IsNil)
+
+	// The empty region is not preferred unless it is enabled.
+	empty := s.regions[2].Clone(core.SetApproximateKeys(0))
+	s.cluster.PutRegion(empty)
+	c.Assert(s.mc.Check(empty), IsNil)
+	s.cluster.SetEnableEmptyRegionMergePriority(true)
+	ops := s.mc.Check(empty)
+	c.Assert(ops, HasLen, 2)
+	c.Assert(ops[0].Desc(), Equals, EmptyRegionMergeDesc)
+	c.Assert(ops[1].RegionID(), Equals, s.regions[1].GetID())
+
+	// The region with keys is not empty.
+	c.Assert(s.mc.Check(s.regions[2]), IsNil)
+
+	// The empty region which is hot for reads is still skipped.
+	region := empty.Clone(core.SetReadBytes(100*1024*1024), core.SetReportInterval(statistics.RegionHeartBeatReportInterval))
+	for i := 0; i < s.cluster.HotCache.GetFilledPeriod(statistics.ReadFlow); i++ {
+		for _, item := range s.cluster.CheckRegionRead(region) {
+			s.cluster.HotCache.Update(item)
+		}
+	}
+	c.Assert(s.cluster.HasHotPeer(empty), IsTrue)
+	c.Assert(s.mc.Check(empty), IsNil)
+}
+
+func (s *testMergeCheckerSuite) TestSplitMergeInterval(c *C) {
+	clock := NewManualClock(time.Now())
+	s.mc.SetClock(clock)
+	s.cluster.SetSplitMergeInterval(time.Hour)
+	c.Assert(s.mc.Check(s.regions[2]), IsNil)
+	clock.Advance(time.Hour)
+	c.Assert(s.mc.Check(s.regions[2]), NotNil)
+
+	// The region which just split can not be merged until the interval expires.
+	s.mc.RecordRegionSplit([]uint64{s.regions[2].GetID()})
+	c.Assert(s.mc.Check(s.regions[2]), IsNil)
+	clock.Advance(30 * time.Minute)
+	c.Assert(s.mc.Check(s.regions[2]), IsNil)
+	clock.Advance(30 * time.Minute)
+	c.Assert(s.mc.Check(s.regions[2]), NotNil)
+}
+
 func (s *testMergeCheckerSuite) checkSteps(c *C, op *operator.Operator, steps []operator.OpStep) {
 	c.Assert(op.Kind()&operator.OpMerge, Not(Equals), 0)
 	c.Assert(steps, NotNil)
@@ -500,11 +576,13 @@ func (s *testMergeCheckerSuite) TestCache(c *C) {
 	}
 
 	s.mc = NewMergeChecker(s.ctx, s.cluster)
+	clock := NewManualClock(time.Now())
+	s.mc.SetClock(clock)
 
 	ops := s.mc.Check(s.regions[1])
 	c.Assert(ops, IsNil)
 	s.cluster.SetSplitMergeInterval(0)
-	time.Sleep(time.Second)
+	clock.Advance(time.Second)
 	ops = s.mc.Check(s.regions[1])
 	c.Assert(ops, NotNil)
 }
@@ -516,3 +594,166 @@ func makeKeyRanges(keys ...string) []interface{} {
 	}
 	return res
 }
+
+func (s *testMergeCheckerSuite) TestMergeDirectionPolicy(c *C) {
+	clock := NewManualClock(time.Now())
+	s.mc.SetClock(clock)
+	s.cluster.SetSplitMergeInterval(time.Hour)
+	clock.Advance(time.Hour)
+	s.regions[3] = s.regions[3].Clone(core.WithAddPeer(&metapb.Peer{Id: 110, StoreId: 1}), core.WithAddPeer(&metapb.Peer{Id: 111, StoreId: 2}))
+	s.cluster.PutRegion(s.regions[3])
+
+	checkTarget := func(target uint64) {
+		ops := s.mc.Check(s.regions[2])
+		c.Assert(ops, NotNil)
+		c.Assert(ops[0].RegionID(), Equals, s.regions[2].GetID())
+		c.Assert(ops[1].RegionID(), Equals, target)
+	}
+	// The smaller adjacent region is picked by default.
+	checkTarget(s.regions[3].GetID())
+
+	s.cluster.SetMergeDirectionPolicy(config.MergeDirectionLeft)
+	checkTarget(s.regions[1].GetID())
+	// Fall back to the default policy if the left region can't be merged.
+	s.mc.RecordRegionSplit([]uint64{s.regions[1].GetID()})
+	checkTarget(s.regions[3].GetID())
+
+	s.cluster.SetMergeDirectionPolicy(config.MergeDirectionRight)
+	checkTarget(s.regions[3].GetID())
+	// Fall back to the default policy if the right region can't be merged.
+	s.mc = NewMergeChecker(s.ctx, s.cluster)
+	s.mc.SetClock(clock)
+	clock.Advance(time.Hour)
+	s.mc.RecordRegionSplit([]uint64{s.regions[3].GetID()})
+	checkTarget(s.regions[1].GetID())
+}
+
+func (s *testMergeCheckerSuite) TestBoundaryDetector(c *C) {
+	s.cluster.SetSplitMergeInterval(0)
+	s.regions[3] = s.regions[3].Clone(core.WithAddPeer(&metapb.Peer{Id: 110, StoreId: 1}), core.WithAddPeer(&metapb.Peer{Id: 111, StoreId: 2}))
+	s.cluster.PutRegion(s.regions[3])
+	ops := s.mc.Check(s.regions[2])
+	c.Assert(ops, NotNil)
+	c.Assert(ops[1].RegionID(), Equals, s.regions[3].GetID())
+
+	// The custom detector doesn't merge across the key "x".
+	var detected [][]byte
+	s.mc.SetBoundaryDetector(func(opts *config.PersistOptions, left, right *core.RegionInfo) bool {
+		detected = append(detected, right.GetStartKey())
+		return !bytes.Equal(right.GetStartKey(), []byte("x"))
+	})
+	ops = s.mc.Check(s.regions[2])
+	c.Assert(ops, NotNil)
+	c.Assert(ops[1].RegionID(), Equals, s.regions[1].GetID())
+	// The boundary is the key between the left region and the right region.
+	c.Assert(detected, HasLen, 2)
+	c.Assert(bytes.Equal(detected[0], detected[1]), IsFalse)
+	for _, key := range detected {
+		c.Assert(string(key), Matches, "t|x")
+	}
+
+	s.mc.SetBoundaryDetector(func(opts *config.PersistOptions, left, right *core.RegionInfo) bool { return false })
+	c.Assert(s.mc.Check(s.regions[2]), IsNil)
+
+	// The default detector is restored.
+	s.mc.SetBoundaryDetector(nil)
+	ops = s.mc.Check(s.regions[2])
+	c.Assert(ops, NotNil)
+	c.Assert(ops[1].RegionID(), Equals, s.regions[3].GetID())
+}
+
+func (s *testMergeCheckerSuite) TestMergeRun(c *C) {
+	cluster := mockcluster.NewCluster(s.ctx, config.NewTestOptions())
+	cluster.SetMaxMergeRegionSize(2)
+	cluster.SetMaxMergeRegionKeys(2)
+	cluster.SetSplitMergeInterval(0)
+	cluster.DisableFeature(versioninfo.JointConsensus)
+	for storeID := uint64(1); storeID <= 4; storeID++ {
+		cluster.PutStoreWithLabels(storeID)
+	}
+	keys := []string{"", "a", "b", "c", "d", "e", ""}
+	regions := make([]*core.RegionInfo, 0, len(keys)-1)
+	for i := 0; i < len(keys)-1; i++ {
+		id := uint64(10 + i)
+		region := core.NewRegionInfo(
+			&metapb.Region{
+				Id:       id,
+				StartKey: []byte(keys[i]),
+				EndKey:   []byte(keys[i+1]),
+				Peers: []*metapb.Peer{
+					{Id: id*10 + 1, StoreId: 1},
+					{Id: id*10 + 2, StoreId: 2},
+					{Id: id*10 + 3, StoreId: 3},
+				},
+			},
+			&metapb.Peer{Id: id*10 + 1, StoreId: 1},
+			core.SetApproximateSize(1),
+			core.SetApproximateKeys(1),
+		)
+		regions = append(regions, region)
+		cluster.PutRegion(region)
+	}
+	mc := NewSyncMergeChecker(cluster)
+	checkPairs := func(pairs ...uint64) {
+		ops := mc.Check(regions[0])
+		c.Assert(ops, HasLen, len(pairs))
+		for i, op := range ops {
+			c.Assert(op.RegionID(), Equals, pairs[i])
+		}
+	}
+
+	// Only a single pair is merged by default.
+	checkPairs(10, 11)
+
+	cluster.SetMaxMergeFanin(3)
+	checkPairs(10, 11, 12, 13, 14, 15)
+	cluster.SetMaxMergeFanin(2)
+	checkPairs(10, 11, 12, 13)
+
+	// The run stops at the pair whose peers are not aligned.
+	cluster.SetMaxMergeFanin(3)
+	regions[3] = regions[3].Clone(
+		core.WithAddPeer(&metapb.Peer{Id: 134, StoreId: 4}),
+		core.WithRemoveStorePeer(3),
+	)
+	cluster.PutRegion(regions[3])
+	checkPairs(10, 11)
+
+	// The run stops at the region which is not small.
+	cluster.PutRegion(regions[3].Clone(core.WithAddPeer(&metapb.Peer{Id: 133, StoreId: 3}), core.WithRemoveStorePeer(4)))
+	cluster.PutRegion(regions[4].Clone(core.SetApproximateSize(10)))
+	checkPairs(10, 11, 12, 13)
+
+	// The run stops at the region which denies merging.
+	cluster.PutRegion(regions[4])
+	checkPairs(10, 11, 12, 13, 14, 15)
+	cluster.GetRegionLabeler().SetLabelRule(&labeler.LabelRule{
+		ID:       "test",
+		Labels:   []labeler.RegionLabel{{Key: mergeOptionLabel, Value: mergeOptionValueDeny}},
+		RuleType: labeler.KeyRange,
+		Data:     makeKeyRanges("64", "65"),
+	})
+	checkPairs(10, 11, 12, 13)
+}
+
+func (s *testMergeCheckerSuite) TestDryRun(c *C) {
+	clock := NewManualClock(time.Now())
+	s.cluster.SetSplitMergeInterval(time.Hour)
+	mc := NewSyncMergeChecker(s.cluster)
+	mc.SetClock(clock)
+	checks := checkerCount("merge_checker", "check")
+
+	// The dry run shares the start time and the clock of the checker.
+	dryRun := mc.DryRun()
+	c.Assert(dryRun.Check(s.regions[2]), IsNil)
+	clock.Advance(time.Hour)
+	c.Assert(dryRun.Check(s.regions[2]), NotNil)
+	// and the recently split regions.
+	mc.RecordRegionSplit([]uint64{s.regions[2].GetID()})
+	c.Assert(dryRun.Check(s.regions[2]), IsNil)
+
+	// It records no metrics.
+	c.Assert(checkerCount("merge_checker", "check"), Equals, checks)
+	c.Assert(mc.Check(s.regions[2]), IsNil)
+	c.Assert(checkerCount("merge_checker", "check"), Equals, checks+1)
+}
@@ -24,8 +24,26 @@ var (
 			Name:      "event_count",
 			Help:      "Counter of checker events.",
 		}, []string{"type", "name"})
+
+	splitMergeThrashCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "checker",
+			Name:      "split_merge_thrash_prevented",
+			Help:      "Counter of the splits and merges prevented because the region is merged or split recently.",
+		}, []string{"type"})
+
+	priorityQueueEvictedCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "checker",
+			Name:      "priority_queue_evicted",
+			Help:      "Counter of the regions evicted from the full priority queue.",
+		})
 )
 
 func init() {
 	prometheus.MustRegister(checkerCounter)
+	prometheus.MustRegister(splitMergeThrashCounter)
+	prometheus.MustRegister(priorityQueueEvictedCounter)
 }
@@ -15,6 +15,8 @@
 package checker
 
 import (
+	"bytes"
+	"sync"
 	"time"
 
 	"github.com/tikv/pd/pkg/cache"
@@ -22,10 +24,14 @@ import (
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule/opt"
 	"github.com/tikv/pd/server/schedule/placement"
+	"github.com/tikv/pd/server/statistics"
 )
 
-// the default value of priority queue size
-const defaultPriorityQueueSize = 1280
+const (
+	// priorityRangeBoost is subtracted from the priority of the regions in the
+	// priority key ranges, so that they come before all the other regions.
+	priorityRangeBoost = 1 << 16
+)
 
 // PriorityChecker ensures high priority region should run first
 type PriorityChecker struct {
@@ -33,14 +39,24 @@ type PriorityChecker struct {
 	cluster opt.Cluster
 	opts    *config.PersistOptions
 	queue   *cache.PriorityQueue
+
+	hooksMu sync.RWMutex
+	hooks   []PriorityRemovedHook
+
+	rangesMu sync.RWMutex
+	ranges   []core.KeyRange
 }
 
+// PriorityRemovedHook is called with the ID of a region after it is removed
+// from the priority queue.
+type PriorityRemovedHook func(regionID uint64)
+
 // NewPriorityChecker creates a priority checker.
 func NewPriorityChecker(cluster opt.Cluster) *PriorityChecker {
 	return &PriorityChecker{
 		cluster: cluster,
 		opts:    cluster.GetOpts(),
-		queue:   cache.NewPriorityQueue(defaultPriorityQueueSize),
+		queue:   cache.NewPriorityQueue(cluster.GetOpts().GetPriorityQueueSize()),
 	}
 }
 
@@ -54,6 +70,29 @@ type RegionPriorityEntry struct {
 	Attempt  int
 	Last     time.Time
 	regionID uint64
+	reason   *PriorityReason
+}
+
+// PriorityReason records why a region is put into the priority queue.
+type PriorityReason struct {
+	// Kind is the set of region statistic kinds observed when the region was checked.
+	Kind statistics.RegionStatisticType `json:"kind"`
+	// MissingPeers is the number of peers the region lacks.
+	MissingPeers int `json:"missing-peers"`
+	// DownPeers is the number of down peers of the region.
+	DownPeers int `json:"down-peers"`
+	// RuleGaps maps the key of each unsatisfied rule to the number of peers it lacks.
+	// It is only set when placement rules are enabled.
+	RuleGaps map[string]int `json:"rule-gaps,omitempty"`
+}
+
+// PriorityRegion is a region in priority queue together with its priority reason.
+type PriorityRegion struct {
+	RegionID uint64          `json:"region-id"`
+	Priority int             `json:"priority"`
+	Attempt  int             `json:"attempt"`
+	Last     time.Time       `json:"last"`
+	Reason   *PriorityReason `json:"reason"`
 }
 
 // ID implement PriorityQueueItem interface
@@ -62,8 +101,8 @@ func (r RegionPriorityEntry) ID() uint64 {
 }
 
 // NewRegionEntry construct of region priority entry
-func NewRegionEntry(regionID uint64) *RegionPriorityEntry {
-	return &RegionPriorityEntry{regionID: regionID, Last: time.Now(), Attempt: 1}
+func NewRegionEntry(regionID uint64, now time.Time) *RegionPriorityEntry {
+	return &RegionPriorityEntry{regionID: regionID, Last: now, Attempt: 1}
 }
 
 // Check check region's replicas, it will put into priority queue if the region lack of replicas.
@@ -72,19 +111,40 @@ func (p *PriorityChecker) Check(region *core.RegionInfo) (fit *placement.RegionF
 		checkerCounter.WithLabelValues("priority_checker", "paused").Inc()
 		return nil
 	}
-	var makeupCount int
+	var (
+		makeupCount int
+		ruleGaps    map[string]int
+	)
 	if p.opts.IsPlacementRulesEnabled() {
-		makeupCount, fit = p.checkRegionInPlacementRule(region)
+		makeupCount, ruleGaps, fit = p.checkRegionInPlacementRule(region)
 	} else {
 		makeupCount = p.checkRegionInReplica(region)
 	}
 	priority := 0 - makeupCount
-	p.addOrRemoveRegion(priority, region.GetID())
+	if priority < 0 && p.InPriorityRange(region) {
+		priority -= priorityRangeBoost
+	}
+	p.addOrRemoveRegion(priority, region.GetID(), newPriorityReason(region, makeupCount, ruleGaps))
 	return
 }
 
+func newPriorityReason(region *core.RegionInfo, makeupCount int, ruleGaps map[string]int) *PriorityReason {
+	reason := &PriorityReason{
+		MissingPeers: makeupCount,
+		DownPeers:    len(region.GetDownPeers()),
+		RuleGaps:     ruleGaps,
+	}
+	if reason.MissingPeers > 0 {
+		reason.Kind |= statistics.MissPeer
+	}
+	if reason.DownPeers > 0 {
+		reason.Kind |= statistics.DownPeer
+	}
+	return reason
+}
+
 // checkRegionInPlacementRule check region in placement rule mode
-func (p *PriorityChecker) checkRegionInPlacementRule(region *core.RegionInfo) (makeupCount int, fit *placement.RegionFit) {
+func (p *PriorityChecker) checkRegionInPlacementRule(region *core.RegionInfo) (makeupCount int, ruleGaps map[string]int, fit *placement.RegionFit) {
 	fit = opt.FitRegion(p.cluster, region)
 	if len(fit.RuleFits) == 0 {
 		return
@@ -95,7 +155,15 @@ func (p *PriorityChecker) checkRegionInPlacementRule(region *core.RegionInfo) (m
 		if rf.Rule.Role == placement.Learner {
 			continue
 		}
-		makeupCount = makeupCount + rf.Rule.Count - len(rf.Peers)
+		gap := rf.Rule.Count - len(rf.Peers)
+		makeupCount = makeupCount + gap
+		if gap > 0 {
+			if ruleGaps == nil {
+				ruleGaps = make(map[string]int)
+			}
+			key := rf.Rule.Key()
+			ruleGaps[key[0]+"/"+key[1]] = gap
+		}
 	}
 	return
 }
@@ -108,17 +176,39 @@ func (p *PriorityChecker) checkRegionInReplica(region *core.RegionInfo) (makeupC
 // addOrRemoveRegion add or remove region from queue
 // it will remove if region's priority equal 0
 // it's Attempt will increase if region's priority equal last
-func (p *PriorityChecker) addOrRemoveRegion(priority int, regionID uint64) {
+func (p *PriorityChecker) addOrRemoveRegion(priority int, regionID uint64, reason *PriorityReason) {
 	if priority < 0 {
-		if entry := p.queue.Get(regionID); entry != nil && entry.Priority == priority {
-			e := entry.Value.(*RegionPriorityEntry)
-			e.Attempt = e.Attempt + 1
-			e.Last = time.Now()
+		p.evict(len(p.queue.SetCapacity(p.opts.GetPriorityQueueSize())))
+		existing := p.queue.Get(regionID)
+		if existing != nil {
+			e := existing.Value.(*RegionPriorityEntry)
+			if existing.Priority == priority {
+				e.Attempt = e.Attempt + 1
+				e.Last = p.now()
+			}
+			// the queue keeps the existing entry, so refresh its reason
+			e.reason = reason
 		}
-		entry := NewRegionEntry(regionID)
+		full := p.queue.Len() >= p.opts.GetPriorityQueueSize()
+		entry := NewRegionEntry(regionID, p.now())
+		entry.reason = reason
+		// a new region either evicts the region with the lowest priority from
+		// the full queue, or is dropped itself.
 		p.queue.Put(priority, entry)
+		if existing == nil && full {
+			p.evict(1)
+		}
 	} else {
-		p.queue.Remove(regionID)
+		p.removeRegion(regionID)
+	}
+}
+
+// evict counts the regions evicted from the full queue. The evicted regions
+// are not removed for good, as they are put back when they are checked again
+// and the queue has room for them, so the hooks are not called for them.
+func (p *PriorityChecker) evict(count int) {
+	if count > 0 {
+		priorityQueueEvictedCounter.Add(float64(count))
 	}
 }
 
@@ -129,14 +219,93 @@ func (p *PriorityChecker) GetPriorityRegions() (ids []uint64) {
 		re := e.Value.(*RegionPriorityEntry)
 		// avoid to some priority region occupy checker, region don't need check on next check interval
 		// the next run time is : last_time+retry*10*patrol_region_interval
-		if t := re.Last.Add(time.Duration(re.Attempt*10) * p.opts.GetPatrolRegionInterval()); t.Before(time.Now()) {
+		if t := re.Last.Add(time.Duration(re.Attempt*10) * p.opts.GetPatrolRegionInterval()); t.Before(p.now()) {
 			ids = append(ids, re.regionID)
 		}
 	}
 	return
 }
 
+// GetPriorityRegionsWithReason returns all regions in priority queue together with
+// the reason why they are prioritized. Unlike GetPriorityRegions, it doesn't skip
+// the regions which are waiting for the next run.
+func (p *PriorityChecker) GetPriorityRegionsWithReason() []*PriorityRegion {
+	entries := p.queue.Elems()
+	regions := make([]*PriorityRegion, 0, len(entries))
+	for _, e := range entries {
+		re := e.Value.(*RegionPriorityEntry)
+		regions = append(regions, &PriorityRegion{
+			RegionID: re.regionID,
+			Priority: e.Priority,
+			Attempt:  re.Attempt,
+			Last:     re.Last,
+			Reason:   re.reason,
+		})
+	}
+	return regions
+}
+
+// SetPriorityRanges replaces the priority key ranges. The regions overlapping
+// any of them are put before the other regions in the queue, and an empty end
+// key means the end of the key space. The ranges are kept in memory only.
+func (p *PriorityChecker) SetPriorityRanges(ranges []core.KeyRange) {
+	p.rangesMu.Lock()
+	defer p.rangesMu.Unlock()
+	p.ranges = append(ranges[:0:0], ranges...)
+}
+
+// GetPriorityRanges returns the priority key ranges.
+func (p *PriorityChecker) GetPriorityRanges() []core.KeyRange {
+	p.rangesMu.RLock()
+	defer p.rangesMu.RUnlock()
+	return append(p.ranges[:0:0], p.ranges...)
+}
+
+// HasPriorityRanges returns true if any priority key range is set.
+func (p *PriorityChecker) HasPriorityRanges() bool {
+	p.rangesMu.RLock()
+	defer p.rangesMu.RUnlock()
+	return len(p.ranges) > 0
+}
+
+// InPriorityRange returns true if the region overlaps any of the priority key ranges.
+func (p *PriorityChecker) InPriorityRange(region *core.RegionInfo) bool {
+	p.rangesMu.RLock()
+	defer p.rangesMu.RUnlock()
+	for _, r := range p.ranges {
+		if (len(r.EndKey) == 0 || bytes.Compare(region.GetStartKey(), r.EndKey) < 0) &&
+			(len(region.GetEndKey()) == 0 || bytes.Compare(r.StartKey, region.GetEndKey()) < 0) {
+			return true
+		}
+	}
+	return false
+}
+
 // RemovePriorityRegion removes priority region from priority queue
 func (p *PriorityChecker) RemovePriorityRegion(regionID uint64) {
+	p.removeRegion(regionID)
+}
+
+// RegisterRemovedHook registers a hook which is called synchronously every time
+// a region is removed from the priority queue, either because it is removed
+// explicitly or because it no longer lacks replicas. Hooks are called in
+// registration order.
+func (p *PriorityChecker) RegisterRemovedHook(hook PriorityRemovedHook) {
+	p.hooksMu.Lock()
+	defer p.hooksMu.Unlock()
+	p.hooks = append(p.hooks, hook)
+}
+
+// removeRegion removes the region from the queue and calls the hooks if the
+// region was in the queue.
+func (p *PriorityChecker) removeRegion(regionID uint64) {
+	if p.queue.Get(regionID) == nil {
+		return
+	}
 	p.queue.Remove(regionID)
+	p.hooksMu.RLock()
+	defer p.hooksMu.RUnlock()
+	for _, hook := range p.hooks {
+		hook(regionID)
+	}
 }
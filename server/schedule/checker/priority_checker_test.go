@@ -19,8 +19,11 @@ import (
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/mock/mockcluster"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/statistics"
 )
 
 var _ = Suite(&testPriorityCheckerSuite{})
@@ -49,13 +52,15 @@ func (s *testPriorityCheckerSuite) TestCheckPriorityRegions(c *C) {
 	tc.AddLeaderRegion(3, 2)
 
 	pc := NewPriorityChecker(tc)
-	checkPriorityRegionTest(pc, tc, c)
+	clock := NewManualClock(time.Now())
+	pc.SetClock(clock)
+	checkPriorityRegionTest(pc, tc, clock, c)
 	opt.SetPlacementRuleEnabled(true)
 	c.Assert(opt.IsPlacementRulesEnabled(), IsTrue)
-	checkPriorityRegionTest(pc, tc, c)
+	checkPriorityRegionTest(pc, tc, clock, c)
 }
 
-func checkPriorityRegionTest(pc *PriorityChecker, tc *mockcluster.Cluster, c *C) {
+func checkPriorityRegionTest(pc *PriorityChecker, tc *mockcluster.Cluster, clock *ManualClock, c *C) {
 	// case1: check region-1, it don't lack of replica
 	region := tc.GetRegion(1)
 	opt := tc.GetOpts()
@@ -73,7 +78,7 @@ func checkPriorityRegionTest(pc *PriorityChecker, tc *mockcluster.Cluster, c *C)
 	region = tc.GetRegion(3)
 	pc.Check(region)
 	c.Assert(2, Equals, pc.queue.Len())
-	time.Sleep(opt.GetPatrolRegionInterval() * 10)
+	clock.Advance(opt.GetPatrolRegionInterval() * 11)
 	// region-3 has higher priority
 	ids := pc.GetPriorityRegions()
 	c.Assert(2, Equals, len(ids))
@@ -90,3 +95,167 @@ func checkPriorityRegionTest(pc *PriorityChecker, tc *mockcluster.Cluster, c *C)
 	tc.AddLeaderRegion(2, 2, 3)
 	pc.RemovePriorityRegion(uint64(3))
 }
+
+func (s *testPriorityCheckerSuite) TestPriorityReason(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(ctx, opt)
+	opt.SetPlacementRuleEnabled(false)
+	tc.AddRegionStore(1, 0)
+	tc.AddRegionStore(2, 0)
+	tc.AddRegionStore(3, 0)
+	region := tc.AddLeaderRegion(1, 1, 2)
+	region = region.Clone(core.WithDownPeers([]*pdpb.PeerStats{{Peer: region.GetStorePeer(2), DownSeconds: 3600}}))
+	tc.PutRegion(region)
+
+	pc := NewPriorityChecker(tc)
+	pc.Check(region)
+	regions := pc.GetPriorityRegionsWithReason()
+	c.Assert(regions, HasLen, 1)
+	c.Assert(regions[0].RegionID, Equals, uint64(1))
+	c.Assert(regions[0].Priority, Equals, -1)
+	reason := regions[0].Reason
+	c.Assert(reason.Kind, Equals, statistics.MissPeer|statistics.DownPeer)
+	c.Assert(reason.MissingPeers, Equals, 1)
+	c.Assert(reason.DownPeers, Equals, 1)
+	c.Assert(reason.RuleGaps, IsNil)
+	// the region is still waiting for the next run
+	c.Assert(pc.GetPriorityRegions(), HasLen, 0)
+
+	// the unsatisfied rule is recorded in placement rule mode
+	opt.SetPlacementRuleEnabled(true)
+	pc.Check(region)
+	regions = pc.GetPriorityRegionsWithReason()
+	c.Assert(regions, HasLen, 1)
+	c.Assert(regions[0].Reason.RuleGaps, DeepEquals, map[string]int{"pd/default": 1})
+}
+
+func (s *testPriorityCheckerSuite) TestPriorityRemovedHook(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	tc.AddRegionStore(1, 0)
+	tc.AddRegionStore(2, 0)
+	tc.AddRegionStore(3, 0)
+	tc.AddLeaderRegion(1, 1)
+	tc.AddLeaderRegion(2, 1, 2)
+
+	pc := NewPriorityChecker(tc)
+	var removed []uint64
+	pc.RegisterRemovedHook(func(regionID uint64) {
+		removed = append(removed, regionID)
+	})
+	pc.Check(tc.GetRegion(1))
+	pc.Check(tc.GetRegion(2))
+	c.Assert(pc.queue.Len(), Equals, 2)
+
+	// a region which is not in the queue doesn't fire the hook.
+	pc.RemovePriorityRegion(3)
+	c.Assert(removed, HasLen, 0)
+
+	// explicit removal.
+	pc.RemovePriorityRegion(1)
+	c.Assert(removed, DeepEquals, []uint64{1})
+
+	// the region becomes fit.
+	tc.AddLeaderRegion(2, 1, 2, 3)
+	pc.Check(tc.GetRegion(2))
+	c.Assert(removed, DeepEquals, []uint64{1, 2})
+	c.Assert(pc.queue.Len(), Equals, 0)
+
+	// a fit region which is not in the queue doesn't fire the hook either.
+	pc.Check(tc.GetRegion(2))
+	c.Assert(removed, HasLen, 2)
+}
+
+func (s *testPriorityCheckerSuite) TestPriorityRanges(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	tc.AddRegionStore(1, 0)
+	tc.AddRegionStore(2, 0)
+	tc.AddRegionStore(3, 0)
+	// region 1 lacks two replicas, and region 2 lacks one.
+	tc.AddLeaderRegionWithRange(1, "a", "b", 1)
+	tc.AddLeaderRegionWithRange(2, "c", "d", 1, 2)
+	tc.AddLeaderRegionWithRange(3, "e", "", 1, 2, 3)
+
+	pc := NewPriorityChecker(tc)
+	c.Assert(pc.HasPriorityRanges(), IsFalse)
+	pc.SetPriorityRanges([]core.KeyRange{core.NewKeyRange("b1", "c1")})
+	c.Assert(pc.HasPriorityRanges(), IsTrue)
+	c.Assert(pc.GetPriorityRanges(), DeepEquals, []core.KeyRange{core.NewKeyRange("b1", "c1")})
+	c.Assert(pc.InPriorityRange(tc.GetRegion(1)), IsFalse)
+	c.Assert(pc.InPriorityRange(tc.GetRegion(2)), IsTrue)
+	c.Assert(pc.InPriorityRange(tc.GetRegion(3)), IsFalse)
+
+	// the region in the priority range comes first though it lacks fewer replicas.
+	pc.Check(tc.GetRegion(1))
+	pc.Check(tc.GetRegion(2))
+	regions := pc.GetPriorityRegionsWithReason()
+	c.Assert(regions, HasLen, 2)
+	c.Assert(regions[0].RegionID, Equals, uint64(2))
+	c.Assert(regions[1].RegionID, Equals, uint64(1))
+
+	// an empty end key means the end of the key space.
+	pc.SetPriorityRanges([]core.KeyRange{core.NewKeyRange("f", "")})
+	c.Assert(pc.InPriorityRange(tc.GetRegion(2)), IsFalse)
+	c.Assert(pc.InPriorityRange(tc.GetRegion(3)), IsTrue)
+
+	// a healthy region is never put into the queue.
+	pc.Check(tc.GetRegion(3))
+	c.Assert(pc.queue.Get(3), IsNil)
+
+	pc.SetPriorityRanges(nil)
+	c.Assert(pc.HasPriorityRanges(), IsFalse)
+}
+
+func (s *testPriorityCheckerSuite) TestPriorityQueueSize(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	tc.SetPriorityQueueSize(2)
+	tc.AddRegionStore(1, 0)
+	tc.AddRegionStore(2, 0)
+	tc.AddRegionStore(3, 0)
+	// region 1 and region 2 lack two replicas, and region 3 lacks one.
+	tc.AddLeaderRegion(1, 1)
+	tc.AddLeaderRegion(2, 2)
+	tc.AddLeaderRegion(3, 1, 2)
+
+	pc := NewPriorityChecker(tc)
+	var removed []uint64
+	pc.RegisterRemovedHook(func(regionID uint64) {
+		removed = append(removed, regionID)
+	})
+	// region 3 is dropped as it lacks the fewest replicas.
+	pc.Check(tc.GetRegion(1))
+	pc.Check(tc.GetRegion(2))
+	pc.Check(tc.GetRegion(3))
+	c.Assert(pc.queue.Len(), Equals, 2)
+	c.Assert(pc.queue.Get(3), IsNil)
+
+	// region 3 evicts region 2 after region 2 lacks fewer replicas.
+	tc.AddLeaderRegion(2, 2, 3)
+	pc.Check(tc.GetRegion(2))
+	c.Assert(pc.queue.Get(2), NotNil)
+	tc.AddLeaderRegion(3, 1)
+	pc.Check(tc.GetRegion(3))
+	c.Assert(pc.queue.Len(), Equals, 2)
+	c.Assert(pc.queue.Get(3), NotNil)
+	c.Assert(pc.queue.Get(2), IsNil)
+	// the evicted regions are not removed for good.
+	c.Assert(removed, HasLen, 0)
+
+	// region 2 is put back after region 1 becomes healthy.
+	tc.AddLeaderRegion(1, 1, 2, 3)
+	pc.Check(tc.GetRegion(1))
+	pc.Check(tc.GetRegion(2))
+	c.Assert(pc.queue.Get(2), NotNil)
+	c.Assert(removed, DeepEquals, []uint64{1})
+
+	// the lowest priority regions are evicted after the queue shrinks.
+	tc.SetPriorityQueueSize(1)
+	pc.Check(tc.GetRegion(3))
+	regions := pc.GetPriorityRegionsWithReason()
+	c.Assert(regions, HasLen, 1)
+	c.Assert(regions[0].RegionID, Equals, uint64(3))
+}
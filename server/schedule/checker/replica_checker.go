@@ -16,13 +16,16 @@ package checker
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/cache"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/filter"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/opt"
 	"go.uber.org/zap"
@@ -65,40 +68,50 @@ func (r *ReplicaChecker) GetType() string {
 
 // Check verifies a region's replicas, creating an operator.Operator if need.
 func (r *ReplicaChecker) Check(region *core.RegionInfo) *operator.Operator {
+	op, _ := r.CheckWithError(region)
+	return op
+}
+
+// CheckWithError is like Check, but it also returns the error if no operator
+// is created since the checker fails to create any of the operators the region
+// needs.
+func (r *ReplicaChecker) CheckWithError(region *core.RegionInfo) (*operator.Operator, error) {
 	checkerCounter.WithLabelValues("replica_checker", "check").Inc()
 	if r.IsPaused() {
 		checkerCounter.WithLabelValues("replica_checker", "paused").Inc()
-		return nil
-	}
-	if op := r.checkDownPeer(region); op != nil {
-		checkerCounter.WithLabelValues("replica_checker", "new-operator").Inc()
-		op.SetPriorityLevel(core.HighPriority)
-		return op
+		return nil, nil
 	}
-	if op := r.checkOfflinePeer(region); op != nil {
-		checkerCounter.WithLabelValues("replica_checker", "new-operator").Inc()
-		op.SetPriorityLevel(core.HighPriority)
-		return op
+	steps := []struct {
+		check  func(*core.RegionInfo) (*operator.Operator, error)
+		urgent bool
+	}{
+		{r.checkDownPeer, true},
+		{r.checkOfflinePeer, true},
+		{r.checkMakeUpReplica, true},
+		{r.checkRemoveExtraReplica, false},
+		{r.checkLocationReplacement, false},
 	}
-	if op := r.checkMakeUpReplica(region); op != nil {
-		checkerCounter.WithLabelValues("replica_checker", "new-operator").Inc()
-		op.SetPriorityLevel(core.HighPriority)
-		return op
-	}
-	if op := r.checkRemoveExtraReplica(region); op != nil {
-		checkerCounter.WithLabelValues("replica_checker", "new-operator").Inc()
-		return op
-	}
-	if op := r.checkLocationReplacement(region); op != nil {
-		checkerCounter.WithLabelValues("replica_checker", "new-operator").Inc()
-		return op
+	// The first error is returned if no operator is created at last.
+	var firstErr error
+	for _, step := range steps {
+		op, err := step.check(region)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if op != nil {
+			checkerCounter.WithLabelValues("replica_checker", "new-operator").Inc()
+			if step.urgent {
+				op.SetPriorityLevel(core.HighPriority)
+			}
+			return op, nil
+		}
 	}
-	return nil
+	return nil, firstErr
 }
 
-func (r *ReplicaChecker) checkDownPeer(region *core.RegionInfo) *operator.Operator {
+func (r *ReplicaChecker) checkDownPeer(region *core.RegionInfo) (*operator.Operator, error) {
 	if !r.opts.IsRemoveDownReplicaEnabled() {
-		return nil
+		return nil, nil
 	}
 
 	for _, stats := range region.GetDownPeers() {
@@ -110,25 +123,35 @@ func (r *ReplicaChecker) checkDownPeer(region *core.RegionInfo) *operator.Operat
 		store := r.cluster.GetStore(storeID)
 		if store == nil {
 			log.Warn("lost the store, maybe you are recovering the PD cluster", zap.Uint64("store-id", storeID))
-			return nil
+			return nil, nil
 		}
 		// Only consider the state of the Store, not `stats.DownSeconds`.
 		if store.DownTime() < r.opts.GetMaxStoreDownTime() {
 			continue
 		}
+		if inDownPeerGracePeriod(r.opts, stats) {
+			checkerCounter.WithLabelValues("replica_checker", "down-peer-in-grace-period").Inc()
+			continue
+		}
 		return r.fixPeer(region, storeID, downStatus)
 	}
-	return nil
+	return nil, nil
+}
+
+// inDownPeerGracePeriod returns true if the peer has not been down for the
+// down peer grace period yet, which means it may come back soon.
+func inDownPeerGracePeriod(opts *config.PersistOptions, stats *pdpb.PeerStats) bool {
+	return time.Duration(stats.GetDownSeconds())*time.Second < opts.GetDownPeerGracePeriod()
 }
 
-func (r *ReplicaChecker) checkOfflinePeer(region *core.RegionInfo) *operator.Operator {
+func (r *ReplicaChecker) checkOfflinePeer(region *core.RegionInfo) (*operator.Operator, error) {
 	if !r.opts.IsReplaceOfflineReplicaEnabled() {
-		return nil
+		return nil, nil
 	}
 
 	// just skip learner
 	if len(region.GetLearners()) != 0 {
-		return nil
+		return nil, nil
 	}
 
 	for _, peer := range region.GetPeers() {
@@ -136,7 +159,7 @@ func (r *ReplicaChecker) checkOfflinePeer(region *core.RegionInfo) *operator.Ope
 		store := r.cluster.GetStore(storeID)
 		if store == nil {
 			log.Warn("lost the store, maybe you are recovering the PD cluster", zap.Uint64("store-id", storeID))
-			return nil
+			return nil, nil
 		}
 		if store.IsUp() {
 			continue
@@ -145,15 +168,15 @@ func (r *ReplicaChecker) checkOfflinePeer(region *core.RegionInfo) *operator.Ope
 		return r.fixPeer(region, storeID, offlineStatus)
 	}
 
-	return nil
+	return nil, nil
 }
 
-func (r *ReplicaChecker) checkMakeUpReplica(region *core.RegionInfo) *operator.Operator {
+func (r *ReplicaChecker) checkMakeUpReplica(region *core.RegionInfo) (*operator.Operator, error) {
 	if !r.opts.IsMakeUpReplicaEnabled() {
-		return nil
+		return nil, nil
 	}
 	if len(region.GetPeers()) >= r.opts.GetMaxReplicas() {
-		return nil
+		return nil, nil
 	}
 	log.Debug("region has fewer than max replicas", zap.Uint64("region-id", region.GetID()), zap.Int("peers", len(region.GetPeers())))
 	regionStores := r.cluster.GetRegionStores(region)
@@ -161,46 +184,81 @@ func (r *ReplicaChecker) checkMakeUpReplica(region *core.RegionInfo) *operator.O
 	if target == 0 {
 		log.Debug("no store to add replica", zap.Uint64("region-id", region.GetID()))
 		checkerCounter.WithLabelValues("replica_checker", "no-target-store").Inc()
-		r.regionWaitingList.Put(region.GetID(), nil)
-		return nil
+		r.warnNoEligibleTarget(region)
+		AddWaitingRegion(r.regionWaitingList, r.opts, region.GetID(), r.now(), "no-target-store")
+		return nil, nil
 	}
 	newPeer := &metapb.Peer{StoreId: target}
 	op, err := operator.CreateAddPeerOperator("make-up-replica", r.cluster, region, newPeer, operator.OpReplica)
 	if err != nil {
 		log.Debug("create make-up-replica operator fail", errs.ZapError(err))
-		return nil
+		return nil, err
 	}
-	return op
+	return op, nil
+}
+
+// ReplicaTarget is the store a new replica of a region would be added to, and
+// the candidates the store is picked from. StoreID is 0 if there is no store
+// to add the replica to.
+type ReplicaTarget struct {
+	StoreID    uint64            `json:"store-id"`
+	Candidates []*StoreCandidate `json:"candidates"`
 }
 
-func (r *ReplicaChecker) checkRemoveExtraReplica(region *core.RegionInfo) *operator.Operator {
+// SelectTarget returns the stores which the replica checker would add the
+// missing replicas of the region to, applying the same location and isolation
+// constraints as making up replicas. One store is selected if the region is
+// not under-replicated, as if it needed one more replica. It is read-only, so
+// no operator is created and the waiting list is not changed.
+func (r *ReplicaChecker) SelectTarget(region *core.RegionInfo) []*ReplicaTarget {
+	missing := r.opts.GetMaxReplicas() - len(region.GetPeers())
+	if missing < 1 {
+		missing = 1
+	}
+	regionStores := r.cluster.GetRegionStores(region)
+	targets := make([]*ReplicaTarget, 0, missing)
+	for i := 0; i < missing; i++ {
+		storeID, candidates := r.strategy(region).ExplainStoreToAdd(regionStores)
+		targets = append(targets, &ReplicaTarget{StoreID: storeID, Candidates: candidates})
+		store := r.cluster.GetStore(storeID)
+		if store == nil {
+			break
+		}
+		// The next replica is selected as if the replica has been added.
+		region = region.Clone(core.WithAddPeer(&metapb.Peer{StoreId: storeID}))
+		regionStores = append(regionStores, store)
+	}
+	return targets
+}
+
+func (r *ReplicaChecker) checkRemoveExtraReplica(region *core.RegionInfo) (*operator.Operator, error) {
 	if !r.opts.IsRemoveExtraReplicaEnabled() {
-		return nil
+		return nil, nil
 	}
 	// when add learner peer, the number of peer will exceed max replicas for a while,
 	// just comparing the the number of voters to avoid too many cancel add operator log.
 	if len(region.GetVoters()) <= r.opts.GetMaxReplicas() {
-		return nil
+		return nil, nil
 	}
 	log.Debug("region has more than max replicas", zap.Uint64("region-id", region.GetID()), zap.Int("peers", len(region.GetPeers())))
 	regionStores := r.cluster.GetRegionStores(region)
 	old := r.strategy(region).SelectStoreToRemove(regionStores)
 	if old == 0 {
 		checkerCounter.WithLabelValues("replica_checker", "no-worst-peer").Inc()
-		r.regionWaitingList.Put(region.GetID(), nil)
-		return nil
+		AddWaitingRegion(r.regionWaitingList, r.opts, region.GetID(), r.now(), "no-worst-peer")
+		return nil, nil
 	}
 	op, err := operator.CreateRemovePeerOperator("remove-extra-replica", r.cluster, operator.OpReplica, region, old)
 	if err != nil {
 		checkerCounter.WithLabelValues("replica_checker", "create-operator-fail").Inc()
-		return nil
+		return nil, err
 	}
-	return op
+	return op, nil
 }
 
-func (r *ReplicaChecker) checkLocationReplacement(region *core.RegionInfo) *operator.Operator {
+func (r *ReplicaChecker) checkLocationReplacement(region *core.RegionInfo) (*operator.Operator, error) {
 	if !r.opts.IsLocationReplacementEnabled() {
-		return nil
+		return nil, nil
 	}
 
 	strategy := r.strategy(region)
@@ -208,25 +266,25 @@ func (r *ReplicaChecker) checkLocationReplacement(region *core.RegionInfo) *oper
 	oldStore := strategy.SelectStoreToRemove(regionStores)
 	if oldStore == 0 {
 		checkerCounter.WithLabelValues("replica_checker", "all-right").Inc()
-		return nil
+		return nil, nil
 	}
 	newStore := strategy.SelectStoreToImprove(regionStores, oldStore)
 	if newStore == 0 {
 		log.Debug("no better peer", zap.Uint64("region-id", region.GetID()))
 		checkerCounter.WithLabelValues("replica_checker", "not-better").Inc()
-		return nil
+		return nil, nil
 	}
 
 	newPeer := &metapb.Peer{StoreId: newStore}
 	op, err := operator.CreateMovePeerOperator("move-to-better-location", r.cluster, region, operator.OpReplica, oldStore, newPeer)
 	if err != nil {
 		checkerCounter.WithLabelValues("replica_checker", "create-operator-fail").Inc()
-		return nil
+		return nil, err
 	}
-	return op
+	return op, nil
 }
 
-func (r *ReplicaChecker) fixPeer(region *core.RegionInfo, storeID uint64, status string) *operator.Operator {
+func (r *ReplicaChecker) fixPeer(region *core.RegionInfo, storeID uint64, status string) (*operator.Operator, error) {
 	// Check the number of replicas first.
 	if len(region.GetVoters()) > r.opts.GetMaxReplicas() {
 		removeExtra := fmt.Sprintf("remove-extra-%s-replica", status)
@@ -234,9 +292,9 @@ func (r *ReplicaChecker) fixPeer(region *core.RegionInfo, storeID uint64, status
 		if err != nil {
 			reason := fmt.Sprintf("%s-fail", removeExtra)
 			checkerCounter.WithLabelValues("replica_checker", reason).Inc()
-			return nil
+			return nil, err
 		}
-		return op
+		return op, nil
 	}
 
 	regionStores := r.cluster.GetRegionStores(region)
@@ -244,9 +302,10 @@ func (r *ReplicaChecker) fixPeer(region *core.RegionInfo, storeID uint64, status
 	if target == 0 {
 		reason := fmt.Sprintf("no-store-%s", status)
 		checkerCounter.WithLabelValues("replica_checker", reason).Inc()
-		r.regionWaitingList.Put(region.GetID(), nil)
+		r.warnNoEligibleTarget(region)
+		AddWaitingRegion(r.regionWaitingList, r.opts, region.GetID(), r.now(), reason)
 		log.Debug("no best store to add replica", zap.Uint64("region-id", region.GetID()))
-		return nil
+		return nil, nil
 	}
 	newPeer := &metapb.Peer{StoreId: target}
 	replace := fmt.Sprintf("replace-%s-replica", status)
@@ -254,9 +313,9 @@ func (r *ReplicaChecker) fixPeer(region *core.RegionInfo, storeID uint64, status
 	if err != nil {
 		reason := fmt.Sprintf("%s-fail", replace)
 		checkerCounter.WithLabelValues("replica_checker", reason).Inc()
-		return nil
+		return nil, err
 	}
-	return op
+	return op, nil
 }
 
 func (r *ReplicaChecker) strategy(region *core.RegionInfo) *ReplicaStrategy {
@@ -266,5 +325,27 @@ func (r *ReplicaChecker) strategy(region *core.RegionInfo) *ReplicaStrategy {
 		locationLabels: r.opts.GetLocationLabels(),
 		isolationLevel: r.opts.GetIsolationLevel(),
 		region:         region,
+		weighted:       r.opts.IsWeightedReplicaSchedulingEnabled(),
+		extraFilters:   []filter.Filter{filter.NewMinFreeSpaceFilter(replicaCheckerName)},
+	}
+}
+
+// warnNoEligibleTarget warns if no store has the minimum free space of the
+// replicas, in which case the region cannot get a new peer until some space
+// is freed or the minimum is lowered.
+func (r *ReplicaChecker) warnNoEligibleTarget(region *core.RegionInfo) {
+	minSpace, minRatio := r.opts.GetReplicaMinFreeSpace(), r.opts.GetReplicaMinFreeRatio()
+	if minSpace == 0 && minRatio == 0 {
+		return
+	}
+	stores := filter.NewCandidates(r.cluster.GetStores()).
+		FilterTarget(r.opts, filter.NewMinFreeSpaceFilter(replicaCheckerName)).Stores
+	if len(stores) > 0 {
+		return
 	}
+	checkerCounter.WithLabelValues("replica_checker", "no-eligible-target").Inc()
+	log.Warn("no eligible target to add replica, all stores are below the minimum free space",
+		zap.Uint64("region-id", region.GetID()),
+		zap.Uint64("replica-min-free-space", minSpace),
+		zap.Float64("replica-min-free-ratio", minRatio))
 }
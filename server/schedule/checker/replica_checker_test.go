@@ -93,6 +93,20 @@ func (s *testReplicaCheckerSuite) SetUpTest(c *C) {
 	s.cluster.AddLabelsStore(2, 1, map[string]string{"noleader": "true"})
 }
 
+func (s *testReplicaCheckerSuite) TestCheckWithError(c *C) {
+	region := s.cluster.AddLeaderRegion(1, 3)
+	op, err := s.rc.CheckWithError(region)
+	c.Assert(err, IsNil)
+	c.Assert(op, NotNil)
+
+	// The operator can't be created for the region without leader.
+	region = region.Clone(core.WithLeader(nil))
+	op, err = s.rc.CheckWithError(region)
+	c.Assert(err, NotNil)
+	c.Assert(op, IsNil)
+	c.Assert(s.rc.Check(region), IsNil)
+}
+
 func (s *testReplicaCheckerSuite) TestReplacePendingPeer(c *C) {
 	peers := []*metapb.Peer{
 		{
@@ -350,6 +364,70 @@ func (s *testReplicaCheckerSuite) TestOffline(c *C) {
 	c.Assert(rc.Check(region), IsNil)
 }
 
+func (s *testReplicaCheckerSuite) TestSelectTarget(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	tc.SetMaxReplicas(3)
+	tc.SetLocationLabels([]string{"zone", "rack", "host"})
+	waitingList := cache.NewDefaultCache(10)
+	rc := NewReplicaChecker(tc, waitingList)
+
+	tc.AddLabelsStore(1, 1, map[string]string{"zone": "z1", "rack": "r1", "host": "h1"})
+	tc.AddLabelsStore(2, 2, map[string]string{"zone": "z2", "rack": "r1", "host": "h1"})
+	tc.AddLabelsStore(3, 3, map[string]string{"zone": "z3", "rack": "r1", "host": "h1"})
+	tc.AddLabelsStore(4, 4, map[string]string{"zone": "z3", "rack": "r2", "host": "h1"})
+	tc.AddLeaderRegion(1, 1)
+	region := tc.GetRegion(1)
+
+	// Two replicas are missing, and the second one avoids the zone of the first one.
+	targets := rc.SelectTarget(region)
+	c.Assert(targets, HasLen, 2)
+	c.Assert(targets[0].StoreID, Equals, uint64(2))
+	c.Assert(targets[0].Candidates, HasLen, 3)
+	c.Assert(targets[0].Candidates[0].StoreID, Equals, uint64(2))
+	c.Assert(targets[0].Candidates[0].Ready, IsTrue)
+	c.Assert(targets[1].StoreID, Equals, uint64(3))
+	c.Assert(targets[1].Candidates, HasLen, 2)
+	c.Assert(targets[1].Candidates[0].IsolationScore, Equals, targets[1].Candidates[1].IsolationScore)
+	c.Assert(targets[1].Candidates[0].RegionScore < targets[1].Candidates[1].RegionScore, IsTrue)
+	// It is the same as the store the operator adds a peer to.
+	testutil.CheckAddPeer(c, rc.Check(region), operator.OpReplica, 2)
+
+	// The store in a temporary state stays a candidate but is not picked.
+	tc.UpdatePendingPeerCount(2, 100)
+	targets = rc.SelectTarget(region)
+	c.Assert(targets[0].StoreID, Equals, uint64(3))
+	c.Assert(targets[0].Candidates[0].StoreID, Equals, uint64(2))
+	c.Assert(targets[0].Candidates[0].Ready, IsFalse)
+
+	// A region with enough replicas gets one target, and nothing is changed.
+	region = region.Clone(core.WithAddPeer(&metapb.Peer{Id: 102, StoreId: 3}), core.WithAddPeer(&metapb.Peer{Id: 103, StoreId: 4}))
+	tc.UpdatePendingPeerCount(2, 0)
+	targets = rc.SelectTarget(region)
+	c.Assert(targets, HasLen, 1)
+	c.Assert(targets[0].StoreID, Equals, uint64(2))
+	c.Assert(waitingList.Len(), Equals, 0)
+	c.Assert(tc.GetRegion(1).GetPeers(), HasLen, 1)
+}
+
+func (s *testReplicaCheckerSuite) TestSelectStoreTieBreak(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	tc.SetMaxReplicas(3)
+	rc := NewReplicaChecker(tc, cache.NewDefaultCache(10))
+
+	// All stores have the same score, so the lowest store ID is preferred.
+	for storeID := uint64(1); storeID <= 6; storeID++ {
+		tc.AddRegionStore(storeID, 10)
+	}
+	tc.AddLeaderRegion(1, 4, 6)
+	tc.AddLeaderRegion(2, 6, 2, 3, 5)
+	for i := 0; i < 10; i++ {
+		testutil.CheckAddPeer(c, rc.Check(tc.GetRegion(1)), operator.OpReplica, 1)
+		testutil.CheckRemovePeer(c, rc.Check(tc.GetRegion(2)), 2)
+	}
+}
+
 func (s *testReplicaCheckerSuite) TestDistinctScore(c *C) {
 	opt := config.NewTestOptions()
 	tc := mockcluster.NewCluster(s.ctx, opt)
@@ -495,6 +573,38 @@ func (s *testReplicaCheckerSuite) TestStorageThreshold(c *C) {
 	testutil.CheckAddPeer(c, rc.Check(region), operator.OpReplica, 2)
 }
 
+func (s *testReplicaCheckerSuite) TestMinFreeSpace(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	tc.DisableFeature(versioninfo.JointConsensus)
+	waitingList := cache.NewDefaultCache(10)
+	rc := NewReplicaChecker(tc, waitingList)
+
+	tc.AddRegionStore(1, 1)
+	tc.AddRegionStore(2, 1)
+	tc.AddRegionStore(3, 10)
+	tc.AddRegionStore(4, 20)
+	tc.UpdateStorageRatio(3, 0.4, 0.6)
+	tc.UpdateStorageRatio(4, 0.2, 0.8)
+	tc.AddLeaderRegion(1, 1, 2)
+	region := tc.GetRegion(1)
+
+	// Store 3 has fewer regions.
+	testutil.CheckAddPeer(c, rc.Check(region), operator.OpReplica, 3)
+
+	// Store 3 is below the minimum free ratio.
+	tc.SetReplicaMinFreeRatio(0.7)
+	testutil.CheckAddPeer(c, rc.Check(region), operator.OpReplica, 4)
+
+	// Store 4 is below the minimum free space too.
+	tc.SetReplicaMinFreeSpace(90 * 1024 * MB)
+	c.Assert(rc.Check(region), IsNil)
+	c.Assert(waitingList.Len(), Equals, 1)
+
+	tc.SetReplicaMinFreeSpace(70 * 1024 * MB)
+	testutil.CheckAddPeer(c, rc.Check(region), operator.OpReplica, 4)
+}
+
 func (s *testReplicaCheckerSuite) TestOpts(c *C) {
 	opt := config.NewTestOptions()
 	tc := mockcluster.NewCluster(s.ctx, opt)
@@ -556,6 +666,34 @@ func (s *testReplicaCheckerSuite) TestFixDownPeer(c *C) {
 	c.Assert(rc.Check(region), IsNil)
 }
 
+func (s *testReplicaCheckerSuite) TestDownPeerGracePeriod(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	tc.DisableFeature(versioninfo.JointConsensus)
+	tc.SetDownPeerGracePeriod(2 * time.Hour)
+	rc := NewReplicaChecker(tc, cache.NewDefaultCache(10))
+	for i := uint64(1); i <= 4; i++ {
+		tc.AddRegionStore(i, 1)
+	}
+	tc.AddLeaderRegion(1, 1, 2, 3)
+	tc.SetStoreDown(3)
+	region := tc.GetRegion(1)
+	downFor := func(seconds uint64) *core.RegionInfo {
+		return region.Clone(core.WithDownPeers([]*pdpb.PeerStats{
+			{Peer: region.GetStorePeer(3), DownSeconds: seconds},
+		}))
+	}
+
+	// the store has been down long enough, but the peer has not.
+	c.Assert(rc.Check(downFor(3600)), IsNil)
+	testutil.CheckTransferPeer(c, rc.Check(downFor(7200)), operator.OpRegion, 3, 4)
+
+	// the grace period does not shorten the max store down time.
+	tc.SetDownPeerGracePeriod(0)
+	tc.PutStore(tc.GetStore(3).Clone(core.SetLastHeartbeatTS(time.Now())))
+	c.Assert(rc.Check(downFor(7200)), IsNil)
+}
+
 // See issue: https://github.com/tikv/pd/issues/3705
 func (s *testReplicaCheckerSuite) TestFixOfflinePeer(c *C) {
 	opt := config.NewTestOptions()
@@ -583,3 +721,43 @@ func (s *testReplicaCheckerSuite) TestFixOfflinePeer(c *C) {
 	tc.SetIsolationLevel("zone")
 	c.Assert(rc.Check(region), IsNil)
 }
+
+func (s *testReplicaCheckerSuite) TestWeightedReplicaScheduling(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	tc.DisableFeature(versioninfo.JointConsensus)
+	tc.SetEnableWeightedReplicaScheduling(true)
+	rc := NewReplicaChecker(tc, cache.NewDefaultCache(10))
+
+	tc.AddRegionStore(1, 1)
+	tc.AddRegionStore(2, 1)
+	// store 3 has fewer regions, but store 4 has more free space.
+	tc.AddRegionStore(3, 1)
+	tc.UpdateStorageRatio(3, 0.5, 0.5)
+	tc.AddRegionStore(4, 10)
+	tc.UpdateStorageRatio(4, 0.2, 0.8)
+	tc.AddLeaderRegion(1, 1, 2)
+	region := tc.GetRegion(1)
+	testutil.CheckAddPeer(c, rc.Check(region), operator.OpReplica, 4)
+
+	// Stores with the same available ratio are picked by store ID.
+	tc.AddRegionStore(5, 10)
+	tc.UpdateStorageRatio(5, 0.2, 0.8)
+	for i := 0; i < 10; i++ {
+		testutil.CheckAddPeer(c, rc.Check(region), operator.OpReplica, 4)
+	}
+
+	// Isolation labels are still honored.
+	tc.SetLocationLabels([]string{"zone"})
+	tc.AddLabelsStore(6, 1, map[string]string{"zone": "z1"})
+	tc.AddLabelsStore(7, 1, map[string]string{"zone": "z1"})
+	tc.AddLabelsStore(8, 1, map[string]string{"zone": "z2"})
+	tc.UpdateStorageRatio(8, 0.5, 0.5)
+	tc.AddLabelsStore(9, 1, map[string]string{"zone": "z1"})
+	tc.UpdateStorageRatio(9, 0.1, 0.9)
+	tc.AddLeaderRegion(2, 6, 7)
+	for _, id := range []uint64{1, 2, 3, 4, 5} {
+		tc.SetStoreOffline(id)
+	}
+	testutil.CheckAddPeer(c, rc.Check(tc.GetRegion(2)), operator.OpReplica, 8)
+}
@@ -15,6 +15,8 @@
 package checker
 
 import (
+	"sort"
+
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule/filter"
@@ -31,6 +33,9 @@ type ReplicaStrategy struct {
 	isolationLevel string
 	region         *core.RegionInfo
 	extraFilters   []filter.Filter
+	// weighted makes the strategy prefer the store with the highest available
+	// ratio instead of the lowest region score when selecting a target.
+	weighted bool
 }
 
 // SelectStoreToAdd returns the store to add a replica to a region.
@@ -52,6 +57,26 @@ func (s *ReplicaStrategy) SelectStoreToAdd(coLocationStores []*core.StoreInfo, e
 	//
 	// The reason for it is to prevent the non-optimal replica placement due
 	// to the short-term state, resulting in redundant scheduling.
+	filters := s.addFilters(coLocationStores, extraFilters...)
+	isolationComparer := filter.IsolationComparer(s.locationLabels, coLocationStores)
+	strictStateFilter := &filter.StoreStateFilter{ActionScope: s.checkerName, MoveRegion: true}
+	scoreComparer := filter.RegionScoreComparer(s.cluster.GetOpts())
+	if s.weighted {
+		scoreComparer = filter.AvailableRatioComparer
+	}
+	target := filter.NewCandidates(s.cluster.GetStores()).
+		FilterTarget(s.cluster.GetOpts(), filters...).
+		Sort(filter.ReverseComparer(isolationComparer)).Top(isolationComparer). // greater isolation score is better
+		Sort(scoreComparer).                                                    // less region score or greater available ratio is better
+		FilterTarget(s.cluster.GetOpts(), strictStateFilter).PickFirst()        // the filter does not ignore temp states
+	if target == nil {
+		return 0
+	}
+	return target.GetID()
+}
+
+// addFilters returns the filters of the first stage of selecting a store to add a replica.
+func (s *ReplicaStrategy) addFilters(coLocationStores []*core.StoreInfo, extraFilters ...filter.Filter) []filter.Filter {
 	filters := []filter.Filter{
 		filter.NewExcludedFilter(s.checkerName, nil, s.region.GetStoreIds()),
 		filter.NewStorageThresholdFilter(s.checkerName),
@@ -67,18 +92,57 @@ func (s *ReplicaStrategy) SelectStoreToAdd(coLocationStores []*core.StoreInfo, e
 	if len(s.extraFilters) > 0 {
 		filters = append(filters, s.extraFilters...)
 	}
+	return filters
+}
 
-	isolationComparer := filter.IsolationComparer(s.locationLabels, coLocationStores)
+// StoreCandidate is a store which passes the filters when selecting a store to
+// add a replica, along with the scores it is compared by.
+type StoreCandidate struct {
+	StoreID uint64 `json:"store-id"`
+	// IsolationScore is how distinct the store is from the other stores of the
+	// region by the location labels. The higher is preferred.
+	IsolationScore float64 `json:"isolation-score"`
+	// RegionScore is preferred to be lower, unless the replicas are weighted.
+	RegionScore float64 `json:"region-score"`
+	// AvailableRatio is preferred to be higher if the replicas are weighted.
+	AvailableRatio float64 `json:"available-ratio"`
+	// Ready is false if the store is in a temporary state, such as being busy
+	// or exceeding the store limit, which keeps it from being picked now.
+	Ready bool `json:"ready"`
+}
+
+// ExplainStoreToAdd returns the store SelectStoreToAdd picks along with all the
+// candidates it picks from, the preferred first. It has no side effect.
+func (s *ReplicaStrategy) ExplainStoreToAdd(coLocationStores []*core.StoreInfo) (uint64, []*StoreCandidate) {
+	target := s.SelectStoreToAdd(coLocationStores)
+	opts := s.cluster.GetOpts()
 	strictStateFilter := &filter.StoreStateFilter{ActionScope: s.checkerName, MoveRegion: true}
-	target := filter.NewCandidates(s.cluster.GetStores()).
-		FilterTarget(s.cluster.GetOpts(), filters...).
-		Sort(isolationComparer).Reverse().Top(isolationComparer).        // greater isolation score is better
-		Sort(filter.RegionScoreComparer(s.cluster.GetOpts())).           // less region score is better
-		FilterTarget(s.cluster.GetOpts(), strictStateFilter).PickFirst() // the filter does not ignore temp states
-	if target == nil {
-		return 0
+	stores := filter.NewCandidates(s.cluster.GetStores()).
+		FilterTarget(opts, s.addFilters(coLocationStores)...).Stores
+	candidates := make([]*StoreCandidate, 0, len(stores))
+	for _, store := range stores {
+		candidates = append(candidates, &StoreCandidate{
+			StoreID:        store.GetID(),
+			IsolationScore: core.DistinctScore(s.locationLabels, coLocationStores, store),
+			RegionScore:    store.RegionScore(opts.GetRegionScoreFormulaVersion(), opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), 0),
+			AvailableRatio: store.AvailableRatio(),
+			Ready:          strictStateFilter.Target(opts, store),
+		})
 	}
-	return target.GetID()
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.IsolationScore != b.IsolationScore {
+			return a.IsolationScore > b.IsolationScore
+		}
+		if s.weighted && a.AvailableRatio != b.AvailableRatio {
+			return a.AvailableRatio > b.AvailableRatio
+		}
+		if !s.weighted && a.RegionScore != b.RegionScore {
+			return a.RegionScore < b.RegionScore
+		}
+		return a.StoreID < b.StoreID
+	})
+	return target, candidates
 }
 
 // SelectStoreToFix returns a store to replace down/offline old peer. The location
@@ -118,7 +182,7 @@ func (s *ReplicaStrategy) SelectStoreToRemove(coLocationStores []*core.StoreInfo
 	source := filter.NewCandidates(coLocationStores).
 		FilterSource(s.cluster.GetOpts(), &filter.StoreStateFilter{ActionScope: replicaCheckerName, MoveRegion: true}).
 		Sort(isolationComparer).Top(isolationComparer).
-		Sort(filter.RegionScoreComparer(s.cluster.GetOpts())).Reverse().
+		Sort(filter.ReverseComparer(filter.RegionScoreComparer(s.cluster.GetOpts()))).
 		PickFirst()
 	if source == nil {
 		log.Debug("no removable store", zap.Uint64("region-id", s.region.GetID()))
@@ -16,6 +16,9 @@ package checker
 
 import (
 	"math"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pingcap/errors"
@@ -40,6 +43,10 @@ type RuleChecker struct {
 	name              string
 	regionWaitingList cache.Cache
 	record            *recorder
+
+	orphanMu sync.Mutex
+	// orphanPeers records the orphan peers of the regions found by the last fit.
+	orphanPeers map[uint64][]*metapb.Peer
 }
 
 // NewRuleChecker creates a checker instance.
@@ -49,10 +56,18 @@ func NewRuleChecker(cluster opt.Cluster, ruleManager *placement.RuleManager, reg
 		ruleManager:       ruleManager,
 		name:              "rule-checker",
 		regionWaitingList: regionWaitingList,
-		record:            newRecord(),
+		record:            newRecord(time.Now()),
+		orphanPeers:       make(map[uint64][]*metapb.Peer),
 	}
 }
 
+// SetClock sets the clock used by the checker and restarts the offline leader
+// record from the time of the clock.
+func (c *RuleChecker) SetClock(clock Clock) {
+	c.PauseController.SetClock(clock)
+	c.record = newRecord(c.now())
+}
+
 // GetType returns RuleChecker's Type
 func (c *RuleChecker) GetType() string {
 	return "rule-checker"
@@ -66,10 +81,18 @@ func (c *RuleChecker) Check(region *core.RegionInfo) *operator.Operator {
 }
 
 // CheckWithFit is similar with Checker with placement.RegionFit
-func (c *RuleChecker) CheckWithFit(region *core.RegionInfo, fit *placement.RegionFit) (op *operator.Operator) {
+func (c *RuleChecker) CheckWithFit(region *core.RegionInfo, fit *placement.RegionFit) *operator.Operator {
+	op, _ := c.CheckWithFitError(region, fit)
+	return op
+}
+
+// CheckWithFitError is like CheckWithFit, but it also returns the error if no
+// operator is created since the checker fails to create any of the operators
+// the region needs.
+func (c *RuleChecker) CheckWithFitError(region *core.RegionInfo, fit *placement.RegionFit) (*operator.Operator, error) {
 	if c.IsPaused() {
 		checkerCounter.WithLabelValues("rule_checker", "paused").Inc()
-		return nil
+		return nil, nil
 	}
 	// If the fit is fetched from cache, it seems that the region doesn't need cache
 	if fit.IsCached() {
@@ -77,7 +100,7 @@ func (c *RuleChecker) CheckWithFit(region *core.RegionInfo, fit *placement.Regio
 			panic("cached shouldn't be used")
 		})
 		checkerCounter.WithLabelValues("rule_checker", "get-cache").Inc()
-		return nil
+		return nil, nil
 	}
 	failpoint.Inject("assertShouldCache", func() {
 		panic("cached should be used")
@@ -88,28 +111,32 @@ func (c *RuleChecker) CheckWithFit(region *core.RegionInfo, fit *placement.Regio
 	c.ruleManager.InvalidCache(region.GetID())
 
 	checkerCounter.WithLabelValues("rule_checker", "check").Inc()
-	c.record.refresh(c.cluster)
+	c.record.refresh(c.cluster, c.now())
 
 	if len(fit.RuleFits) == 0 {
 		checkerCounter.WithLabelValues("rule_checker", "need-split").Inc()
 		// If the region matches no rules, the most possible reason is it spans across
 		// multiple rules.
-		return nil
+		return nil, nil
 	}
-	op, err := c.fixOrphanPeers(region, fit)
-	if err != nil {
-		log.Debug("fail to fix orphan peer", errs.ZapError(err))
+	// The first error is returned if no operator is created at last.
+	op, firstErr := c.fixOrphanPeers(region, fit)
+	if firstErr != nil {
+		log.Debug("fail to fix orphan peer", errs.ZapError(firstErr))
 	} else if op != nil {
-		return op
+		return op, nil
 	}
 	for _, rf := range fit.RuleFits {
 		op, err := c.fixRulePeer(region, fit, rf)
 		if err != nil {
 			log.Debug("fail to fix rule peer", zap.String("rule-group", rf.Rule.GroupID), zap.String("rule-id", rf.Rule.ID), errs.ZapError(err))
+			if firstErr == nil {
+				firstErr = err
+			}
 			continue
 		}
 		if op != nil {
-			return op
+			return op, nil
 		}
 	}
 	if fit.IsSatisfied() && len(region.GetDownPeers()) == 0 {
@@ -117,12 +144,190 @@ func (c *RuleChecker) CheckWithFit(region *core.RegionInfo, fit *placement.Regio
 		c.ruleManager.SetRegionFitCache(region, fit)
 		checkerCounter.WithLabelValues("rule_checker", "set-cache").Inc()
 	}
+	return nil, firstErr
+}
+
+// The reasons why a region violates the placement rules.
+const (
+	ViolationNoMatchedRule  = "no-matched-rule"
+	ViolationMissingPeer    = "missing-peer"
+	ViolationDownPeer       = "down-peer"
+	ViolationOfflinePeer    = "offline-peer"
+	ViolationRoleMismatch   = "role-mismatch"
+	ViolationBetterLocation = "better-location"
+	ViolationOrphanPeer     = "orphan-peer"
+)
+
+// RuleViolation describes a placement rule which is not satisfied by a region.
+type RuleViolation struct {
+	// Rule is nil if the violation is not related to a specific rule.
+	Rule   *placement.Rule `json:"rule,omitempty"`
+	Reason string          `json:"reason"`
+	// Peers are the peers which cause the violation.
+	Peers []*metapb.Peer `json:"peers,omitempty"`
+}
+
+// CheckDryRun checks if the region matches placement rules and reports the
+// violations without creating any operator. It does not change the waiting
+// list or any cache, so it can be used to check all regions against new rules.
+func (c *RuleChecker) CheckDryRun(region *core.RegionInfo) (*placement.RegionFit, []*RuleViolation) {
+	fit := opt.FitRegion(c.cluster, region)
+	if len(fit.RuleFits) == 0 {
+		return fit, []*RuleViolation{{Reason: ViolationNoMatchedRule}}
+	}
+	var violations []*RuleViolation
+	for _, rf := range fit.RuleFits {
+		if len(rf.Peers) < rf.Rule.Count {
+			violations = append(violations, &RuleViolation{Rule: rf.Rule, Reason: ViolationMissingPeer})
+		}
+		for _, peer := range rf.Peers {
+			if c.isDownPeer(region, peer) {
+				violations = append(violations, &RuleViolation{Rule: rf.Rule, Reason: ViolationDownPeer, Peers: []*metapb.Peer{peer}})
+			} else if c.isOfflinePeer(peer) {
+				violations = append(violations, &RuleViolation{Rule: rf.Rule, Reason: ViolationOfflinePeer, Peers: []*metapb.Peer{peer}})
+			}
+		}
+		if len(rf.PeersWithDifferentRole) > 0 {
+			violations = append(violations, &RuleViolation{Rule: rf.Rule, Reason: ViolationRoleMismatch, Peers: rf.PeersWithDifferentRole})
+		}
+		if c.hasBetterLocation(region, rf) {
+			violations = append(violations, &RuleViolation{Rule: rf.Rule, Reason: ViolationBetterLocation})
+		}
+	}
+	if len(fit.OrphanPeers) > 0 {
+		violations = append(violations, &RuleViolation{Reason: ViolationOrphanPeer, Peers: fit.OrphanPeers})
+	}
+	return fit, violations
+}
+
+// OrphanPeerRegion is a region with the peers not required by any rule.
+type OrphanPeerRegion struct {
+	RegionID uint64         `json:"region-id"`
+	Peers    []*metapb.Peer `json:"peers"`
+}
+
+// RecordOrphanPeers records the orphan peers of the region by its fit, without
+// creating any operator. The regions which match no rule are not recorded,
+// since all their peers are orphan peers before the region is split.
+func (c *RuleChecker) RecordOrphanPeers(region *core.RegionInfo, fit *placement.RegionFit) {
+	c.orphanMu.Lock()
+	defer c.orphanMu.Unlock()
+	if fit == nil || len(fit.RuleFits) == 0 || len(fit.OrphanPeers) == 0 {
+		delete(c.orphanPeers, region.GetID())
+		return
+	}
+	c.orphanPeers[region.GetID()] = append([]*metapb.Peer(nil), fit.OrphanPeers...)
+}
+
+// GetOrphanPeerRegions returns the regions with orphan peers sorted by the region ID.
+// The regions which no longer exist are dropped.
+func (c *RuleChecker) GetOrphanPeerRegions() []*OrphanPeerRegion {
+	c.orphanMu.Lock()
+	defer c.orphanMu.Unlock()
+	regions := make([]*OrphanPeerRegion, 0, len(c.orphanPeers))
+	for id, peers := range c.orphanPeers {
+		if c.cluster.GetRegion(id) == nil {
+			delete(c.orphanPeers, id)
+			continue
+		}
+		regions = append(regions, &OrphanPeerRegion{RegionID: id, Peers: peers})
+	}
+	sort.Slice(regions, func(i, j int) bool {
+		return regions[i].RegionID < regions[j].RegionID
+	})
+	return regions
+}
+
+// The reasons why a placement rule can never be satisfied.
+const (
+	UnsatisfiableNotEnoughStores    = "not-enough-stores"
+	UnsatisfiableNotEnoughIsolation = "not-enough-isolation"
+)
+
+// UnsatisfiableRule describes a placement rule which can never be satisfied by
+// the store topology, no matter how the peers are placed.
+type UnsatisfiableRule struct {
+	Rule   *placement.Rule `json:"rule"`
+	Reason string          `json:"reason"`
+	// Required is the number of stores or isolation domains the rule requires.
+	Required int `json:"required"`
+	// Available is the number of stores or isolation domains the topology provides.
+	Available int `json:"available"`
+}
+
+// CheckRulesSatisfiable checks whether the rules can be satisfied by the stores,
+// including the isolation level of each rule, and returns the rules which can
+// never be satisfied. Only the tombstone stores are ignored, since the stores
+// which are down or busy now may come back later. Each rule is checked alone,
+// and no operator is created.
+func (c *RuleChecker) CheckRulesSatisfiable(rules []*placement.Rule, stores []*core.StoreInfo) (bool, []*UnsatisfiableRule) {
+	var unsatisfiable []*UnsatisfiableRule
+	for _, rule := range rules {
+		if u := checkRuleSatisfiable(rule, stores); u != nil {
+			unsatisfiable = append(unsatisfiable, u)
+		}
+	}
+	return len(unsatisfiable) == 0, unsatisfiable
+}
+
+func checkRuleSatisfiable(rule *placement.Rule, stores []*core.StoreInfo) *UnsatisfiableRule {
+	var matched []*core.StoreInfo
+	for _, store := range stores {
+		if !store.IsTombstone() && placement.MatchLabelConstraints(store, rule.LabelConstraints) {
+			matched = append(matched, store)
+		}
+	}
+	// Each peer of the rule needs a distinct store.
+	if len(matched) < rule.Count {
+		return &UnsatisfiableRule{Rule: rule, Reason: UnsatisfiableNotEnoughStores, Required: rule.Count, Available: len(matched)}
+	}
+	if rule.IsolationLevel == "" || len(rule.LocationLabels) == 0 {
+		return nil
+	}
+	// Each peer of the rule needs a distinct location up to the isolation level,
+	// which is the same as what the isolation filter enforces.
+	var isolationLevelIdx int
+	for level, label := range rule.LocationLabels {
+		if label == rule.IsolationLevel {
+			isolationLevelIdx = level
+			break
+		}
+	}
+	domains := make(map[string]struct{})
+	for _, store := range matched {
+		var location []string
+		for i := 0; i <= isolationLevelIdx; i++ {
+			location = append(location, store.GetLabelValue(rule.LocationLabels[i]))
+		}
+		domains[strings.Join(location, "/")] = struct{}{}
+	}
+	if len(domains) < rule.Count {
+		return &UnsatisfiableRule{Rule: rule, Reason: UnsatisfiableNotEnoughIsolation, Required: rule.Count, Available: len(domains)}
+	}
 	return nil
 }
 
+// hasBetterLocation returns true if a peer of the rule can be moved to a
+// better location.
+func (c *RuleChecker) hasBetterLocation(region *core.RegionInfo, rf *placement.RuleFit) bool {
+	if len(rf.Rule.LocationLabels) == 0 || rf.Rule.Count <= 1 {
+		return false
+	}
+	strategy := c.strategy(region, rf.Rule)
+	ruleStores := c.getRuleFitStores(rf)
+	oldStore := strategy.SelectStoreToRemove(ruleStores)
+	if oldStore == 0 {
+		return false
+	}
+	return strategy.SelectStoreToImprove(ruleStores, oldStore) != 0
+}
+
 func (c *RuleChecker) fixRulePeer(region *core.RegionInfo, fit *placement.RegionFit, rf *placement.RuleFit) (*operator.Operator, error) {
 	// make up peers.
 	if len(rf.Peers) < rf.Rule.Count {
+		if c.reachLearnerLimit(region, fit) {
+			return nil, nil
+		}
 		return c.addRulePeer(region, rf)
 	}
 	// fix down/offline peers.
@@ -146,16 +351,56 @@ func (c *RuleChecker) fixRulePeer(region *core.RegionInfo, fit *placement.Region
 			return op, nil
 		}
 	}
+	if c.reachLearnerLimit(region, fit) {
+		return nil, nil
+	}
 	return c.fixBetterLocation(region, rf)
 }
 
+// reachLearnerLimit returns true and puts the region into the waiting list if
+// the region has as many learners in transition as the rule checker allows, so
+// that the rule checker doesn't add another learner to it, which takes another
+// snapshot, until the learners catch up and are promoted. The down and offline
+// peers are still replaced, as they make the region less available.
+func (c *RuleChecker) reachLearnerLimit(region *core.RegionInfo, fit *placement.RegionFit) bool {
+	limit := c.cluster.GetOpts().GetMaxRuleLearnersPerRegion()
+	if limit <= 0 || transitionalLearnerCount(region, fit) < limit {
+		return false
+	}
+	checkerCounter.WithLabelValues("rule_checker", "learner-limit").Inc()
+	AddWaitingRegion(c.regionWaitingList, c.cluster.GetOpts(), region.GetID(), c.now(), "learner-limit")
+	return true
+}
+
+// transitionalLearnerCount returns the number of the learners of the region
+// which are catching up, or are not required as learners by the rules, such as
+// the learners to be promoted to voters.
+func transitionalLearnerCount(region *core.RegionInfo, fit *placement.RegionFit) int {
+	settled := make(map[uint64]struct{})
+	for _, rf := range fit.RuleFits {
+		if rf.Rule.Role != placement.Learner {
+			continue
+		}
+		for _, peer := range rf.Peers {
+			settled[peer.GetId()] = struct{}{}
+		}
+	}
+	var count int
+	for _, learner := range region.GetLearners() {
+		if _, ok := settled[learner.GetId()]; !ok || region.GetPendingLearner(learner.GetId()) != nil {
+			count++
+		}
+	}
+	return count
+}
+
 func (c *RuleChecker) addRulePeer(region *core.RegionInfo, rf *placement.RuleFit) (*operator.Operator, error) {
 	checkerCounter.WithLabelValues("rule_checker", "add-rule-peer").Inc()
 	ruleStores := c.getRuleFitStores(rf)
 	store := c.strategy(region, rf.Rule).SelectStoreToAdd(ruleStores)
 	if store == 0 {
 		checkerCounter.WithLabelValues("rule_checker", "no-store-add").Inc()
-		c.regionWaitingList.Put(region.GetID(), nil)
+		AddWaitingRegion(c.regionWaitingList, c.cluster.GetOpts(), region.GetID(), c.now(), "no-store-add")
 		return nil, errors.New("no store to add peer")
 	}
 	peer := &metapb.Peer{StoreId: store, Role: rf.Rule.Role.MetaPeerRole()}
@@ -173,7 +418,7 @@ func (c *RuleChecker) replaceUnexpectRulePeer(region *core.RegionInfo, rf *place
 	store := c.strategy(region, rf.Rule).SelectStoreToFix(ruleStores, peer.GetStoreId())
 	if store == 0 {
 		checkerCounter.WithLabelValues("rule_checker", "no-store-replace").Inc()
-		c.regionWaitingList.Put(region.GetID(), nil)
+		AddWaitingRegion(c.regionWaitingList, c.cluster.GetOpts(), region.GetID(), c.now(), "no-store-replace")
 		return nil, errors.New("no store to replace peer")
 	}
 	newPeer := &metapb.Peer{StoreId: store, Role: rf.Rule.Role.MetaPeerRole()}
@@ -210,7 +455,7 @@ func (c *RuleChecker) replaceUnexpectRulePeer(region *core.RegionInfo, rf *place
 		return nil, err
 	}
 	if newLeader != nil {
-		c.record.incOfflineLeaderCount(newLeader.GetStoreId())
+		c.record.incOfflineLeaderCount(newLeader.GetStoreId(), c.now())
 	}
 	op.SetPriorityLevel(core.HighPriority)
 	return op, nil
@@ -334,6 +579,10 @@ func (c *RuleChecker) isDownPeer(region *core.RegionInfo, peer *metapb.Peer) boo
 		if store.DownTime() < c.cluster.GetOpts().GetMaxStoreDownTime() {
 			continue
 		}
+		if inDownPeerGracePeriod(c.cluster.GetOpts(), stats) {
+			checkerCounter.WithLabelValues("rule_checker", "down-peer-in-grace-period").Inc()
+			continue
+		}
 		return true
 	}
 	return false
@@ -374,10 +623,10 @@ type recorder struct {
 	lastUpdateTime       time.Time
 }
 
-func newRecord() *recorder {
+func newRecord(now time.Time) *recorder {
 	return &recorder{
 		offlineLeaderCounter: make(map[uint64]uint64),
-		lastUpdateTime:       time.Now(),
+		lastUpdateTime:       now,
 	}
 }
 
@@ -385,17 +634,17 @@ func (o *recorder) getOfflineLeaderCount(storeID uint64) uint64 {
 	return o.offlineLeaderCounter[storeID]
 }
 
-func (o *recorder) incOfflineLeaderCount(storeID uint64) {
+func (o *recorder) incOfflineLeaderCount(storeID uint64, now time.Time) {
 	o.offlineLeaderCounter[storeID] += 1
-	o.lastUpdateTime = time.Now()
+	o.lastUpdateTime = now
 }
 
 // Offline is triggered manually and only appears when the node makes some adjustments. here is an operator timeout / 2.
 var offlineCounterTTL = 5 * time.Minute
 
-func (o *recorder) refresh(cluster opt.Cluster) {
+func (o *recorder) refresh(cluster opt.Cluster, now time.Time) {
 	// re-count the offlineLeaderCounter if the store is already tombstone or store is gone.
-	if len(o.offlineLeaderCounter) > 0 && time.Since(o.lastUpdateTime) > offlineCounterTTL {
+	if len(o.offlineLeaderCounter) > 0 && now.Sub(o.lastUpdateTime) > offlineCounterTTL {
 		needClean := false
 		for _, storeID := range o.offlineLeaderCounter {
 			store := cluster.GetStore(storeID)
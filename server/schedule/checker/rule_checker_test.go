@@ -16,6 +16,7 @@ package checker
 
 import (
 	"context"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/failpoint"
@@ -96,6 +97,35 @@ func (s *testRuleCheckerSuite) TestAddRulePeer(c *C) {
 	c.Assert(op.Step(0).(operator.AddLearner).ToStore, Equals, uint64(3))
 }
 
+func (s *testRuleCheckerSuite) TestMaxRuleLearnersPerRegion(c *C) {
+	for i := uint64(1); i <= 6; i++ {
+		s.cluster.AddLeaderStore(i, 1)
+	}
+	c.Assert(s.ruleManager.SetRule(&placement.Rule{GroupID: "pd", ID: "learner", Role: placement.Learner, Count: 3}), IsNil)
+	region := s.cluster.AddRegionWithLearner(1, 1, []uint64{2, 3}, []uint64{4})
+	region = region.Clone(core.WithPendingPeers([]*metapb.Peer{region.GetStorePeer(4)}))
+	s.cluster.PutRegion(region)
+
+	// A learner is added even if another one is catching up without the limit.
+	op := s.rc.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "add-rule-peer")
+
+	// The region waits at the limit.
+	s.cluster.SetMaxRuleLearnersPerRegion(1)
+	c.Assert(s.rc.Check(region), IsNil)
+	_, ok := s.rc.regionWaitingList.Get(region.GetID())
+	c.Assert(ok, IsTrue)
+
+	// A learner required by the rules doesn't count once it catches up.
+	region = region.Clone(core.WithPendingPeers(nil))
+	s.cluster.PutRegion(region)
+	op = s.rc.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "add-rule-peer")
+	c.Assert(op.Step(0).(operator.AddLearner).ToStore, Not(Equals), uint64(4))
+}
+
 func (s *testRuleCheckerSuite) TestAddRulePeerWithIsolationLevel(c *C) {
 	s.cluster.AddLabelsStore(1, 1, map[string]string{"zone": "z1", "rack": "r1", "host": "h1"})
 	s.cluster.AddLabelsStore(2, 1, map[string]string{"zone": "z1", "rack": "r1", "host": "h2"})
@@ -187,6 +217,77 @@ func (s *testRuleCheckerSuite) TestFixOrphanPeers(c *C) {
 	c.Assert(op.Step(0).(operator.RemovePeer).FromStore, Equals, uint64(4))
 }
 
+func (s *testRuleCheckerSuite) TestCheckDryRun(c *C) {
+	s.cluster.AddLeaderStore(1, 1)
+	s.cluster.AddLeaderStore(2, 1)
+	s.cluster.AddLeaderStore(3, 1)
+	s.cluster.AddLeaderStore(4, 1)
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2)
+	waitingList := s.rc.regionWaitingList.Len()
+
+	fit, violations := s.rc.CheckDryRun(s.cluster.GetRegion(1))
+	c.Assert(fit, NotNil)
+	c.Assert(violations, HasLen, 1)
+	c.Assert(violations[0].Reason, Equals, ViolationMissingPeer)
+	c.Assert(violations[0].Rule.ID, Equals, "default")
+
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2, 3, 4)
+	_, violations = s.rc.CheckDryRun(s.cluster.GetRegion(1))
+	c.Assert(violations, HasLen, 1)
+	c.Assert(violations[0].Reason, Equals, ViolationOrphanPeer)
+	c.Assert(violations[0].Peers, HasLen, 1)
+	c.Assert(violations[0].Peers[0].GetStoreId(), Equals, uint64(4))
+
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2, 3)
+	_, violations = s.rc.CheckDryRun(s.cluster.GetRegion(1))
+	c.Assert(violations, HasLen, 0)
+	c.Assert(s.rc.regionWaitingList.Len(), Equals, waitingList)
+}
+
+func (s *testRuleCheckerSuite) TestCheckRulesSatisfiable(c *C) {
+	s.cluster.AddLabelsStore(1, 1, map[string]string{"zone": "z1", "host": "h1"})
+	s.cluster.AddLabelsStore(2, 1, map[string]string{"zone": "z1", "host": "h2"})
+	s.cluster.AddLabelsStore(3, 1, map[string]string{"zone": "z2", "host": "h3"})
+	s.cluster.AddLabelsStore(4, 1, map[string]string{"zone": "z3", "host": "h4"})
+	s.cluster.SetStoreDown(3)
+
+	rules := []*placement.Rule{
+		{GroupID: "pd", ID: "host", Role: placement.Voter, Count: 3, LocationLabels: []string{"zone", "host"}, IsolationLevel: "host"},
+		{GroupID: "pd", ID: "zone", Role: placement.Voter, Count: 3, LocationLabels: []string{"zone", "host"}, IsolationLevel: "zone"},
+	}
+	// The down store may come back, so it counts.
+	ok, unsatisfiable := s.rc.CheckRulesSatisfiable(rules, s.cluster.GetStores())
+	c.Assert(ok, IsTrue)
+	c.Assert(unsatisfiable, HasLen, 0)
+
+	// Only 2 zones are left after the store 4 is removed.
+	stores := []*core.StoreInfo{
+		s.cluster.GetStore(1),
+		s.cluster.GetStore(2),
+		s.cluster.GetStore(3),
+		s.cluster.GetStore(4).Clone(core.TombstoneStore()),
+	}
+	ok, unsatisfiable = s.rc.CheckRulesSatisfiable(rules, stores)
+	c.Assert(ok, IsFalse)
+	c.Assert(unsatisfiable, HasLen, 1)
+	c.Assert(unsatisfiable[0].Rule.ID, Equals, "zone")
+	c.Assert(unsatisfiable[0].Reason, Equals, UnsatisfiableNotEnoughIsolation)
+	c.Assert(unsatisfiable[0].Required, Equals, 3)
+	c.Assert(unsatisfiable[0].Available, Equals, 2)
+
+	// The label constraints limit the stores.
+	rules = []*placement.Rule{
+		{GroupID: "pd", ID: "z1", Role: placement.Voter, Count: 3, LabelConstraints: []placement.LabelConstraint{
+			{Key: "zone", Op: placement.In, Values: []string{"z1"}},
+		}},
+	}
+	ok, unsatisfiable = s.rc.CheckRulesSatisfiable(rules, s.cluster.GetStores())
+	c.Assert(ok, IsFalse)
+	c.Assert(unsatisfiable, HasLen, 1)
+	c.Assert(unsatisfiable[0].Reason, Equals, UnsatisfiableNotEnoughStores)
+	c.Assert(unsatisfiable[0].Available, Equals, 2)
+}
+
 func (s *testRuleCheckerSuite) TestFixOrphanPeers2(c *C) {
 	// check orphan peers can only be handled when all rules are satisfied.
 	s.cluster.AddLabelsStore(1, 1, map[string]string{"foo": "bar"})
@@ -569,6 +670,13 @@ func (s *testRuleCheckerSuite) TestFixDownPeer(c *C) {
 	s.cluster.SetStoreDown(5)
 	testutil.CheckTransferPeer(c, s.rc.Check(region), operator.OpRegion, 4, 2)
 
+	// the peer is not replaced until it has been down for the grace period.
+	s.cluster.SetDownPeerGracePeriod(2 * time.Hour)
+	c.Assert(s.rc.Check(region), IsNil)
+	s.cluster.SetDownPeerGracePeriod(time.Hour)
+	testutil.CheckTransferPeer(c, s.rc.Check(region), operator.OpRegion, 4, 2)
+	s.cluster.SetDownPeerGracePeriod(0)
+
 	rule.IsolationLevel = "zone"
 	s.ruleManager.SetRule(rule)
 	c.Assert(s.rc.Check(region), IsNil)
@@ -761,3 +869,39 @@ func (s *testRuleCheckerSuite) TestDemoteVoter(c *C) {
 	c.Assert(op, NotNil)
 	c.Assert(op.Desc(), Equals, "fix-demote-voter")
 }
+
+func (s *testRuleCheckerSuite) TestSimulateRules(c *C) {
+	for i := uint64(1); i <= 4; i++ {
+		s.cluster.AddLeaderStore(i, 1)
+	}
+	s.cluster.AddLeaderRegion(1, 1, 2, 3)
+	s.cluster.AddLeaderRegion(2, 2, 1, 3)
+	s.cluster.AddLeaderRegion(3, 1, 2)
+
+	ruleManager, err := s.ruleManager.CloneWithGroupBundles([]placement.GroupBundle{{
+		ID:    "pd",
+		Rules: []*placement.Rule{{GroupID: "pd", ID: "default", Role: placement.Voter, Count: 4}},
+	}}, true)
+	c.Assert(err, IsNil)
+	simulation := SimulateRules(s.cluster, ruleManager, s.cluster.GetRegions())
+	c.Assert(simulation.Regions, Equals, 3)
+	c.Assert(simulation.NonCompliantRegions, Equals, 3)
+	// Region 3 misses a peer already, and only the first operator of it is
+	// counted.
+	c.Assert(simulation.NewlyNonCompliantRegions, Equals, 2)
+	c.Assert(simulation.OperatorRegions, Equals, 3)
+	size := s.cluster.GetRegion(1).GetApproximateSize()
+	c.Assert(simulation.MoveSize, Equals, 3*size)
+	changes := make(map[uint64]StorePeerChange)
+	for _, change := range simulation.Stores {
+		changes[change.StoreID] = *change
+	}
+	// Region 3 gains a peer in store 3 or store 4.
+	c.Assert(changes[3].Peers+changes[4].Peers, Equals, int64(3))
+	c.Assert(changes[4].Peers >= 2, IsTrue)
+	c.Assert(changes[4].Size, Equals, changes[4].Peers*size)
+
+	// The rules of the cluster are unchanged.
+	c.Assert(s.ruleManager.GetRule("pd", "default").Count, Equals, 3)
+	c.Assert(s.rc.Check(s.cluster.GetRegion(1)), IsNil)
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"sort"
+
+	"github.com/tikv/pd/pkg/cache"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/tikv/pd/server/schedule/placement"
+)
+
+// RuleSimulation is the estimated effect of a set of placement rules on the
+// regions, before the rules are set.
+type RuleSimulation struct {
+	// Regions is the number of the regions checked.
+	Regions int `json:"regions"`
+	// NonCompliantRegions is the number of the regions which do not satisfy
+	// the rules.
+	NonCompliantRegions int `json:"non-compliant-regions"`
+	// NewlyNonCompliantRegions is the number of the non-compliant regions which
+	// satisfy the current rules.
+	NewlyNonCompliantRegions int `json:"newly-non-compliant-regions"`
+	// OperatorRegions is the number of the regions which the rule checker
+	// creates operators for.
+	OperatorRegions int `json:"operator-regions"`
+	// MoveSize is the total size in MB of the peers added by the operators.
+	MoveSize int64 `json:"move-size"`
+	// Stores are the stores which gain or lose peers, sorted by store ID.
+	Stores []*StorePeerChange `json:"stores,omitempty"`
+}
+
+// StorePeerChange is the change of the peers of a store made by the operators.
+type StorePeerChange struct {
+	StoreID uint64 `json:"store-id"`
+	// Peers is the number of the peers gained, or lost if it is negative.
+	Peers int64 `json:"peers"`
+	// Size is the size in MB of the peers gained, or lost if it is negative.
+	Size int64 `json:"size"`
+}
+
+// ruleSimulationCluster fits the regions with the simulated rules.
+type ruleSimulationCluster struct {
+	opt.Cluster
+	ruleManager *placement.RuleManager
+}
+
+func (c *ruleSimulationCluster) GetRuleManager() *placement.RuleManager {
+	return c.ruleManager
+}
+
+// SimulateRules checks the regions against the rules of the rule manager,
+// which are not set to the cluster, and estimates how many regions need to be
+// fixed and how the peers move. Only the first operator the rule checker
+// creates for a region is counted, while fixing a region may need several
+// operators, so the moves are underestimated if several peers of a region are
+// misplaced. No operator is added and the rules of the cluster are unchanged.
+func SimulateRules(cluster opt.Cluster, ruleManager *placement.RuleManager, regions []*core.RegionInfo) *RuleSimulation {
+	simCluster := &ruleSimulationCluster{Cluster: cluster, ruleManager: ruleManager}
+	// The waiting list is dropped after the simulation.
+	checker := NewRuleChecker(simCluster, ruleManager, cache.NewDefaultCache(len(regions)+1))
+	simulation := &RuleSimulation{Regions: len(regions)}
+	stores := make(map[uint64]*StorePeerChange)
+	for _, region := range regions {
+		fit := ruleManager.FitRegion(simCluster, region)
+		if fit.IsSatisfied() {
+			continue
+		}
+		simulation.NonCompliantRegions++
+		if opt.FitRegion(cluster, region).IsSatisfied() {
+			simulation.NewlyNonCompliantRegions++
+		}
+		op := checker.CheckWithFit(region, fit)
+		if op == nil {
+			continue
+		}
+		simulation.OperatorRegions++
+		influence := operator.OpInfluence{StoresInfluence: make(map[uint64]*operator.StoreInfluence)}
+		op.TotalInfluence(influence, region)
+		for storeID, inf := range influence.StoresInfluence {
+			if inf.RegionCount == 0 && inf.RegionSize == 0 {
+				continue
+			}
+			if inf.RegionSize > 0 {
+				simulation.MoveSize += inf.RegionSize
+			}
+			change, ok := stores[storeID]
+			if !ok {
+				change = &StorePeerChange{StoreID: storeID}
+				stores[storeID] = change
+			}
+			change.Peers += inf.RegionCount
+			change.Size += inf.RegionSize
+		}
+	}
+	for _, change := range stores {
+		simulation.Stores = append(simulation.Stores, change)
+	}
+	sort.Slice(simulation.Stores, func(i, j int) bool {
+		return simulation.Stores[i].StoreID < simulation.Stores[j].StoreID
+	})
+	return simulation
+}
@@ -15,8 +15,14 @@
 package checker
 
 import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/cache"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule/labeler"
@@ -25,20 +31,54 @@ import (
 	"github.com/tikv/pd/server/schedule/placement"
 )
 
-// SplitChecker splits regions when the key range spans across rule/label boundary.
+// SplitChecker splits regions when the key range spans across rule/label boundary,
+// when the region is much larger than the region split size, or optionally when
+// the region serves too many queries.
 type SplitChecker struct {
 	PauseController
 	cluster     opt.Cluster
 	ruleManager *placement.RuleManager
 	labeler     *labeler.RegionLabeler
+	mergeCache  *cache.TTLUint64
+	dryRun      bool
 }
 
 // NewSplitChecker creates a new SplitChecker.
-func NewSplitChecker(cluster opt.Cluster, ruleManager *placement.RuleManager, labeler *labeler.RegionLabeler) *SplitChecker {
+func NewSplitChecker(ctx context.Context, cluster opt.Cluster, ruleManager *placement.RuleManager, labeler *labeler.RegionLabeler) *SplitChecker {
+	mergeCache := cache.NewIDTTL(ctx, time.Minute, cluster.GetOpts().GetMergeSplitCooldown())
+	return newSplitChecker(cluster, ruleManager, labeler, mergeCache)
+}
+
+// NewSyncSplitChecker creates a split checker which runs no background goroutine,
+// so that its checks are purely synchronous. The expired merge records are not
+// garbage collected, which is fine for tests.
+func NewSyncSplitChecker(cluster opt.Cluster, ruleManager *placement.RuleManager, labeler *labeler.RegionLabeler) *SplitChecker {
+	mergeCache := cache.NewIDTTL(context.Background(), 0, cluster.GetOpts().GetMergeSplitCooldown())
+	return newSplitChecker(cluster, ruleManager, labeler, mergeCache)
+}
+
+func newSplitChecker(cluster opt.Cluster, ruleManager *placement.RuleManager, labeler *labeler.RegionLabeler, mergeCache *cache.TTLUint64) *SplitChecker {
 	return &SplitChecker{
 		cluster:     cluster,
 		ruleManager: ruleManager,
 		labeler:     labeler,
+		mergeCache:  mergeCache,
+	}
+}
+
+// DryRun returns a checker which shares the state of the checker, but records
+// no metrics, so that checking a region with it leaves nothing behind. It is
+// never paused.
+func (c *SplitChecker) DryRun() *SplitChecker {
+	d := newSplitChecker(c.cluster, c.ruleManager, c.labeler, c.mergeCache)
+	d.clock = c.clock
+	d.dryRun = true
+	return d
+}
+
+func (c *SplitChecker) count(name string) {
+	if !c.dryRun {
+		checkerCounter.WithLabelValues("split_checker", name).Inc()
 	}
 }
 
@@ -47,12 +87,31 @@ func (c *SplitChecker) GetType() string {
 	return "split-checker"
 }
 
+// RecordRegionMerge puts the recently merged regions into cache. SplitChecker
+// will not split them by size until the merge split cooldown expires.
+func (c *SplitChecker) RecordRegionMerge(regionIDs []uint64) {
+	cooldown := c.cluster.GetOpts().GetMergeSplitCooldown()
+	for _, regionID := range regionIDs {
+		c.mergeCache.PutWithTTL(regionID, c.now().Add(cooldown), cooldown)
+	}
+}
+
+// recentlyMerged returns true if the region is merged within the merge split cooldown.
+func (c *SplitChecker) recentlyMerged(regionID uint64) bool {
+	v, ok := c.mergeCache.Get(regionID)
+	if !ok {
+		return false
+	}
+	expireTime, ok := v.(time.Time)
+	return !ok || c.now().Before(expireTime)
+}
+
 // Check checks whether the region need to split and returns Operator to fix.
 func (c *SplitChecker) Check(region *core.RegionInfo) *operator.Operator {
-	checkerCounter.WithLabelValues("split_checker", "check").Inc()
+	c.count("check")
 
 	if c.IsPaused() {
-		checkerCounter.WithLabelValues("split_checker", "paused").Inc()
+		c.count("paused")
 		return nil
 	}
 
@@ -69,7 +128,10 @@ func (c *SplitChecker) Check(region *core.RegionInfo) *operator.Operator {
 	}
 
 	if len(keys) == 0 {
-		return nil
+		if op := c.checkSize(region); op != nil {
+			return op
+		}
+		return c.checkQuery(region)
 	}
 
 	op, err := operator.CreateSplitRegionOperator(desc, region, 0, pdpb.CheckPolicy_USEKEY, keys)
@@ -79,3 +141,114 @@ func (c *SplitChecker) Check(region *core.RegionInfo) *operator.Operator {
 	}
 	return op
 }
+
+// checkSize splits a region which is at least twice as large as the region split
+// size. The split key is decided by TiKV with the approximate size, so a much
+// larger region is broken down by the following checks. A region merged within
+// the merge split cooldown is not split, so that a region around both the split
+// and merge thresholds doesn't keep being split and merged. The splits by the
+// rules and labels are not delayed, as the merge checker never merges across them.
+func (c *SplitChecker) checkSize(region *core.RegionInfo) *operator.Operator {
+	splitSize := c.cluster.GetOpts().GetRegionSplitSize()
+	size := region.GetApproximateSize()
+	if splitSize == 0 || size <= 0 || uint64(size) < 2*splitSize {
+		return nil
+	}
+	if c.recentlyMerged(region.GetID()) {
+		c.count("recently-merged")
+		if !c.dryRun {
+			splitMergeThrashCounter.WithLabelValues("split_checker").Inc()
+		}
+		return nil
+	}
+	c.count("size-split")
+	op, err := operator.CreateSplitRegionOperator("size-split-region", region, 0, pdpb.CheckPolicy_APPROXIMATE, nil)
+	if err != nil {
+		log.Debug("create split region operator failed", errs.ZapError(err))
+		return nil
+	}
+	return op
+}
+
+// checkQuery splits a region whose read and write query rate exceeds the query
+// split threshold into two, so that a small region serving many queries is not
+// a hotspot of a single raft group. The middle key is decided by TiKV with the
+// approximate size. The region is not split if either part would have fewer
+// keys than the query split min keys, or it is merged within the merge split
+// cooldown.
+func (c *SplitChecker) checkQuery(region *core.RegionInfo) *operator.Operator {
+	opts := c.cluster.GetOpts()
+	if !opts.IsQuerySplitEnabled() {
+		return nil
+	}
+	interval := region.GetInterval()
+	if interval.GetEndTimestamp() <= interval.GetStartTimestamp() {
+		return nil
+	}
+	seconds := interval.GetEndTimestamp() - interval.GetStartTimestamp()
+	rate := float64(region.GetReadQueryNum()+region.GetWriteQueryNum()) / float64(seconds)
+	if rate <= opts.GetQuerySplitThreshold() {
+		return nil
+	}
+	if keys := region.GetApproximateKeys(); keys < 0 || uint64(keys) < 2*opts.GetQuerySplitMinKeys() {
+		c.count("query-too-few-keys")
+		return nil
+	}
+	if c.recentlyMerged(region.GetID()) {
+		c.count("recently-merged")
+		if !c.dryRun {
+			splitMergeThrashCounter.WithLabelValues("split_checker").Inc()
+		}
+		return nil
+	}
+	c.count("query-split")
+	op, err := operator.CreateSplitRegionOperator("query-split-region", region, 0, pdpb.CheckPolicy_APPROXIMATE, nil)
+	if err != nil {
+		log.Debug("create split region operator failed", errs.ZapError(err))
+		return nil
+	}
+	return op
+}
+
+// CheckWithSplitKeys returns an Operator to split the region at the given keys.
+// The keys which are not inside the region are ignored.
+func (c *SplitChecker) CheckWithSplitKeys(region *core.RegionInfo, splitKeys [][]byte) *operator.Operator {
+	c.count("check-with-keys")
+
+	if c.IsPaused() {
+		c.count("paused")
+		return nil
+	}
+
+	keys := filterSplitKeys(region.GetStartKey(), region.GetEndKey(), splitKeys)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	op, err := operator.CreateSplitRegionOperator("keys-split-region", region, 0, pdpb.CheckPolicy_USEKEY, keys)
+	if err != nil {
+		log.Debug("create split region operator failed", errs.ZapError(err))
+		return nil
+	}
+	return op
+}
+
+// filterSplitKeys returns the sorted and deduplicated keys which are inside
+// the range (start, end).
+func filterSplitKeys(start, end []byte, splitKeys [][]byte) [][]byte {
+	keys := make([][]byte, 0, len(splitKeys))
+	for _, key := range splitKeys {
+		if bytes.Compare(key, start) > 0 && (len(end) == 0 || bytes.Compare(key, end) < 0) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	n := 0
+	for i := range keys {
+		if i == 0 || !bytes.Equal(keys[i], keys[n-1]) {
+			keys[n] = keys[i]
+			n++
+		}
+	}
+	return keys[:n]
+}
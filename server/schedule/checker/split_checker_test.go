@@ -17,10 +17,14 @@ package checker
 import (
 	"context"
 	"encoding/hex"
+	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/tikv/pd/pkg/mock/mockcluster"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule/labeler"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/placement"
@@ -51,7 +55,7 @@ func (s *testSplitCheckerSuite) SetUpTest(c *C) {
 	s.cluster = mockcluster.NewCluster(s.ctx, cfg)
 	s.ruleManager = s.cluster.RuleManager
 	s.labeler = s.cluster.RegionLabeler
-	s.sc = NewSplitChecker(s.cluster, s.ruleManager, s.labeler)
+	s.sc = NewSplitChecker(s.ctx, s.cluster, s.ruleManager, s.labeler)
 }
 
 func (s *testSplitCheckerSuite) TestSplit(c *C) {
@@ -86,3 +90,155 @@ func (s *testSplitCheckerSuite) TestSplit(c *C) {
 	c.Assert(hex.EncodeToString(splitKeys[0]), Equals, "bb")
 	c.Assert(hex.EncodeToString(splitKeys[1]), Equals, "dd")
 }
+
+func (s *testSplitCheckerSuite) TestCheckWithSplitKeys(c *C) {
+	s.cluster.AddLeaderStore(1, 1)
+	s.cluster.AddLeaderRegionWithRange(1, "b", "e", 1)
+	region := s.cluster.GetRegion(1)
+
+	c.Assert(s.sc.CheckWithSplitKeys(region, nil), IsNil)
+	c.Assert(s.sc.CheckWithSplitKeys(region, [][]byte{[]byte("a"), []byte("b"), []byte("e"), []byte("f")}), IsNil)
+
+	op := s.sc.CheckWithSplitKeys(region, [][]byte{[]byte("d"), []byte("a"), []byte("c"), []byte("d"), []byte("f")})
+	c.Assert(op, NotNil)
+	c.Assert(op.Len(), Equals, 1)
+	c.Assert(op.Kind()&operator.OpSplit, Not(Equals), operator.OpKind(0))
+	c.Assert(op.Step(0).(operator.SplitRegion).SplitKeys, DeepEquals, [][]byte{[]byte("c"), []byte("d")})
+
+	// The end key of the last region is empty.
+	s.cluster.AddLeaderRegionWithRange(2, "e", "", 1)
+	op = s.sc.CheckWithSplitKeys(s.cluster.GetRegion(2), [][]byte{[]byte("z"), []byte("a")})
+	c.Assert(op, NotNil)
+	c.Assert(op.Step(0).(operator.SplitRegion).SplitKeys, DeepEquals, [][]byte{[]byte("z")})
+}
+
+func (s *testSplitCheckerSuite) TestSplitBySize(c *C) {
+	s.cluster.AddLeaderStore(1, 1)
+	s.cluster.AddLeaderRegionWithRange(1, "a", "b", 1)
+	region := s.cluster.GetRegion(1).Clone(core.SetApproximateSize(1000), core.SetApproximateKeys(100000))
+
+	// split by size is disabled.
+	c.Assert(s.sc.Check(region), IsNil)
+
+	// the split key is decided by TiKV.
+	s.cluster.SetRegionSplitSize(100)
+	op := s.sc.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "size-split-region")
+	step := op.Step(0).(operator.SplitRegion)
+	c.Assert(step.Policy, Equals, pdpb.CheckPolicy_APPROXIMATE)
+	c.Assert(step.SplitKeys, HasLen, 0)
+
+	// the region is not large enough.
+	c.Assert(s.sc.Check(region.Clone(core.SetApproximateSize(150))), IsNil)
+
+	// the last region can be split too.
+	s.cluster.AddLeaderRegionWithRange(2, "b", "", 1)
+	c.Assert(s.sc.Check(s.cluster.GetRegion(2).Clone(core.SetApproximateSize(300))), NotNil)
+}
+
+func (s *testSplitCheckerSuite) TestSplitByQuery(c *C) {
+	clock := NewManualClock(time.Now())
+	s.sc = NewSyncSplitChecker(s.cluster, s.ruleManager, s.labeler)
+	s.sc.SetClock(clock)
+	s.cluster.SetMergeSplitCooldown(time.Hour)
+	s.cluster.SetQuerySplitThreshold(100)
+	s.cluster.SetQuerySplitMinKeys(1000)
+	s.cluster.AddLeaderStore(1, 1)
+	s.cluster.AddLeaderRegionWithRange(1, "a", "b", 1)
+	// 120 queries per second.
+	region := s.cluster.GetRegion(1).Clone(core.SetApproximateSize(10), core.SetApproximateKeys(5000),
+		core.SetQueryStats(&pdpb.QueryStats{Get: 600, Put: 600}), core.SetReportInterval(10))
+
+	// split by query is disabled by default.
+	c.Assert(s.sc.Check(region), IsNil)
+
+	s.cluster.SetEnableQuerySplit(true)
+	op := s.sc.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "query-split-region")
+	step := op.Step(0).(operator.SplitRegion)
+	c.Assert(step.Policy, Equals, pdpb.CheckPolicy_APPROXIMATE)
+	c.Assert(step.SplitKeys, HasLen, 0)
+
+	// the query rate is not high enough.
+	s.cluster.SetQuerySplitThreshold(120)
+	c.Assert(s.sc.Check(region), IsNil)
+	s.cluster.SetQuerySplitThreshold(100)
+
+	// either part would have too few keys.
+	c.Assert(s.sc.Check(region.Clone(core.SetApproximateKeys(1999))), IsNil)
+
+	// the merge split cooldown is respected.
+	s.sc.RecordRegionMerge([]uint64{region.GetID()})
+	c.Assert(s.sc.Check(region), IsNil)
+	clock.Advance(time.Hour)
+	c.Assert(s.sc.Check(region), NotNil)
+}
+
+func (s *testSplitCheckerSuite) TestRecentlyMerged(c *C) {
+	clock := NewManualClock(time.Now())
+	s.sc = NewSyncSplitChecker(s.cluster, s.ruleManager, s.labeler)
+	s.sc.SetClock(clock)
+	s.cluster.SetRegionSplitSize(100)
+	s.cluster.SetMergeSplitCooldown(time.Hour)
+	s.cluster.AddLeaderStore(1, 1)
+	s.cluster.AddLeaderRegionWithRange(1, "a", "b", 1)
+	region := s.cluster.GetRegion(1).Clone(core.SetApproximateSize(1000), core.SetApproximateKeys(100000))
+	c.Assert(s.sc.Check(region), NotNil)
+
+	// A merged region is not split by size during the cooldown.
+	s.sc.RecordRegionMerge([]uint64{region.GetID()})
+	c.Assert(s.sc.Check(region), IsNil)
+	clock.Advance(30 * time.Minute)
+	c.Assert(s.sc.Check(region), IsNil)
+
+	// but it is split by the labels.
+	s.labeler.SetLabelRule(&labeler.LabelRule{
+		ID:       "test",
+		Labels:   []labeler.RegionLabel{{Key: "test", Value: "test"}},
+		RuleType: labeler.KeyRange,
+		Data:     makeKeyRanges("6170", "6180"),
+	})
+	op := s.sc.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "labeler-split-region")
+	c.Assert(s.labeler.DeleteLabelRule("test"), IsNil)
+
+	// The region is split by size again after the cooldown.
+	clock.Advance(30 * time.Minute)
+	op = s.sc.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "size-split-region")
+}
+
+// checkerCount returns the value of the checker counter.
+func checkerCount(checker, event string) float64 {
+	return testutil.ToFloat64(checkerCounter.WithLabelValues(checker, event))
+}
+
+func (s *testSplitCheckerSuite) TestDryRun(c *C) {
+	clock := NewManualClock(time.Now())
+	s.sc = NewSyncSplitChecker(s.cluster, s.ruleManager, s.labeler)
+	s.sc.SetClock(clock)
+	s.cluster.SetRegionSplitSize(100)
+	s.cluster.SetMergeSplitCooldown(time.Hour)
+	s.cluster.AddLeaderStore(1, 1)
+	s.cluster.AddLeaderRegionWithRange(1, "a", "b", 1)
+	region := s.cluster.GetRegion(1).Clone(core.SetApproximateSize(1000), core.SetApproximateKeys(100000))
+	splits := checkerCount("split_checker", "size-split")
+
+	// The dry run records no metrics.
+	dryRun := s.sc.DryRun()
+	c.Assert(dryRun.Check(region), NotNil)
+	c.Assert(checkerCount("split_checker", "size-split"), Equals, splits)
+
+	// It shares the merged regions and the clock of the checker.
+	s.sc.RecordRegionMerge([]uint64{region.GetID()})
+	c.Assert(dryRun.Check(region), IsNil)
+	clock.Advance(time.Hour)
+	c.Assert(dryRun.Check(region), NotNil)
+	c.Assert(checkerCount("split_checker", "size-split"), Equals, splits)
+	c.Assert(s.sc.Check(region), NotNil)
+	c.Assert(checkerCount("split_checker", "size-split"), Equals, splits+1)
+}
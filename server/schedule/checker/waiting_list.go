@@ -0,0 +1,123 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tikv/pd/pkg/cache"
+	"github.com/tikv/pd/server/config"
+)
+
+// waitingListMu serializes the updates of the waiting lists which read the list
+// before writing it, so that they don't interleave with each other.
+var waitingListMu sync.Mutex
+
+// WaitingRegion records the backoff state of a region in the waiting list.
+type WaitingRegion struct {
+	// Attempt is the number of times the region has been blocked.
+	Attempt int
+	// NextCheck is the earliest time the region should be checked again.
+	NextCheck time.Time
+	// EnqueuedAt is the time the region was first put into the waiting list.
+	// It is kept when the region is blocked again.
+	EnqueuedAt time.Time
+	// Reason is why the region is blocked the last time, or empty if unknown.
+	Reason string
+}
+
+// Requeued returns how many times the region has been put into the waiting
+// list again since it was first enqueued.
+func (w *WaitingRegion) Requeued() int {
+	if w.Attempt <= 1 {
+		return 0
+	}
+	return w.Attempt - 1
+}
+
+// WaitingDuration returns how long the region has been waiting at the given time.
+func (w *WaitingRegion) WaitingDuration(now time.Time) time.Duration {
+	if w.EnqueuedAt.IsZero() || now.Before(w.EnqueuedAt) {
+		return 0
+	}
+	return now.Sub(w.EnqueuedAt)
+}
+
+// AddWaitingRegion puts the region into the waiting list. Each time a region is
+// blocked again, the time it waits before the next check doubles, starting from
+// the patrol region interval and capped by the max waiting region backoff.
+// The backoff starts from now, and the reason replaces the previous one.
+func AddWaitingRegion(waitingList cache.Cache, opts *config.PersistOptions, regionID uint64, now time.Time, reason string) {
+	waitingListMu.Lock()
+	defer waitingListMu.Unlock()
+	attempt, enqueuedAt := 1, now
+	v, exist := waitingList.Peek(regionID)
+	if exist {
+		if w, ok := v.(*WaitingRegion); ok && w != nil {
+			attempt = w.Attempt + 1
+			if !w.EnqueuedAt.IsZero() {
+				enqueuedAt = w.EnqueuedAt
+			}
+		}
+	}
+	backoff := waitingRegionBackoff(attempt, opts.GetPatrolRegionInterval(), opts.GetMaxWaitingRegionBackoff())
+	size := waitingList.Len()
+	waitingList.Put(regionID, &WaitingRegion{Attempt: attempt, NextCheck: now.Add(backoff), EnqueuedAt: enqueuedAt, Reason: reason})
+	// a new region doesn't enlarge a full list, which means another region is evicted.
+	if !exist && waitingList.Len() <= size {
+		checkerCounter.WithLabelValues("waiting_list", "evicted").Inc()
+	}
+}
+
+// DrainWaitingList removes all regions from the waiting list and returns their IDs,
+// regardless of their backoff. A region added concurrently is either returned or
+// kept in the list.
+func DrainWaitingList(waitingList cache.Cache) []uint64 {
+	waitingListMu.Lock()
+	defer waitingListMu.Unlock()
+	items := waitingList.Elems()
+	ids := make([]uint64, 0, len(items))
+	for _, item := range items {
+		waitingList.Remove(item.Key)
+		ids = append(ids, item.Key)
+	}
+	return ids
+}
+
+// IsWaitingRegionReady returns true if the waiting region can be checked at the given time.
+func IsWaitingRegionReady(item *cache.Item, now time.Time) bool {
+	w, ok := item.Value.(*WaitingRegion)
+	if !ok || w == nil {
+		return true
+	}
+	return !now.Before(w.NextCheck)
+}
+
+// waitingRegionBackoff returns the time to wait before the given attempt is checked.
+// The first attempt doesn't wait.
+func waitingRegionBackoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt <= 1 || base <= 0 {
+		return 0
+	}
+	backoff := base
+	for i := 2; i < attempt && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		return max
+	}
+	return backoff
+}
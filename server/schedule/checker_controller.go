@@ -16,7 +16,15 @@ package schedule
 
 import (
 	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/cache"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/config"
@@ -26,9 +34,11 @@ import (
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/opt"
 	"github.com/tikv/pd/server/schedule/placement"
+	"go.uber.org/zap"
 )
 
-// DefaultCacheSize is the default length of waiting list.
+// DefaultCacheSize is the default length of waiting list. It is used when
+// the region waiting list size is not configured.
 const DefaultCacheSize = 1000
 
 // CheckerController is used to manage all checkers.
@@ -36,6 +46,8 @@ type CheckerController struct {
 	cluster           opt.Cluster
 	opts              *config.PersistOptions
 	opController      *OperatorController
+	ruleManager       *placement.RuleManager
+	labeler           *labeler.RegionLabeler
 	learnerChecker    *checker.LearnerChecker
 	replicaChecker    *checker.ReplicaChecker
 	ruleChecker       *checker.RuleChecker
@@ -44,97 +56,1353 @@ type CheckerController struct {
 	jointStateChecker *checker.JointStateChecker
 	priorityChecker   *checker.PriorityChecker
 	regionWaitingList cache.Cache
+	clock             checker.Clock
+	snapshotLimiter   *snapshotRateLimiter
+	operatorHistory   *regionOperatorHistory
+	recentOperators   *recentOperators
+	replicaMismatch   *replicaMismatchStats
+	convergence       *convergenceStats
+	// duplicatePeerChecker and allPeersDownChecker run right after the
+	// joint-state checker by default.
+	duplicatePeerChecker *checker.DuplicatePeerChecker
+	allPeersDownChecker  *checker.AllPeersDownChecker
+	// emergency is 1 if the replica schedule limit is raised since too many
+	// regions are under-replicated.
+	emergency int32
+	// splitsInScan is the number of split operators produced by the split
+	// checker since the current scan of the regions starts.
+	splitsInScan uint64
+	// lowChurnUntil is the time in unix nanoseconds until which the checkers
+	// produce the urgent operators only, declared by an external job.
+	lowChurnUntil int64
+
+	statsMu sync.RWMutex
+	stats   map[string]*CheckerStats
+
+	// pauseMu makes sure that pausing or resuming all checkers is not
+	// interleaved with one another.
+	pauseMu  sync.Mutex
+	pauseAll checker.PauseController
+
+	hooksMu sync.RWMutex
+	hooks   []OperatorHook
+
+	filtersMu sync.RWMutex
+	filters   []OperatorFilter
+
+	traceMu sync.RWMutex
+	tracer  *decisionTracer
+
+	// storeUsageMu guards the number of the replica operators in flight
+	// touching each store, which is counted by RefreshStoreBudget.
+	storeUsageMu sync.RWMutex
+	storeUsage   map[uint64]uint64
+
+	// rulesMu guards the placement rules seen enabled by the last check, and
+	// the time when they are seen enabled, from which the rule checker ramps up.
+	rulesMu        sync.Mutex
+	rulesEnabled   bool
+	rulesEnabledAt time.Time
+}
+
+// OperatorHook is called with the operators created by a checker before they
+// are returned to the caller. The hook must not modify the operators.
+type OperatorHook func(ops []*operator.Operator, checkerName string)
+
+// CheckerStats records the operators produced by a checker since PD started.
+type CheckerStats struct {
+	// Produced is the number of operators returned to the caller.
+	Produced uint64 `json:"produced"`
+	// Blocked is the number of operators dropped because of the schedule limit.
+	Blocked uint64 `json:"blocked"`
 }
 
 // NewCheckerController create a new CheckerController.
 // TODO: isSupportMerge should be removed.
-func NewCheckerController(ctx context.Context, cluster opt.Cluster, ruleManager *placement.RuleManager, labeler *labeler.RegionLabeler, opController *OperatorController) *CheckerController {
-	regionWaitingList := cache.NewDefaultCache(DefaultCacheSize)
-	return &CheckerController{
+func NewCheckerController(ctx context.Context, cluster opt.Cluster, ruleManager *placement.RuleManager, labeler *labeler.RegionLabeler, opController *OperatorController, opts ...CheckerControllerCreateOption) *CheckerController {
+	return newCheckerController(cluster, ruleManager, labeler, opController, checker.NewSplitChecker(ctx, cluster, ruleManager, labeler), checker.NewMergeChecker(ctx, cluster), opts...)
+}
+
+// NewSyncCheckerController creates a CheckerController whose checkers run no
+// background goroutine, so that checking a region is purely synchronous.
+// It is mainly used in tests.
+func NewSyncCheckerController(cluster opt.Cluster, ruleManager *placement.RuleManager, labeler *labeler.RegionLabeler, opController *OperatorController, opts ...CheckerControllerCreateOption) *CheckerController {
+	return newCheckerController(cluster, ruleManager, labeler, opController, checker.NewSyncSplitChecker(cluster, ruleManager, labeler), checker.NewSyncMergeChecker(cluster), opts...)
+}
+
+// CheckerControllerCreateOption is used to create a CheckerController with options.
+type CheckerControllerCreateOption func(c *CheckerController)
+
+// WithCheckerClock sets the clock used by the CheckerController and all its
+// checkers. The real clock is used by default.
+func WithCheckerClock(clock checker.Clock) CheckerControllerCreateOption {
+	return func(c *CheckerController) {
+		c.clock = clock
+	}
+}
+
+// WithMergeBoundaryDetector sets the detector deciding whether the merge
+// checker may merge two adjacent regions by their keys, which is the TiDB-aware
+// checker.DefaultMergeBoundaryDetector by default.
+func WithMergeBoundaryDetector(detector checker.MergeBoundaryDetector) CheckerControllerCreateOption {
+	return func(c *CheckerController) {
+		if c.mergeChecker != nil {
+			c.mergeChecker.SetBoundaryDetector(detector)
+		}
+	}
+}
+
+func newCheckerController(cluster opt.Cluster, ruleManager *placement.RuleManager, labeler *labeler.RegionLabeler, opController *OperatorController, splitChecker *checker.SplitChecker, mergeChecker *checker.MergeChecker, opts ...CheckerControllerCreateOption) *CheckerController {
+	waitingListSize := cluster.GetOpts().GetRegionWaitingListSize()
+	if waitingListSize <= 0 {
+		waitingListSize = DefaultCacheSize
+	}
+	regionWaitingList := cache.NewDefaultCache(waitingListSize)
+	c := &CheckerController{
 		cluster:           cluster,
 		opts:              cluster.GetOpts(),
 		opController:      opController,
+		ruleManager:       ruleManager,
+		labeler:           labeler,
 		learnerChecker:    checker.NewLearnerChecker(cluster),
 		replicaChecker:    checker.NewReplicaChecker(cluster, regionWaitingList),
 		ruleChecker:       checker.NewRuleChecker(cluster, ruleManager, regionWaitingList),
-		splitChecker:      checker.NewSplitChecker(cluster, ruleManager, labeler),
-		mergeChecker:      checker.NewMergeChecker(ctx, cluster),
+		splitChecker:      splitChecker,
+		mergeChecker:      mergeChecker,
 		jointStateChecker: checker.NewJointStateChecker(cluster),
 		priorityChecker:   checker.NewPriorityChecker(cluster),
 		regionWaitingList: regionWaitingList,
+		clock:             checker.RealClock,
+		stats:             make(map[string]*CheckerStats),
+		operatorHistory:   newRegionOperatorHistory(),
+		recentOperators:   newRecentOperators(),
+		replicaMismatch:   newReplicaMismatchStats(),
+		convergence:       newConvergenceStats(),
+		// The placement rules enabled before PD starts are not ramped up.
+		rulesEnabled: cluster.GetOpts().IsPlacementRulesEnabled(),
+
+		duplicatePeerChecker: checker.NewDuplicatePeerChecker(cluster),
+		allPeersDownChecker:  checker.NewAllPeersDownChecker(cluster),
+	}
+	for _, option := range opts {
+		option(c)
 	}
+	c.snapshotLimiter = newSnapshotRateLimiter(c.clock)
+	c.pauseAll.SetClock(c.clock)
+	c.learnerChecker.SetClock(c.clock)
+	c.replicaChecker.SetClock(c.clock)
+	c.ruleChecker.SetClock(c.clock)
+	c.splitChecker.SetClock(c.clock)
+	c.jointStateChecker.SetClock(c.clock)
+	c.duplicatePeerChecker.SetClock(c.clock)
+	c.allPeersDownChecker.SetClock(c.clock)
+	c.priorityChecker.SetClock(c.clock)
+	if c.mergeChecker != nil {
+		c.mergeChecker.SetClock(c.clock)
+	}
+	return c
 }
 
 // CheckRegion will check the region and add a new operator if needed.
 func (c *CheckerController) CheckRegion(region *core.RegionInfo) []*operator.Operator {
 	// If PD has restarted, it need to check learners added before and promote them.
 	// Don't check isRaftLearnerEnabled cause it maybe disable learner feature but there are still some learners to promote.
-	opController := c.opController
+	if c.pauseAll.IsPaused() {
+		return nil
+	}
+	ops, _ := c.checkRegion(region, c.newCheckBudget())
+	return ops
+}
 
-	if op := c.jointStateChecker.Check(region); op != nil {
-		return []*operator.Operator{op}
+// CheckRegions checks the regions like CheckRegion and returns the operators
+// created for each region. The operator counts and the schedule limits are
+// sampled once for the whole batch, and the operators created for the former
+// regions count towards the limits of the latter ones.
+func (c *CheckerController) CheckRegions(regions []*core.RegionInfo) map[uint64][]*operator.Operator {
+	if c.pauseAll.IsPaused() {
+		return nil
+	}
+	budget := c.newCheckBudget()
+	results := make(map[uint64][]*operator.Operator)
+	for _, region := range c.sortByPriorityRange(regions) {
+		if ops, _ := c.checkRegion(region, budget); ops != nil {
+			results[region.GetID()] = ops
+		}
 	}
+	return results
+}
 
-	if op := c.splitChecker.Check(region); op != nil {
-		return []*operator.Operator{op}
+func (c *CheckerController) checkRegion(region *core.RegionInfo, budget *checkBudget) ([]*operator.Operator, []*CheckerDecision) {
+	check := c.checkRegionFirst
+	if c.opts.GetCheckRegionPolicy() == config.CheckRegionMostUrgent {
+		check = c.checkRegionMostUrgent
 	}
+	return c.checkRegionWith(check, region, budget)
+}
 
-	if c.opts.IsPlacementRulesEnabled() {
-		fit := c.priorityChecker.Check(region)
-		if fit != nil { // priority checker is not paused
-			if op := c.ruleChecker.CheckWithFit(region, fit); op != nil {
-				if opController.OperatorCount(operator.OpReplica) < c.opts.GetReplicaScheduleLimit() {
-					return []*operator.Operator{op}
-				}
-				operator.OperatorLimitCounter.WithLabelValues(c.ruleChecker.GetType(), operator.OpReplica.String()).Inc()
-				c.regionWaitingList.Put(region.GetID(), nil)
+// checkRegionWith checks the region by the check function, which is one of the
+// ways the checkers are run, and records and traces what the checkers decide.
+func (c *CheckerController) checkRegionWith(check func(*core.RegionInfo, *checkBudget) ([]*operator.Operator, []*CheckerDecision), region *core.RegionInfo, budget *checkBudget) ([]*operator.Operator, []*CheckerDecision) {
+	ops, decisions := check(region, budget)
+	c.recordDecisions(region, decisions)
+	c.convergence.observe(c.cluster, region.GetID(), needsAction(decisions) || c.opController.GetOperator(region.GetID()) != nil)
+	return c.traceDecisions(region, ops, decisions), decisions
+}
+
+// checkerOrder returns the types of the checkers in the checker order. The
+// order is validated along with the configuration, so the unknown names are
+// never there.
+func (c *CheckerController) checkerOrder() []CheckerType {
+	order := c.opts.GetCheckerOrder()
+	types := make([]CheckerType, 0, len(order))
+	for _, name := range order {
+		if t, err := ParseCheckerType(name); err == nil {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// checkRegionFirst checks the region with the checkers in the checker order
+// and returns the operators created by the first checker which is not blocked.
+func (c *CheckerController) checkRegionFirst(region *core.RegionInfo, budget *checkBudget) ([]*operator.Operator, []*CheckerDecision) {
+	var decisions []*CheckerDecision
+	for _, t := range c.checkerOrder() {
+		d := c.checkRegionBy(t, region, budget)
+		if d == nil {
+			continue
+		}
+		decisions = append(decisions, d)
+		// The later checkers still run if the cluster is read-only, so that all
+		// the operators they would create are logged.
+		if !c.filterDecision(t, region, d) {
+			continue
+		}
+		// The later checkers may still preempt the operator in flight.
+		if preempted, ok := c.checkInFlight(t, region, d.Operators); ok {
+			c.markPreempted(d, preempted)
+			budget.consume(d.Operators, c.priorityChecker.InPriorityRange(region))
+			c.onProduced(d.checkerType, d.Urgency, region, d.Operators)
+			return d.Operators, decisions
+		}
+		d.block(DiagnosisOperatorInFlight)
+	}
+	return nil, decisions
+}
+
+// filterDecision returns true if the decision needs operators and they are
+// neither held back by the low-churn window, dropped since the cluster is
+// read-only, vetoed by the filters nor the same as the ones produced recently.
+// The decision is blocked if the operators are dropped.
+func (c *CheckerController) filterDecision(t CheckerType, region *core.RegionInfo, d *CheckerDecision) bool {
+	if d.Kind != DecisionNeedsOperator {
+		return false
+	}
+	d.Urgency = operatorUrgency(t, region, d.Operators)
+	if d.Urgency < UrgencyUnderReplicated && c.IsLowChurn() {
+		d.block(DiagnosisLowChurn)
+		return false
+	}
+	if c.filterReadOnly(t, d.checkerType, region, d.Operators) == nil {
+		d.block(DiagnosisReadOnly)
+		return false
+	}
+	if c.filterVetoed(t.String(), d.checkerType, region, d.Operators) == nil {
+		d.block(DiagnosisVetoed)
+		return false
+	}
+	if c.opts.GetCheckerOperatorDedupTTL() > 0 && c.recentOperators.contains(d.Operators, c.clock.Now()) {
+		checkerDeduplicatedCounter.WithLabelValues(d.checkerType).Add(float64(len(d.Operators)))
+		d.block(DiagnosisRecentlyProduced)
+		return false
+	}
+	return true
+}
+
+// sortByPriorityRange returns the regions with the ones in the priority key
+// ranges moved to the front, keeping the order otherwise.
+func (c *CheckerController) sortByPriorityRange(regions []*core.RegionInfo) []*core.RegionInfo {
+	if !c.priorityChecker.HasPriorityRanges() {
+		return regions
+	}
+	sorted := make([]*core.RegionInfo, 0, len(regions))
+	var others []*core.RegionInfo
+	for _, region := range regions {
+		if c.priorityChecker.InPriorityRange(region) {
+			sorted = append(sorted, region)
+		} else {
+			others = append(others, region)
+		}
+	}
+	return append(sorted, others...)
+}
+
+// checkBudget is the number of operators which can still be created before
+// reaching the schedule limits.
+type checkBudget struct {
+	replica uint64
+	merge   uint64
+	// reserved is the part of the replica budget which only the regions in the
+	// priority key ranges can take.
+	reserved uint64
+	// ramp is the part of the replica budget which the rule checker can take
+	// while it ramps up after the placement rules are enabled.
+	ramp    uint64
+	ramping bool
+	// storeShare is the part of the replica budget the operators touching a
+	// store can take, and storeUsage is the number of the replica operators
+	// touching each store, including the ones in flight. The share is not
+	// enforced if it is 0.
+	storeShare uint64
+	storeUsage map[uint64]uint64
+}
+
+func (c *CheckerController) newCheckBudget() *checkBudget {
+	remaining := func(limit, count uint64) uint64 {
+		if count >= limit {
+			return 0
+		}
+		return limit - count
+	}
+	limit := c.replicaScheduleLimit()
+	budget := &checkBudget{
+		replica: remaining(limit, c.opController.OperatorCount(operator.OpReplica)),
+		merge:   remaining(c.mergeScheduleLimit(), c.opController.OperatorCount(operator.OpMerge)),
+	}
+	// The operators in flight are not told apart, so the reserved part is kept
+	// out of the limit no matter which regions they are for.
+	if c.priorityChecker.HasPriorityRanges() {
+		budget.reserved = uint64(math.Ceil(float64(limit) * c.opts.GetPriorityRangeReservedRatio()))
+	}
+	if ratio := c.placementRulesRampRatio(); ratio < 1 {
+		budget.ramping = true
+		budget.ramp = remaining(uint64(math.Ceil(float64(limit)*ratio)), c.opController.OperatorCount(operator.OpReplica))
+	}
+	budget.storeShare, budget.storeUsage = c.storeBudget(limit)
+	return budget
+}
+
+// storeBudget returns the part of the replica schedule limit a store can take
+// and a copy of the number of the replica operators in flight touching each
+// store counted by the last RefreshStoreBudget.
+func (c *CheckerController) storeBudget(limit uint64) (uint64, map[uint64]uint64) {
+	share := c.opts.GetStoreBudgetShare()
+	if share <= 0 {
+		return 0, nil
+	}
+	storeShare := uint64(math.Ceil(float64(limit) * share))
+	if storeShare < 1 {
+		storeShare = 1
+	}
+	c.storeUsageMu.RLock()
+	defer c.storeUsageMu.RUnlock()
+	usage := make(map[uint64]uint64, len(c.storeUsage))
+	for storeID, n := range c.storeUsage {
+		usage[storeID] = n
+	}
+	return storeShare, usage
+}
+
+// RefreshStoreBudget counts the replica operators in flight touching each
+// store, which are taken against the store budget share until it is refreshed
+// again, and reports them by the metrics. It should be called in each round of
+// the patrol loop. Nothing is counted while the store budget share is disabled.
+func (c *CheckerController) RefreshStoreBudget() {
+	var usage map[uint64]uint64
+	if c.opts.GetStoreBudgetShare() > 0 {
+		usage = make(map[uint64]uint64)
+		for _, op := range c.opController.GetOperators() {
+			if op.Kind()&operator.OpReplica == 0 {
+				continue
+			}
+			for _, storeID := range operatorStores(op) {
+				usage[storeID]++
 			}
 		}
-	} else {
-		if op := c.learnerChecker.Check(region); op != nil {
-			return []*operator.Operator{op}
+	}
+	c.storeUsageMu.Lock()
+	defer c.storeUsageMu.Unlock()
+	for storeID := range c.storeUsage {
+		if _, ok := usage[storeID]; !ok {
+			checkerStoreBudgetUsageGauge.DeleteLabelValues(strconv.FormatUint(storeID, 10))
 		}
-		if op := c.replicaChecker.Check(region); op != nil {
-			if opController.OperatorCount(operator.OpReplica) < c.opts.GetReplicaScheduleLimit() {
-				return []*operator.Operator{op}
+	}
+	for storeID, n := range usage {
+		checkerStoreBudgetUsageGauge.WithLabelValues(strconv.FormatUint(storeID, 10)).Set(float64(n))
+	}
+	c.storeUsage = usage
+}
+
+// operatorStores returns the stores which the operator adds peers on or
+// removes peers from.
+func operatorStores(op *operator.Operator) []uint64 {
+	var stores []uint64
+	add := func(storeID uint64) {
+		for _, id := range stores {
+			if id == storeID {
+				return
 			}
-			operator.OperatorLimitCounter.WithLabelValues(c.replicaChecker.GetType(), operator.OpReplica.String()).Inc()
-			c.regionWaitingList.Put(region.GetID(), nil)
+		}
+		stores = append(stores, storeID)
+	}
+	for i := 0; i < op.Len(); i++ {
+		switch step := op.Step(i).(type) {
+		case operator.AddPeer:
+			add(step.ToStore)
+		case operator.AddLearner:
+			add(step.ToStore)
+		case operator.RemovePeer:
+			add(step.FromStore)
 		}
 	}
+	return stores
+}
 
-	if c.mergeChecker != nil {
-		allowed := opController.OperatorCount(operator.OpMerge) < c.opts.GetMergeScheduleLimit()
-		if !allowed {
-			operator.OperatorLimitCounter.WithLabelValues(c.mergeChecker.GetType(), operator.OpMerge.String()).Inc()
+// placementRulesRampRatio returns the ratio of the replica schedule limit the
+// rule checker can take, which grows from 0 to 1 in the ramp duration after
+// the placement rules are enabled. It is 1 if the rule checker is not ramping
+// up.
+func (c *CheckerController) placementRulesRampRatio() float64 {
+	enabled := c.opts.IsPlacementRulesEnabled()
+	duration := c.opts.GetPlacementRulesRampDuration()
+	now := c.clock.Now()
+	c.rulesMu.Lock()
+	if enabled && !c.rulesEnabled {
+		c.rulesEnabledAt = now
+		if duration > 0 {
+			log.Info("placement rules are enabled, ramp up the rule checker", zap.Duration("ramp-duration", duration))
+		}
+	}
+	c.rulesEnabled = enabled
+	enabledAt := c.rulesEnabledAt
+	c.rulesMu.Unlock()
+	if !enabled || duration <= 0 || enabledAt.IsZero() {
+		return 1
+	}
+	elapsed := now.Sub(enabledAt)
+	if elapsed >= duration {
+		return 1
+	}
+	return float64(elapsed) / float64(duration)
+}
+
+// PlacementRulesEnabledAt returns when the placement rules are seen enabled,
+// which is zero if they are disabled or enabled before PD starts.
+func (c *CheckerController) PlacementRulesEnabledAt() time.Time {
+	c.placementRulesRampRatio()
+	c.rulesMu.Lock()
+	defer c.rulesMu.Unlock()
+	if !c.rulesEnabled {
+		return time.Time{}
+	}
+	return c.rulesEnabledAt
+}
+
+// allowReplica returns true if a replica operator can be created for a region
+// in the priority key ranges or not.
+func (b *checkBudget) allowReplica(inPriorityRange bool) bool {
+	if inPriorityRange {
+		return b.replica > 0
+	}
+	return b.replica > b.reserved
+}
+
+// allowStores returns true if a replica operator touching the stores can be
+// created. A store which has taken its share of the budget can only take the
+// spare budget, which is what is left beyond one share, so that at least one
+// share is always kept for the other stores.
+func (b *checkBudget) allowStores(stores []uint64) bool {
+	if b.storeShare == 0 || b.replica > b.storeShare {
+		return true
+	}
+	for _, storeID := range stores {
+		if b.storeUsage[storeID] >= b.storeShare {
+			return false
+		}
+	}
+	return true
+}
+
+// consume takes the operators out of the budget. The replica operators for the
+// regions in the priority key ranges take the reserved part first.
+func (b *checkBudget) consume(ops []*operator.Operator, inPriorityRange bool) {
+	for _, op := range ops {
+		if op.Kind()&operator.OpReplica != 0 && b.ramp > 0 {
+			b.ramp--
+		}
+		if op.Kind()&operator.OpReplica != 0 && b.replica > 0 {
+			b.replica--
+			if inPriorityRange && b.reserved > 0 {
+				b.reserved--
+			}
+		}
+		if op.Kind()&operator.OpReplica != 0 && b.storeUsage != nil {
+			for _, storeID := range operatorStores(op) {
+				b.storeUsage[storeID]++
+			}
+		}
+		if op.Kind()&operator.OpMerge != 0 && b.merge > 0 {
+			b.merge--
+		}
+	}
+}
+
+// checkRegionMostUrgent checks the region with all checkers and returns the
+// operators with the highest urgency. If several checkers create operators with
+// the same urgency, the one comes first in the checker order wins.
+func (c *CheckerController) checkRegionMostUrgent(region *core.RegionInfo, budget *checkBudget) ([]*operator.Operator, []*CheckerDecision) {
+	var (
+		decisions  []*CheckerDecision
+		mostUrgent *CheckerDecision
+	)
+	for _, t := range c.checkerOrder() {
+		d := c.checkRegionBy(t, region, budget)
+		if d == nil {
+			continue
+		}
+		decisions = append(decisions, d)
+		if !c.filterDecision(t, region, d) {
+			continue
+		}
+		if mostUrgent == nil || d.Urgency > mostUrgent.Urgency {
+			if mostUrgent != nil {
+				mostUrgent.block(DiagnosisLessUrgent)
+			}
+			mostUrgent = d
 		} else {
-			if ops := c.mergeChecker.Check(region); ops != nil {
-				// It makes sure that two operators can be added successfully altogether.
-				return ops
+			d.block(DiagnosisLessUrgent)
+		}
+		if mostUrgent.Urgency == UrgencyJointState {
+			break
+		}
+	}
+	if mostUrgent == nil {
+		return nil, decisions
+	}
+	// None of the operators preempts the one in flight if the most urgent can't.
+	preempted, ok := c.checkInFlight(mostUrgent.checker, region, mostUrgent.Operators)
+	if !ok {
+		mostUrgent.block(DiagnosisOperatorInFlight)
+		return nil, decisions
+	}
+	c.markPreempted(mostUrgent, preempted)
+	budget.consume(mostUrgent.Operators, c.priorityChecker.InPriorityRange(region))
+	c.onProduced(mostUrgent.checkerType, mostUrgent.Urgency, region, mostUrgent.Operators)
+	return mostUrgent.Operators, decisions
+}
+
+// CheckRegionBatch checks the region with all checkers and returns the
+// operators which can be added together. Unlike CheckRegion, it does not stop
+// at the first checker which creates an operator, and conflicting operators
+// are dropped in favor of the ones created earlier.
+func (c *CheckerController) CheckRegionBatch(region *core.RegionInfo) []*operator.Operator {
+	if c.pauseAll.IsPaused() {
+		return nil
+	}
+	ops, _ := c.checkRegionWith(c.checkRegionBatch, region, c.newCheckBudget())
+	return ops
+}
+
+func (c *CheckerController) checkRegionBatch(region *core.RegionInfo, budget *checkBudget) ([]*operator.Operator, []*CheckerDecision) {
+	var (
+		ops       []*operator.Operator
+		decisions []*CheckerDecision
+	)
+	for _, t := range c.checkerOrder() {
+		d := c.checkRegionBy(t, region, budget)
+		if d == nil {
+			continue
+		}
+		decisions = append(decisions, d)
+		if !c.filterDecision(t, region, d) {
+			continue
+		}
+		newOps := d.Operators
+		preempted, ok := c.checkInFlight(t, region, newOps)
+		if !ok {
+			d.block(DiagnosisOperatorInFlight)
+			continue
+		}
+		if opsConflictWith(ops, newOps) {
+			d.block(DiagnosisConflict)
+			continue
+		}
+		c.markPreempted(d, preempted)
+		budget.consume(newOps, c.priorityChecker.InPriorityRange(region))
+		c.onProduced(d.checkerType, d.Urgency, region, newOps)
+		ops = append(ops, newOps...)
+		// Leaving joint state must be done before anything else, and the
+		// joint-state checker always comes first.
+		if t == CheckerJointState {
+			break
+		}
+	}
+	return ops, decisions
+}
+
+// checkRegionBy checks the region with the checker of the given type and
+// returns what the checker decides. The operators are only created within the
+// budget, which are not taken out of it. It returns nil if the checker doesn't
+// apply under the current configuration.
+func (c *CheckerController) checkRegionBy(t CheckerType, region *core.RegionInfo, budget *checkBudget) *CheckerDecision {
+	if !c.isCheckerApplicable(t) {
+		return nil
+	}
+	if c.opts.IsCheckerDisabled(t.String()) {
+		return decisionBlocked(t, DiagnosisDisabled)
+	}
+	if c.isSkippedByLabel(t, region) {
+		return decisionBlocked(t, DiagnosisSkippedByLabel)
+	}
+	// Only observe the duration when debug metrics are enabled, to keep the hot path cheap.
+	if c.opts.IsDebugMetricsEnabled() {
+		start := time.Now()
+		defer func() {
+			checkerDuration.WithLabelValues(t.String()).Observe(time.Since(start).Seconds())
+		}()
+	}
+	switch t {
+	case CheckerAllPeersDown:
+		// No operator can be carried out for a region whose peers are all
+		// down, which is only recorded.
+		if c.allPeersDownChecker.Check(region) {
+			return decisionBlocked(t, DiagnosisAllPeersDown)
+		}
+	case CheckerJointState:
+		if op := c.jointStateChecker.Check(region); op != nil {
+			return decisionNeedsOperator(t, c.jointStateChecker.GetType(), []*operator.Operator{op})
+		}
+	case CheckerDuplicatePeer:
+		if op := c.duplicatePeerChecker.Check(region); op != nil {
+			return decisionNeedsOperator(t, c.duplicatePeerChecker.GetType(), []*operator.Operator{op})
+		}
+	case CheckerSplit:
+		if op := c.splitChecker.Check(region); op != nil {
+			// The region is split in a later scan.
+			if c.isSplitBudgetExhausted() {
+				checkerSplitBudgetExhaustedCounter.Inc()
+				c.addWaitingRegion(region, DiagnosisMaxSplitsPerScan)
+				return decisionBlocked(t, DiagnosisMaxSplitsPerScan)
+			}
+			return decisionNeedsOperator(t, c.splitChecker.GetType(), []*operator.Operator{op})
+		}
+	case CheckerRule:
+		fit := c.priorityChecker.Check(region)
+		if fit == nil { // priority checker is paused
+			return decisionBlocked(t, DiagnosisPaused)
+		}
+		c.ruleChecker.RecordOrphanPeers(region, fit)
+		c.replicaMismatch.observe(region, fit)
+		op, err := c.ruleChecker.CheckWithFitError(region, fit)
+		if err != nil {
+			return decisionError(t, c.ruleChecker.GetType(), err)
+		}
+		if op != nil {
+			return c.checkReplicaOperator(t, c.ruleChecker.GetType(), region, op, budget)
+		}
+	case CheckerLearner:
+		if op := c.learnerChecker.Check(region); op != nil {
+			return decisionNeedsOperator(t, c.learnerChecker.GetType(), []*operator.Operator{op})
+		}
+	case CheckerReplica:
+		c.replicaMismatch.observeReplicas(region, c.opts.GetMaxReplicas())
+		op, err := c.replicaChecker.CheckWithError(region)
+		if err != nil {
+			return decisionError(t, c.replicaChecker.GetType(), err)
+		}
+		if op != nil {
+			return c.checkReplicaOperator(t, c.replicaChecker.GetType(), region, op, budget)
+		}
+	case CheckerMerge:
+		if budget.merge == 0 {
+			operator.OperatorLimitCounter.WithLabelValues(c.mergeChecker.GetType(), operator.OpMerge.String()).Inc()
+			return decisionBlocked(t, DiagnosisMergeScheduleLimit)
+		}
+		if ops := c.mergeChecker.Check(region); ops != nil {
+			// Each pair of merge operators is counted twice by the merge limit,
+			// but the first pair is always allowed like a single merge.
+			pairs := budget.merge / 2
+			if pairs < 1 {
+				pairs = 1
+			}
+			if uint64(len(ops)) > 2*pairs {
+				ops = ops[:2*pairs]
 			}
+			// The other regions in the run are touched by the merge too. The
+			// operators of each pair are the one of the source and then the
+			// one of the target, and the run is cut before the first pair
+			// touching a skipped region.
+			for i := 1; i < len(ops); i++ {
+				if other := c.cluster.GetRegion(ops[i].RegionID()); other != nil && c.isSkippedByLabel(t, other) {
+					if i < 2 {
+						return decisionBlocked(t, DiagnosisSkippedByLabel)
+					}
+					ops = ops[:i-i%2]
+					break
+				}
+			}
+			// It makes sure that two operators can be added successfully altogether.
+			return decisionNeedsOperator(t, c.mergeChecker.GetType(), ops)
 		}
 	}
+	// The checkers create no operator when they are paused.
+	if p, err := c.GetPauseControllerByType(t); err == nil && p.IsPaused() {
+		return decisionBlocked(t, DiagnosisPaused)
+	}
+	return decisionOK(t)
+}
+
+// checkReplicaOperator returns the decision of the replica operator created by
+// the checker, which is blocked if the operator is not allowed by the limits.
+// The region is added into the waiting list then.
+func (c *CheckerController) checkReplicaOperator(t CheckerType, checkerType string, region *core.RegionInfo, op *operator.Operator, budget *checkBudget) *CheckerDecision {
+	if reason := c.allowReplicaOperator(t, checkerType, region, op, budget); reason != "" {
+		c.recordBlocked(checkerType)
+		// The region is only taken as blocked by the limit if raising the
+		// limit unblocks it.
+		waitingReason := reason
+		if reason == DiagnosisReplicaScheduleLimit {
+			if other := c.snapshotBlockReason(op); other != "" {
+				waitingReason = other
+			}
+		}
+		c.addWaitingRegion(region, waitingReason)
+		d := decisionNeedsOperator(t, checkerType, []*operator.Operator{op})
+		d.block(reason)
+		return d
+	}
+	return decisionNeedsOperator(t, checkerType, []*operator.Operator{op})
+}
+
+// checkInFlight returns true if there is no operator in flight for the region,
+// or the operators created by the checker are more urgent than the one in
+// flight, which is returned to be preempted then. The operators are never more
+// urgent than the one in flight if they are of the same urgency.
+func (c *CheckerController) checkInFlight(t CheckerType, region *core.RegionInfo, ops []*operator.Operator) (*operator.Operator, bool) {
+	old := c.opController.GetOperator(region.GetID())
+	if old == nil {
+		return nil, true
+	}
+	if operatorUrgency(t, region, ops) <= inFlightUrgency(region, old) {
+		return nil, false
+	}
+	return old, true
+}
+
+// MayPreempt returns true if the region may need an operator more urgent than
+// the one in flight, so that it is worth checking the region again. It only
+// looks at the unhealthy peers of the region, which is cheaper than checking
+// the region.
+func (c *CheckerController) MayPreempt(region *core.RegionInfo, op *operator.Operator) bool {
+	urgency := UrgencyMerge
+	switch {
+	case core.IsInJointState(region.GetPeers()...):
+		urgency = UrgencyJointState
+	case len(region.GetDownPeers()) > 0:
+		urgency = UrgencyDownPeer
+	case len(region.GetVoters()) < c.opts.GetMaxReplicas():
+		urgency = UrgencyUnderReplicated
+	}
+	return urgency > inFlightUrgency(region, op)
+}
+
+// markPreempted records the operator in flight which is preempted by the
+// operators of the decision, together with the other half if it is a merge.
+// Nothing is canceled here since the operators may still be dropped, e.g. by
+// the store limit; the operator controller cancels the preempted operators
+// once the operators are added.
+func (c *CheckerController) markPreempted(d *CheckerDecision, old *operator.Operator) {
+	if old == nil {
+		return
+	}
+	olds := []*operator.Operator{old}
+	if old.Kind()&operator.OpMerge != 0 {
+		for i := 0; i < old.Len(); i++ {
+			step, ok := old.Step(i).(operator.MergeRegion)
+			if !ok {
+				continue
+			}
+			other := step.ToRegion.GetId()
+			if other == old.RegionID() {
+				other = step.FromRegion.GetId()
+			}
+			if op := c.opController.GetOperator(other); op != nil && op.Kind()&operator.OpMerge != 0 {
+				olds = append(olds, op)
+			}
+		}
+	}
+	d.Preempted = olds
+	for _, op := range d.Operators {
+		op.SetPreempts(olds)
+	}
+}
+
+// filterReadOnly returns nil and logs the operators created by the checker if
+// the cluster is read-only. The operators leaving the joint state or removing
+// the duplicate peers are kept if it is allowed.
+func (c *CheckerController) filterReadOnly(t CheckerType, checkerType string, region *core.RegionInfo, ops []*operator.Operator) []*operator.Operator {
+	if ops == nil || !c.opts.IsReadOnlyClusterEnabled() {
+		return ops
+	}
+	if t == CheckerJointState && c.opts.IsReadOnlyJointStateAllowed() {
+		return ops
+	}
+	if t == CheckerDuplicatePeer && c.opts.IsReadOnlyDuplicatePeerAllowed() {
+		return ops
+	}
+	for _, op := range ops {
+		log.Info("drop operator since the cluster is read-only",
+			zap.Uint64("region-id", region.GetID()),
+			zap.String("checker", checkerType),
+			zap.Stringer("operator", op))
+	}
+	checkerReadOnlyCounter.WithLabelValues(checkerType).Add(float64(len(ops)))
 	return nil
 }
 
+// opsConflictWith returns true if any of the new operators conflicts with the
+// existing ones.
+func opsConflictWith(ops, newOps []*operator.Operator) bool {
+	for _, op := range newOps {
+		for _, o := range ops {
+			if opsConflict(o, op) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// opsConflict returns true if the two operators can not be executed on the
+// same region together. An operator which changes the region range conflicts
+// with any other one, and two operators conflict if both of them change peers
+// or both of them change the leader.
+func opsConflict(a, b *operator.Operator) bool {
+	rangeKind := operator.OpSplit | operator.OpMerge
+	if a.Kind()&rangeKind != 0 || b.Kind()&rangeKind != 0 {
+		return true
+	}
+	return a.Kind()&b.Kind()&(operator.OpRegion|operator.OpLeader) != 0
+}
+
+// RegisterOperatorHook registers a hook which is called synchronously every
+// time a checker produces operators. Hooks are called in registration order.
+func (c *CheckerController) RegisterOperatorHook(hook OperatorHook) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.hooks = append(c.hooks, hook)
+}
+
+// onProduced is called when the operators produced by the checker are
+// returned to the caller. It sets the priority level of the urgency and
+// attaches the estimated impact of the region to the operators before calling
+// the hooks.
+func (c *CheckerController) onProduced(checkerType string, urgency OperatorUrgency, region *core.RegionInfo, ops []*operator.Operator) {
+	setPriorityLevel(urgency, ops)
+	// The operators are dropped in the dry run.
+	if c.isDryRun() {
+		return
+	}
+	c.recordProduced(checkerType, uint64(len(ops)))
+	if checkerType == c.splitChecker.GetType() {
+		atomic.AddUint64(&c.splitsInScan, uint64(len(ops)))
+	}
+	c.operatorHistory.record(region.GetID(), checkerType, ops, c.clock.Now(), c.opts.GetRegionOperatorHistorySize())
+	c.recentOperators.record(ops, c.clock.Now(), c.opts.GetCheckerOperatorDedupTTL())
+	for _, op := range ops {
+		op.SetChecker(checkerType)
+		checkerProducedCounter.WithLabelValues(checkerType, op.Kind().String(), op.Desc()).Inc()
+		op.SetImpact(operator.EstimateImpact(op, region))
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	for _, hook := range c.hooks {
+		// Pass a copy so that the hook can not replace the operators.
+		hook(append(ops[:0:0], ops...), checkerType)
+	}
+}
+
+func (c *CheckerController) recordProduced(checkerType string, count uint64) {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	c.getStatsLocked(checkerType).Produced += count
+}
+
+func (c *CheckerController) recordBlocked(checkerType string) {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	c.getStatsLocked(checkerType).Blocked++
+}
+
+func (c *CheckerController) getStatsLocked(checkerType string) *CheckerStats {
+	stats, ok := c.stats[checkerType]
+	if !ok {
+		stats = &CheckerStats{}
+		c.stats[checkerType] = stats
+	}
+	return stats
+}
+
+// GetCheckerStats returns the operator statistics of each checker.
+func (c *CheckerController) GetCheckerStats() map[string]CheckerStats {
+	c.statsMu.RLock()
+	defer c.statsMu.RUnlock()
+	stats := make(map[string]CheckerStats, len(c.stats))
+	for checkerType, s := range c.stats {
+		stats[checkerType] = *s
+	}
+	return stats
+}
+
+// GetRegionOperatorHistory returns the latest operators produced for the region
+// by the checkers, from the oldest to the latest. The number of operators kept
+// for each region is bounded by the region operator history size.
+func (c *CheckerController) GetRegionOperatorHistory(regionID uint64) []OperatorRecord {
+	return c.operatorHistory.get(regionID)
+}
+
+// isSkippedByLabel returns true if the region carries the skip-checkers label
+// whose value is "all" or contains the name of the checker. The joint-state
+// checker is never skipped, as leaving the joint state is required for safety.
+func (c *CheckerController) isSkippedByLabel(t CheckerType, region *core.RegionInfo) bool {
+	if t == CheckerJointState || c.labeler == nil {
+		return false
+	}
+	key := c.opts.GetSkipCheckersLabel()
+	if key == "" {
+		return false
+	}
+	value := c.labeler.GetRegionLabel(region, key)
+	if value == "" {
+		return false
+	}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v == "all" || v == t.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// allowReplicaOperator checks whether the operator created by the checker is allowed
+// by the replica schedule limit, the part of it reserved for the priority key ranges,
+// the part of it the rule checker can take while ramping up, the share of it each store can take,
+// the snapshot limits of the stores it adds peers on and the snapshot rate of the checkers.
+// It returns the reason of the diagnosis if the operator is not allowed.
+func (c *CheckerController) allowReplicaOperator(t CheckerType, checkerType string, region *core.RegionInfo, op *operator.Operator, budget *checkBudget) string {
+	if budget.replica == 0 {
+		operator.OperatorLimitCounter.WithLabelValues(checkerType, operator.OpReplica.String()).Inc()
+		return DiagnosisReplicaScheduleLimit
+	}
+	if !budget.allowReplica(c.priorityChecker.InPriorityRange(region)) {
+		operator.OperatorLimitCounter.WithLabelValues(checkerType, "priority-reserved").Inc()
+		return DiagnosisPriorityReserved
+	}
+	if budget.ramping && budget.ramp == 0 && t == CheckerRule {
+		operator.OperatorLimitCounter.WithLabelValues(checkerType, "placement-rules-ramp").Inc()
+		return DiagnosisPlacementRulesRamp
+	}
+	// The operators fixing the down peers are never held back for fairness.
+	if operatorUrgency(t, region, []*operator.Operator{op}) < UrgencyDownPeer && !budget.allowStores(operatorStores(op)) {
+		operator.OperatorLimitCounter.WithLabelValues(checkerType, "store-budget-share").Inc()
+		return DiagnosisStoreBudgetShare
+	}
+	if c.opController.ExceedStoreSnapshotLimit(op) {
+		operator.OperatorLimitCounter.WithLabelValues(checkerType, "store-snapshot").Inc()
+		return DiagnosisStoreSnapshotLimit
+	}
+	// Taking the token goes last, so that it is not wasted on a blocked operator.
+	if len(c.opController.snapshotTargetStores(op)) > 0 && !c.snapshotLimiter.allow(c.opts.GetCheckerSnapshotRate()) {
+		operator.OperatorLimitCounter.WithLabelValues(checkerType, "snapshot-rate").Inc()
+		return DiagnosisCheckerSnapshotRate
+	}
+	return ""
+}
+
+// snapshotBlockReason returns why the operator is blocked by the snapshots it
+// sends, without taking any token, or empty if it is not.
+func (c *CheckerController) snapshotBlockReason(op *operator.Operator) string {
+	if c.opController.ExceedStoreSnapshotLimit(op) {
+		return DiagnosisStoreSnapshotLimit
+	}
+	if len(c.opController.snapshotTargetStores(op)) > 0 && c.snapshotLimiter.exhausted(c.opts.GetCheckerSnapshotRate()) {
+		return DiagnosisCheckerSnapshotRate
+	}
+	return ""
+}
+
+// CheckRegionWithSplitKeys returns an operator to split the region at the
+// given keys if needed.
+func (c *CheckerController) CheckRegionWithSplitKeys(region *core.RegionInfo, splitKeys [][]byte) *operator.Operator {
+	op := c.splitChecker.CheckWithSplitKeys(region, splitKeys)
+	if op != nil {
+		c.onProduced(c.splitChecker.GetType(), UrgencyNormal, region, []*operator.Operator{op})
+	}
+	return op
+}
+
 // GetMergeChecker returns the merge checker.
 func (c *CheckerController) GetMergeChecker() *checker.MergeChecker {
 	return c.mergeChecker
 }
 
+// GetSplitChecker returns the split checker.
+func (c *CheckerController) GetSplitChecker() *checker.SplitChecker {
+	return c.splitChecker
+}
+
 // GetRuleChecker returns the rule checker.
 func (c *CheckerController) GetRuleChecker() *checker.RuleChecker {
 	return c.ruleChecker
 }
 
+// SelectReplicaTarget returns the stores the replica checker would add the new
+// replicas of the region to. It doesn't create any operator.
+func (c *CheckerController) SelectReplicaTarget(region *core.RegionInfo) []*checker.ReplicaTarget {
+	return c.replicaChecker.SelectTarget(region)
+}
+
+// GetOrphanPeerRegions returns the regions found with orphan peers when they
+// are checked, whether or not the orphan peers are being removed.
+func (c *CheckerController) GetOrphanPeerRegions() []*checker.OrphanPeerRegion {
+	return c.ruleChecker.GetOrphanPeerRegions()
+}
+
 // GetWaitingRegions returns the regions in the waiting list.
 func (c *CheckerController) GetWaitingRegions() []*cache.Item {
 	return c.regionWaitingList.Elems()
 }
 
-// AddWaitingRegion returns the regions in the waiting list.
+// WaitingRegionInfo describes a region in the waiting list.
+type WaitingRegionInfo struct {
+	RegionID   uint64        `json:"region-id"`
+	EnqueuedAt time.Time     `json:"enqueued-at"`
+	Requeued   int           `json:"requeued"`
+	Waiting    time.Duration `json:"waiting"`
+	NextCheck  time.Time     `json:"next-check"`
+	// Reason is why the region is blocked the last time, or empty if unknown.
+	Reason string `json:"reason,omitempty"`
+}
+
+// GetWaitingRegionInfos returns the regions in the waiting list along with how
+// long they have been waiting, the longest waiting first.
+func (c *CheckerController) GetWaitingRegionInfos() []*WaitingRegionInfo {
+	now := c.clock.Now()
+	items := c.regionWaitingList.Elems()
+	infos := make([]*WaitingRegionInfo, 0, len(items))
+	for _, item := range items {
+		info := &WaitingRegionInfo{RegionID: item.Key}
+		if w, ok := item.Value.(*checker.WaitingRegion); ok && w != nil {
+			info.EnqueuedAt = w.EnqueuedAt
+			info.Requeued = w.Requeued()
+			info.Waiting = w.WaitingDuration(now)
+			info.NextCheck = w.NextCheck
+			info.Reason = w.Reason
+		}
+		infos = append(infos, info)
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		return infos[i].Waiting > infos[j].Waiting
+	})
+	return infos
+}
+
+// GetLimitBlockedRegions returns the IDs of the regions in the waiting list
+// which are blocked by nothing but the replica schedule limit the last time
+// they are checked. Their operators are ready to go, and raising the limit
+// unblocks them.
+func (c *CheckerController) GetLimitBlockedRegions() []uint64 {
+	var ids []uint64
+	for _, item := range c.regionWaitingList.Elems() {
+		if w, ok := item.Value.(*checker.WaitingRegion); ok && w != nil && w.Reason == DiagnosisReplicaScheduleLimit {
+			ids = append(ids, item.Key)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// GetReadyWaitingRegions returns the regions in the waiting list whose backoff has expired.
+func (c *CheckerController) GetReadyWaitingRegions() []*cache.Item {
+	items := c.regionWaitingList.Elems()
+	ready := items[:0]
+	for _, item := range items {
+		if checker.IsWaitingRegionReady(item, c.clock.Now()) {
+			ready = append(ready, item)
+		}
+	}
+	return ready
+}
+
+// GetNextCheckTime returns when the controller will next consider the region,
+// which is deferred by the backoff of the waiting list and by pausing all
+// checkers. It is now if the region is not deferred. The cooldowns of a single
+// checker, such as the split merge cooldown, are not counted since the other
+// checkers still check the region.
+func (c *CheckerController) GetNextCheckTime(regionID uint64) time.Time {
+	now := c.clock.Now()
+	next := now
+	if v, ok := c.regionWaitingList.Peek(regionID); ok {
+		if w, ok := v.(*checker.WaitingRegion); ok && w != nil && w.NextCheck.After(next) {
+			next = w.NextCheck
+		}
+	}
+	if pausedUntil := c.pauseAll.PausedUntil(); pausedUntil > 0 {
+		if t := time.Unix(pausedUntil, 0); t.After(next) {
+			next = t
+		}
+	}
+	return next
+}
+
+// isSplitBudgetExhausted returns true if the split checker has produced the max
+// number of split operators allowed in a scan.
+func (c *CheckerController) isSplitBudgetExhausted() bool {
+	limit := c.opts.GetMaxSplitsPerScan()
+	return limit > 0 && atomic.LoadUint64(&c.splitsInScan) >= limit
+}
+
+// ResetSplitBudget resets the number of split operators produced by the split
+// checker. It should be called when a new scan of the regions starts.
+func (c *CheckerController) ResetSplitBudget() {
+	atomic.StoreUint64(&c.splitsInScan, 0)
+}
+
+// replicaScheduleLimit returns the replica schedule limit used by the checkers,
+// which is raised by the emergency multiplier during the replica emergency.
+func (c *CheckerController) replicaScheduleLimit() uint64 {
+	limit := c.opts.GetReplicaScheduleLimit()
+	if !c.IsReplicaEmergency() {
+		return limit
+	}
+	return uint64(math.Ceil(float64(limit) * c.opts.GetEmergencyReplicaLimitMultiplier()))
+}
+
+// mergeScheduleLimit returns the merge schedule limit used by the checkers,
+// which is scaled up by the ratio of the average region count of the stores to
+// the target regions per store while the ratio is above 1. The scaled limit is
+// capped by the max merge schedule limit.
+func (c *CheckerController) mergeScheduleLimit() uint64 {
+	limit := c.opts.GetMergeScheduleLimit()
+	target := c.opts.GetMergeTargetRegionsPerStore()
+	if target == 0 {
+		return limit
+	}
+	var regions, stores int
+	for _, store := range c.cluster.GetStores() {
+		if store.IsUp() {
+			regions += store.GetRegionCount()
+			stores++
+		}
+	}
+	if stores == 0 {
+		return limit
+	}
+	ratio := float64(regions) / float64(stores) / float64(target)
+	if ratio <= 1 {
+		return limit
+	}
+	scaled := uint64(math.Ceil(float64(limit) * ratio))
+	if max := c.opts.GetMaxMergeScheduleLimit(); scaled > max {
+		scaled = max
+	}
+	if scaled < limit {
+		return limit
+	}
+	return scaled
+}
+
+// UpdateReplicaEmergency updates whether the replica schedule limit is raised,
+// which is while the ratio of the under-replicated regions reaches the
+// emergency threshold, and logs when the emergency engages or ends. It should
+// be called when a scan of the regions completes.
+func (c *CheckerController) UpdateReplicaEmergency() {
+	threshold := c.opts.GetEmergencyUnderReplicatedRatio()
+	var ratio float64
+	if threshold > 0 {
+		ratio = c.replicaMismatch.underReplicatedRatio(c.cluster)
+	}
+	emergency := threshold > 0 && ratio >= threshold
+	var v int32
+	if emergency {
+		v = 1
+	}
+	if old := atomic.SwapInt32(&c.emergency, v); old != v {
+		if emergency {
+			log.Warn("too many regions are under-replicated, raise the replica schedule limit",
+				zap.Float64("under-replicated-ratio", ratio),
+				zap.Float64("threshold", threshold),
+				zap.Float64("multiplier", c.opts.GetEmergencyReplicaLimitMultiplier()))
+		} else {
+			log.Info("under-replicated regions recover, restore the replica schedule limit",
+				zap.Float64("under-replicated-ratio", ratio),
+				zap.Float64("threshold", threshold))
+		}
+	}
+}
+
+// IsReplicaEmergency returns true if the replica schedule limit is raised since
+// too many regions are under-replicated.
+func (c *CheckerController) IsReplicaEmergency() bool {
+	return atomic.LoadInt32(&c.emergency) == 1
+}
+
+// SetLowChurnWindow declares a low-churn window of the duration from now, in
+// which the checkers only produce the operators fixing the under-replicated,
+// down or joint-state regions. An external job, such as a backup, declares it
+// to keep the cluster quiet while it runs. A non-positive duration ends the
+// window.
+func (c *CheckerController) SetLowChurnWindow(d time.Duration) {
+	if d <= 0 {
+		if atomic.SwapInt64(&c.lowChurnUntil, 0) != 0 {
+			log.Info("low-churn window ends")
+		}
+		return
+	}
+	until := c.clock.Now().Add(d)
+	atomic.StoreInt64(&c.lowChurnUntil, until.UnixNano())
+	log.Info("low-churn window is declared", zap.Duration("duration", d), zap.Time("until", until))
+}
+
+// LowChurnUntil returns the time when the low-churn window ends, or the zero
+// time if there is no window in effect.
+func (c *CheckerController) LowChurnUntil() time.Time {
+	until := atomic.LoadInt64(&c.lowChurnUntil)
+	if until == 0 || !c.clock.Now().Before(time.Unix(0, until)) {
+		return time.Time{}
+	}
+	return time.Unix(0, until)
+}
+
+// IsLowChurn returns true if a low-churn window is in effect.
+func (c *CheckerController) IsLowChurn() bool {
+	return !c.LowChurnUntil().IsZero()
+}
+
+// EffectiveLimits are the schedule limits in effect at the moment, which differ
+// from the configured ones while they are adjusted at runtime.
+type EffectiveLimits struct {
+	// ReplicaScheduleLimit is raised by the emergency multiplier while too many
+	// regions are under-replicated.
+	ReplicaScheduleLimit uint64 `json:"replica-schedule-limit"`
+	// MergeScheduleLimit is scaled up while the stores hold more regions than
+	// the target regions per store.
+	MergeScheduleLimit  uint64 `json:"merge-schedule-limit"`
+	LeaderScheduleLimit uint64 `json:"leader-schedule-limit"`
+	// RuleCheckerReplicaLimit is the part of the replica schedule limit the
+	// rule checker can take, which is less than the whole while it ramps up
+	// after the placement rules are enabled.
+	RuleCheckerReplicaLimit uint64 `json:"rule-checker-replica-limit"`
+	// PriorityReserved is the part of the replica schedule limit which only
+	// the regions in the priority key ranges can take.
+	PriorityReserved uint64 `json:"priority-reserved"`
+	// StoreBudgetShare is the part of the replica schedule limit the operators
+	// touching a store can take unless there is spare budget, or 0 if not set.
+	StoreBudgetShare uint64 `json:"store-budget-share,omitempty"`
+	ReplicaEmergency bool   `json:"replica-emergency"`
+}
+
+// GetEffectiveLimits returns the schedule limits the checkers enforce at the
+// moment, with the runtime adjustments applied.
+func (c *CheckerController) GetEffectiveLimits() *EffectiveLimits {
+	replica := c.replicaScheduleLimit()
+	limits := &EffectiveLimits{
+		ReplicaScheduleLimit:    replica,
+		MergeScheduleLimit:      c.mergeScheduleLimit(),
+		LeaderScheduleLimit:     c.opts.GetLeaderScheduleLimit(),
+		RuleCheckerReplicaLimit: replica,
+		ReplicaEmergency:        c.IsReplicaEmergency(),
+	}
+	if ratio := c.placementRulesRampRatio(); ratio < 1 {
+		limits.RuleCheckerReplicaLimit = uint64(math.Ceil(float64(replica) * ratio))
+	}
+	if c.priorityChecker.HasPriorityRanges() {
+		limits.PriorityReserved = uint64(math.Ceil(float64(replica) * c.opts.GetPriorityRangeReservedRatio()))
+	}
+	if share := c.opts.GetStoreBudgetShare(); share > 0 {
+		limits.StoreBudgetShare = uint64(math.Max(1, math.Ceil(float64(replica)*share)))
+	}
+	return limits
+}
+
+// PruneReplicaMismatch drops the regions which no longer exist from the
+// replica mismatch statistics. It should be called when a scan of the regions
+// finishes.
+func (c *CheckerController) PruneReplicaMismatch() {
+	c.replicaMismatch.prune(c.cluster)
+}
+
+// PruneConvergence drops the regions which no longer exist from the
+// convergence statistics. It should be called when a scan of the regions
+// finishes.
+func (c *CheckerController) PruneConvergence() {
+	c.convergence.prune(c.cluster)
+}
+
+// PrunePendingPromotions drops the regions which no longer exist from the
+// records of the learner checker.
+func (c *CheckerController) PrunePendingPromotions() {
+	c.learnerChecker.Prune()
+}
+
+// GetConvergence returns the ratio of the regions which needed no action from
+// the checkers and had no operator pending the last time they were checked.
+// It approaches 1 as the cluster converges.
+func (c *CheckerController) GetConvergence() float64 {
+	return c.convergence.ratio(c.cluster)
+}
+
+// GetReplicaMismatchCounts returns the number of the regions which have fewer
+// peers than the placement rules require and the number of the regions which
+// have peers matching no rule, as found by the rule checker.
+func (c *CheckerController) GetReplicaMismatchCounts() (under, over int) {
+	return c.replicaMismatch.counts()
+}
+
+// AddWaitingRegion adds the region into the waiting list, backing off if it is already there.
 func (c *CheckerController) AddWaitingRegion(region *core.RegionInfo) {
-	c.regionWaitingList.Put(region.GetID(), nil)
+	c.addWaitingRegion(region, "")
+}
+
+// addWaitingRegion adds the region into the waiting list with the reason why
+// it is blocked.
+func (c *CheckerController) addWaitingRegion(region *core.RegionInfo, reason string) {
+	checker.AddWaitingRegion(c.regionWaitingList, c.opts, region.GetID(), c.clock.Now(), reason)
+}
+
+// GetWaitingRegionIDs returns the IDs of at most limit regions in the waiting
+// list.
+func (c *CheckerController) GetWaitingRegionIDs(limit int) []uint64 {
+	items := c.regionWaitingList.Elems()
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	ids := make([]uint64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.Key)
+	}
+	return ids
+}
+
+// SeedWaitingList adds the regions into the waiting list, which are saved by
+// the previous PD leader. The regions which no longer exist are skipped. It
+// returns the number of the regions added.
+func (c *CheckerController) SeedWaitingList(regionIDs []uint64) int {
+	var n int
+	for _, id := range regionIDs {
+		if region := c.cluster.GetRegion(id); region != nil {
+			c.AddWaitingRegion(region)
+			n++
+		}
+	}
+	return n
+}
+
+// FlushWaitingList drains the waiting list and returns the IDs of the regions in it,
+// so that the caller can check them again immediately. It is safe to call it
+// concurrently with the checks, and the regions added during the flush are not lost.
+func (c *CheckerController) FlushWaitingList() []uint64 {
+	return checker.DrainWaitingList(c.regionWaitingList)
 }
 
 // RemoveWaitingRegion removes the region from the waiting list.
@@ -147,29 +1415,273 @@ func (c *CheckerController) GetPriorityRegions() []uint64 {
 	return c.priorityChecker.GetPriorityRegions()
 }
 
+// GetStuckJointRegions returns the regions which are stuck in joint state.
+func (c *CheckerController) GetStuckJointRegions() []uint64 {
+	return c.jointStateChecker.GetStuckJointRegions()
+}
+
+// GetAllPeersDownRegions returns the regions whose voters are all down, along
+// with the recovery planned for them if it is enabled.
+func (c *CheckerController) GetAllPeersDownRegions() []*checker.AllPeersDownRegion {
+	return c.allPeersDownChecker.GetAllPeersDownRegions()
+}
+
+// GetPendingPromotions returns the learners which the learner checker intends
+// to promote but has not yet, along with why they are held back.
+func (c *CheckerController) GetPendingPromotions() []*checker.PendingPromotion {
+	return c.learnerChecker.GetPendingPromotions()
+}
+
+// GetDuplicatePeerRegions returns the regions which have more than one peer on a store.
+func (c *CheckerController) GetDuplicatePeerRegions() []uint64 {
+	return c.duplicatePeerChecker.GetDuplicatePeerRegions()
+}
+
+// GetPriorityRegionsWithReason returns the regions in priority queue with the reason why they are prioritized.
+func (c *CheckerController) GetPriorityRegionsWithReason() []*checker.PriorityRegion {
+	return c.priorityChecker.GetPriorityRegionsWithReason()
+}
+
 // RemovePriorityRegions removes priority region from priority queue
 func (c *CheckerController) RemovePriorityRegions(id uint64) {
 	c.priorityChecker.RemovePriorityRegion(id)
 }
 
-// GetPauseController returns pause controller of the checker
+// SetPriorityRanges replaces the priority key ranges. The regions overlapping
+// them are checked before the others, and a part of the replica schedule limit
+// is reserved for them. Nil or empty ranges clear the priority key ranges.
+func (c *CheckerController) SetPriorityRanges(ranges []core.KeyRange) {
+	c.priorityChecker.SetPriorityRanges(ranges)
+}
+
+// GetPriorityRanges returns the priority key ranges.
+func (c *CheckerController) GetPriorityRanges() []core.KeyRange {
+	return c.priorityChecker.GetPriorityRanges()
+}
+
+// RegisterPriorityRemovedHook registers a hook which is called with the ID of a
+// region every time it is removed from the priority queue.
+func (c *CheckerController) RegisterPriorityRemovedHook(hook checker.PriorityRemovedHook) {
+	c.priorityChecker.RegisterRemovedHook(hook)
+}
+
+// GetPauseController returns pause controller of the checker of the name.
+// It is used by the HTTP API, and the internal callers should use
+// GetPauseControllerByType instead.
 func (c *CheckerController) GetPauseController(name string) (*checker.PauseController, error) {
-	switch name {
-	case "learner":
+	t, err := ParseCheckerType(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetPauseControllerByType(t)
+}
+
+// GetPauseControllerByType returns pause controller of the checker.
+func (c *CheckerController) GetPauseControllerByType(t CheckerType) (*checker.PauseController, error) {
+	switch t {
+	case CheckerLearner:
 		return &c.learnerChecker.PauseController, nil
-	case "replica":
+	case CheckerReplica:
 		return &c.replicaChecker.PauseController, nil
-	case "rule":
+	case CheckerRule:
 		return &c.ruleChecker.PauseController, nil
-	case "split":
+	case CheckerSplit:
 		return &c.splitChecker.PauseController, nil
-	case "merge":
+	case CheckerMerge:
 		return &c.mergeChecker.PauseController, nil
-	case "joint-state":
+	case CheckerJointState:
 		return &c.jointStateChecker.PauseController, nil
-	case "priority":
+	case CheckerPriority:
 		return &c.priorityChecker.PauseController, nil
+	case CheckerDuplicatePeer:
+		return &c.duplicatePeerChecker.PauseController, nil
+	case CheckerAllPeersDown:
+		return &c.allPeersDownChecker.PauseController, nil
 	default:
 		return nil, errs.ErrCheckerNotFound.FastGenByArgs()
 	}
 }
+
+// CheckerStatus describes the state of a checker.
+type CheckerStatus struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Paused  bool   `json:"paused"`
+	// RemainingDelay is the number of seconds the checker is still paused for.
+	RemainingDelay int64 `json:"remaining-delay"`
+}
+
+// ListCheckers returns the status of all checkers.
+func (c *CheckerController) ListCheckers() []CheckerStatus {
+	allDelay := c.pauseAll.RemainingDelay()
+	status := make([]CheckerStatus, 0, len(checkerTypes))
+	for _, t := range checkerTypes {
+		p, _ := c.GetPauseControllerByType(t)
+		delay := p.RemainingDelay()
+		if allDelay > delay {
+			delay = allDelay
+		}
+		status = append(status, CheckerStatus{
+			Name:           t.String(),
+			Enabled:        c.isCheckerEnabled(t),
+			Paused:         c.pauseAll.IsPaused() || p.IsPaused(),
+			RemainingDelay: delay,
+		})
+	}
+	return status
+}
+
+// isCheckerEnabled returns if the checker takes part in checking regions
+// under the current configuration.
+func (c *CheckerController) isCheckerEnabled(t CheckerType) bool {
+	if c.opts.IsCheckerDisabled(t.String()) {
+		return false
+	}
+	if t == CheckerPriority {
+		// the priority checker only runs along with the rule checker.
+		return c.opts.IsPlacementRulesEnabled() && !c.opts.IsCheckerDisabled(CheckerRule.String())
+	}
+	return c.isCheckerApplicable(t)
+}
+
+// isCheckerApplicable returns if the checker can check regions under the
+// current configuration, no matter whether it is disabled.
+func (c *CheckerController) isCheckerApplicable(t CheckerType) bool {
+	switch t {
+	case CheckerLearner, CheckerReplica:
+		return !c.opts.IsPlacementRulesEnabled()
+	case CheckerRule:
+		return c.opts.IsPlacementRulesEnabled()
+	case CheckerMerge:
+		return c.mergeChecker != nil
+	default:
+		return true
+	}
+}
+
+// SetCheckerEnabled enables or disables the checker. Unlike pausing, a disabled
+// checker never checks any region until it is enabled again. The joint-state
+// checker cannot be disabled, and neither can the priority checker, which runs
+// along with the rule checker. The caller should persist the options to keep
+// the checker disabled after PD restarts.
+func (c *CheckerController) SetCheckerEnabled(name string, enabled bool) error {
+	t, err := ParseCheckerType(name)
+	if err != nil {
+		return err
+	}
+	if !enabled && !t.canDisable() {
+		return errs.ErrCheckerCannotDisable.FastGenByArgs(name)
+	}
+	c.opts.SetCheckerDisabled(name, !enabled)
+	return nil
+}
+
+// PauseAll pauses all checkers for t seconds. The checkers added later also
+// respect the pause until it expires, and it can only be lifted by ResumeAll.
+func (c *CheckerController) PauseAll(t int64) {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+	c.pauseAll.PauseOrResume(t)
+	for _, typ := range checkerTypes {
+		p, _ := c.GetPauseControllerByType(typ)
+		p.PauseOrResume(t)
+	}
+}
+
+// ResumeAll resumes all checkers.
+func (c *CheckerController) ResumeAll() {
+	c.PauseAll(0)
+}
+
+// CheckerState is the state of a checker which is kept by ExportState.
+type CheckerState struct {
+	Name string `json:"name"`
+	// Enabled is false if the checker is disabled, no matter whether it
+	// applies under the current configuration.
+	Enabled bool `json:"enabled"`
+	// PausedUntil is the unix time in seconds until which the checker is
+	// paused, which is 0 if the checker is not paused.
+	PausedUntil int64 `json:"paused-until"`
+}
+
+// CheckersState is the pause and enabled states of all checkers.
+type CheckersState struct {
+	// AllPausedUntil is the unix time in seconds until which all checkers are
+	// paused by PauseAll, which is 0 if they are not.
+	AllPausedUntil int64          `json:"all-paused-until"`
+	Checkers       []CheckerState `json:"checkers"`
+}
+
+// Validate checks that all the checkers exist, and the ones which cannot be
+// disabled are enabled.
+func (s *CheckersState) Validate() error {
+	for _, state := range s.Checkers {
+		t, err := ParseCheckerType(state.Name)
+		if err != nil {
+			return err
+		}
+		if !state.Enabled && !t.canDisable() {
+			return errs.ErrCheckerCannotDisable.FastGenByArgs(state.Name)
+		}
+	}
+	return nil
+}
+
+// ExportState returns the pause and enabled states of all checkers, which can
+// be restored by ImportState.
+func (c *CheckerController) ExportState() *CheckersState {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+	state := &CheckersState{
+		AllPausedUntil: c.pauseAll.PausedUntil(),
+		Checkers:       make([]CheckerState, 0, len(checkerTypes)),
+	}
+	for _, t := range checkerTypes {
+		p, _ := c.GetPauseControllerByType(t)
+		state.Checkers = append(state.Checkers, CheckerState{
+			Name:        t.String(),
+			Enabled:     !c.opts.IsCheckerDisabled(t.String()),
+			PausedUntil: p.PausedUntil(),
+		})
+	}
+	return state
+}
+
+// ImportState restores the states exported by ExportState. The checkers whose
+// pause has expired since are resumed, and the checkers not in the state are
+// left alone. Nothing is changed if any of the states is invalid. The caller
+// should persist the options to keep the checkers disabled after PD restarts.
+func (c *CheckerController) ImportState(state *CheckersState) error {
+	if err := state.Validate(); err != nil {
+		return err
+	}
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+	now := c.clock.Now().Unix()
+	pausedUntil := func(t int64) int64 {
+		if t <= now {
+			return 0
+		}
+		return t
+	}
+	c.pauseAll.PauseUntil(pausedUntil(state.AllPausedUntil))
+	for _, s := range state.Checkers {
+		p, _ := c.GetPauseController(s.Name)
+		p.PauseUntil(pausedUntil(s.PausedUntil))
+		c.opts.SetCheckerDisabled(s.Name, !s.Enabled)
+	}
+	return nil
+}
+
+// GetPausedCheckers returns the names of the checkers which are paused.
+func (c *CheckerController) GetPausedCheckers() []string {
+	allPaused := c.pauseAll.IsPaused()
+	var paused []string
+	for _, t := range checkerTypes {
+		p, _ := c.GetPauseControllerByType(t)
+		if allPaused || p.IsPaused() {
+			paused = append(paused, t.String())
+		}
+	}
+	return paused
+}
@@ -0,0 +1,1620 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/mock/mockcluster"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/core/storelimit"
+	"github.com/tikv/pd/server/schedule/checker"
+	"github.com/tikv/pd/server/schedule/hbstream"
+	"github.com/tikv/pd/server/schedule/labeler"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/placement"
+)
+
+var _ = Suite(&testCheckerControllerSuite{})
+
+type testCheckerControllerSuite struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	cluster *mockcluster.Cluster
+	cc      *CheckerController
+}
+
+func (s *testCheckerControllerSuite) SetUpTest(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	opt := config.NewTestOptions()
+	opt.GetReplicationConfig().EnablePlacementRules = true
+	s.cluster = mockcluster.NewCluster(s.ctx, opt)
+	oc := NewOperatorController(s.ctx, s.cluster, nil)
+	s.cc = NewCheckerController(s.ctx, s.cluster, s.cluster.RuleManager, s.cluster.RegionLabeler, oc)
+	for i := uint64(1); i <= 3; i++ {
+		s.cluster.AddLeaderStore(i, 1)
+	}
+}
+
+func (s *testCheckerControllerSuite) TearDownTest(c *C) {
+	s.cancel()
+}
+
+func (s *testCheckerControllerSuite) TestCheckRegionBatch(c *C) {
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2)
+
+	ops := s.cc.CheckRegionBatch(s.cluster.GetRegion(1))
+	c.Assert(ops, HasLen, 1)
+	c.Assert(ops[0].Kind()&operator.OpReplica, Not(Equals), operator.OpKind(0))
+	c.Assert(ops[0].GetImpact(), DeepEquals, operator.EstimateImpact(ops[0], s.cluster.GetRegion(1)))
+	c.Assert(ops[0].GetImpact().TargetStores, DeepEquals, []uint64{3})
+	c.Assert(ops[0].Checker(), Equals, "rule-checker")
+	// The region is observed like it is by CheckRegion.
+	c.Assert(s.cc.GetConvergence(), Equals, 0.0)
+
+	// The split operator conflicts with the replica operator.
+	s.cluster.RuleManager.SetRule(&placement.Rule{
+		GroupID:     "test",
+		ID:          "test",
+		StartKeyHex: "aa",
+		EndKeyHex:   "cc",
+		Role:        placement.Voter,
+		Count:       3,
+	})
+	ops = s.cc.CheckRegionBatch(s.cluster.GetRegion(1))
+	c.Assert(ops, HasLen, 1)
+	c.Assert(ops[0].Kind()&operator.OpSplit, Not(Equals), operator.OpKind(0))
+	c.Assert(ops[0].Checker(), Equals, "split-checker")
+
+	// The replica operator is blocked by the limit.
+	s.cluster.RuleManager.DeleteRule("test", "test")
+	s.cluster.SetReplicaScheduleLimit(0)
+	ops = s.cc.CheckRegionBatch(s.cluster.GetRegion(1))
+	c.Assert(ops, HasLen, 0)
+	c.Assert(s.cc.GetWaitingRegions(), HasLen, 1)
+}
+
+func (s *testCheckerControllerSuite) TestCheckRegions(c *C) {
+	var regions []*core.RegionInfo
+	for i := uint64(1); i <= 3; i++ {
+		s.cluster.AddLeaderRegionWithRange(i, fmt.Sprintf("%d", i), fmt.Sprintf("%d", i+1), 1, 2)
+		regions = append(regions, s.cluster.GetRegion(i))
+	}
+	results := s.cc.CheckRegions(regions)
+	c.Assert(results, HasLen, 3)
+	for _, region := range regions {
+		c.Assert(results[region.GetID()], HasLen, 1)
+	}
+
+	// The operators created in the batch count towards the limit.
+	s.cluster.SetReplicaScheduleLimit(2)
+	results = s.cc.CheckRegions(regions)
+	c.Assert(results, HasLen, 2)
+	c.Assert(results[3], IsNil)
+	c.Assert(s.cc.GetWaitingRegions(), HasLen, 1)
+	c.Assert(s.cc.GetWaitingRegions()[0].Key, Equals, uint64(3))
+
+	s.cc.PauseAll(60)
+	c.Assert(s.cc.CheckRegions(regions), HasLen, 0)
+}
+
+func (s *testCheckerControllerSuite) TestOrphanPeerRegions(c *C) {
+	s.cluster.AddLeaderStore(4, 1)
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2, 3, 4)
+
+	// The orphan peers are reported even if the rule checker doesn't remove them.
+	pc, err := s.cc.GetPauseController("rule")
+	c.Assert(err, IsNil)
+	pc.PauseOrResume(60)
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 0)
+	regions := s.cc.GetOrphanPeerRegions()
+	c.Assert(regions, HasLen, 1)
+	c.Assert(regions[0].RegionID, Equals, uint64(1))
+	c.Assert(regions[0].Peers, HasLen, 1)
+
+	// The region is no longer reported once the orphan peer is removed.
+	pc.PauseOrResume(0)
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 1)
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2, 3)
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 0)
+	c.Assert(s.cc.GetOrphanPeerRegions(), HasLen, 0)
+
+	// The region which no longer exists is dropped.
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2, 3, 4)
+	s.cc.CheckRegion(s.cluster.GetRegion(1))
+	c.Assert(s.cc.GetOrphanPeerRegions(), HasLen, 1)
+	s.cluster.RemoveRegion(s.cluster.GetRegion(1))
+	c.Assert(s.cc.GetOrphanPeerRegions(), HasLen, 0)
+}
+
+func (s *testCheckerControllerSuite) TestDuplicatePeerRegions(c *C) {
+	peers := []*metapb.Peer{
+		{Id: 101, StoreId: 1},
+		{Id: 102, StoreId: 2},
+		{Id: 103, StoreId: 2},
+	}
+	s.cluster.PutRegion(core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers}, peers[0]))
+
+	// The duplicate peer is removed before any other checker runs.
+	ops, decisions := s.cc.CheckRegionWithDecisions(s.cluster.GetRegion(1))
+	c.Assert(ops, HasLen, 1)
+	c.Assert(ops[0].Desc(), Equals, "remove-duplicate-peer")
+	c.Assert(ops[0].Step(0), DeepEquals, operator.RemovePeer{FromStore: 2, PeerID: 103, IsDuplicate: true})
+	c.Assert(ops[0].GetPriorityLevel(), Equals, core.HighPriority)
+	c.Assert(decisions[2].Checker, Equals, "duplicate-peer")
+	c.Assert(decisions[2].Urgency, Equals, UrgencyDuplicatePeer)
+	c.Assert(s.cc.GetDuplicatePeerRegions(), DeepEquals, []uint64{1})
+
+	// The operator is dropped if the cluster is read-only, unless it is allowed.
+	s.cluster.SetEnableReadOnlyCluster(true)
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 0)
+	s.cluster.SetReadOnlyAllowDuplicatePeer(true)
+	ops = s.cc.CheckRegion(s.cluster.GetRegion(1))
+	c.Assert(ops, HasLen, 1)
+	s.cluster.SetEnableReadOnlyCluster(false)
+
+	// The checker can be paused and disabled like the others.
+	p, err := s.cc.GetPauseController("duplicate-peer")
+	c.Assert(err, IsNil)
+	p.PauseOrResume(60)
+	_, decisions = s.cc.CheckRegionWithDecisions(s.cluster.GetRegion(1))
+	c.Assert(decisions[2].Reason, Equals, DiagnosisPaused)
+	p.PauseOrResume(0)
+	c.Assert(s.cc.SetCheckerEnabled("duplicate-peer", false), IsNil)
+	_, decisions = s.cc.CheckRegionWithDecisions(s.cluster.GetRegion(1))
+	c.Assert(decisions[2].Reason, Equals, DiagnosisDisabled)
+	c.Assert(s.cc.SetCheckerEnabled("duplicate-peer", true), IsNil)
+
+	s.cluster.PutRegion(core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers[:2]}, peers[0]))
+	s.cc.CheckRegion(s.cluster.GetRegion(1))
+	c.Assert(s.cc.GetDuplicatePeerRegions(), HasLen, 0)
+}
+
+func (s *testCheckerControllerSuite) TestAllPeersDownRegions(c *C) {
+	s.cluster.AddLeaderRegion(1, 1, 2)
+	s.cluster.SetStoreDown(1)
+	s.cluster.SetStoreDown(2)
+
+	_, decisions := s.cc.CheckRegionWithDecisions(s.cluster.GetRegion(1))
+	c.Assert(decisions[1].Checker, Equals, "all-peers-down")
+	c.Assert(decisions[1].Kind, Equals, DecisionBlocked)
+	c.Assert(decisions[1].Reason, Equals, DiagnosisAllPeersDown)
+	regions := s.cc.GetAllPeersDownRegions()
+	c.Assert(regions, HasLen, 1)
+	c.Assert(regions[0].RegionID, Equals, uint64(1))
+	diagnosis, err := s.cc.Diagnose(1)
+	c.Assert(err, IsNil)
+	c.Assert(diagnosis.Checkers[1].Reason, Equals, DiagnosisAllPeersDown)
+
+	// The checker can be disabled like the others.
+	c.Assert(s.cc.SetCheckerEnabled("all-peers-down", false), IsNil)
+	_, decisions = s.cc.CheckRegionWithDecisions(s.cluster.GetRegion(1))
+	c.Assert(decisions[1].Reason, Equals, DiagnosisDisabled)
+}
+
+func (s *testCheckerControllerSuite) TestReplicaMismatch(c *C) {
+	s.cluster.AddLeaderStore(4, 1)
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2)
+	s.cluster.AddLeaderRegionWithRange(2, "a", "b", 1, 2, 3, 4)
+	s.cluster.AddLeaderRegionWithRange(3, "b", "", 1, 2, 3)
+	for id := uint64(1); id <= 3; id++ {
+		s.cc.CheckRegion(s.cluster.GetRegion(id))
+	}
+	under, over := s.cc.GetReplicaMismatchCounts()
+	c.Assert(under, Equals, 1)
+	c.Assert(over, Equals, 1)
+
+	// The region is no longer counted once its peers match the rules.
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2, 3)
+	s.cc.CheckRegion(s.cluster.GetRegion(1))
+	under, over = s.cc.GetReplicaMismatchCounts()
+	c.Assert(under, Equals, 0)
+	c.Assert(over, Equals, 1)
+
+	// The region which no longer exists is dropped.
+	s.cluster.RemoveRegion(s.cluster.GetRegion(2))
+	s.cc.PruneReplicaMismatch()
+	under, over = s.cc.GetReplicaMismatchCounts()
+	c.Assert(under, Equals, 0)
+	c.Assert(over, Equals, 0)
+}
+
+func (s *testCheckerControllerSuite) TestConvergence(c *C) {
+	c.Assert(s.cc.GetConvergence(), Equals, 1.0)
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2)
+	s.cluster.AddLeaderRegionWithRange(2, "a", "b", 1, 2, 3)
+	s.cluster.AddLeaderRegionWithRange(3, "b", "c", 1, 2, 3)
+	s.cluster.AddLeaderRegionWithRange(4, "c", "", 1, 2, 3)
+	for id := uint64(1); id <= 4; id++ {
+		s.cc.CheckRegion(s.cluster.GetRegion(id))
+	}
+	c.Assert(s.cc.GetConvergence(), Equals, 0.75)
+
+	// The region blocked by the limit still needs action.
+	s.cluster.SetReplicaScheduleLimit(0)
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 0)
+	c.Assert(s.cc.GetConvergence(), Equals, 0.75)
+
+	// So does the region with an operator pending.
+	op := operator.NewOperator("test", "test", 2, s.cluster.GetRegion(2).GetRegionEpoch(), operator.OpLeader, operator.TransferLeader{FromStore: 1, ToStore: 2})
+	c.Assert(op.Start(), IsTrue)
+	s.cc.opController.SetOperator(op)
+	s.cc.CheckRegion(s.cluster.GetRegion(2))
+	c.Assert(s.cc.GetConvergence(), Equals, 0.5)
+
+	c.Assert(s.cc.opController.RemoveOperator(op), IsTrue)
+	s.cc.CheckRegion(s.cluster.GetRegion(2))
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2, 3)
+	s.cc.CheckRegion(s.cluster.GetRegion(1))
+	c.Assert(s.cc.GetConvergence(), Equals, 1.0)
+
+	// The regions which are gone are pruned.
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2)
+	s.cc.CheckRegion(s.cluster.GetRegion(1))
+	s.cluster.RemoveRegion(s.cluster.GetRegion(1))
+	s.cc.PruneConvergence()
+	c.Assert(s.cc.GetConvergence(), Equals, 1.0)
+}
+
+func (s *testCheckerControllerSuite) TestReplicaEmergency(c *C) {
+	s.cluster.SetReplicaScheduleLimit(2)
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2)
+	s.cluster.AddLeaderRegionWithRange(2, "a", "b", 1, 2, 3)
+	s.cluster.AddLeaderRegionWithRange(3, "b", "c", 1, 2, 3)
+	s.cluster.AddLeaderRegionWithRange(4, "c", "", 1, 2, 3)
+	// The down peers are taken as missing.
+	region := s.cluster.GetRegion(2)
+	s.cluster.PutRegion(region.Clone(core.WithDownPeers([]*pdpb.PeerStats{{Peer: region.GetStorePeer(3), DownSeconds: 3600}})))
+	check := func() {
+		for id := uint64(1); id <= 4; id++ {
+			s.cc.CheckRegion(s.cluster.GetRegion(id))
+		}
+		s.cc.UpdateReplicaEmergency()
+	}
+	check()
+	under, _ := s.cc.GetReplicaMismatchCounts()
+	c.Assert(under, Equals, 2)
+
+	// It is disabled by default.
+	c.Assert(s.cc.replicaScheduleLimit(), Equals, uint64(2))
+	c.Assert(s.cc.IsReplicaEmergency(), IsFalse)
+
+	s.cluster.SetEmergencyUnderReplicatedRatio(0.5)
+	s.cluster.SetEmergencyReplicaLimitMultiplier(2.5)
+	// It only changes when a scan completes.
+	c.Assert(s.cc.replicaScheduleLimit(), Equals, uint64(2))
+	s.cc.UpdateReplicaEmergency()
+	c.Assert(s.cc.replicaScheduleLimit(), Equals, uint64(5))
+	c.Assert(s.cc.IsReplicaEmergency(), IsTrue)
+	limits := s.cc.GetEffectiveLimits()
+	c.Assert(limits.ReplicaScheduleLimit, Equals, uint64(5))
+	c.Assert(limits.RuleCheckerReplicaLimit, Equals, uint64(5))
+	c.Assert(limits.ReplicaEmergency, IsTrue)
+	c.Assert(limits.MergeScheduleLimit, Equals, s.cluster.GetOpts().GetMergeScheduleLimit())
+
+	// It reverts once the regions recover.
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2, 3)
+	check()
+	c.Assert(s.cc.replicaScheduleLimit(), Equals, uint64(2))
+	c.Assert(s.cc.IsReplicaEmergency(), IsFalse)
+}
+
+func (s *testCheckerControllerSuite) TestMergeScheduleLimit(c *C) {
+	s.cluster.SetMergeScheduleLimit(4)
+	for id := uint64(1); id <= 3; id++ {
+		s.cluster.UpdateRegionCount(id, 300)
+	}
+	// It is not scaled by default.
+	c.Assert(s.cc.mergeScheduleLimit(), Equals, uint64(4))
+
+	// It is not scaled if the stores hold no more regions than the target.
+	s.cluster.SetMergeTargetRegionsPerStore(300)
+	c.Assert(s.cc.mergeScheduleLimit(), Equals, uint64(4))
+
+	s.cluster.SetMergeTargetRegionsPerStore(100)
+	c.Assert(s.cc.mergeScheduleLimit(), Equals, uint64(12))
+	c.Assert(s.cc.GetEffectiveLimits().MergeScheduleLimit, Equals, uint64(12))
+	// The offline stores are not counted.
+	s.cluster.UpdateRegionCount(3, 900)
+	s.cluster.SetStoreOffline(3)
+	c.Assert(s.cc.mergeScheduleLimit(), Equals, uint64(12))
+
+	// It is capped, but never below the merge schedule limit.
+	s.cluster.SetMaxMergeScheduleLimit(10)
+	c.Assert(s.cc.mergeScheduleLimit(), Equals, uint64(10))
+	s.cluster.SetMaxMergeScheduleLimit(2)
+	c.Assert(s.cc.mergeScheduleLimit(), Equals, uint64(4))
+}
+
+func (s *testCheckerControllerSuite) TestPriorityRanges(c *C) {
+	var regions []*core.RegionInfo
+	for i := uint64(1); i <= 4; i++ {
+		s.cluster.AddLeaderRegionWithRange(i, fmt.Sprintf("%d", i), fmt.Sprintf("%d", i+1), 1, 2)
+		regions = append(regions, s.cluster.GetRegion(i))
+	}
+	s.cluster.SetReplicaScheduleLimit(2)
+	results := s.cc.CheckRegions(regions)
+	c.Assert(results, HasLen, 2)
+	c.Assert(results[4], IsNil)
+	s.cc.FlushWaitingList()
+
+	// The region in the priority range is checked first.
+	s.cc.SetPriorityRanges([]core.KeyRange{core.NewKeyRange("4", "")})
+	c.Assert(s.cc.GetPriorityRanges(), HasLen, 1)
+	c.Assert(s.cc.GetEffectiveLimits().PriorityReserved, Not(Equals), uint64(0))
+	results = s.cc.CheckRegions(regions)
+	c.Assert(results, HasLen, 2)
+	c.Assert(results[1], NotNil)
+	c.Assert(results[4], NotNil)
+	s.cc.FlushWaitingList()
+
+	// The reserved part of the limit is kept for the regions in the priority
+	// ranges even if the other regions are checked first.
+	ops := s.cc.CheckRegion(regions[0])
+	c.Assert(ops, HasLen, 1)
+	c.Assert(ops[0].Start(), IsTrue)
+	s.cc.opController.SetOperator(ops[0])
+	c.Assert(s.cc.CheckRegion(regions[1]), HasLen, 0)
+	c.Assert(s.cc.CheckRegion(regions[3]), HasLen, 1)
+
+	// The reservation is lifted along with the ranges.
+	s.cc.SetPriorityRanges(nil)
+	c.Assert(s.cc.CheckRegion(regions[1]), HasLen, 1)
+}
+
+func (s *testCheckerControllerSuite) TestCheckerDecisions(c *C) {
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2)
+	s.cluster.AddLeaderRegionWithRange(2, "a", "", 1, 2, 3)
+	decisionOf := func(decisions []*CheckerDecision, name string) *CheckerDecision {
+		for _, d := range decisions {
+			if d.Checker == name {
+				return d
+			}
+		}
+		return nil
+	}
+
+	ops, decisions := s.cc.CheckRegionWithDecisions(s.cluster.GetRegion(2))
+	c.Assert(ops, HasLen, 0)
+	c.Assert(decisions, Not(HasLen), 0)
+	for _, d := range decisions {
+		c.Assert(d.Kind, Equals, DecisionOK)
+	}
+	// The checkers which don't apply under placement rules are left out.
+	c.Assert(decisionOf(decisions, "replica"), IsNil)
+	c.Assert(decisionOf(decisions, "learner"), IsNil)
+
+	ops, decisions = s.cc.CheckRegionWithDecisions(s.cluster.GetRegion(1))
+	c.Assert(ops, HasLen, 1)
+	d := decisionOf(decisions, "rule")
+	c.Assert(d.Kind, Equals, DecisionNeedsOperator)
+	c.Assert(d.Operators, DeepEquals, ops)
+	// The checkers after the one creating the operators don't run.
+	c.Assert(decisionOf(decisions, "merge"), IsNil)
+
+	s.cluster.SetReplicaScheduleLimit(0)
+	ops, decisions = s.cc.CheckRegionWithDecisions(s.cluster.GetRegion(1))
+	c.Assert(ops, HasLen, 0)
+	d = decisionOf(decisions, "rule")
+	c.Assert(d.Kind, Equals, DecisionBlocked)
+	c.Assert(d.Reason, Equals, DiagnosisReplicaScheduleLimit)
+	c.Assert(d.Operators, HasLen, 1)
+	s.cluster.SetReplicaScheduleLimit(64)
+
+	c.Assert(s.cc.SetCheckerEnabled("merge", false), IsNil)
+	_, decisions = s.cc.CheckRegionWithDecisions(s.cluster.GetRegion(2))
+	d = decisionOf(decisions, "merge")
+	c.Assert(d.Kind, Equals, DecisionBlocked)
+	c.Assert(d.Reason, Equals, DiagnosisDisabled)
+
+	// The error of the checker is returned instead of being swallowed.
+	region := s.cluster.GetRegion(1)
+	s.cluster.PutRegion(region.Clone(core.WithLeader(nil)))
+	ops, decisions = s.cc.CheckRegionWithDecisions(s.cluster.GetRegion(1))
+	c.Assert(ops, HasLen, 0)
+	d = decisionOf(decisions, "rule")
+	c.Assert(d.Kind, Equals, DecisionError)
+	c.Assert(d.Err, NotNil)
+}
+
+func (s *testCheckerControllerSuite) TestCheckerStats(c *C) {
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2)
+
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 1)
+	s.cluster.SetReplicaScheduleLimit(0)
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 0)
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 0)
+
+	stats := s.cc.GetCheckerStats()
+	c.Assert(stats, HasLen, 1)
+	c.Assert(stats["rule-checker"], DeepEquals, CheckerStats{Produced: 1, Blocked: 2})
+}
+
+func (s *testCheckerControllerSuite) TestRegionOperatorHistory(c *C) {
+	clock := checker.NewManualClock(time.Now())
+	cc := NewSyncCheckerController(s.cluster, s.cluster.RuleManager, s.cluster.RegionLabeler, NewOperatorController(s.ctx, s.cluster, nil), WithCheckerClock(clock))
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2)
+	s.cluster.SetRegionOperatorHistorySize(2)
+	c.Assert(cc.GetRegionOperatorHistory(1), HasLen, 0)
+
+	start := clock.Now()
+	for i := 0; i < 3; i++ {
+		c.Assert(cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 1)
+		clock.Advance(time.Second)
+	}
+	// Only the latest operators are kept.
+	history := cc.GetRegionOperatorHistory(1)
+	c.Assert(history, HasLen, 2)
+	for i, record := range history {
+		c.Assert(record.Time, Equals, start.Add(time.Duration(i+1)*time.Second))
+		c.Assert(record.Checker, Equals, "rule-checker")
+		c.Assert(record.Desc, Equals, "add-rule-peer")
+	}
+
+	// The latest operators are kept when the size shrinks.
+	s.cluster.SetRegionOperatorHistorySize(1)
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 1)
+	history = cc.GetRegionOperatorHistory(1)
+	c.Assert(history, HasLen, 1)
+	c.Assert(history[0].Time, Equals, start.Add(3*time.Second))
+
+	// Nothing is recorded if the size is 0.
+	s.cluster.SetRegionOperatorHistorySize(0)
+	s.cluster.AddLeaderRegionWithRange(2, "", "", 1, 2)
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(2)), HasLen, 1)
+	c.Assert(cc.GetRegionOperatorHistory(2), HasLen, 0)
+}
+
+func (s *testCheckerControllerSuite) TestOperatorDedup(c *C) {
+	clock := checker.NewManualClock(time.Now())
+	cc := NewSyncCheckerController(s.cluster, s.cluster.RuleManager, s.cluster.RegionLabeler, NewOperatorController(s.ctx, s.cluster, nil), WithCheckerClock(clock))
+	s.cluster.AddLeaderRegionWithRange(1, "", "b", 1, 2)
+
+	// The operators are not deduplicated by default.
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 1)
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 1)
+
+	s.cluster.SetCheckerOperatorDedupTTL(10 * time.Second)
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 1)
+	ops, decisions := cc.CheckRegionWithDecisions(s.cluster.GetRegion(1))
+	c.Assert(ops, HasLen, 0)
+	for _, d := range decisions {
+		if d.Checker == "rule" {
+			c.Assert(d.Kind, Equals, DecisionBlocked)
+			c.Assert(d.Reason, Equals, DiagnosisRecentlyProduced)
+		}
+	}
+
+	// The operator of another region is not the same one.
+	s.cluster.AddLeaderRegionWithRange(2, "b", "", 1, 2)
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(2)), HasLen, 1)
+
+	clock.Advance(10 * time.Second)
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 1)
+}
+
+func (s *testCheckerControllerSuite) TestDecisionTrace(c *C) {
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2)
+	s.cluster.AddLeaderRegionWithRange(2, "a", "", 1, 2, 3)
+	readRecords := func(buf *bytes.Buffer) []*DecisionTraceRecord {
+		var records []*DecisionTraceRecord
+		decoder := json.NewDecoder(buf)
+		for decoder.More() {
+			record := &DecisionTraceRecord{}
+			c.Assert(decoder.Decode(record), IsNil)
+			records = append(records, record)
+		}
+		return records
+	}
+
+	var buf bytes.Buffer
+	c.Assert(s.cc.StartDecisionTrace(&buf, false), IsNil)
+	c.Assert(s.cc.StartDecisionTrace(&buf, false), NotNil)
+	results := s.cc.CheckRegions([]*core.RegionInfo{s.cluster.GetRegion(1), s.cluster.GetRegion(2)})
+	c.Assert(results[1], HasLen, 1)
+	count, err := s.cc.StopDecisionTrace()
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, uint64(2))
+	records := readRecords(&buf)
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[0].RegionID, Equals, uint64(1))
+	c.Assert(records[0].Operators, DeepEquals, []string{results[1][0].String()})
+	c.Assert(records[0].Checkers[len(records[0].Checkers)-1], DeepEquals, DecisionTraceItem{
+		Checker:   "rule",
+		Decision:  "needs-operator",
+		Operators: []string{results[1][0].String()},
+	})
+	c.Assert(records[1].RegionID, Equals, uint64(2))
+	c.Assert(records[1].Operators, HasLen, 0)
+
+	// Nothing is written once the trace stops.
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 1)
+	c.Assert(buf.Len(), Equals, 0)
+
+	// The operators are dropped in the dry run.
+	history := s.cc.GetRegionOperatorHistory(1)
+	c.Assert(s.cc.StartDecisionTrace(&buf, true), IsNil)
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 0)
+	c.Assert(s.cc.CheckRegionBatch(s.cluster.GetRegion(1)), HasLen, 0)
+	count, err = s.cc.StopDecisionTrace()
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, uint64(2))
+	c.Assert(s.cc.GetRegionOperatorHistory(1), DeepEquals, history)
+	for _, record := range readRecords(&buf) {
+		c.Assert(record.DryRun, IsTrue)
+		c.Assert(record.Operators, HasLen, 0)
+		for _, item := range record.Checkers {
+			if item.Checker == "rule" {
+				c.Assert(item.Operators, HasLen, 1)
+			}
+		}
+	}
+}
+
+func (s *testCheckerControllerSuite) TestOperatorHistoryResize(c *C) {
+	h := newOperatorHistory(3)
+	for i := 0; i < 5; i++ {
+		h.add(OperatorRecord{Desc: fmt.Sprint(i)})
+	}
+	descs := func() []string {
+		var ret []string
+		for _, record := range h.list() {
+			ret = append(ret, record.Desc)
+		}
+		return ret
+	}
+	c.Assert(descs(), DeepEquals, []string{"2", "3", "4"})
+	h.resize(5)
+	c.Assert(descs(), DeepEquals, []string{"2", "3", "4"})
+	h.add(OperatorRecord{Desc: "5"})
+	h.add(OperatorRecord{Desc: "6"})
+	h.add(OperatorRecord{Desc: "7"})
+	c.Assert(descs(), DeepEquals, []string{"3", "4", "5", "6", "7"})
+	h.resize(2)
+	c.Assert(descs(), DeepEquals, []string{"6", "7"})
+	h.add(OperatorRecord{Desc: "8"})
+	c.Assert(descs(), DeepEquals, []string{"7", "8"})
+}
+
+func (s *testCheckerControllerSuite) TestPauseAll(c *C) {
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2)
+	c.Assert(s.cc.GetPausedCheckers(), HasLen, 0)
+
+	s.cc.PauseAll(60)
+	c.Assert(s.cc.GetPausedCheckers(), DeepEquals, []string{"learner", "replica", "rule", "split", "merge", "joint-state", "priority", "duplicate-peer", "all-peers-down"})
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 0)
+	c.Assert(s.cc.CheckRegionBatch(s.cluster.GetRegion(1)), HasLen, 0)
+
+	// Resuming a single checker does not lift the global pause.
+	p, err := s.cc.GetPauseController("rule")
+	c.Assert(err, IsNil)
+	p.PauseOrResume(0)
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 0)
+
+	s.cc.ResumeAll()
+	c.Assert(s.cc.GetPausedCheckers(), HasLen, 0)
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 1)
+}
+
+func (s *testCheckerControllerSuite) TestOperatorHook(c *C) {
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2)
+
+	var calls []string
+	s.cc.RegisterOperatorHook(func(ops []*operator.Operator, checkerName string) {
+		c.Assert(ops, HasLen, 1)
+		calls = append(calls, "first:"+checkerName)
+		ops[0] = nil
+	})
+	s.cc.RegisterOperatorHook(func(ops []*operator.Operator, checkerName string) {
+		c.Assert(ops[0], NotNil)
+		calls = append(calls, "second:"+checkerName)
+	})
+	ops := s.cc.CheckRegion(s.cluster.GetRegion(1))
+	c.Assert(ops, HasLen, 1)
+	c.Assert(ops[0], NotNil)
+	c.Assert(calls, DeepEquals, []string{"first:rule-checker", "second:rule-checker"})
+
+	// No hook is called if there is no operator.
+	s.cluster.SetReplicaScheduleLimit(0)
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 0)
+	c.Assert(calls, HasLen, 2)
+}
+
+func (s *testCheckerControllerSuite) TestOperatorFilter(c *C) {
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2)
+
+	var calls []string
+	veto := ""
+	s.cc.RegisterOperatorFilter(func(ctx *OperatorFilterContext, region *core.RegionInfo, op *operator.Operator) string {
+		c.Assert(region.GetID(), Equals, op.RegionID())
+		calls = append(calls, "first:"+ctx.Checker)
+		return veto
+	})
+	s.cc.RegisterOperatorFilter(func(ctx *OperatorFilterContext, region *core.RegionInfo, op *operator.Operator) string {
+		calls = append(calls, "second:"+ctx.Checker)
+		return ""
+	})
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 1)
+	c.Assert(calls, DeepEquals, []string{"first:rule", "second:rule"})
+
+	// The vetoed region is added into the waiting list, and the chain stops
+	// at the first veto.
+	calls, veto = nil, "draining"
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 0)
+	c.Assert(calls, DeepEquals, []string{"first:rule"})
+	c.Assert(s.cc.GetWaitingRegions(), HasLen, 1)
+	c.Assert(s.cc.CheckRegionBatch(s.cluster.GetRegion(1)), HasLen, 0)
+}
+
+func (s *testCheckerControllerSuite) TestLowChurnWindow(c *C) {
+	clock := checker.NewManualClock(time.Now())
+	cc := NewSyncCheckerController(s.cluster, s.cluster.RuleManager, s.cluster.RegionLabeler, NewOperatorController(s.ctx, s.cluster, nil), WithCheckerClock(clock))
+	s.cluster.AddLeaderStore(4, 1)
+	// The region with an extra peer is fixed by a normal operator.
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2, 3, 4)
+	s.cluster.AddLeaderRegionWithRange(2, "a", "b", 1, 2)
+	s.cluster.AddLeaderRegionWithRange(3, "b", "", 1, 2, 3)
+	region := s.cluster.GetRegion(3)
+	s.cluster.PutRegion(region.Clone(core.WithDownPeers([]*pdpb.PeerStats{{Peer: region.GetStorePeer(3), DownSeconds: 3600}})))
+	s.cluster.SetStoreDown(3)
+
+	c.Assert(cc.IsLowChurn(), IsFalse)
+	c.Assert(cc.LowChurnUntil().IsZero(), IsTrue)
+	cc.SetLowChurnWindow(time.Minute)
+	c.Assert(cc.IsLowChurn(), IsTrue)
+	c.Assert(cc.LowChurnUntil(), Equals, time.Unix(0, clock.Now().Add(time.Minute).UnixNano()))
+
+	// The non-urgent operators are held back.
+	ops, decisions := cc.CheckRegionWithDecisions(s.cluster.GetRegion(1))
+	c.Assert(ops, HasLen, 0)
+	for _, d := range decisions {
+		if d.Checker == "rule" {
+			c.Assert(d.Kind, Equals, DecisionBlocked)
+			c.Assert(d.Reason, Equals, DiagnosisLowChurn)
+		}
+	}
+	// The under-replicated and down regions are still fixed.
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(2)), HasLen, 1)
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(3)), HasLen, 1)
+
+	// The window expires by itself.
+	clock.Advance(time.Minute)
+	c.Assert(cc.IsLowChurn(), IsFalse)
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 1)
+
+	// It can be ended early.
+	cc.SetLowChurnWindow(time.Minute)
+	c.Assert(cc.IsLowChurn(), IsTrue)
+	cc.SetLowChurnWindow(0)
+	c.Assert(cc.IsLowChurn(), IsFalse)
+}
+
+func (s *testCheckerControllerSuite) TestOpsConflict(c *C) {
+	newOp := func(kind operator.OpKind) *operator.Operator {
+		return operator.NewOperator("test", "test", 1, &metapb.RegionEpoch{}, kind)
+	}
+	testCases := []struct {
+		a, b     operator.OpKind
+		conflict bool
+	}{
+		{operator.OpSplit, operator.OpRegion, true},
+		{operator.OpRegion, operator.OpMerge | operator.OpRegion, true},
+		{operator.OpRegion | operator.OpReplica, operator.OpRegion, true},
+		{operator.OpLeader, operator.OpLeader | operator.OpHotRegion, true},
+		{operator.OpLeader, operator.OpRegion | operator.OpReplica, false},
+	}
+	for _, t := range testCases {
+		c.Assert(opsConflict(newOp(t.a), newOp(t.b)), Equals, t.conflict)
+	}
+}
+
+func (s *testCheckerControllerSuite) TestWaitingRegionBackoff(c *C) {
+	s.cluster.SetMaxWaitingRegionBackoff(time.Minute)
+	region := s.cluster.AddLeaderRegion(1, 1, 2)
+
+	// The first time a region is blocked, it can be checked at once.
+	s.cc.AddWaitingRegion(region)
+	c.Assert(s.cc.GetReadyWaitingRegions(), HasLen, 1)
+	// The region waits before it is checked again after being blocked again.
+	s.cc.AddWaitingRegion(region)
+	c.Assert(s.cc.GetWaitingRegions(), HasLen, 1)
+	c.Assert(s.cc.GetReadyWaitingRegions(), HasLen, 0)
+	w := s.cc.GetWaitingRegions()[0].Value.(*checker.WaitingRegion)
+	c.Assert(w.Attempt, Equals, 2)
+
+	// The backoff is capped by the max waiting region backoff.
+	for i := 0; i < 30; i++ {
+		s.cc.AddWaitingRegion(region)
+	}
+	w = s.cc.GetWaitingRegions()[0].Value.(*checker.WaitingRegion)
+	c.Assert(w.Attempt, Equals, 32)
+	c.Assert(w.NextCheck.After(time.Now().Add(30*time.Second)), IsTrue)
+	c.Assert(w.NextCheck.After(time.Now().Add(time.Minute)), IsFalse)
+
+	// The backoff is reset once the region leaves the waiting list.
+	s.cc.RemoveWaitingRegion(region.GetID())
+	s.cc.AddWaitingRegion(region)
+	c.Assert(s.cc.GetReadyWaitingRegions(), HasLen, 1)
+}
+
+func (s *testCheckerControllerSuite) TestWaitingRegionInfos(c *C) {
+	start := time.Now()
+	clock := checker.NewManualClock(start)
+	cc := NewSyncCheckerController(s.cluster, s.cluster.RuleManager, s.cluster.RegionLabeler, NewOperatorController(s.ctx, s.cluster, nil), WithCheckerClock(clock))
+	region1 := s.cluster.AddLeaderRegion(1, 1, 2)
+	region2 := s.cluster.AddLeaderRegion(2, 1, 2)
+
+	cc.AddWaitingRegion(region1)
+	clock.Advance(time.Minute)
+	cc.AddWaitingRegion(region2)
+	cc.AddWaitingRegion(region1)
+	clock.Advance(time.Hour)
+	cc.AddWaitingRegion(region1)
+
+	// The first enqueue time is kept when the region is blocked again.
+	infos := cc.GetWaitingRegionInfos()
+	c.Assert(infos, HasLen, 2)
+	c.Assert(infos[0].RegionID, Equals, uint64(1))
+	c.Assert(infos[0].EnqueuedAt.Equal(start), IsTrue)
+	c.Assert(infos[0].Requeued, Equals, 2)
+	c.Assert(infos[0].Waiting, Equals, time.Hour+time.Minute)
+	c.Assert(infos[1].RegionID, Equals, uint64(2))
+	c.Assert(infos[1].Requeued, Equals, 0)
+	c.Assert(infos[1].Waiting, Equals, time.Hour)
+
+	// The waiting time restarts once the region leaves the waiting list.
+	cc.RemoveWaitingRegion(1)
+	cc.AddWaitingRegion(region1)
+	infos = cc.GetWaitingRegionInfos()
+	c.Assert(infos[1].RegionID, Equals, uint64(1))
+	c.Assert(infos[1].Waiting, Equals, time.Duration(0))
+}
+
+func (s *testCheckerControllerSuite) TestNextCheckTime(c *C) {
+	start := time.Now()
+	clock := checker.NewManualClock(start)
+	cc := NewSyncCheckerController(s.cluster, s.cluster.RuleManager, s.cluster.RegionLabeler, NewOperatorController(s.ctx, s.cluster, nil), WithCheckerClock(clock))
+	s.cluster.SetMaxWaitingRegionBackoff(time.Hour)
+	region := s.cluster.AddLeaderRegion(1, 1, 2)
+
+	// A region not deferred is checked now.
+	c.Assert(cc.GetNextCheckTime(1).Equal(start), IsTrue)
+	cc.AddWaitingRegion(region)
+	c.Assert(cc.GetNextCheckTime(1).Equal(start), IsTrue)
+
+	// The region is checked after the backoff once it is blocked again.
+	cc.AddWaitingRegion(region)
+	next := cc.GetNextCheckTime(1)
+	c.Assert(next.After(start), IsTrue)
+	w := cc.GetWaitingRegions()[0].Value.(*checker.WaitingRegion)
+	c.Assert(next.Equal(w.NextCheck), IsTrue)
+	clock.Advance(next.Sub(start))
+	c.Assert(cc.GetNextCheckTime(1).Equal(clock.Now()), IsTrue)
+
+	// Pausing all checkers defers all regions.
+	cc.PauseAll(60)
+	c.Assert(cc.GetNextCheckTime(2).Unix(), Equals, clock.Now().Unix()+60)
+	cc.ResumeAll()
+	c.Assert(cc.GetNextCheckTime(2).Equal(clock.Now()), IsTrue)
+}
+
+func (s *testCheckerControllerSuite) TestCheckerType(c *C) {
+	for _, t := range checkerTypes {
+		parsed, err := ParseCheckerType(t.String())
+		c.Assert(err, IsNil)
+		c.Assert(parsed, Equals, t)
+		byName, err := s.cc.GetPauseController(t.String())
+		c.Assert(err, IsNil)
+		byType, err := s.cc.GetPauseControllerByType(t)
+		c.Assert(err, IsNil)
+		c.Assert(byName, Equals, byType)
+	}
+	_, err := ParseCheckerType("dummy")
+	c.Assert(err, ErrorMatches, ".*checker not found.*")
+	_, err = s.cc.GetPauseControllerByType(CheckerType(len(checkerTypes)))
+	c.Assert(err, NotNil)
+	c.Assert(CheckerType(len(checkerTypes)).String(), Equals, "unknown")
+}
+
+func (s *testCheckerControllerSuite) TestListCheckers(c *C) {
+	status := s.cc.ListCheckers()
+	c.Assert(status, HasLen, len(checkerTypes))
+	for _, st := range status {
+		c.Assert(st.Paused, IsFalse)
+		c.Assert(st.RemainingDelay, Equals, int64(0))
+		switch st.Name {
+		case "learner", "replica":
+			c.Assert(st.Enabled, IsFalse)
+		default:
+			c.Assert(st.Enabled, IsTrue)
+		}
+	}
+
+	p, err := s.cc.GetPauseController("merge")
+	c.Assert(err, IsNil)
+	p.PauseOrResume(60)
+	for _, st := range s.cc.ListCheckers() {
+		if st.Name == "merge" {
+			c.Assert(st.Paused, IsTrue)
+			c.Assert(st.RemainingDelay > 50, IsTrue)
+		} else {
+			c.Assert(st.Paused, IsFalse)
+		}
+	}
+
+	s.cc.PauseAll(120)
+	for _, st := range s.cc.ListCheckers() {
+		c.Assert(st.Paused, IsTrue)
+		c.Assert(st.RemainingDelay > 110, IsTrue)
+	}
+	s.cc.ResumeAll()
+	for _, st := range s.cc.ListCheckers() {
+		c.Assert(st.Paused, IsFalse)
+	}
+}
+
+func (s *testCheckerControllerSuite) TestWaitingListSize(c *C) {
+	s.cluster.SetRegionWaitingListSize(2)
+	cc := NewCheckerController(s.ctx, s.cluster, s.cluster.RuleManager, s.cluster.RegionLabeler, NewOperatorController(s.ctx, s.cluster, nil))
+	for i := uint64(1); i <= 3; i++ {
+		cc.AddWaitingRegion(s.cluster.AddLeaderRegion(i, 1, 2))
+	}
+	items := cc.GetWaitingRegions()
+	c.Assert(items, HasLen, 2)
+	// the oldest region is evicted.
+	for _, item := range items {
+		c.Assert(item.Key, Not(Equals), uint64(1))
+	}
+}
+
+func (s *testCheckerControllerSuite) TestDiagnose(c *C) {
+	_, err := s.cc.Diagnose(1)
+	c.Assert(err, NotNil)
+
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2)
+	s.cluster.SetReplicaScheduleLimit(0)
+	diagnosis, err := s.cc.Diagnose(1)
+	c.Assert(err, IsNil)
+	c.Assert(diagnosis.Fit, NotNil)
+	c.Assert(diagnosis.Violations, HasLen, 1)
+	c.Assert(diagnosis.Violations[0].Reason, Equals, checker.ViolationMissingPeer)
+	c.Assert(diagnosis.Checkers, HasLen, len(s.cluster.GetOpts().GetCheckerOrder()))
+	for _, d := range diagnosis.Checkers {
+		switch d.Name {
+		case "rule":
+			c.Assert(d.Operators, HasLen, 1)
+			c.Assert(d.Reason, Equals, DiagnosisReplicaScheduleLimit)
+		case "learner", "replica":
+			c.Assert(d.Reason, Equals, DiagnosisDisabled)
+		default:
+			c.Assert(d.Operators, HasLen, 0)
+		}
+	}
+	// Diagnosing doesn't change the scheduling state.
+	c.Assert(s.cc.GetWaitingRegions(), HasLen, 0)
+	c.Assert(s.cc.GetPriorityRegionsWithReason(), HasLen, 0)
+	c.Assert(s.cc.GetCheckerStats(), HasLen, 0)
+
+	p, err := s.cc.GetPauseController("rule")
+	c.Assert(err, IsNil)
+	p.PauseOrResume(60)
+	diagnosis, err = s.cc.Diagnose(1)
+	c.Assert(err, IsNil)
+	for _, d := range diagnosis.Checkers {
+		if d.Name == "rule" {
+			c.Assert(d.Reason, Equals, DiagnosisPaused)
+		}
+	}
+
+	// The pending promotions of the learner checker are left untouched.
+	s.cluster.SetEnablePlacementRules(false)
+	s.cluster.AddLeaderRegionWithRange(2, "", "", 1, 2)
+	region := s.cluster.GetRegion(2)
+	learner := &metapb.Peer{Id: 100, StoreId: 3, Role: metapb.PeerRole_Learner}
+	region = region.Clone(core.WithAddPeer(learner), core.WithPendingPeers([]*metapb.Peer{learner}))
+	s.cluster.PutRegion(region)
+	_, err = s.cc.Diagnose(2)
+	c.Assert(err, IsNil)
+	c.Assert(s.cc.GetPendingPromotions(), HasLen, 0)
+	s.cc.CheckRegion(region)
+	promotions := s.cc.GetPendingPromotions()
+	c.Assert(promotions, HasLen, 1)
+	s.cluster.PutRegion(region.Clone(core.WithPendingPeers(nil)))
+	_, err = s.cc.Diagnose(2)
+	c.Assert(err, IsNil)
+	c.Assert(s.cc.GetPendingPromotions(), DeepEquals, promotions)
+}
+
+func (s *testCheckerControllerSuite) TestStoreBudgetShare(c *C) {
+	s.cluster.AddLeaderStore(4, 10)
+	var regions []*core.RegionInfo
+	for i := uint64(1); i <= 4; i++ {
+		s.cluster.AddLeaderRegionWithRange(i, fmt.Sprintf("%d", i), fmt.Sprintf("%d", i+1), 1, 2)
+		regions = append(regions, s.cluster.GetRegion(i))
+	}
+	s.cluster.SetReplicaScheduleLimit(4)
+	s.cluster.SetStoreBudgetShare(0.25)
+	c.Assert(s.cc.GetEffectiveLimits().StoreBudgetShare, Equals, uint64(1))
+
+	// All the operators add peers on store 3, which takes the spare budget
+	// but leaves the last share to the other stores.
+	results := s.cc.CheckRegions(regions)
+	c.Assert(results, HasLen, 3)
+	c.Assert(results[4], IsNil)
+	s.cc.FlushWaitingList()
+
+	// The operators in flight are counted.
+	for i := uint64(1); i <= 3; i++ {
+		c.Assert(results[i][0].Start(), IsTrue)
+		s.cc.opController.SetOperator(results[i][0])
+	}
+	// They are counted once they are refreshed.
+	c.Assert(s.cc.newCheckBudget().storeUsage[3], Equals, uint64(0))
+	s.cc.RefreshStoreBudget()
+	c.Assert(s.cc.newCheckBudget().storeUsage[3], Equals, uint64(3))
+	_, decisions := s.cc.CheckRegionWithDecisions(regions[3])
+	var d *CheckerDecision
+	for _, decision := range decisions {
+		if decision.Checker == "rule" {
+			d = decision
+		}
+	}
+	c.Assert(d, NotNil)
+	c.Assert(d.Kind, Equals, DecisionBlocked)
+	c.Assert(d.Reason, Equals, DiagnosisStoreBudgetShare)
+	diagnosis, err := s.cc.Diagnose(4)
+	c.Assert(err, IsNil)
+	for _, d := range diagnosis.Checkers {
+		if d.Name == "rule" {
+			c.Assert(d.Reason, Equals, DiagnosisStoreBudgetShare)
+		}
+	}
+	for i := uint64(1); i <= 3; i++ {
+		s.cc.opController.RemoveOperator(results[i][0])
+	}
+	s.cc.RefreshStoreBudget()
+	s.cc.FlushWaitingList()
+
+	// The operators fixing the down peers are exempt.
+	s.cluster.AddLeaderRegionWithRange(5, "5", "6", 1, 2, 4)
+	region := s.cluster.GetRegion(5)
+	region = region.Clone(core.WithDownPeers([]*pdpb.PeerStats{{Peer: region.GetStorePeer(4), DownSeconds: 6000}}))
+	s.cluster.PutRegion(region)
+	s.cluster.SetStoreDown(4)
+	results = s.cc.CheckRegions(append(regions, region))
+	c.Assert(results, HasLen, 4)
+	c.Assert(results[4], IsNil)
+	c.Assert(results[5], NotNil)
+
+	// The share is not enforced once disabled, and nothing is counted.
+	s.cluster.SetStoreBudgetShare(0)
+	c.Assert(s.cc.CheckRegions(regions), HasLen, 4)
+	s.cc.RefreshStoreBudget()
+	c.Assert(s.cc.storeUsage, IsNil)
+}
+
+func (s *testCheckerControllerSuite) TestStoreSnapshotLimit(c *C) {
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2)
+	s.cluster.AddLeaderRegionWithRange(2, "a", "", 1, 2)
+	op := operator.NewOperator("test", "test", 2, &metapb.RegionEpoch{}, operator.OpRegion, operator.AddPeer{ToStore: 3, PeerID: 10})
+	c.Assert(op.Start(), IsTrue)
+	s.cc.opController.SetOperator(op)
+
+	s.cluster.GetOpts().SetStoreSnapshotLimit(3, 1)
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 0)
+	c.Assert(s.cc.GetWaitingRegions(), HasLen, 1)
+	diagnosis, err := s.cc.Diagnose(1)
+	c.Assert(err, IsNil)
+	for _, d := range diagnosis.Checkers {
+		if d.Name == "rule" {
+			c.Assert(d.Reason, Equals, DiagnosisStoreSnapshotLimit)
+		}
+	}
+
+	s.cluster.GetOpts().SetStoreSnapshotLimit(3, 2)
+	ops := s.cc.CheckRegion(s.cluster.GetRegion(1))
+	c.Assert(ops, HasLen, 1)
+	c.Assert(ops[0].Kind()&operator.OpReplica, Not(Equals), operator.OpKind(0))
+}
+
+func (s *testCheckerControllerSuite) TestLimitBlockedRegions(c *C) {
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2)
+	s.cluster.AddLeaderRegionWithRange(2, "a", "b", 1, 2)
+	s.cluster.AddLeaderRegionWithRange(3, "b", "", 1, 2)
+	op := operator.NewOperator("test", "test", 3, &metapb.RegionEpoch{}, operator.OpRegion, operator.AddPeer{ToStore: 3, PeerID: 10})
+	c.Assert(op.Start(), IsTrue)
+	s.cc.opController.SetOperator(op)
+	s.cluster.SetReplicaScheduleLimit(0)
+
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 0)
+	c.Assert(s.cc.GetLimitBlockedRegions(), DeepEquals, []uint64{1})
+
+	// The region which is still blocked by the store snapshot limit after
+	// raising the replica schedule limit is not counted.
+	s.cluster.GetOpts().SetStoreSnapshotLimit(3, 1)
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(2)), HasLen, 0)
+	c.Assert(s.cc.GetLimitBlockedRegions(), DeepEquals, []uint64{1})
+	infos := s.cc.GetWaitingRegionInfos()
+	c.Assert(infos, HasLen, 2)
+	for _, info := range infos {
+		if info.RegionID == 2 {
+			c.Assert(info.Reason, Equals, DiagnosisStoreSnapshotLimit)
+		} else {
+			c.Assert(info.Reason, Equals, DiagnosisReplicaScheduleLimit)
+		}
+	}
+
+	// Both regions are fixed once the limits are raised.
+	s.cluster.GetOpts().SetStoreSnapshotLimit(3, 0)
+	s.cluster.SetReplicaScheduleLimit(1)
+	s.cc.opController.RemoveOperator(op)
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(2)), HasLen, 1)
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 1)
+}
+
+func (s *testCheckerControllerSuite) TestCheckerSnapshotRate(c *C) {
+	clock := checker.NewManualClock(time.Now())
+	cc := NewSyncCheckerController(s.cluster, s.cluster.RuleManager, s.cluster.RegionLabeler, NewOperatorController(s.ctx, s.cluster, nil), WithCheckerClock(clock))
+	for i := uint64(1); i <= 3; i++ {
+		s.cluster.AddLeaderRegionWithRange(i, fmt.Sprintf("%d", i), fmt.Sprintf("%d", i+1), 1, 2)
+	}
+
+	// The rate is unlimited by default.
+	for i := uint64(1); i <= 3; i++ {
+		c.Assert(cc.CheckRegion(s.cluster.GetRegion(i)), HasLen, 1)
+	}
+
+	// Only one operator adding a peer is created per second.
+	s.cluster.SetCheckerSnapshotRate(1)
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 1)
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(2)), HasLen, 0)
+	c.Assert(cc.GetWaitingRegions(), HasLen, 1)
+	c.Assert(cc.GetWaitingRegions()[0].Key, Equals, uint64(2))
+	diagnosis, err := cc.Diagnose(3)
+	c.Assert(err, IsNil)
+	for _, d := range diagnosis.Checkers {
+		if d.Name == "rule" {
+			c.Assert(d.Reason, Equals, DiagnosisCheckerSnapshotRate)
+		}
+	}
+
+	clock.Advance(time.Second)
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(2)), HasLen, 1)
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(3)), HasLen, 0)
+}
+
+func (s *testCheckerControllerSuite) TestFlushWaitingList(c *C) {
+	c.Assert(s.cc.FlushWaitingList(), HasLen, 0)
+
+	for i := uint64(1); i <= 3; i++ {
+		s.cc.AddWaitingRegion(s.cluster.AddLeaderRegion(i, 1, 2))
+	}
+	// the backoff doesn't prevent the regions from being flushed.
+	s.cc.AddWaitingRegion(s.cluster.GetRegion(1))
+	ids := s.cc.FlushWaitingList()
+	c.Assert(ids, HasLen, 3)
+	c.Assert(s.cc.GetWaitingRegions(), HasLen, 0)
+
+	// the regions added during the flush are not lost.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := uint64(1); i <= 100; i++ {
+			s.cc.AddWaitingRegion(s.cluster.AddLeaderRegion(i, 1, 2))
+		}
+	}()
+	flushed := make(map[uint64]struct{})
+	for i := 0; i < 10; i++ {
+		for _, id := range s.cc.FlushWaitingList() {
+			flushed[id] = struct{}{}
+		}
+	}
+	wg.Wait()
+	for _, id := range s.cc.FlushWaitingList() {
+		flushed[id] = struct{}{}
+	}
+	c.Assert(flushed, HasLen, 100)
+}
+
+func (s *testCheckerControllerSuite) TestSkipCheckersByLabel(c *C) {
+	s.cluster.AddLeaderRegionWithRange(1, "a", "b", 1, 2)
+	region := s.cluster.GetRegion(1)
+	setLabel := func(key, value string) {
+		err := s.cluster.RegionLabeler.SetLabelRule(&labeler.LabelRule{
+			ID:       "skip",
+			Labels:   []labeler.RegionLabel{{Key: key, Value: value}},
+			RuleType: labeler.KeyRange,
+			Data:     []interface{}{map[string]interface{}{"start_key": "61", "end_key": "62"}},
+		})
+		c.Assert(err, IsNil)
+	}
+
+	setLabel("skip-checkers", "all")
+	c.Assert(s.cc.CheckRegion(region), HasLen, 0)
+	setLabel("skip-checkers", "split, rule")
+	c.Assert(s.cc.CheckRegion(region), HasLen, 0)
+	diagnosis, err := s.cc.Diagnose(1)
+	c.Assert(err, IsNil)
+	for _, d := range diagnosis.Checkers {
+		if d.Name == "rule" {
+			c.Assert(d.Reason, Equals, DiagnosisSkippedByLabel)
+		}
+	}
+	setLabel("skip-checkers", "merge")
+	c.Assert(s.cc.CheckRegion(region), HasLen, 1)
+
+	// the label name is configurable.
+	s.cluster.SetSkipCheckersLabel("external")
+	setLabel("skip-checkers", "all")
+	c.Assert(s.cc.CheckRegion(region), HasLen, 1)
+	setLabel("external", "all")
+	c.Assert(s.cc.CheckRegion(region), HasLen, 0)
+
+	// the joint-state checker still runs.
+	joint := core.NewRegionInfo(&metapb.Region{
+		Id:       1,
+		StartKey: []byte("a"),
+		EndKey:   []byte("b"),
+		Peers: []*metapb.Peer{
+			{Id: 101, StoreId: 1, Role: metapb.PeerRole_Voter},
+			{Id: 102, StoreId: 2, Role: metapb.PeerRole_DemotingVoter},
+			{Id: 103, StoreId: 3, Role: metapb.PeerRole_IncomingVoter},
+		},
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+	}, &metapb.Peer{Id: 101, StoreId: 1})
+	s.cluster.PutRegion(joint)
+	ops := s.cc.CheckRegion(joint)
+	c.Assert(ops, HasLen, 1)
+	c.Assert(ops[0].Desc(), Equals, "leave-joint-state")
+}
+
+func (s *testCheckerControllerSuite) TestSkipMergeRunByLabel(c *C) {
+	s.cluster.SetSplitMergeInterval(0)
+	s.cluster.SetMaxMergeFanin(3)
+	keys := []string{"", "a", "b", "c", "d", "e", ""}
+	for i := 0; i < len(keys)-1; i++ {
+		id := uint64(i + 1)
+		s.cluster.AddLeaderRegionWithRange(id, keys[i], keys[i+1], 1, 2, 3)
+		s.cluster.PutRegion(s.cluster.GetRegion(id).Clone(core.SetApproximateSize(1), core.SetApproximateKeys(1)))
+	}
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 6)
+
+	// The run is cut before the pair touching a region which skips the merge
+	// checker.
+	err := s.cluster.RegionLabeler.SetLabelRule(&labeler.LabelRule{
+		ID:       "skip",
+		Labels:   []labeler.RegionLabel{{Key: "skip-checkers", Value: "merge"}},
+		RuleType: labeler.KeyRange,
+		Data:     []interface{}{map[string]interface{}{"start_key": "62", "end_key": "64"}},
+	})
+	c.Assert(err, IsNil)
+	ops := s.cc.CheckRegion(s.cluster.GetRegion(1))
+	c.Assert(ops, HasLen, 2)
+	c.Assert(ops[0].RegionID(), Equals, uint64(1))
+	c.Assert(ops[1].RegionID(), Equals, uint64(2))
+}
+
+func (s *testCheckerControllerSuite) TestReadOnlyCluster(c *C) {
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2)
+	region := s.cluster.GetRegion(1)
+	c.Assert(s.cc.CheckRegion(region), HasLen, 1)
+
+	s.cluster.SetEnableReadOnlyCluster(true)
+	c.Assert(s.cc.CheckRegion(region), HasLen, 0)
+	c.Assert(s.cc.CheckRegions([]*core.RegionInfo{region}), HasLen, 0)
+	c.Assert(s.cc.CheckRegionBatch(region), HasLen, 0)
+	s.cluster.SetCheckRegionPolicy(config.CheckRegionMostUrgent)
+	c.Assert(s.cc.CheckRegion(region), HasLen, 0)
+	s.cluster.SetCheckRegionPolicy(config.CheckRegionFirstChecker)
+	// The dropped operators are not counted as produced.
+	c.Assert(s.cc.GetCheckerStats()["rule-checker"].Produced, Equals, uint64(1))
+
+	joint := core.NewRegionInfo(&metapb.Region{
+		Id:       2,
+		StartKey: []byte("a"),
+		EndKey:   []byte(""),
+		Peers: []*metapb.Peer{
+			{Id: 101, StoreId: 1, Role: metapb.PeerRole_Voter},
+			{Id: 102, StoreId: 2, Role: metapb.PeerRole_DemotingVoter},
+			{Id: 103, StoreId: 3, Role: metapb.PeerRole_IncomingVoter},
+		},
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+	}, &metapb.Peer{Id: 101, StoreId: 1})
+	s.cluster.PutRegion(joint)
+	c.Assert(s.cc.CheckRegion(joint), HasLen, 0)
+	// Leaving the joint state is allowed by the sub-flag.
+	s.cluster.SetReadOnlyAllowJointState(true)
+	ops := s.cc.CheckRegion(joint)
+	c.Assert(ops, HasLen, 1)
+	c.Assert(ops[0].Desc(), Equals, "leave-joint-state")
+	c.Assert(ops[0].GetPriorityLevel(), Equals, core.HighPriority)
+	c.Assert(s.cc.CheckRegion(region), HasLen, 0)
+
+	// It takes effect as soon as the flag is toggled.
+	s.cluster.SetEnableReadOnlyCluster(false)
+	c.Assert(s.cc.CheckRegion(region), HasLen, 1)
+}
+
+func (s *testCheckerControllerSuite) TestSyncCheckerController(c *C) {
+	s.cluster.SetSplitMergeInterval(0)
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2, 3)
+	s.cluster.AddLeaderRegionWithRange(2, "a", "", 1, 2, 3)
+	for _, id := range []uint64{1, 2} {
+		s.cluster.PutRegion(s.cluster.GetRegion(id).Clone(core.SetApproximateSize(1), core.SetApproximateKeys(1)))
+	}
+	// the sync controller doesn't depend on any context.
+	s.cancel()
+	cc := NewSyncCheckerController(s.cluster, s.cluster.RuleManager, s.cluster.RegionLabeler, NewOperatorController(context.Background(), s.cluster, nil))
+	region := s.cluster.GetRegion(1)
+	ops := cc.CheckRegion(region)
+	c.Assert(ops, HasLen, 2)
+	for i := 0; i < 10; i++ {
+		again := cc.CheckRegion(region)
+		c.Assert(again, HasLen, len(ops))
+		for j := range ops {
+			c.Assert(again[j].Desc(), Equals, ops[j].Desc())
+			c.Assert(again[j].RegionID(), Equals, ops[j].RegionID())
+		}
+	}
+}
+
+func (s *testCheckerControllerSuite) TestOperatorUrgency(c *C) {
+	s.cluster.AddLeaderRegion(1, 1, 2, 3)
+	region := s.cluster.GetRegion(1)
+	newOp := func(steps ...operator.OpStep) []*operator.Operator {
+		return []*operator.Operator{operator.NewOperator("test", "test", 1, region.GetRegionEpoch(), operator.OpRegion, steps...)}
+	}
+	addPeer := operator.AddLearner{ToStore: 4, PeerID: 4}
+	removePeer := operator.RemovePeer{FromStore: 3, PeerID: 3}
+
+	c.Assert(operatorUrgency(CheckerJointState, region, nil), Equals, UrgencyJointState)
+	c.Assert(operatorUrgency(CheckerMerge, region, nil), Equals, UrgencyMerge)
+	emptyMerge := []*operator.Operator{operator.NewOperator(checker.EmptyRegionMergeDesc, "test", 1, region.GetRegionEpoch(), operator.OpMerge)}
+	c.Assert(operatorUrgency(CheckerMerge, region, emptyMerge), Equals, UrgencyEmptyRegionMerge)
+	c.Assert(operatorUrgency(CheckerRule, region, newOp(addPeer)), Equals, UrgencyUnderReplicated)
+	c.Assert(operatorUrgency(CheckerRule, region, newOp(addPeer, removePeer)), Equals, UrgencyNormal)
+	c.Assert(operatorUrgency(CheckerSplit, region, newOp()), Equals, UrgencyNormal)
+	down := region.Clone(core.WithDownPeers([]*pdpb.PeerStats{{Peer: region.GetStorePeer(3), DownSeconds: 6000}}))
+	c.Assert(operatorUrgency(CheckerRule, down, newOp(addPeer, removePeer)), Equals, UrgencyDownPeer)
+	c.Assert(operatorUrgency(CheckerDuplicatePeer, region, nil), Equals, UrgencyDuplicatePeer)
+	// the operator in flight is told by its steps.
+	c.Assert(inFlightUrgency(region, newOp(operator.RemovePeer{FromStore: 3, PeerID: 3, IsDuplicate: true})[0]), Equals, UrgencyDuplicatePeer)
+
+	c.Assert(UrgencyJointState > UrgencyDuplicatePeer, IsTrue)
+	c.Assert(UrgencyDuplicatePeer > UrgencyDownPeer, IsTrue)
+	c.Assert(UrgencyDownPeer > UrgencyUnderReplicated, IsTrue)
+	c.Assert(UrgencyUnderReplicated > UrgencyEmptyRegionMerge, IsTrue)
+	c.Assert(UrgencyEmptyRegionMerge > UrgencyMerge, IsTrue)
+
+	c.Assert(UrgencyJointState.PriorityLevel(), Equals, core.HighPriority)
+	c.Assert(UrgencyDuplicatePeer.PriorityLevel(), Equals, core.HighPriority)
+	c.Assert(UrgencyDownPeer.PriorityLevel(), Equals, core.HighPriority)
+	c.Assert(UrgencyUnderReplicated.PriorityLevel(), Equals, core.NormalPriority)
+	c.Assert(UrgencyNormal.PriorityLevel(), Equals, core.NormalPriority)
+	c.Assert(UrgencyEmptyRegionMerge.PriorityLevel(), Equals, core.NormalPriority)
+	c.Assert(UrgencyMerge.PriorityLevel(), Equals, core.LowPriority)
+}
+
+func (s *testCheckerControllerSuite) TestCheckRegionMostUrgent(c *C) {
+	s.cluster.AddLeaderStore(4, 1)
+	s.cluster.SetRegionSplitSize(10)
+	s.cluster.AddLeaderRegion(1, 1, 2, 3)
+	s.cluster.SetStoreDown(3)
+	region := s.cluster.GetRegion(1)
+	region = region.Clone(
+		core.SetApproximateSize(30),
+		core.WithDownPeers([]*pdpb.PeerStats{{Peer: region.GetStorePeer(3), DownSeconds: 6000}}),
+	)
+	s.cluster.PutRegion(region)
+
+	// the split checker comes first.
+	ops := s.cc.CheckRegion(region)
+	c.Assert(ops, HasLen, 1)
+	c.Assert(ops[0].Kind()&operator.OpSplit, Not(Equals), operator.OpKind(0))
+
+	// fixing the down peer is more urgent.
+	s.cluster.SetCheckRegionPolicy(config.CheckRegionMostUrgent)
+	ops = s.cc.CheckRegion(region)
+	c.Assert(ops, HasLen, 1)
+	c.Assert(ops[0].Desc(), Equals, "replace-rule-down-peer")
+}
+
+func (s *testCheckerControllerSuite) TestPreemptInFlight(c *C) {
+	s.cluster.SetSplitMergeInterval(0)
+	s.cluster.AddLeaderStore(4, 1)
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2, 3)
+	s.cluster.AddLeaderRegionWithRange(2, "a", "", 1, 2, 3)
+	for _, id := range []uint64{1, 2} {
+		s.cluster.PutRegion(s.cluster.GetRegion(id).Clone(core.SetApproximateSize(1), core.SetApproximateKeys(1)))
+	}
+	stream := hbstream.NewTestHeartbeatStreams(s.ctx, s.cluster.ID, s.cluster, false /* no need to run */)
+	oc := NewOperatorController(s.ctx, s.cluster, stream)
+	cc := NewSyncCheckerController(s.cluster, s.cluster.RuleManager, s.cluster.RegionLabeler, oc)
+
+	merges := cc.CheckRegion(s.cluster.GetRegion(1))
+	c.Assert(merges, HasLen, 2)
+	for _, op := range merges {
+		c.Assert(op.GetPriorityLevel(), Equals, core.LowPriority)
+	}
+	c.Assert(oc.AddOperator(merges...), IsTrue)
+	// The operators of the same urgency don't preempt the one in flight.
+	c.Assert(cc.MayPreempt(s.cluster.GetRegion(1), merges[0]), IsFalse)
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 0)
+	c.Assert(cc.CheckRegionBatch(s.cluster.GetRegion(1)), HasLen, 0)
+
+	// Fixing the down peer is more urgent than merging.
+	s.cluster.SetStoreDown(3)
+	region := s.cluster.GetRegion(1)
+	region = region.Clone(
+		core.SetApproximateSize(30),
+		core.WithDownPeers([]*pdpb.PeerStats{{Peer: region.GetStorePeer(3), DownSeconds: 6000}}),
+	)
+	s.cluster.PutRegion(region)
+	c.Assert(cc.MayPreempt(region, merges[0]), IsTrue)
+	ops := cc.CheckRegion(region)
+	c.Assert(ops, HasLen, 1)
+	c.Assert(ops[0].Desc(), Equals, "replace-rule-down-peer")
+	c.Assert(ops[0].GetPriorityLevel(), Equals, core.HighPriority)
+	c.Assert(ops[0].ID(), Not(Equals), merges[0].ID())
+	// Both halves of the merge are preempted, but they are canceled only when
+	// the new operator is added.
+	c.Assert(ops[0].Preempts(), DeepEquals, merges)
+	for _, op := range merges {
+		c.Assert(op.Status(), Equals, operator.STARTED)
+	}
+
+	// The merge keeps running if the new operator is rejected by the store limit.
+	s.cluster.SetStoreLimit(4, storelimit.AddPeer, 60)
+	oc.getOrCreateStoreLimit(4, storelimit.AddPeer).Take(storelimit.RegionInfluence[storelimit.AddPeer])
+	c.Assert(oc.ExceedStoreLimit(ops...), IsTrue)
+	c.Assert(oc.AddWaitingOperator(ops...), Equals, 1)
+	c.Assert(ops[0].Status(), Equals, operator.CANCELED)
+	c.Assert(oc.GetOperator(1), Equals, merges[0])
+	c.Assert(oc.GetOperator(2), Equals, merges[1])
+	for _, op := range merges {
+		c.Assert(op.Status(), Equals, operator.STARTED)
+	}
+
+	// Both halves of the merge are canceled once the new operator is added.
+	// The new store limit takes effect when it is checked.
+	s.cluster.SetStoreLimit(4, storelimit.AddPeer, 120)
+	c.Assert(oc.ExceedStoreLimit(ops...), IsFalse)
+	ops = cc.CheckRegion(region)
+	c.Assert(ops, HasLen, 1)
+	c.Assert(oc.AddWaitingOperator(ops...), Equals, 1)
+	c.Assert(oc.GetOperator(1), Equals, ops[0])
+	c.Assert(oc.GetOperator(2), IsNil)
+	for _, op := range merges {
+		c.Assert(op.Status(), Equals, operator.CANCELED)
+	}
+
+	c.Assert(cc.MayPreempt(region, ops[0]), IsFalse)
+	c.Assert(cc.CheckRegion(region), HasLen, 0)
+}
+
+func (s *testCheckerControllerSuite) TestMaxSplitsPerScan(c *C) {
+	s.cluster.SetRegionSplitSize(10)
+	s.cluster.SetMaxSplitsPerScan(2)
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2, 3)
+	s.cluster.AddLeaderRegionWithRange(2, "a", "b", 1, 2, 3)
+	s.cluster.AddLeaderRegionWithRange(3, "b", "", 1, 2, 3)
+	for _, id := range []uint64{1, 2, 3} {
+		s.cluster.PutRegion(s.cluster.GetRegion(id).Clone(core.SetApproximateSize(30)))
+	}
+	checkSplit := func(id uint64, count int) {
+		ops := s.cc.CheckRegion(s.cluster.GetRegion(id))
+		c.Assert(ops, HasLen, count)
+		if count > 0 {
+			c.Assert(ops[0].Kind()&operator.OpSplit, Not(Equals), operator.OpKind(0))
+		}
+	}
+	checkSplit(1, 1)
+	checkSplit(2, 1)
+	// The split of the third region is deferred to the waiting list.
+	checkSplit(3, 0)
+	c.Assert(s.cc.GetWaitingRegions(), HasLen, 1)
+	c.Assert(s.cc.GetWaitingRegions()[0].Key, Equals, uint64(3))
+	diagnosis, err := s.cc.Diagnose(3)
+	c.Assert(err, IsNil)
+	for _, d := range diagnosis.Checkers {
+		if d.Name == "split" {
+			c.Assert(d.Reason, Equals, DiagnosisMaxSplitsPerScan)
+		}
+	}
+
+	// The budget is refilled when a new scan starts.
+	s.cc.ResetSplitBudget()
+	checkSplit(3, 1)
+
+	// There is no limit if it is 0.
+	s.cluster.SetMaxSplitsPerScan(0)
+	checkSplit(1, 1)
+	checkSplit(2, 1)
+	checkSplit(3, 1)
+}
+
+func (s *testCheckerControllerSuite) TestCheckerClock(c *C) {
+	clock := checker.NewManualClock(time.Now())
+	s.cluster.SetSplitMergeInterval(time.Hour)
+	s.cluster.SetMaxWaitingRegionBackoff(time.Hour)
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2, 3)
+	s.cluster.AddLeaderRegionWithRange(2, "a", "", 1, 2, 3)
+	for _, id := range []uint64{1, 2} {
+		s.cluster.PutRegion(s.cluster.GetRegion(id).Clone(core.SetApproximateSize(1), core.SetApproximateKeys(1)))
+	}
+	cc := NewSyncCheckerController(s.cluster, s.cluster.RuleManager, s.cluster.RegionLabeler, NewOperatorController(s.ctx, s.cluster, nil), WithCheckerClock(clock))
+
+	// The regions are not merged within the split merge interval after start.
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 0)
+	clock.Advance(time.Hour)
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 2)
+
+	// The regions are not merged within the split merge interval after split.
+	cc.GetMergeChecker().RecordRegionSplit([]uint64{1})
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 0)
+	clock.Advance(time.Hour)
+	c.Assert(cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 2)
+
+	// The waiting region is ready once the backoff passes.
+	region := s.cluster.GetRegion(1)
+	cc.AddWaitingRegion(region)
+	cc.AddWaitingRegion(region)
+	c.Assert(cc.GetReadyWaitingRegions(), HasLen, 0)
+	clock.Advance(s.cluster.GetOpts().GetPatrolRegionInterval())
+	c.Assert(cc.GetReadyWaitingRegions(), HasLen, 1)
+
+	// The pause follows the clock as well.
+	cc.PauseAll(60)
+	c.Assert(cc.CheckRegion(region), HasLen, 0)
+	clock.Advance(time.Minute)
+	c.Assert(cc.CheckRegion(region), HasLen, 2)
+}
+
+func (s *testCheckerControllerSuite) TestPlacementRulesRamp(c *C) {
+	clock := checker.NewManualClock(time.Now())
+	s.cluster.SetEnablePlacementRules(false)
+	s.cluster.SetReplicaScheduleLimit(4)
+	s.cluster.SetPlacementRulesRampDuration(10 * time.Minute)
+	var regions []*core.RegionInfo
+	for i := uint64(1); i <= 4; i++ {
+		s.cluster.AddLeaderRegionWithRange(i, fmt.Sprintf("%d", i), fmt.Sprintf("%d", i+1), 1, 2)
+		regions = append(regions, s.cluster.GetRegion(i))
+	}
+	cc := NewSyncCheckerController(s.cluster, s.cluster.RuleManager, s.cluster.RegionLabeler, NewOperatorController(s.ctx, s.cluster, nil), WithCheckerClock(clock))
+	c.Assert(cc.CheckRegions(regions), HasLen, 4)
+	c.Assert(cc.PlacementRulesEnabledAt().IsZero(), IsTrue)
+
+	// The rule checker takes over gradually after the placement rules are enabled.
+	s.cluster.SetEnablePlacementRules(true)
+	c.Assert(cc.CheckRegions(regions), HasLen, 0)
+	c.Assert(cc.PlacementRulesEnabledAt(), Equals, clock.Now())
+	_, decisions := cc.CheckRegionWithDecisions(regions[0])
+	for _, d := range decisions {
+		if d.Checker == "rule" {
+			c.Assert(d.Reason, Equals, DiagnosisPlacementRulesRamp)
+		}
+	}
+	clock.Advance(5 * time.Minute)
+	c.Assert(cc.GetEffectiveLimits().ReplicaScheduleLimit, Equals, uint64(4))
+	c.Assert(cc.GetEffectiveLimits().RuleCheckerReplicaLimit, Equals, uint64(2))
+	c.Assert(cc.CheckRegions(regions), HasLen, 2)
+	clock.Advance(5 * time.Minute)
+	c.Assert(cc.CheckRegions(regions), HasLen, 4)
+
+	// It ramps up again after the placement rules are enabled again.
+	s.cluster.SetEnablePlacementRules(false)
+	c.Assert(cc.CheckRegions(regions), HasLen, 4)
+	c.Assert(cc.PlacementRulesEnabledAt().IsZero(), IsTrue)
+	s.cluster.SetEnablePlacementRules(true)
+	c.Assert(cc.CheckRegions(regions), HasLen, 0)
+
+	// It takes over at once without the ramp duration.
+	s.cluster.SetPlacementRulesRampDuration(0)
+	c.Assert(cc.CheckRegions(regions), HasLen, 4)
+}
+
+func (s *testCheckerControllerSuite) TestSetCheckerEnabled(c *C) {
+	s.cluster.SetSplitMergeInterval(0)
+	s.cluster.AddLeaderRegionWithRange(1, "", "a", 1, 2, 3)
+	s.cluster.AddLeaderRegionWithRange(2, "a", "", 1, 2, 3)
+	for _, id := range []uint64{1, 2} {
+		s.cluster.PutRegion(s.cluster.GetRegion(id).Clone(core.SetApproximateSize(1), core.SetApproximateKeys(1)))
+	}
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 2)
+
+	c.Assert(s.cc.SetCheckerEnabled("merge", false), IsNil)
+	c.Assert(s.cluster.GetOpts().IsCheckerDisabled("merge"), IsTrue)
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 0)
+	for _, st := range s.cc.ListCheckers() {
+		if st.Name == "merge" {
+			c.Assert(st.Enabled, IsFalse)
+			c.Assert(st.Paused, IsFalse)
+		}
+	}
+
+	// The joint-state and priority checkers cannot be disabled.
+	c.Assert(s.cc.SetCheckerEnabled("joint-state", false), NotNil)
+	c.Assert(s.cc.SetCheckerEnabled("priority", false), NotNil)
+	c.Assert(s.cc.SetCheckerEnabled("unknown", false), NotNil)
+
+	c.Assert(s.cc.SetCheckerEnabled("merge", true), IsNil)
+	c.Assert(s.cluster.GetOpts().IsCheckerDisabled("merge"), IsFalse)
+	c.Assert(s.cc.CheckRegion(s.cluster.GetRegion(1)), HasLen, 2)
+}
+
+func (s *testCheckerControllerSuite) TestExportImportState(c *C) {
+	clock := checker.NewManualClock(time.Now())
+	cc := NewSyncCheckerController(s.cluster, s.cluster.RuleManager, s.cluster.RegionLabeler, NewOperatorController(s.ctx, s.cluster, nil), WithCheckerClock(clock))
+	stateOf := func(state *CheckersState, name string) CheckerState {
+		for _, st := range state.Checkers {
+			if st.Name == name {
+				return st
+			}
+		}
+		return CheckerState{}
+	}
+
+	// Nothing is paused or disabled at first.
+	initial := cc.ExportState()
+	c.Assert(initial.AllPausedUntil, Equals, int64(0))
+	c.Assert(initial.Checkers, HasLen, len(checkerTypes))
+	for _, st := range initial.Checkers {
+		c.Assert(st.Enabled, IsTrue)
+		c.Assert(st.PausedUntil, Equals, int64(0))
+	}
+
+	p, err := cc.GetPauseController("rule")
+	c.Assert(err, IsNil)
+	p.PauseOrResume(60)
+	c.Assert(cc.SetCheckerEnabled("merge", false), IsNil)
+	saved := cc.ExportState()
+	c.Assert(stateOf(saved, "rule").PausedUntil, Equals, clock.Now().Unix()+60)
+	c.Assert(stateOf(saved, "merge").Enabled, IsFalse)
+	data, err := json.Marshal(saved)
+	c.Assert(err, IsNil)
+
+	// A nested script changes the states and restores its own prior state.
+	cc.PauseAll(300)
+	c.Assert(cc.SetCheckerEnabled("merge", true), IsNil)
+	c.Assert(cc.SetCheckerEnabled("split", false), IsNil)
+	var restored CheckersState
+	c.Assert(json.Unmarshal(data, &restored), IsNil)
+	c.Assert(cc.ImportState(&restored), IsNil)
+	c.Assert(cc.ExportState(), DeepEquals, saved)
+	c.Assert(cc.GetPausedCheckers(), DeepEquals, []string{"rule"})
+
+	// The pause expired since is taken as resumed.
+	clock.Advance(2 * time.Minute)
+	c.Assert(cc.ImportState(&restored), IsNil)
+	c.Assert(stateOf(cc.ExportState(), "rule").PausedUntil, Equals, int64(0))
+	c.Assert(cc.GetPausedCheckers(), HasLen, 0)
+	c.Assert(cc.ImportState(initial), IsNil)
+	c.Assert(s.cluster.GetOpts().IsCheckerDisabled("merge"), IsFalse)
+
+	// Nothing is changed if any state is invalid.
+	c.Assert(cc.ImportState(&CheckersState{Checkers: []CheckerState{{Name: "merge"}, {Name: "unknown", Enabled: true}}}), NotNil)
+	c.Assert(cc.ImportState(&CheckersState{Checkers: []CheckerState{{Name: "merge"}, {Name: "joint-state"}}}), NotNil)
+	c.Assert(s.cluster.GetOpts().IsCheckerDisabled("merge"), IsFalse)
+}
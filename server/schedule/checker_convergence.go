@@ -0,0 +1,96 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"sync"
+
+	"github.com/tikv/pd/server/schedule/opt"
+)
+
+// convergenceStats tracks the regions which needed action the last time they
+// were checked, that is, any checker created operators for them, whether or
+// not the operators were blocked, or they had an operator pending. The rest of
+// the regions are taken as clean, so the ratio of the clean regions tells how
+// far the cluster is from converging, regardless of which checker is at work.
+type convergenceStats struct {
+	mu      sync.Mutex
+	regions map[uint64]struct{}
+}
+
+func newConvergenceStats() *convergenceStats {
+	return &convergenceStats{regions: make(map[uint64]struct{})}
+}
+
+// needsAction returns true if any checker created operators for the region,
+// or failed to.
+func needsAction(decisions []*CheckerDecision) bool {
+	for _, d := range decisions {
+		switch d.Kind {
+		case DecisionNeedsOperator, DecisionError:
+			return true
+		case DecisionBlocked:
+			if len(d.Operators) > 0 || d.Reason == DiagnosisAllPeersDown {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// observe records whether the region needed action when it was checked.
+func (s *convergenceStats) observe(cluster opt.Cluster, regionID uint64, action bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if action {
+		s.regions[regionID] = struct{}{}
+	} else {
+		delete(s.regions, regionID)
+	}
+	checkerConvergenceGauge.Set(s.ratioLocked(cluster))
+}
+
+// prune drops the regions which no longer exist.
+func (s *convergenceStats) prune(cluster opt.Cluster) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := range s.regions {
+		if cluster.GetRegion(id) == nil {
+			delete(s.regions, id)
+		}
+	}
+	checkerConvergenceGauge.Set(s.ratioLocked(cluster))
+}
+
+// ratio returns the ratio of the clean regions to all the regions of the
+// cluster, which is 1 if there is no region.
+func (s *convergenceStats) ratio(cluster opt.Cluster) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ratioLocked(cluster)
+}
+
+func (s *convergenceStats) ratioLocked(cluster opt.Cluster) float64 {
+	total := cluster.GetRegionCount()
+	if total == 0 {
+		return 1
+	}
+	// The regions which are gone may be counted until they are pruned.
+	clean := total - len(s.regions)
+	if clean < 0 {
+		clean = 0
+	}
+	return float64(clean) / float64(total)
+}
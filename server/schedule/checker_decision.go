@@ -0,0 +1,124 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/operator"
+	"go.uber.org/zap"
+)
+
+// DecisionKind is the kind of what a checker decides about a region.
+type DecisionKind int
+
+const (
+	// DecisionOK means the region needs nothing from the checker.
+	DecisionOK DecisionKind = iota
+	// DecisionNeedsOperator means the checker creates operators for the region.
+	DecisionNeedsOperator
+	// DecisionBlocked means the checker doesn't check the region, or the
+	// operators it creates are dropped.
+	DecisionBlocked
+	// DecisionError means the checker fails to create the operators the region
+	// needs.
+	DecisionError
+)
+
+var decisionKindNames = map[DecisionKind]string{
+	DecisionOK:            "ok",
+	DecisionNeedsOperator: "needs-operator",
+	DecisionBlocked:       "blocked",
+	DecisionError:         "error",
+}
+
+func (k DecisionKind) String() string {
+	if name, ok := decisionKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// CheckerDecision is what a checker decides about a region in a check.
+type CheckerDecision struct {
+	// Checker is the name of the checker.
+	Checker string
+	Kind    DecisionKind
+	// Operators are the operators created by the checker. They are kept when
+	// the decision is blocked after they are created.
+	Operators []*operator.Operator
+	// Reason is why the decision is blocked, which is one of the reasons of
+	// the diagnosis.
+	Reason string
+	// Err is why the checker fails to create the operators.
+	Err error
+	// Urgency is how urgent the operators are. It is propagated to the
+	// operators as their priority level when they are produced.
+	Urgency OperatorUrgency
+	// Preempted are the less urgent operators in flight which the operators
+	// preempt. They are canceled only when the operators are added.
+	Preempted []*operator.Operator
+
+	checker     CheckerType
+	checkerType string
+}
+
+func decisionOK(t CheckerType) *CheckerDecision {
+	return &CheckerDecision{Checker: t.String(), Kind: DecisionOK, checker: t}
+}
+
+func decisionNeedsOperator(t CheckerType, checkerType string, ops []*operator.Operator) *CheckerDecision {
+	return &CheckerDecision{Checker: t.String(), Kind: DecisionNeedsOperator, Operators: ops, checker: t, checkerType: checkerType}
+}
+
+func decisionBlocked(t CheckerType, reason string) *CheckerDecision {
+	return &CheckerDecision{Checker: t.String(), Kind: DecisionBlocked, Reason: reason, checker: t}
+}
+
+func decisionError(t CheckerType, checkerType string, err error) *CheckerDecision {
+	return &CheckerDecision{Checker: t.String(), Kind: DecisionError, Err: err, checker: t, checkerType: checkerType}
+}
+
+// block turns the decision into a blocked one for the reason.
+func (d *CheckerDecision) block(reason string) {
+	d.Kind = DecisionBlocked
+	d.Reason = reason
+}
+
+// CheckRegionWithDecisions is like CheckRegion, but it also returns what each
+// checker which takes part in the check decides about the region, in the order
+// they are called. The checkers which don't apply to the region under the
+// current configuration are left out.
+func (c *CheckerController) CheckRegionWithDecisions(region *core.RegionInfo) ([]*operator.Operator, []*CheckerDecision) {
+	if c.pauseAll.IsPaused() {
+		return nil, nil
+	}
+	return c.checkRegion(region, c.newCheckBudget())
+}
+
+// recordDecisions counts the decisions of the checkers, and logs the errors
+// which are otherwise swallowed by the checkers.
+func (c *CheckerController) recordDecisions(region *core.RegionInfo, decisions []*CheckerDecision) {
+	for _, d := range decisions {
+		checkerDecisionCounter.WithLabelValues(d.Checker, d.Kind.String()).Inc()
+		if d.Kind == DecisionError {
+			log.Warn("checker fails to create operator",
+				zap.Uint64("region-id", region.GetID()),
+				zap.String("checker", d.Checker),
+				errs.ZapError(d.Err))
+		}
+	}
+}
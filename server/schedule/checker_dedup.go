@@ -0,0 +1,118 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+// recentOperatorsGCThreshold is the number of the recorded operators above
+// which the expired ones are removed when recording new ones.
+const recentOperatorsGCThreshold = DefaultCacheSize
+
+// operatorSignature tells apart the operators which do different things to a
+// region. The operators of the same signature are taken as the same even if
+// they are created by different checks.
+type operatorSignature struct {
+	regionID uint64
+	kind     operator.OpKind
+	// target is the store the operator adds a peer to or transfers the leader
+	// to, the store it removes a peer from if it adds nothing, or the region
+	// it merges into.
+	target uint64
+}
+
+func newOperatorSignature(op *operator.Operator) operatorSignature {
+	return operatorSignature{regionID: op.RegionID(), kind: op.Kind(), target: operatorTarget(op)}
+}
+
+func operatorTarget(op *operator.Operator) uint64 {
+	var removed uint64
+	for i := 0; i < op.Len(); i++ {
+		switch step := op.Step(i).(type) {
+		case operator.AddPeer:
+			return step.ToStore
+		case operator.AddLearner:
+			return step.ToStore
+		case operator.TransferLeader:
+			return step.ToStore
+		case operator.MergeRegion:
+			return step.ToRegion.GetId()
+		case operator.RemovePeer:
+			if removed == 0 {
+				removed = step.FromStore
+			}
+		}
+	}
+	return removed
+}
+
+// recentOperators remembers the operators produced by the checkers until
+// they expire, so that an operator which is the same as the one in flight is
+// not produced again on the next scan of the regions just to be rejected by
+// the operator controller.
+type recentOperators struct {
+	mu      sync.Mutex
+	expires map[operatorSignature]time.Time
+}
+
+func newRecentOperators() *recentOperators {
+	return &recentOperators{expires: make(map[operatorSignature]time.Time)}
+}
+
+// record remembers the operators until now+ttl. Nothing is recorded if ttl is
+// not positive.
+func (r *recentOperators) record(ops []*operator.Operator, now time.Time, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.expires) >= recentOperatorsGCThreshold {
+		for sig, expire := range r.expires {
+			if !now.Before(expire) {
+				delete(r.expires, sig)
+			}
+		}
+	}
+	for _, op := range ops {
+		r.expires[newOperatorSignature(op)] = now.Add(ttl)
+	}
+}
+
+// contains returns true if all the operators are the same as the recent ones
+// which have not expired yet.
+func (r *recentOperators) contains(ops []*operator.Operator, now time.Time) bool {
+	if len(ops) == 0 {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, op := range ops {
+		sig := newOperatorSignature(op)
+		expire, ok := r.expires[sig]
+		if !ok {
+			return false
+		}
+		if !now.Before(expire) {
+			delete(r.expires, sig)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,186 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"github.com/tikv/pd/pkg/cache"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/checker"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/placement"
+)
+
+// The reasons why a checker doesn't create operators or why its operators
+// are blocked when diagnosing or checking a region.
+const (
+	DiagnosisDisabled             = "disabled"
+	DiagnosisPaused               = "paused"
+	DiagnosisSkippedByLabel       = "skipped-by-label"
+	DiagnosisNoOperator           = "no-operator"
+	DiagnosisReplicaScheduleLimit = "exceed-replica-schedule-limit"
+	DiagnosisMergeScheduleLimit   = "exceed-merge-schedule-limit"
+	DiagnosisStoreSnapshotLimit   = "exceed-store-snapshot-limit"
+	DiagnosisCheckerSnapshotRate  = "exceed-checker-snapshot-rate"
+	DiagnosisMaxSplitsPerScan     = "exceed-max-splits-per-scan"
+	DiagnosisPriorityReserved     = "reserved-for-priority-ranges"
+	DiagnosisReadOnly             = "read-only"
+	DiagnosisVetoed               = "vetoed"
+	DiagnosisOperatorInFlight     = "operator-in-flight"
+	DiagnosisLessUrgent           = "less-urgent"
+	DiagnosisConflict             = "conflict"
+	DiagnosisRecentlyProduced     = "recently-produced"
+	DiagnosisPlacementRulesRamp   = "placement-rules-ramping-up"
+	DiagnosisLowChurn             = "low-churn-window"
+	DiagnosisAllPeersDown         = "all-peers-down"
+	DiagnosisStoreBudgetShare     = "exceed-store-budget-share"
+)
+
+// CheckerDiagnosis describes what a checker thinks of a region.
+type CheckerDiagnosis struct {
+	Name string `json:"name"`
+	// Operators are the operators the checker would create.
+	Operators []string `json:"operators,omitempty"`
+	// Reason is why the checker creates no operator, or why its
+	// operators would be blocked.
+	Reason string `json:"reason,omitempty"`
+}
+
+// RegionDiagnosis is the result of diagnosing a region with all checkers.
+type RegionDiagnosis struct {
+	RegionID uint64 `json:"region-id"`
+	// Fit and Violations are only set when placement rules are enabled.
+	Fit        *placement.RegionFit     `json:"fit,omitempty"`
+	Violations []*checker.RuleViolation `json:"violations,omitempty"`
+	Checkers   []CheckerDiagnosis       `json:"checkers"`
+}
+
+// Diagnose runs all checkers against the region in the checker order and
+// reports what each of them would do. Unlike CheckRegion, it never changes the
+// scheduling state: no operator is added, and the waiting list, the priority
+// queue and the checker stats are left untouched.
+func (c *CheckerController) Diagnose(regionID uint64) (*RegionDiagnosis, error) {
+	region := c.cluster.GetRegion(regionID)
+	if region == nil {
+		return nil, errs.ErrCheckerRegionNotFound.FastGenByArgs(regionID)
+	}
+	diagnosis := &RegionDiagnosis{RegionID: regionID}
+	if c.opts.IsPlacementRulesEnabled() {
+		diagnosis.Fit, diagnosis.Violations = c.ruleChecker.CheckDryRun(region)
+	}
+	for _, t := range c.checkerOrder() {
+		diagnosis.Checkers = append(diagnosis.Checkers, c.diagnoseBy(t, region))
+	}
+	return diagnosis, nil
+}
+
+func (c *CheckerController) diagnoseBy(t CheckerType, region *core.RegionInfo) CheckerDiagnosis {
+	diagnosis := CheckerDiagnosis{Name: t.String()}
+	if !c.isCheckerEnabled(t) {
+		diagnosis.Reason = DiagnosisDisabled
+		return diagnosis
+	}
+	if p, err := c.GetPauseControllerByType(t); err == nil && (c.pauseAll.IsPaused() || p.IsPaused()) {
+		diagnosis.Reason = DiagnosisPaused
+		return diagnosis
+	}
+	if c.isSkippedByLabel(t, region) {
+		diagnosis.Reason = DiagnosisSkippedByLabel
+		return diagnosis
+	}
+
+	// The checkers which keep state across checks are replaced by new ones
+	// with a scratch waiting list or by their dry runs, so that diagnosing
+	// doesn't affect them. The new ones share the clock of the controller.
+	waitingList := cache.NewDefaultCache(1)
+	var ops []*operator.Operator
+	switch t {
+	case CheckerJointState:
+		jointStateChecker := checker.NewJointStateChecker(c.cluster)
+		jointStateChecker.SetClock(c.clock)
+		if op := jointStateChecker.Check(region); op != nil {
+			ops = append(ops, op)
+		}
+	case CheckerAllPeersDown:
+		if c.allPeersDownChecker.CheckDryRun(region) {
+			diagnosis.Reason = DiagnosisAllPeersDown
+			return diagnosis
+		}
+	case CheckerDuplicatePeer:
+		duplicatePeerChecker := checker.NewDuplicatePeerChecker(c.cluster)
+		duplicatePeerChecker.SetClock(c.clock)
+		if op := duplicatePeerChecker.Check(region); op != nil {
+			ops = append(ops, op)
+		}
+	case CheckerSplit:
+		if op := c.splitChecker.DryRun().Check(region); op != nil {
+			ops = append(ops, op)
+		}
+	case CheckerRule:
+		ruleChecker := checker.NewRuleChecker(c.cluster, c.ruleManager, waitingList)
+		ruleChecker.SetClock(c.clock)
+		if op := ruleChecker.Check(region); op != nil {
+			ops = append(ops, op)
+		}
+	case CheckerLearner:
+		if op := c.learnerChecker.CheckDryRun(region); op != nil {
+			ops = append(ops, op)
+		}
+	case CheckerReplica:
+		replicaChecker := checker.NewReplicaChecker(c.cluster, waitingList)
+		replicaChecker.SetClock(c.clock)
+		if op := replicaChecker.Check(region); op != nil {
+			ops = append(ops, op)
+		}
+	case CheckerMerge:
+		ops = c.mergeChecker.DryRun().Check(region)
+	}
+	if len(ops) == 0 {
+		diagnosis.Reason = DiagnosisNoOperator
+		return diagnosis
+	}
+	for _, op := range ops {
+		diagnosis.Operators = append(diagnosis.Operators, op.String())
+	}
+	switch t {
+	case CheckerRule, CheckerReplica:
+		if c.opController.OperatorCount(operator.OpReplica) >= c.replicaScheduleLimit() {
+			diagnosis.Reason = DiagnosisReplicaScheduleLimit
+		} else if budget := c.newCheckBudget(); t == CheckerRule && budget.ramping && budget.ramp == 0 {
+			diagnosis.Reason = DiagnosisPlacementRulesRamp
+		} else if operatorUrgency(t, region, ops) < UrgencyDownPeer && !budget.allowStores(operatorStores(ops[0])) {
+			diagnosis.Reason = DiagnosisStoreBudgetShare
+		} else if c.opController.ExceedStoreSnapshotLimit(ops...) {
+			diagnosis.Reason = DiagnosisStoreSnapshotLimit
+		} else if len(c.opController.snapshotTargetStores(ops[0])) > 0 && c.snapshotLimiter.exhausted(c.opts.GetCheckerSnapshotRate()) {
+			diagnosis.Reason = DiagnosisCheckerSnapshotRate
+		}
+	case CheckerMerge:
+		if c.opController.OperatorCount(operator.OpMerge) >= c.mergeScheduleLimit() {
+			diagnosis.Reason = DiagnosisMergeScheduleLimit
+		}
+	case CheckerSplit:
+		if c.isSplitBudgetExhausted() {
+			diagnosis.Reason = DiagnosisMaxSplitsPerScan
+		}
+	}
+	if diagnosis.Reason == "" && c.IsLowChurn() && operatorUrgency(t, region, ops) < UrgencyUnderReplicated {
+		diagnosis.Reason = DiagnosisLowChurn
+	}
+	if diagnosis.Reason == "" && c.opts.GetCheckerOperatorDedupTTL() > 0 && c.recentOperators.contains(ops, c.clock.Now()) {
+		diagnosis.Reason = DiagnosisRecentlyProduced
+	}
+	return diagnosis
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/checker"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"go.uber.org/zap"
+)
+
+// OperatorFilterContext is what an OperatorFilter knows about the check
+// besides the operator and the region.
+type OperatorFilterContext struct {
+	Cluster opt.Cluster
+	// Checker is the name of the checker which creates the operator.
+	Checker string
+	// CheckerType is the type of the checker, which is also the description
+	// of the operator.
+	CheckerType string
+	// Now is the time of the check by the clock of the CheckerController.
+	Now time.Time
+}
+
+// OperatorFilter decides whether an operator created by a checker can be
+// returned to the caller. It returns a non-empty reason to veto the operator.
+// The filter must not modify the operator or the region.
+type OperatorFilter func(ctx *OperatorFilterContext, region *core.RegionInfo, op *operator.Operator) (reason string)
+
+// RegisterOperatorFilter appends a filter to the chain which every operator
+// created by the checkers passes through. Filters are called in registration
+// order, and the first veto stops the chain.
+func (c *CheckerController) RegisterOperatorFilter(filter OperatorFilter) {
+	c.filtersMu.Lock()
+	defer c.filtersMu.Unlock()
+	c.filters = append(c.filters, filter)
+}
+
+// filterVetoed returns nil if any operator created by the checker is vetoed by
+// the filters, and the region is added into the waiting list then. The
+// operators are dropped altogether since they must be added together.
+func (c *CheckerController) filterVetoed(name, checkerType string, region *core.RegionInfo, ops []*operator.Operator) []*operator.Operator {
+	if ops == nil {
+		return nil
+	}
+	c.filtersMu.RLock()
+	defer c.filtersMu.RUnlock()
+	if len(c.filters) == 0 {
+		return ops
+	}
+	ctx := &OperatorFilterContext{
+		Cluster:     c.cluster,
+		Checker:     name,
+		CheckerType: checkerType,
+		Now:         c.clock.Now(),
+	}
+	for _, op := range ops {
+		for _, filter := range c.filters {
+			reason := filter(ctx, region, op)
+			if reason == "" {
+				continue
+			}
+			log.Info("operator is vetoed by filter",
+				zap.Uint64("region-id", region.GetID()),
+				zap.String("checker", checkerType),
+				zap.String("reason", reason),
+				zap.Stringer("operator", op))
+			checkerVetoedCounter.WithLabelValues(checkerType).Inc()
+			checker.AddWaitingRegion(c.regionWaitingList, c.opts, region.GetID(), ctx.Now, DiagnosisVetoed)
+			return nil
+		}
+	}
+	return ops
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tikv/pd/pkg/cache"
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+// operatorHistoryRegionLimit is the max number of regions whose operator
+// history is kept. The history of the region which produced operators least
+// recently is dropped first.
+const operatorHistoryRegionLimit = DefaultCacheSize
+
+// OperatorRecord is an operator produced for a region by a checker.
+type OperatorRecord struct {
+	Time    time.Time `json:"time"`
+	Checker string    `json:"checker"`
+	Kind    string    `json:"kind"`
+	Desc    string    `json:"desc"`
+}
+
+// operatorHistory is a ring buffer of the latest operator records of a region.
+type operatorHistory struct {
+	records []OperatorRecord
+	// next is the index the next record is written to.
+	next int
+	full bool
+}
+
+func newOperatorHistory(size int) *operatorHistory {
+	return &operatorHistory{records: make([]OperatorRecord, size)}
+}
+
+func (h *operatorHistory) add(record OperatorRecord) {
+	h.records[h.next] = record
+	h.next = (h.next + 1) % len(h.records)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// list returns the records from the oldest to the latest.
+func (h *operatorHistory) list() []OperatorRecord {
+	if !h.full {
+		return append([]OperatorRecord(nil), h.records[:h.next]...)
+	}
+	return append(append([]OperatorRecord(nil), h.records[h.next:]...), h.records[:h.next]...)
+}
+
+// resize changes the size of the buffer and keeps the latest records.
+func (h *operatorHistory) resize(size int) {
+	records := h.list()
+	if len(records) > size {
+		records = records[len(records)-size:]
+	}
+	h.records = make([]OperatorRecord, size)
+	h.next = copy(h.records, records) % size
+	h.full = len(records) == size
+}
+
+// regionOperatorHistory records the latest operators produced for each region,
+// which helps to find out the regions flapping between checkers.
+type regionOperatorHistory struct {
+	mu        sync.Mutex
+	histories cache.Cache
+}
+
+func newRegionOperatorHistory() *regionOperatorHistory {
+	return &regionOperatorHistory{
+		histories: cache.NewCache(operatorHistoryRegionLimit, cache.LRUCache),
+	}
+}
+
+// record adds the operators to the history of the region, which keeps size
+// records at most. Nothing is recorded if size is not positive.
+func (r *regionOperatorHistory) record(regionID uint64, checkerType string, ops []*operator.Operator, now time.Time, size int) {
+	if size <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var h *operatorHistory
+	if v, ok := r.histories.Get(regionID); ok {
+		h = v.(*operatorHistory)
+		if len(h.records) != size {
+			h.resize(size)
+		}
+	} else {
+		h = newOperatorHistory(size)
+		r.histories.Put(regionID, h)
+	}
+	for _, op := range ops {
+		h.add(OperatorRecord{Time: now, Checker: checkerType, Kind: op.Kind().String(), Desc: op.Desc()})
+	}
+}
+
+func (r *regionOperatorHistory) get(regionID uint64) []OperatorRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.histories.Peek(regionID)
+	if !ok {
+		return nil
+	}
+	return v.(*operatorHistory).list()
+}
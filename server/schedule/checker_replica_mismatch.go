@@ -0,0 +1,154 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"sync"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/tikv/pd/server/schedule/placement"
+)
+
+// replicaMismatch tells how the peers of a region mismatch the placement rules.
+type replicaMismatch int
+
+const (
+	// underReplicated means some rule has fewer healthy peers than it requires.
+	underReplicated replicaMismatch = 1 << iota
+	// overReplicated means some peers of the region match no rule.
+	overReplicated
+)
+
+// replicaMismatchStats counts the regions whose peer counts don't match the
+// placement rules, by the fits computed when the regions are checked, or the
+// max replicas if the placement rules are disabled. It is a
+// direct signal of how far the cluster is from converging after rule changes.
+type replicaMismatchStats struct {
+	mu      sync.Mutex
+	regions map[uint64]replicaMismatch
+	under   int
+	over    int
+}
+
+func newReplicaMismatchStats() *replicaMismatchStats {
+	return &replicaMismatchStats{regions: make(map[uint64]replicaMismatch)}
+}
+
+func fitMismatch(region *core.RegionInfo, fit *placement.RegionFit) replicaMismatch {
+	var mismatch replicaMismatch
+	// All peers are orphan peers before the region matching no rule is split.
+	if fit == nil || len(fit.RuleFits) == 0 {
+		return mismatch
+	}
+	for _, rf := range fit.RuleFits {
+		if healthyPeerCount(region, rf.Peers) < rf.Rule.Count {
+			mismatch |= underReplicated
+		}
+	}
+	if len(fit.OrphanPeers) > 0 {
+		mismatch |= overReplicated
+	}
+	return mismatch
+}
+
+// healthyPeerCount returns the number of the peers which are not down. The down
+// peers are taken as missing, so that a region is under-replicated as soon as
+// its stores fail, rather than after the down peers are removed.
+func healthyPeerCount(region *core.RegionInfo, peers []*metapb.Peer) int {
+	count := 0
+	for _, p := range peers {
+		if region.GetDownPeer(p.GetId()) == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// observe records the mismatch of the region by its fit.
+func (s *replicaMismatchStats) observe(region *core.RegionInfo, fit *placement.RegionFit) {
+	mismatch := fitMismatch(region, fit)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setLocked(region.GetID(), mismatch)
+}
+
+// observeReplicas records the mismatch of the region by the number of its
+// voters, which is used when the placement rules are disabled.
+func (s *replicaMismatchStats) observeReplicas(region *core.RegionInfo, maxReplicas int) {
+	var mismatch replicaMismatch
+	voters := region.GetVoters()
+	if healthyPeerCount(region, voters) < maxReplicas {
+		mismatch |= underReplicated
+	}
+	if len(voters) > maxReplicas {
+		mismatch |= overReplicated
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setLocked(region.GetID(), mismatch)
+}
+
+// prune drops the regions which no longer exist.
+func (s *replicaMismatchStats) prune(cluster opt.Cluster) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := range s.regions {
+		if cluster.GetRegion(id) == nil {
+			s.setLocked(id, 0)
+		}
+	}
+}
+
+func (s *replicaMismatchStats) setLocked(regionID uint64, mismatch replicaMismatch) {
+	old := s.regions[regionID]
+	if old == mismatch {
+		return
+	}
+	count := func(m replicaMismatch) int {
+		if m != 0 {
+			return 1
+		}
+		return 0
+	}
+	s.under += count(mismatch&underReplicated) - count(old&underReplicated)
+	s.over += count(mismatch&overReplicated) - count(old&overReplicated)
+	if mismatch == 0 {
+		delete(s.regions, regionID)
+	} else {
+		s.regions[regionID] = mismatch
+	}
+	checkerReplicaMismatchGauge.WithLabelValues("under-replicated").Set(float64(s.under))
+	checkerReplicaMismatchGauge.WithLabelValues("over-replicated").Set(float64(s.over))
+}
+
+// underReplicatedRatio returns the ratio of the under-replicated regions to
+// all the regions of the cluster.
+func (s *replicaMismatchStats) underReplicatedRatio(cluster opt.Cluster) float64 {
+	total := cluster.GetRegionCount()
+	if total == 0 {
+		return 0
+	}
+	under, _ := s.counts()
+	return float64(under) / float64(total)
+}
+
+// counts returns the number of the under-replicated and over-replicated regions.
+func (s *replicaMismatchStats) counts() (under, over int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.under, s.over
+}
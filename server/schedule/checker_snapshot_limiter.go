@@ -0,0 +1,81 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juju/ratelimit"
+	"github.com/tikv/pd/server/schedule/checker"
+)
+
+// snapshotRateLimiter is a token bucket which limits the rate of the operators
+// sending snapshots created by the replica and rule checkers. Unlike the store
+// snapshot limit, which caps the number of running operators on a store, it
+// caps how fast such operators are created over the whole cluster.
+type snapshotRateLimiter struct {
+	mu     sync.Mutex
+	clock  checker.Clock
+	rate   float64
+	bucket *ratelimit.Bucket
+}
+
+func newSnapshotRateLimiter(clock checker.Clock) *snapshotRateLimiter {
+	return &snapshotRateLimiter{clock: clock}
+}
+
+// allow takes a token if the rate is limited, and returns false if there is no
+// token left. The bucket is rebuilt when the rate changes, and it holds the
+// tokens of one second at most, but no less than one token.
+func (l *snapshotRateLimiter) allow(rate float64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if rate <= 0 {
+		l.rate, l.bucket = 0, nil
+		return true
+	}
+	if l.bucket == nil || l.rate != rate {
+		capacity := int64(rate)
+		if capacity < 1 {
+			capacity = 1
+		}
+		l.rate = rate
+		l.bucket = ratelimit.NewBucketWithRateAndClock(rate, capacity, bucketClock{l.clock})
+	}
+	allowed := l.bucket.TakeAvailable(1) == 1
+	checkerSnapshotTokensGauge.Set(float64(l.bucket.Available()))
+	return allowed
+}
+
+// exhausted returns true if the rate is limited and there is no token left.
+// It doesn't take any token.
+func (l *snapshotRateLimiter) exhausted(rate float64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if rate <= 0 || l.bucket == nil || l.rate != rate {
+		return false
+	}
+	return l.bucket.Available() < 1
+}
+
+// bucketClock adapts the clock of the checkers to the token bucket.
+type bucketClock struct {
+	checker.Clock
+}
+
+func (bucketClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+// DecisionTraceRecord is what the checkers decide about a region in a check,
+// which is written by the decision trace.
+type DecisionTraceRecord struct {
+	Time     time.Time           `json:"time"`
+	RegionID uint64              `json:"region-id"`
+	Checkers []DecisionTraceItem `json:"checkers"`
+	// Operators are the operators returned to the caller. They are not
+	// returned in the dry run.
+	Operators []string `json:"operators,omitempty"`
+	DryRun    bool     `json:"dry-run,omitempty"`
+}
+
+// DecisionTraceItem is what a checker decides about a region.
+type DecisionTraceItem struct {
+	Checker   string   `json:"checker"`
+	Decision  string   `json:"decision"`
+	Reason    string   `json:"reason,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Operators []string `json:"operators,omitempty"`
+}
+
+// decisionTracer writes the decisions of the checked regions as newline
+// delimited JSON.
+type decisionTracer struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	dryRun  bool
+	records uint64
+	// err is the first error of writing, after which nothing is written.
+	err     error
+	stopped bool
+}
+
+func newDecisionTraceRecord(region *core.RegionInfo, ops []*operator.Operator, decisions []*CheckerDecision, now time.Time, dryRun bool) *DecisionTraceRecord {
+	record := &DecisionTraceRecord{
+		Time:     now,
+		RegionID: region.GetID(),
+		Checkers: make([]DecisionTraceItem, 0, len(decisions)),
+		DryRun:   dryRun,
+	}
+	for _, d := range decisions {
+		item := DecisionTraceItem{Checker: d.Checker, Decision: d.Kind.String(), Reason: d.Reason}
+		if d.Err != nil {
+			item.Error = d.Err.Error()
+		}
+		for _, op := range d.Operators {
+			item.Operators = append(item.Operators, op.String())
+		}
+		record.Checkers = append(record.Checkers, item)
+	}
+	for _, op := range ops {
+		record.Operators = append(record.Operators, op.String())
+	}
+	return record
+}
+
+func (t *decisionTracer) write(record *DecisionTraceRecord) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopped || t.err != nil {
+		return
+	}
+	if err := t.encoder.Encode(record); err != nil {
+		t.err = err
+		log.Warn("fail to write the decision trace, stop writing", errs.ZapError(err))
+		return
+	}
+	t.records++
+}
+
+// StartDecisionTrace makes the controller write what the checkers decide about
+// every region it checks to w as newline delimited JSON, until the trace is
+// stopped. If dryRun is true, the operators created by the checkers are not
+// returned to the caller, and the operators in flight are not preempted, so
+// that the checks change nothing. It fails if a trace is already running.
+func (c *CheckerController) StartDecisionTrace(w io.Writer, dryRun bool) error {
+	c.traceMu.Lock()
+	defer c.traceMu.Unlock()
+	if c.tracer != nil {
+		return errs.ErrCheckerTraceRunning.FastGenByArgs()
+	}
+	c.tracer = &decisionTracer{encoder: json.NewEncoder(w), dryRun: dryRun}
+	return nil
+}
+
+// StopDecisionTrace stops the trace and returns the number of the records
+// written, and the error of writing if any. Nothing is written to the writer
+// once it returns.
+func (c *CheckerController) StopDecisionTrace() (uint64, error) {
+	c.traceMu.Lock()
+	tracer := c.tracer
+	c.tracer = nil
+	c.traceMu.Unlock()
+	if tracer == nil {
+		return 0, nil
+	}
+	// Wait for the record being written by a check which started before.
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	tracer.stopped = true
+	return tracer.records, tracer.err
+}
+
+func (c *CheckerController) getTracer() *decisionTracer {
+	c.traceMu.RLock()
+	defer c.traceMu.RUnlock()
+	return c.tracer
+}
+
+// isDryRun returns true if a dry run trace is running.
+func (c *CheckerController) isDryRun() bool {
+	tracer := c.getTracer()
+	return tracer != nil && tracer.dryRun
+}
+
+// traceDecisions writes the decisions about the region if a trace is running,
+// and returns the operators to be returned to the caller, which are nil in
+// the dry run.
+func (c *CheckerController) traceDecisions(region *core.RegionInfo, ops []*operator.Operator, decisions []*CheckerDecision) []*operator.Operator {
+	tracer := c.getTracer()
+	if tracer == nil {
+		return ops
+	}
+	if tracer.dryRun {
+		ops = nil
+	}
+	tracer.write(newDecisionTraceRecord(region, ops, decisions, c.clock.Now(), tracer.dryRun))
+	return ops
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import "github.com/tikv/pd/pkg/errs"
+
+// CheckerType is the type of a checker managed by the CheckerController.
+type CheckerType int
+
+// The checkers which can be paused.
+const (
+	CheckerLearner CheckerType = iota
+	CheckerReplica
+	CheckerRule
+	CheckerSplit
+	CheckerMerge
+	CheckerJointState
+	CheckerPriority
+	CheckerDuplicatePeer
+	CheckerAllPeersDown
+)
+
+// checkerTypes are all checker types, in the order they are listed.
+var checkerTypes = []CheckerType{
+	CheckerLearner,
+	CheckerReplica,
+	CheckerRule,
+	CheckerSplit,
+	CheckerMerge,
+	CheckerJointState,
+	CheckerPriority,
+	CheckerDuplicatePeer,
+	CheckerAllPeersDown,
+}
+
+var checkerTypeNames = map[CheckerType]string{
+	CheckerLearner:    "learner",
+	CheckerReplica:    "replica",
+	CheckerRule:       "rule",
+	CheckerSplit:      "split",
+	CheckerMerge:      "merge",
+	CheckerJointState: "joint-state",
+	CheckerPriority:   "priority",
+
+	CheckerDuplicatePeer: "duplicate-peer",
+	CheckerAllPeersDown:  "all-peers-down",
+}
+
+// String returns the name of the checker, which is used by the HTTP API and
+// the configuration.
+func (t CheckerType) String() string {
+	if name, ok := checkerTypeNames[t]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// canDisable returns true if the checker can be disabled. Leaving the joint
+// state is required for safety, and the priority checker runs along with the
+// rule checker.
+func (t CheckerType) canDisable() bool {
+	return t != CheckerJointState && t != CheckerPriority
+}
+
+// ParseCheckerType returns the checker type of the name. It returns
+// ErrCheckerNotFound if there is no checker of the name.
+func ParseCheckerType(name string) (CheckerType, error) {
+	for _, t := range checkerTypes {
+		if checkerTypeNames[t] == name {
+			return t, nil
+		}
+	}
+	return 0, errs.ErrCheckerNotFound.FastGenByArgs()
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/checker"
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+// OperatorUrgency is how urgent the operators created by a checker are.
+// The operators with a higher urgency are preferred when the check region
+// policy is most-urgent, and they preempt the operators in flight with a lower
+// urgency.
+type OperatorUrgency int
+
+// The urgencies of the operators created by checkers, from the lowest to the highest.
+const (
+	// UrgencyMerge is the urgency of merging regions.
+	UrgencyMerge OperatorUrgency = iota
+	// UrgencyEmptyRegionMerge is the urgency of merging an empty region, which
+	// goes ahead of the other merges if the empty region merge priority is enabled.
+	UrgencyEmptyRegionMerge
+	// UrgencyNormal is the urgency of the operators which are not listed below,
+	// like splitting the region or moving a peer to a better location.
+	UrgencyNormal
+	// UrgencyUnderReplicated is the urgency of adding peers to a region which lacks replicas.
+	UrgencyUnderReplicated
+	// UrgencyDownPeer is the urgency of replacing or removing a down peer.
+	UrgencyDownPeer
+	// UrgencyDuplicatePeer is the urgency of removing a peer from a store
+	// which holds more than one peer of the region.
+	UrgencyDuplicatePeer
+	// UrgencyJointState is the urgency of leaving the joint state.
+	UrgencyJointState
+)
+
+var urgencyNames = map[OperatorUrgency]string{
+	UrgencyMerge:            "merge",
+	UrgencyEmptyRegionMerge: "empty-region-merge",
+	UrgencyNormal:           "normal",
+	UrgencyUnderReplicated:  "under-replicated",
+	UrgencyDownPeer:         "down-peer",
+	UrgencyDuplicatePeer:    "duplicate-peer",
+	UrgencyJointState:       "joint-state",
+}
+
+func (u OperatorUrgency) String() string {
+	if name, ok := urgencyNames[u]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// PriorityLevel returns the priority level of the operators of the urgency,
+// by which the operator controller admits them. Leaving the joint state and
+// fixing duplicate or down peers go ahead of the operators of the schedulers, while merging
+// gives way to them unless an empty region is merged.
+func (u OperatorUrgency) PriorityLevel() core.PriorityLevel {
+	switch u {
+	case UrgencyJointState, UrgencyDuplicatePeer, UrgencyDownPeer:
+		return core.HighPriority
+	case UrgencyMerge:
+		return core.LowPriority
+	default:
+		return core.NormalPriority
+	}
+}
+
+// setPriorityLevel sets the priority level of the urgency to the operators.
+// The operators of the normal priority level are left alone, which keeps the
+// level set by the checker.
+func setPriorityLevel(u OperatorUrgency, ops []*operator.Operator) {
+	level := u.PriorityLevel()
+	if level == core.NormalPriority {
+		return
+	}
+	for _, op := range ops {
+		op.SetPriorityLevel(level)
+	}
+}
+
+// inFlightUrgency returns the urgency of the operator in flight for the region,
+// which may be created by a scheduler as well. The checker is told by the
+// operator itself, and the region in the joint state is never preempted.
+func inFlightUrgency(region *core.RegionInfo, op *operator.Operator) OperatorUrgency {
+	if core.IsInJointState(region.GetPeers()...) {
+		return UrgencyJointState
+	}
+	if op.Kind()&operator.OpMerge != 0 {
+		return operatorUrgency(CheckerMerge, region, []*operator.Operator{op})
+	}
+	return stepUrgency(region, []*operator.Operator{op})
+}
+
+// operatorUrgency returns the urgency of the operators created by the checker
+// for the region.
+func operatorUrgency(t CheckerType, region *core.RegionInfo, ops []*operator.Operator) OperatorUrgency {
+	switch t {
+	case CheckerJointState:
+		return UrgencyJointState
+	case CheckerDuplicatePeer:
+		return UrgencyDuplicatePeer
+	case CheckerAllPeersDown:
+		// It never creates operators, as there is no leader to carry them out.
+		return UrgencyNormal
+	case CheckerMerge:
+		if len(ops) > 0 && ops[0].Desc() == checker.EmptyRegionMergeDesc {
+			return UrgencyEmptyRegionMerge
+		}
+		return UrgencyMerge
+	default:
+		return stepUrgency(region, ops)
+	}
+}
+
+// stepUrgency returns the urgency of the operators by their steps, which
+// tells whether they fix the duplicate peers, the down peers or the missing replicas.
+func stepUrgency(region *core.RegionInfo, ops []*operator.Operator) OperatorUrgency {
+	urgency := UrgencyNormal
+	for _, op := range ops {
+		var adds, removes int
+		for i := 0; i < op.Len(); i++ {
+			switch step := op.Step(i).(type) {
+			case operator.AddPeer, operator.AddLearner:
+				adds++
+			case operator.RemovePeer:
+				if step.IsDuplicate {
+					return UrgencyDuplicatePeer
+				}
+				removes++
+				for _, down := range region.GetDownPeers() {
+					if down.GetPeer().GetStoreId() == step.FromStore {
+						return UrgencyDownPeer
+					}
+				}
+			}
+		}
+		if adds > removes {
+			urgency = UrgencyUnderReplicated
+		}
+	}
+	return urgency
+}
@@ -45,13 +45,22 @@ func (c *StoreCandidates) FilterTarget(opt *config.PersistOptions, filters ...Fi
 	return c
 }
 
-// Sort sorts store list by given comparer in ascending order.
+// Sort sorts store list by given comparer in ascending order. Stores the
+// comparer considers equal are sorted by store ID, so that the same stores are
+// always picked in the same order.
 func (c *StoreCandidates) Sort(less StoreComparer) *StoreCandidates {
-	sort.Slice(c.Stores, func(i, j int) bool { return less(c.Stores[i], c.Stores[j]) < 0 })
+	sort.Slice(c.Stores, func(i, j int) bool {
+		if r := less(c.Stores[i], c.Stores[j]); r != 0 {
+			return r < 0
+		}
+		return c.Stores[i].GetID() < c.Stores[j].GetID()
+	})
 	return c
 }
 
-// Reverse reverses the candidate store list.
+// Reverse reverses the candidate store list, including the order of the stores
+// with the same priority. Sort with ReverseComparer to sort in descending order
+// while keeping the lower store ID first.
 func (c *StoreCandidates) Reverse() *StoreCandidates {
 	for i := len(c.Stores)/2 - 1; i >= 0; i-- {
 		opp := len(c.Stores) - 1 - i
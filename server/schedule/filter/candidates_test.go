@@ -93,6 +93,19 @@ func (s *testCandidatesSuite) TestCandidates(c *C) {
 	s.check(c, cs, 33, 32, 31)
 }
 
+func (s *testCandidatesSuite) TestSortTieBreak(c *C) {
+	// Stores the comparer considers equal are sorted by store ID.
+	cs := s.newCandidates(23, 12, 21, 11, 22, 13)
+	cs.Sort(idComparer2)
+	s.check(c, cs, 11, 12, 13, 21, 22, 23)
+	cs.Shuffle()
+	cs.Sort(ReverseComparer(idComparer2))
+	s.check(c, cs, 21, 22, 23, 11, 12, 13)
+	cs.Top(idComparer2)
+	s.check(c, cs, 21, 22, 23)
+	c.Assert(cs.PickFirst().GetID(), Equals, uint64(21))
+}
+
 func (s *testCandidatesSuite) newCandidates(ids ...uint64) *StoreCandidates {
 	stores := make([]*core.StoreInfo, 0, len(ids))
 	for _, id := range ids {
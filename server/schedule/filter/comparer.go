@@ -23,6 +23,14 @@ import (
 // sort candidate stores.
 type StoreComparer func(a, b *core.StoreInfo) int
 
+// ReverseComparer creates a StoreComparer which sorts stores in the reverse
+// order of the given comparer.
+func ReverseComparer(less StoreComparer) StoreComparer {
+	return func(a, b *core.StoreInfo) int {
+		return less(b, a)
+	}
+}
+
 // RegionScoreComparer creates a StoreComparer to sort store by region
 // score.
 func RegionScoreComparer(opt *config.PersistOptions) StoreComparer {
@@ -40,6 +48,25 @@ func RegionScoreComparer(opt *config.PersistOptions) StoreComparer {
 	}
 }
 
+// AvailableRatioComparer is a StoreComparer to sort store by available ratio,
+// the store with the higher available ratio comes first. Stores with the same
+// available ratio are sorted by store ID to keep the order deterministic.
+func AvailableRatioComparer(a, b *core.StoreInfo) int {
+	ra, rb := a.AvailableRatio(), b.AvailableRatio()
+	switch {
+	case ra > rb:
+		return -1
+	case ra < rb:
+		return 1
+	case a.GetID() < b.GetID():
+		return -1
+	case a.GetID() > b.GetID():
+		return 1
+	default:
+		return 0
+	}
+}
+
 // IsolationComparer creates a StoreComparer to sort store by isolation score.
 func IsolationComparer(locationLabels []string, regionStores []*core.StoreInfo) StoreComparer {
 	return func(a, b *core.StoreInfo) int {
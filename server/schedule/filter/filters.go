@@ -189,6 +189,34 @@ func (f *storageThresholdFilter) Target(opt *config.PersistOptions, store *core.
 	return !store.IsLowSpace(opt.GetLowSpaceRatio())
 }
 
+type minFreeSpaceFilter struct{ scope string }
+
+// NewMinFreeSpaceFilter creates a Filter that filters all stores whose
+// available space or ratio is below the minimum free space of the replicas.
+func NewMinFreeSpaceFilter(scope string) Filter {
+	return &minFreeSpaceFilter{scope: scope}
+}
+
+func (f *minFreeSpaceFilter) Scope() string {
+	return f.scope
+}
+
+func (f *minFreeSpaceFilter) Type() string {
+	return "min-free-space-filter"
+}
+
+func (f *minFreeSpaceFilter) Source(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	return true
+}
+
+func (f *minFreeSpaceFilter) Target(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	// The store which has not reported its space yet is not judged.
+	if store.GetStoreStats() == nil {
+		return true
+	}
+	return store.GetAvailable() >= opt.GetReplicaMinFreeSpace() && store.AvailableRatio() >= opt.GetReplicaMinFreeRatio()
+}
+
 // distinctScoreFilter ensures that distinct score will not decrease.
 type distinctScoreFilter struct {
 	scope     string
@@ -67,6 +67,107 @@ var (
 			Help:      "Counter of region scatter operators.",
 		}, []string{"type", "event"})
 
+	checkerDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "checker_duration_seconds",
+			Help:      "Bucketed histogram of processing time (s) of checking a region by a checker.",
+			Buckets:   prometheus.ExponentialBuckets(0.00001, 2, 20), // 10us ~ 5s
+		}, []string{"type"})
+
+	checkerSnapshotTokensGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "checker_snapshot_tokens",
+			Help:      "Available tokens of the snapshot rate limit of the replica and rule checkers.",
+		})
+
+	checkerReadOnlyCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "checker_read_only_dropped_operators",
+			Help:      "Counter of the operators dropped by the checkers because the cluster is read-only.",
+		}, []string{"type"})
+
+	checkerVetoedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "checker_vetoed_operators",
+			Help:      "Counter of the operators created by the checkers which are vetoed by the operator filters.",
+		}, []string{"type"})
+
+	checkerSplitBudgetExhaustedCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "checker_split_budget_exhausted_count",
+			Help:      "Counter of the regions whose split is deferred because the max splits per scan is reached.",
+		})
+
+	checkerReplicaMismatchGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "checker_replica_mismatch_regions",
+			Help:      "Number of the regions whose peer counts don't match the placement rules or the max replicas.",
+		}, []string{"type"})
+
+	checkerConvergenceGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "checker_convergence_ratio",
+			Help:      "Ratio of the regions which need no action from the checkers and have no operator pending.",
+		})
+
+	checkerStoreBudgetUsageGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "checker_store_budget_usage",
+			Help:      "Number of the replica operators in flight touching each store, counted against the store budget share of the checkers.",
+		}, []string{"store"})
+
+	checkerDecisionCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "checker_decisions",
+			Help:      "Counter of the decisions made by the checkers about the regions.",
+		}, []string{"checker", "decision"})
+
+	checkerDeduplicatedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "checker_deduplicated_operators",
+			Help:      "Counter of the operators skipped by the checkers since the same ones are produced recently.",
+		}, []string{"type"})
+
+	// checkerProducedCounter is the counterpart of operator.OperatorLimitCounter
+	// for the operators which are not blocked by the schedule limits.
+	checkerProducedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "checker_produced_operators",
+			Help:      "Counter of the operators produced by the checkers.",
+		}, []string{"type", "kind", "desc"})
+
+	// checkerOperatorCounter counts the end status of the operators created by
+	// the checkers, e.g. success or timeout.
+	checkerOperatorCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "checker_finished_operators",
+			Help:      "Counter of the operators created by the checkers by the end status.",
+		}, []string{"type", "status"})
+
 	scatterDistributionCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "pd",
@@ -84,4 +185,16 @@ func init() {
 	prometheus.MustRegister(operatorWaitCounter)
 	prometheus.MustRegister(scatterCounter)
 	prometheus.MustRegister(scatterDistributionCounter)
+	prometheus.MustRegister(checkerDuration)
+	prometheus.MustRegister(checkerSnapshotTokensGauge)
+	prometheus.MustRegister(checkerReadOnlyCounter)
+	prometheus.MustRegister(checkerVetoedCounter)
+	prometheus.MustRegister(checkerSplitBudgetExhaustedCounter)
+	prometheus.MustRegister(checkerReplicaMismatchGauge)
+	prometheus.MustRegister(checkerConvergenceGauge)
+	prometheus.MustRegister(checkerStoreBudgetUsageGauge)
+	prometheus.MustRegister(checkerDecisionCounter)
+	prometheus.MustRegister(checkerDeduplicatedCounter)
+	prometheus.MustRegister(checkerProducedCounter)
+	prometheus.MustRegister(checkerOperatorCounter)
 }
@@ -58,6 +58,33 @@ func CreateRemovePeerOperator(desc string, cluster opt.Cluster, kind OpKind, reg
 		Build(kind)
 }
 
+// CreateRemoveDuplicatePeerOperator creates an operator that removes the peer
+// from its store, which holds another peer of the region. It is not built by
+// the Builder, which tells the peers apart by their stores. The leader is
+// transferred to a healthy voter on another store first if it is the peer.
+func CreateRemoveDuplicatePeerOperator(desc string, region *core.RegionInfo, peer *metapb.Peer) (*Operator, error) {
+	storeID := peer.GetStoreId()
+	var kind OpKind
+	var steps []OpStep
+	if region.GetLeader().GetId() == peer.GetId() {
+		var target *metapb.Peer
+		for _, p := range region.GetVoters() {
+			if p.GetStoreId() != storeID && region.GetDownPeer(p.GetId()) == nil && region.GetPendingPeer(p.GetId()) == nil {
+				target = p
+				break
+			}
+		}
+		if target == nil {
+			return nil, errors.Errorf("no healthy voter on another store to transfer the leader to")
+		}
+		steps = append(steps, TransferLeader{FromStore: storeID, ToStore: target.GetStoreId()})
+		kind |= OpLeader
+	}
+	steps = append(steps, RemovePeer{FromStore: storeID, PeerID: peer.GetId(), IsDuplicate: true})
+	brief := fmt.Sprintf("rm duplicate peer: store [%d] peer [%d]", storeID, peer.GetId())
+	return NewOperator(desc, brief, region.GetID(), region.GetRegionEpoch(), kind|OpRegion, steps...), nil
+}
+
 // CreateTransferLeaderOperator creates an operator that transfers the leader from a source store to a target store.
 func CreateTransferLeaderOperator(desc string, cluster opt.Cluster, region *core.RegionInfo, sourceStoreID uint64, targetStoreID uint64, kind OpKind) (*Operator, error) {
 	return NewBuilder(desc, cluster, region, SkipOriginJointStateCheck).
@@ -0,0 +1,74 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operator
+
+import (
+	"sort"
+
+	"github.com/tikv/pd/server/core"
+)
+
+// OpImpact is the estimated impact of an operator on the cluster. It is only
+// informative and doesn't change how the operator is executed.
+type OpImpact struct {
+	// SnapshotBytes is the expected bytes sent by snapshots to the new peers.
+	SnapshotBytes uint64 `json:"snapshot-bytes"`
+	// TransferLeader is true if the operator transfers the leader of the region.
+	TransferLeader bool `json:"transfer-leader"`
+	// TargetStores are the stores which receive a new peer or the leader.
+	TargetStores []uint64 `json:"target-stores"`
+}
+
+// EstimateImpact estimates the impact of the operator by its steps and the size
+// of the region. The light weight peers, which are added to the empty regions,
+// are not counted in the snapshot bytes.
+func EstimateImpact(op *Operator, region *core.RegionInfo) *OpImpact {
+	impact := &OpImpact{}
+	snapshotBytes := uint64(region.GetApproximateSize()) << 20
+	targets := make(map[uint64]struct{})
+	for _, step := range op.steps {
+		switch s := step.(type) {
+		case AddPeer:
+			if !s.IsLightWeight {
+				impact.SnapshotBytes += snapshotBytes
+			}
+			targets[s.ToStore] = struct{}{}
+		case AddLearner:
+			if !s.IsLightWeight {
+				impact.SnapshotBytes += snapshotBytes
+			}
+			targets[s.ToStore] = struct{}{}
+		case TransferLeader:
+			impact.TransferLeader = true
+			targets[s.ToStore] = struct{}{}
+		}
+	}
+	for id := range targets {
+		impact.TargetStores = append(impact.TargetStores, id)
+	}
+	sort.Slice(impact.TargetStores, func(i, j int) bool { return impact.TargetStores[i] < impact.TargetStores[j] })
+	return impact
+}
+
+// SetImpact attaches the estimated impact to the operator.
+func (o *Operator) SetImpact(impact *OpImpact) {
+	o.impact = impact
+}
+
+// GetImpact returns the estimated impact of the operator, which is nil if it is
+// not estimated.
+func (o *Operator) GetImpact() *OpImpact {
+	return o.impact
+}
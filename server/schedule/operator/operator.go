@@ -39,8 +39,12 @@ const (
 	SlowOperatorWaitTime = 10 * time.Minute
 )
 
+// operatorIDAlloc allocates the IDs of the operators.
+var operatorIDAlloc uint64
+
 // Operator contains execution steps generated by scheduler.
 type Operator struct {
+	id               uint64
 	desc             string
 	brief            string
 	regionID         uint64
@@ -54,6 +58,9 @@ type Operator struct {
 	Counters         []prometheus.Counter
 	FinishedCounters []prometheus.Counter
 	AdditionalInfos  map[string]string
+	impact           *OpImpact
+	checker          string
+	preempts         []*Operator
 }
 
 // NewOperator creates a new operator.
@@ -63,6 +70,7 @@ func NewOperator(desc, brief string, regionID uint64, regionEpoch *metapb.Region
 		level = core.HighPriority
 	}
 	return &Operator{
+		id:              atomic.AddUint64(&operatorIDAlloc, 1),
 		desc:            desc,
 		brief:           brief,
 		regionID:        regionID,
@@ -96,6 +104,11 @@ func (o *Operator) MarshalJSON() ([]byte, error) {
 	return []byte(`"` + o.String() + `"`), nil
 }
 
+// ID returns the ID of the operator, which is unique within the PD process.
+func (o *Operator) ID() uint64 {
+	return o.id
+}
+
 // Desc returns the operator's short description.
 func (o *Operator) Desc() string {
 	return o.desc
@@ -106,6 +119,29 @@ func (o *Operator) SetDesc(desc string) {
 	o.desc = desc
 }
 
+// SetChecker records the type of the checker which creates the operator.
+func (o *Operator) SetChecker(checker string) {
+	o.checker = checker
+}
+
+// Checker returns the type of the checker which creates the operator, or an
+// empty string if the operator is not created by a checker.
+func (o *Operator) Checker() string {
+	return o.checker
+}
+
+// SetPreempts records the operators in flight which the operator preempts.
+// They are canceled when the operator is added, not when it is created, so
+// that they keep running if the operator is never added.
+func (o *Operator) SetPreempts(ops []*Operator) {
+	o.preempts = ops
+}
+
+// Preempts returns the operators in flight which the operator preempts.
+func (o *Operator) Preempts() []*Operator {
+	return o.preempts
+}
+
 // AttachKind attaches an operator kind for the operator.
 func (o *Operator) AttachKind(kind OpKind) {
 	o.kind |= kind
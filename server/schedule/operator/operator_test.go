@@ -233,6 +233,29 @@ func (s *testOperatorSuite) TestInfluence(c *C) {
 	})
 }
 
+func (s *testOperatorSuite) TestEstimateImpact(c *C) {
+	region := s.newTestRegion(1, 1, [2]uint64{1, 1}, [2]uint64{2, 2})
+	op := s.newTestOperator(1, OpRegion,
+		AddLearner{ToStore: 3, PeerID: 3},
+		PromoteLearner{ToStore: 3, PeerID: 3},
+		TransferLeader{FromStore: 1, ToStore: 2},
+		RemovePeer{FromStore: 1},
+	)
+	c.Assert(op.GetImpact(), IsNil)
+	impact := EstimateImpact(op, region)
+	c.Assert(impact, DeepEquals, &OpImpact{
+		SnapshotBytes:  50 << 20,
+		TransferLeader: true,
+		TargetStores:   []uint64{2, 3},
+	})
+	op.SetImpact(impact)
+	c.Assert(op.GetImpact(), Equals, impact)
+
+	// The light weight peers don't need snapshots.
+	op = s.newTestOperator(1, OpRegion, AddPeer{ToStore: 3, PeerID: 3, IsLightWeight: true}, RemovePeer{FromStore: 1})
+	c.Assert(EstimateImpact(op, region), DeepEquals, &OpImpact{TargetStores: []uint64{3}})
+}
+
 func (s *testOperatorSuite) TestOperatorKind(c *C) {
 	c.Assert((OpLeader | OpReplica).String(), Equals, "replica,leader")
 	c.Assert(OpKind(0).String(), Equals, "unknown")
@@ -229,10 +229,17 @@ func (pl PromoteLearner) Influence(opInfluence OpInfluence, region *core.RegionI
 type RemovePeer struct {
 	FromStore, PeerID uint64
 	IsDownStore       bool
+	// IsDuplicate is true if the store holds more than one peer of the region,
+	// which only happens after a corrupted membership change. The step removes
+	// the peer of PeerID then, and keeps the other peers on the store.
+	IsDuplicate bool
 }
 
 // ConfVerChanged returns the delta value for version increased by this step.
 func (rp RemovePeer) ConfVerChanged(region *core.RegionInfo) uint64 {
+	if rp.IsDuplicate {
+		return typeutil.BoolToUint64(region.GetPeer(rp.PeerID) == nil)
+	}
 	id := region.GetStorePeer(rp.FromStore).GetId()
 	// 1. id == 0 -> The peer does not exist, it needs to return 1.
 	// 2. id != 0 && rp.PeerId == 0 -> No rp.PeerID is specified, and there is a Peer on the Store, it needs to return 0.
@@ -246,16 +253,36 @@ func (rp RemovePeer) ConfVerChanged(region *core.RegionInfo) uint64 {
 }
 
 func (rp RemovePeer) String() string {
+	if rp.IsDuplicate {
+		return fmt.Sprintf("remove duplicate peer %v on store %v", rp.PeerID, rp.FromStore)
+	}
 	return fmt.Sprintf("remove peer on store %v", rp.FromStore)
 }
 
 // IsFinish checks if current step is finished.
 func (rp RemovePeer) IsFinish(region *core.RegionInfo) bool {
+	if rp.IsDuplicate {
+		return region.GetPeer(rp.PeerID) == nil
+	}
 	return region.GetStorePeer(rp.FromStore) == nil
 }
 
+// GetPeer returns the peer of the region the step removes.
+func (rp RemovePeer) GetPeer(region *core.RegionInfo) *metapb.Peer {
+	if rp.IsDuplicate {
+		return region.GetPeer(rp.PeerID)
+	}
+	return region.GetStorePeer(rp.FromStore)
+}
+
 // CheckSafety checks if the step meets the safety properties.
 func (rp RemovePeer) CheckSafety(region *core.RegionInfo) error {
+	if rp.IsDuplicate {
+		if rp.PeerID == region.GetLeader().GetId() {
+			return errors.New("cannot remove leader peer")
+		}
+		return nil
+	}
 	if rp.FromStore == region.GetLeader().GetStoreId() {
 		return errors.New("cannot remove leader peer")
 	}
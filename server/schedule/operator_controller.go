@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -395,7 +396,7 @@ func (oc *OperatorController) checkAddOperator(ops ...*operator.Operator) bool {
 			operatorWaitCounter.WithLabelValues(op.Desc(), "epoch-not-match").Inc()
 			return false
 		}
-		if old := oc.operators[op.RegionID()]; old != nil && !isHigherPriorityOperator(op, old) {
+		if old := oc.operators[op.RegionID()]; old != nil && !isHigherPriorityOperator(op, old) && !isPreemptedBy(old, op) {
 			log.Debug("already have operator, cancel add operator",
 				zap.Uint64("region-id", op.RegionID()),
 				zap.Reflect("old", old))
@@ -433,6 +434,17 @@ func isHigherPriorityOperator(new, old *operator.Operator) bool {
 	return new.GetPriorityLevel() > old.GetPriorityLevel()
 }
 
+// isPreemptedBy returns true if the operator in flight is preempted by the new
+// one, which is more urgent.
+func isPreemptedBy(old, new *operator.Operator) bool {
+	for _, o := range new.Preempts() {
+		if o == old {
+			return true
+		}
+	}
+	return false
+}
+
 func (oc *OperatorController) addOperatorLocked(op *operator.Operator) bool {
 	regionID := op.RegionID()
 
@@ -441,6 +453,8 @@ func (oc *OperatorController) addOperatorLocked(op *operator.Operator) bool {
 		zap.Reflect("operator", op),
 		zap.String("additional-info", op.GetAdditionalInfo()))
 
+	oc.cancelPreemptedLocked(op)
+
 	// If there is an old operator, replace it. The priority should be checked
 	// already.
 	if old, ok := oc.operators[regionID]; ok {
@@ -515,6 +529,26 @@ func (oc *OperatorController) RemoveOperator(op *operator.Operator, extraFields
 	return removed
 }
 
+// cancelPreemptedLocked cancels the operators in flight which are preempted by
+// the operator, now that it is added. The ones which are already gone are
+// skipped.
+func (oc *OperatorController) cancelPreemptedLocked(op *operator.Operator) {
+	for _, old := range op.Preempts() {
+		if !oc.removeOperatorLocked(old) {
+			continue
+		}
+		if old.Cancel() {
+			log.Info("cancel operator in flight for a more urgent one",
+				zap.Uint64("region-id", old.RegionID()),
+				zap.Uint64("old-operator-id", old.ID()),
+				zap.Uint64("new-operator-id", op.ID()),
+				zap.Stringer("old-operator", old),
+				zap.Stringer("new-operator", op))
+		}
+		oc.buryOperator(old, zap.Uint64("preempted-by", op.ID()))
+	}
+}
+
 func (oc *OperatorController) removeOperatorWithoutBury(op *operator.Operator) bool {
 	oc.Lock()
 	defer oc.Unlock()
@@ -553,7 +587,8 @@ func (oc *OperatorController) buryOperator(op *operator.Operator, extraFields ..
 			zap.Uint64("region-id", op.RegionID()),
 			zap.Duration("takes", op.RunningTime()),
 			zap.Reflect("operator", op),
-			zap.String("additional-info", op.GetAdditionalInfo()))
+			zap.String("additional-info", op.GetAdditionalInfo()),
+			checkerField(op))
 		operatorCounter.WithLabelValues(op.Desc(), "finish").Inc()
 		operatorDuration.WithLabelValues(op.Desc()).Observe(op.RunningTime().Seconds())
 		for _, counter := range op.FinishedCounters {
@@ -563,25 +598,29 @@ func (oc *OperatorController) buryOperator(op *operator.Operator, extraFields ..
 		log.Info("replace old operator",
 			zap.Uint64("region-id", op.RegionID()),
 			zap.Duration("takes", op.RunningTime()),
-			zap.Reflect("operator", op))
+			zap.Reflect("operator", op),
+			checkerField(op))
 		operatorCounter.WithLabelValues(op.Desc(), "replace").Inc()
 	case operator.EXPIRED:
 		log.Info("operator expired",
 			zap.Uint64("region-id", op.RegionID()),
 			zap.Duration("lives", op.ElapsedTime()),
-			zap.Reflect("operator", op))
+			zap.Reflect("operator", op),
+			checkerField(op))
 		operatorCounter.WithLabelValues(op.Desc(), "expire").Inc()
 	case operator.TIMEOUT:
 		log.Info("operator timeout",
 			zap.Uint64("region-id", op.RegionID()),
 			zap.Duration("takes", op.RunningTime()),
-			zap.Reflect("operator", op))
+			zap.Reflect("operator", op),
+			checkerField(op))
 		operatorCounter.WithLabelValues(op.Desc(), "timeout").Inc()
 	case operator.CANCELED:
 		fields := []zap.Field{
 			zap.Uint64("region-id", op.RegionID()),
 			zap.Duration("takes", op.RunningTime()),
 			zap.Reflect("operator", op),
+			checkerField(op),
 		}
 		fields = append(fields, extraFields...)
 		log.Info("operator canceled",
@@ -589,10 +628,22 @@ func (oc *OperatorController) buryOperator(op *operator.Operator, extraFields ..
 		)
 		operatorCounter.WithLabelValues(op.Desc(), "cancel").Inc()
 	}
+	if checker := op.Checker(); checker != "" {
+		checkerOperatorCounter.WithLabelValues(checker, strings.ToLower(operator.OpStatusToString(op.Status()))).Inc()
+	}
 
 	oc.opRecords.Put(op)
 }
 
+// checkerField returns the log field of the checker which creates the operator,
+// which is skipped for the operators not created by a checker.
+func checkerField(op *operator.Operator) zap.Field {
+	if op.Checker() == "" {
+		return zap.Skip()
+	}
+	return zap.String("checker", op.Checker())
+}
+
 // GetOperatorStatus gets the operator and its status with the specify id.
 func (oc *OperatorController) GetOperatorStatus(id uint64) *OperatorWithStatus {
 	oc.Lock()
@@ -665,7 +716,7 @@ func (oc *OperatorController) SendScheduleCommand(region *core.RegionInfo, step
 		cmd = &pdpb.RegionHeartbeatResponse{
 			ChangePeer: &pdpb.ChangePeer{
 				ChangeType: eraftpb.ConfChangeType_RemoveNode,
-				Peer:       region.GetStorePeer(st.FromStore),
+				Peer:       st.GetPeer(region),
 			},
 		}
 	case operator.MergeRegion:
@@ -800,6 +851,79 @@ func (oc *OperatorController) GetOpInfluence(cluster opt.Cluster) operator.OpInf
 	return influence
 }
 
+// GetOperatorsByTargetStore gets the running operators grouped by the stores
+// they are sending snapshots to, i.e. the stores on which they are adding peers.
+func (oc *OperatorController) GetOperatorsByTargetStore() map[uint64][]*operator.Operator {
+	oc.RLock()
+	defer oc.RUnlock()
+	return oc.getOperatorsByTargetStoreLocked()
+}
+
+func (oc *OperatorController) getOperatorsByTargetStoreLocked() map[uint64][]*operator.Operator {
+	operators := make(map[uint64][]*operator.Operator)
+	for _, op := range oc.operators {
+		if op.CheckTimeout() || op.CheckSuccess() {
+			continue
+		}
+		for _, storeID := range oc.snapshotTargetStores(op) {
+			operators[storeID] = append(operators[storeID], op)
+		}
+	}
+	return operators
+}
+
+// snapshotTargetStores returns the stores to which the unfinished steps of the
+// operator send snapshots. Light weight peers are not counted.
+func (oc *OperatorController) snapshotTargetStores(op *operator.Operator) []uint64 {
+	region := oc.cluster.GetRegion(op.RegionID())
+	if region == nil {
+		return nil
+	}
+	var stores []uint64
+	for i := 0; i < op.Len(); i++ {
+		step := op.Step(i)
+		if step.IsFinish(region) {
+			continue
+		}
+		switch s := step.(type) {
+		case operator.AddPeer:
+			if !s.IsLightWeight {
+				stores = append(stores, s.ToStore)
+			}
+		case operator.AddLearner:
+			if !s.IsLightWeight {
+				stores = append(stores, s.ToStore)
+			}
+		}
+	}
+	return stores
+}
+
+// ExceedStoreSnapshotLimit returns true if adding the operators makes the number of running
+// operators which send snapshots to a store exceed the snapshot limit of the store.
+func (oc *OperatorController) ExceedStoreSnapshotLimit(ops ...*operator.Operator) bool {
+	oc.RLock()
+	defer oc.RUnlock()
+	var running map[uint64][]*operator.Operator
+	added := make(map[uint64]uint64)
+	for _, op := range ops {
+		for _, storeID := range oc.snapshotTargetStores(op) {
+			limit := oc.cluster.GetOpts().GetStoreSnapshotLimit(storeID)
+			if limit == 0 {
+				continue
+			}
+			if running == nil {
+				running = oc.getOperatorsByTargetStoreLocked()
+			}
+			added[storeID]++
+			if uint64(len(running[storeID]))+added[storeID] > limit {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // GetFastOpInfluence get fast finish operator influence
 func (oc *OperatorController) GetFastOpInfluence(cluster opt.Cluster, influence operator.OpInfluence) {
 	for _, id := range oc.fastOperators.GetAllID() {
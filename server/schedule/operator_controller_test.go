@@ -411,6 +411,46 @@ func (t *testOperatorControllerSuite) TestStoreLimit(c *C) {
 }
 
 // #1652
+func (t *testOperatorControllerSuite) TestStoreSnapshotLimit(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(t.ctx, opt)
+	oc := NewOperatorController(t.ctx, tc, nil)
+	tc.AddLeaderStore(1, 4)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderStore(3, 0)
+	for i := uint64(1); i <= 4; i++ {
+		tc.AddLeaderRegion(i, 1)
+	}
+	newAddPeer := func(regionID, storeID uint64) *operator.Operator {
+		op := operator.NewOperator("test", "test", regionID, &metapb.RegionEpoch{}, operator.OpRegion, operator.AddPeer{ToStore: storeID, PeerID: regionID + 10})
+		c.Assert(op.Start(), IsTrue)
+		return op
+	}
+	oc.SetOperator(newAddPeer(1, 2))
+	oc.SetOperator(newAddPeer(2, 2))
+	oc.SetOperator(newAddPeer(3, 3))
+	// a transfer leader operator doesn't send snapshots.
+	tl := operator.NewOperator("test", "test", 4, &metapb.RegionEpoch{}, operator.OpLeader, operator.TransferLeader{FromStore: 1, ToStore: 3})
+	c.Assert(tl.Start(), IsTrue)
+	oc.SetOperator(tl)
+
+	operators := oc.GetOperatorsByTargetStore()
+	c.Assert(operators, HasLen, 2)
+	c.Assert(operators[2], HasLen, 2)
+	c.Assert(operators[3], HasLen, 1)
+
+	// unlimited by default.
+	c.Assert(oc.ExceedStoreSnapshotLimit(newAddPeer(4, 2)), IsFalse)
+	opt.SetStoreSnapshotLimit(2, 2)
+	c.Assert(oc.ExceedStoreSnapshotLimit(newAddPeer(4, 2)), IsTrue)
+	c.Assert(oc.ExceedStoreSnapshotLimit(newAddPeer(4, 3)), IsFalse)
+	opt.SetStoreSnapshotLimit(3, 2)
+	c.Assert(oc.ExceedStoreSnapshotLimit(newAddPeer(4, 3)), IsFalse)
+	c.Assert(oc.ExceedStoreSnapshotLimit(newAddPeer(4, 3), newAddPeer(4, 3)), IsTrue)
+	opt.SetStoreSnapshotLimit(2, 0)
+	c.Assert(oc.ExceedStoreSnapshotLimit(newAddPeer(4, 2)), IsFalse)
+}
+
 func (t *testOperatorControllerSuite) TestDispatchOutdatedRegion(c *C) {
 	cluster := mockcluster.NewCluster(t.ctx, config.NewTestOptions())
 	stream := hbstream.NewTestHeartbeatStreams(t.ctx, cluster.ID, cluster, false /* no need to run */)
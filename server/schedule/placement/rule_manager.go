@@ -29,6 +29,7 @@ import (
 	"github.com/tikv/pd/pkg/codec"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
 	"go.uber.org/zap"
 )
 
@@ -569,6 +570,14 @@ func (m *RuleManager) GetGroupBundle(id string) (b GroupBundle) {
 func (m *RuleManager) SetAllGroupBundles(groups []GroupBundle, override bool) error {
 	m.Lock()
 	defer m.Unlock()
+	if err := m.setAllGroupBundlesLocked(groups, override); err != nil {
+		return err
+	}
+	log.Info("full config reset", zap.String("config", fmt.Sprint(groups)))
+	return nil
+}
+
+func (m *RuleManager) setAllGroupBundlesLocked(groups []GroupBundle, override bool) error {
 	p := m.beginPatch()
 	matchID := func(a string) bool {
 		for _, g := range groups {
@@ -601,11 +610,39 @@ func (m *RuleManager) SetAllGroupBundles(groups []GroupBundle, override bool) er
 			p.setRule(r)
 		}
 	}
-	if err := m.tryCommitPatch(p); err != nil {
-		return err
+	return m.tryCommitPatch(p)
+}
+
+// CloneWithGroupBundles returns a rule manager which holds the rules of the
+// manager reset by the groups like SetAllGroupBundles, and leaves the manager
+// unchanged. The returned manager keeps the rules in memory, so that the
+// rules can be tried out before they are set.
+func (m *RuleManager) CloneWithGroupBundles(groups []GroupBundle, override bool) (*RuleManager, error) {
+	clone := NewRuleManager(core.NewStorage(kv.NewMemoryKV()), m.storeSetInformer)
+	m.RLock()
+	clone.keyType = m.keyType
+	for _, r := range m.ruleConfig.rules {
+		clone.ruleConfig.setRule(r.Clone())
 	}
-	log.Info("full config reset", zap.String("config", fmt.Sprint(groups)))
-	return nil
+	for _, g := range m.ruleConfig.groups {
+		group := *g
+		clone.ruleConfig.setGroup(&group)
+	}
+	m.RUnlock()
+
+	clone.Lock()
+	defer clone.Unlock()
+	clone.ruleConfig.adjust()
+	ruleList, err := buildRuleList(clone.ruleConfig)
+	if err != nil {
+		return nil, err
+	}
+	clone.ruleList = ruleList
+	clone.initialized = true
+	if err := clone.setAllGroupBundlesLocked(groups, override); err != nil {
+		return nil, err
+	}
+	return clone, nil
 }
 
 // SetGroupBundle resets a Group and all rules belong to it. All old rules
@@ -303,6 +303,39 @@ func (s *testManagerSuite) TestGroupConfig(c *C) {
 	c.Assert(s.manager.GetRuleGroups(), DeepEquals, []*RuleGroup{g2})
 }
 
+func (s *testManagerSuite) TestCloneWithGroupBundles(c *C) {
+	c.Assert(s.manager.SetRuleGroup(&RuleGroup{ID: "pd", Index: 100}), IsNil)
+	c.Assert(s.manager.SetRule(&Rule{GroupID: "foo", ID: "bar", Role: "learner", Count: 1}), IsNil)
+	before := s.manager.GetAllGroupBundles()
+
+	clone, err := s.manager.CloneWithGroupBundles([]GroupBundle{{
+		ID:    "foo",
+		Rules: []*Rule{{ID: "baz", Role: "voter", Count: 2}},
+	}}, false)
+	c.Assert(err, IsNil)
+	s.checkRules(c, clone.GetAllRules(), [][2]string{{"foo", "baz"}, {"pd", "default"}})
+	c.Assert(clone.GetRuleGroup("pd").Index, Equals, 100)
+	clone, err = s.manager.CloneWithGroupBundles([]GroupBundle{{
+		ID:    "foo",
+		Rules: []*Rule{{ID: "baz", Role: "voter", Count: 2}},
+	}}, true)
+	c.Assert(err, IsNil)
+	s.checkRules(c, clone.GetAllRules(), [][2]string{{"foo", "baz"}})
+
+	// Invalid rules are rejected.
+	_, err = s.manager.CloneWithGroupBundles([]GroupBundle{{
+		ID:    "foo",
+		Rules: []*Rule{{ID: "baz", Role: "voter", Count: 0}},
+	}}, false)
+	c.Assert(err, NotNil)
+
+	// The manager and its storage are unchanged.
+	c.Assert(s.manager.GetAllGroupBundles(), DeepEquals, before)
+	m2 := NewRuleManager(s.store, nil)
+	c.Assert(m2.Initialize(3, []string{}), IsNil)
+	s.checkRules(c, m2.GetAllRules(), [][2]string{{"foo", "bar"}, {"pd", "default"}})
+}
+
 func (s *testManagerSuite) TestRuleVersion(c *C) {
 	// default rule
 	rule1 := s.manager.GetRule("pd", "default")
@@ -15,6 +15,7 @@
 package schedulers
 
 import (
+	"net/http"
 	"sort"
 	"strconv"
 
@@ -76,6 +77,7 @@ type balanceLeaderScheduler struct {
 	opController *schedule.OperatorController
 	filters      []filter.Filter
 	counter      *prometheus.CounterVec
+	explainer    *explanationRecorder
 }
 
 // newBalanceLeaderScheduler creates a scheduler that tends to keep leaders on
@@ -89,6 +91,7 @@ func newBalanceLeaderScheduler(opController *schedule.OperatorController, conf *
 		conf:          conf,
 		opController:  opController,
 		counter:       balanceLeaderCounter,
+		explainer:     newExplanationRecorder(),
 	}
 	for _, option := range options {
 		option(s)
@@ -117,6 +120,12 @@ func WithBalanceLeaderName(name string) BalanceLeaderCreateOption {
 	}
 }
 
+// ServeHTTP serves the explanations of the recent schedules, which are
+// recorded after they are enabled by the API.
+func (l *balanceLeaderScheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.explainer.ServeHTTP(w, r)
+}
+
 func (l *balanceLeaderScheduler) GetName() string {
 	return l.conf.Name
 }
@@ -144,21 +153,28 @@ func (l *balanceLeaderScheduler) Schedule(cluster opt.Cluster) []*operator.Opera
 	opInfluence := l.opController.GetOpInfluence(cluster)
 	kind := core.NewScheduleKind(core.LeaderKind, leaderSchedulePolicy)
 	plan := newBalancePlan(kind, cluster, opInfluence)
+	plan.explainer = l.explainer
 
 	stores := cluster.GetStores()
 	sources := filter.SelectSourceStores(stores, l.filters, cluster.GetOpts())
 	targets := filter.SelectTargetStores(stores, l.filters, cluster.GetOpts())
+	// Stores with the same score are sorted by store ID to make the
+	// scheduling deterministic.
 	sort.Slice(sources, func(i, j int) bool {
-		iOp := plan.GetOpInfluence(sources[i].GetID())
-		jOp := plan.GetOpInfluence(sources[j].GetID())
-		return sources[i].LeaderScore(leaderSchedulePolicy, iOp) >
-			sources[j].LeaderScore(leaderSchedulePolicy, jOp)
+		iScore := sources[i].LeaderScore(leaderSchedulePolicy, plan.GetOpInfluence(sources[i].GetID()))
+		jScore := sources[j].LeaderScore(leaderSchedulePolicy, plan.GetOpInfluence(sources[j].GetID()))
+		if iScore != jScore {
+			return iScore > jScore
+		}
+		return sources[i].GetID() < sources[j].GetID()
 	})
 	sort.Slice(targets, func(i, j int) bool {
-		iOp := plan.GetOpInfluence(targets[i].GetID())
-		jOp := plan.GetOpInfluence(targets[j].GetID())
-		return targets[i].LeaderScore(leaderSchedulePolicy, iOp) <
-			targets[j].LeaderScore(leaderSchedulePolicy, jOp)
+		iScore := targets[i].LeaderScore(leaderSchedulePolicy, plan.GetOpInfluence(targets[i].GetID()))
+		jScore := targets[j].LeaderScore(leaderSchedulePolicy, plan.GetOpInfluence(targets[j].GetID()))
+		if iScore != jScore {
+			return iScore < jScore
+		}
+		return targets[i].GetID() < targets[j].GetID()
 	})
 
 	for i := 0; i < len(sources) || i < len(targets); i++ {
@@ -217,9 +233,12 @@ func (l *balanceLeaderScheduler) transferLeaderOut(plan *balancePlan) []*operato
 	targets = filter.SelectTargetStores(targets, finalFilters, plan.cluster.GetOpts())
 	leaderSchedulePolicy := plan.cluster.GetOpts().GetLeaderSchedulePolicy()
 	sort.Slice(targets, func(i, j int) bool {
-		iOp := plan.GetOpInfluence(targets[i].GetID())
-		jOp := plan.GetOpInfluence(targets[j].GetID())
-		return targets[i].LeaderScore(leaderSchedulePolicy, iOp) < targets[j].LeaderScore(leaderSchedulePolicy, jOp)
+		iScore := targets[i].LeaderScore(leaderSchedulePolicy, plan.GetOpInfluence(targets[i].GetID()))
+		jScore := targets[j].LeaderScore(leaderSchedulePolicy, plan.GetOpInfluence(targets[j].GetID()))
+		if iScore != jScore {
+			return iScore < jScore
+		}
+		return targets[i].GetID() < targets[j].GetID()
 	})
 	for _, plan.target = range targets {
 		if op := l.createOperator(plan); len(op) > 0 {
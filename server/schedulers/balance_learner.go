@@ -0,0 +1,206 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/checker"
+	"github.com/tikv/pd/server/schedule/filter"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"go.uber.org/zap"
+)
+
+func init() {
+	schedule.RegisterSliceDecoderBuilder(BalanceLearnerType, func(args []string) schedule.ConfigDecoder {
+		return func(v interface{}) error {
+			conf, ok := v.(*balanceLearnerSchedulerConfig)
+			if !ok {
+				return errs.ErrScheduleConfigNotExist.FastGenByArgs()
+			}
+			ranges, err := getKeyRanges(args)
+			if err != nil {
+				return err
+			}
+			conf.Ranges = ranges
+			conf.Name = BalanceLearnerName
+			return nil
+		}
+	})
+	schedule.RegisterScheduler(BalanceLearnerType, func(opController *schedule.OperatorController, storage *core.Storage, decoder schedule.ConfigDecoder) (schedule.Scheduler, error) {
+		conf := &balanceLearnerSchedulerConfig{}
+		if err := decoder(conf); err != nil {
+			return nil, err
+		}
+		return newBalanceLearnerScheduler(opController, conf), nil
+	})
+}
+
+const (
+	// balanceLearnerRetryLimit is the limit to retry schedule for selected store.
+	balanceLearnerRetryLimit = 10
+	// balanceLearnerTolerance is the least difference of the learner counts
+	// between the source and the target store, below which moving a learner
+	// only swaps the imbalance.
+	balanceLearnerTolerance = 2
+	// BalanceLearnerName is balance learner scheduler name.
+	BalanceLearnerName = "balance-learner-scheduler"
+	// BalanceLearnerType is balance learner scheduler type.
+	BalanceLearnerType = "balance-learner"
+)
+
+type balanceLearnerSchedulerConfig struct {
+	Name   string          `json:"name"`
+	Ranges []core.KeyRange `json:"ranges"`
+}
+
+// balanceLearnerScheduler balances the learner counts of the stores, so that
+// the snapshots sent to the learners which are catching up are spread out.
+// It only moves the learners the learner checker is not going to promote.
+type balanceLearnerScheduler struct {
+	*BaseScheduler
+	conf    *balanceLearnerSchedulerConfig
+	filters []filter.Filter
+}
+
+// newBalanceLearnerScheduler creates a scheduler that tends to keep learners
+// on each store balanced.
+func newBalanceLearnerScheduler(opController *schedule.OperatorController, conf *balanceLearnerSchedulerConfig) schedule.Scheduler {
+	s := &balanceLearnerScheduler{
+		BaseScheduler: NewBaseScheduler(opController),
+		conf:          conf,
+	}
+	s.filters = []filter.Filter{
+		&filter.StoreStateFilter{ActionScope: s.GetName(), MoveRegion: true},
+		filter.NewSpecialUseFilter(s.GetName()),
+	}
+	return s
+}
+
+func (s *balanceLearnerScheduler) GetName() string {
+	return s.conf.Name
+}
+
+func (s *balanceLearnerScheduler) GetType() string {
+	return BalanceLearnerType
+}
+
+func (s *balanceLearnerScheduler) EncodeConfig() ([]byte, error) {
+	return schedule.EncodeConfig(s.conf)
+}
+
+func (s *balanceLearnerScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
+	allowed := s.OpController.OperatorCount(operator.OpRegion) < cluster.GetOpts().GetRegionScheduleLimit()
+	if !allowed {
+		operator.OperatorLimitCounter.WithLabelValues(s.GetType(), operator.OpRegion.String()).Inc()
+	}
+	return allowed
+}
+
+func (s *balanceLearnerScheduler) Schedule(cluster opt.Cluster) []*operator.Operator {
+	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+	stores := filter.SelectSourceStores(cluster.GetStores(), s.filters, cluster.GetOpts())
+	opInfluence := s.OpController.GetOpInfluence(cluster)
+	learnerCount := func(storeID uint64) int64 {
+		return int64(cluster.GetStoreLearnerCount(storeID)) + opInfluence.GetStoreInfluence(storeID).RegionCount
+	}
+	sort.Slice(stores, func(i, j int) bool {
+		if iCount, jCount := learnerCount(stores[i].GetID()), learnerCount(stores[j].GetID()); iCount != jCount {
+			return iCount > jCount
+		}
+		return stores[i].GetID() < stores[j].GetID()
+	})
+
+	learnerChecker := checker.NewLearnerChecker(cluster)
+	for _, source := range stores {
+		sourceID := source.GetID()
+		for i := 0; i < balanceLearnerRetryLimit; i++ {
+			schedulerCounter.WithLabelValues(s.GetName(), "total").Inc()
+			region := cluster.RandLearnerRegion(sourceID, s.conf.Ranges, opt.HealthAllowPending(cluster))
+			if region == nil {
+				schedulerCounter.WithLabelValues(s.GetName(), "no-region").Inc()
+				break
+			}
+			// The learner may be being promoted or removed by the checkers.
+			if s.OpController.GetOperator(region.GetID()) != nil {
+				schedulerCounter.WithLabelValues(s.GetName(), "operator-exists").Inc()
+				continue
+			}
+			// The learner checker only promotes the learners when the placement
+			// rules are disabled. Moving a learner which has caught up throws away
+			// the snapshot it has received.
+			if !cluster.GetOpts().IsPlacementRulesEnabled() && learnerChecker.WillPromote(region, sourceID) {
+				schedulerCounter.WithLabelValues(s.GetName(), "learner-promoting").Inc()
+				continue
+			}
+			if op := s.transferLearner(cluster, region, source, learnerCount); op != nil {
+				op.Counters = append(op.Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-operator"))
+				return []*operator.Operator{op}
+			}
+		}
+	}
+	return nil
+}
+
+// transferLearner moves the learner in the source store to the store with the
+// least learners.
+func (s *balanceLearnerScheduler) transferLearner(cluster opt.Cluster, region *core.RegionInfo, source *core.StoreInfo, learnerCount func(uint64) int64) *operator.Operator {
+	filters := []filter.Filter{
+		filter.NewExcludedFilter(s.GetName(), nil, region.GetStoreIds()),
+		filter.NewPlacementSafeguard(s.GetName(), cluster, region, source),
+		filter.NewSpecialUseFilter(s.GetName()),
+		&filter.StoreStateFilter{ActionScope: s.GetName(), MoveRegion: true},
+	}
+	candidates := filter.NewCandidates(cluster.GetStores()).
+		FilterTarget(cluster.GetOpts(), filters...).Stores
+	if len(candidates) == 0 {
+		schedulerCounter.WithLabelValues(s.GetName(), "no-target").Inc()
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if iCount, jCount := learnerCount(candidates[i].GetID()), learnerCount(candidates[j].GetID()); iCount != jCount {
+			return iCount < jCount
+		}
+		return candidates[i].GetID() < candidates[j].GetID()
+	})
+	target := candidates[0]
+
+	sourceID, targetID := source.GetID(), target.GetID()
+	if learnerCount(sourceID)-learnerCount(targetID) < balanceLearnerTolerance {
+		schedulerCounter.WithLabelValues(s.GetName(), "skip").Inc()
+		return nil
+	}
+
+	newPeer := &metapb.Peer{StoreId: targetID, Role: metapb.PeerRole_Learner}
+	op, err := operator.CreateMovePeerOperator(BalanceLearnerType, cluster, region, operator.OpRegion, sourceID, newPeer)
+	if err != nil {
+		log.Debug("fail to create balance learner operator", zap.Uint64("region-id", region.GetID()), errs.ZapError(err))
+		schedulerCounter.WithLabelValues(s.GetName(), "create-operator-fail").Inc()
+		return nil
+	}
+	sourceLabel := strconv.FormatUint(sourceID, 10)
+	targetLabel := strconv.FormatUint(targetID, 10)
+	op.FinishedCounters = append(op.FinishedCounters,
+		balanceDirectionCounter.WithLabelValues(s.GetName(), sourceLabel, targetLabel),
+	)
+	return op
+}
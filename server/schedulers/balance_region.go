@@ -15,6 +15,7 @@
 package schedulers
 
 import (
+	"net/http"
 	"sort"
 	"strconv"
 
@@ -76,6 +77,7 @@ type balanceRegionScheduler struct {
 	opController *schedule.OperatorController
 	filters      []filter.Filter
 	counter      *prometheus.CounterVec
+	explainer    *explanationRecorder
 }
 
 // newBalanceRegionScheduler creates a scheduler that tends to keep regions on
@@ -88,6 +90,7 @@ func newBalanceRegionScheduler(opController *schedule.OperatorController, conf *
 		conf:          conf,
 		opController:  opController,
 		counter:       balanceRegionCounter,
+		explainer:     newExplanationRecorder(),
 	}
 	for _, setOption := range opts {
 		setOption(scheduler)
@@ -116,6 +119,12 @@ func WithBalanceRegionName(name string) BalanceRegionCreateOption {
 	}
 }
 
+// ServeHTTP serves the explanations of the recent schedules, which are
+// recorded after they are enabled by the API.
+func (s *balanceRegionScheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.explainer.ServeHTTP(w, r)
+}
+
 func (s *balanceRegionScheduler) GetName() string {
 	return s.conf.Name
 }
@@ -145,12 +154,19 @@ func (s *balanceRegionScheduler) Schedule(cluster opt.Cluster) []*operator.Opera
 	s.OpController.GetFastOpInfluence(cluster, opInfluence)
 	kind := core.NewScheduleKind(core.RegionKind, core.BySize)
 	plan := newBalancePlan(kind, cluster, opInfluence)
+	plan.explainer = s.explainer
 
+	// Stores with the same score are sorted by store ID to make the
+	// scheduling deterministic.
 	sort.Slice(stores, func(i, j int) bool {
 		iOp := plan.GetOpInfluence(stores[i].GetID())
 		jOp := plan.GetOpInfluence(stores[j].GetID())
-		return stores[i].RegionScore(opts.GetRegionScoreFormulaVersion(), opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), iOp) >
-			stores[j].RegionScore(opts.GetRegionScoreFormulaVersion(), opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), jOp)
+		iScore := stores[i].RegionScore(opts.GetRegionScoreFormulaVersion(), opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), iOp)
+		jScore := stores[j].RegionScore(opts.GetRegionScoreFormulaVersion(), opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), jOp)
+		if iScore != jScore {
+			return iScore > jScore
+		}
+		return stores[i].GetID() < stores[j].GetID()
 	})
 
 	var allowBalanceEmptyRegion func(*core.RegionInfo) bool
@@ -0,0 +1,336 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/filter"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/unrolled/render"
+	"go.uber.org/zap"
+)
+
+func init() {
+	schedule.RegisterSliceDecoderBuilder(BalanceRegionSizeType, func(args []string) schedule.ConfigDecoder {
+		return func(v interface{}) error {
+			conf, ok := v.(*balanceRegionSizeSchedulerConfig)
+			if !ok {
+				return errs.ErrScheduleConfigNotExist.FastGenByArgs()
+			}
+			ranges, err := getKeyRanges(args)
+			if err != nil {
+				return err
+			}
+			conf.Ranges = ranges
+			conf.ToleranceRatio = defaultBalanceRegionSizeToleranceRatio
+			conf.Name = BalanceRegionSizeName
+			return nil
+		}
+	})
+	schedule.RegisterScheduler(BalanceRegionSizeType, func(opController *schedule.OperatorController, storage *core.Storage, decoder schedule.ConfigDecoder) (schedule.Scheduler, error) {
+		conf := &balanceRegionSizeSchedulerConfig{storage: storage}
+		if err := decoder(conf); err != nil {
+			return nil, err
+		}
+		return newBalanceRegionSizeScheduler(opController, conf), nil
+	})
+}
+
+const (
+	// balanceRegionSizeRetryLimit is the number of the regions sampled from
+	// the selected store, the largest of which is tried first.
+	balanceRegionSizeRetryLimit = 10
+	// defaultBalanceRegionSizeToleranceRatio is the default ratio of the
+	// region size of the source store, below which the difference of the
+	// region sizes between the source and the target store is ignored.
+	defaultBalanceRegionSizeToleranceRatio = 0.05
+	// BalanceRegionSizeName is balance region size scheduler name.
+	BalanceRegionSizeName = "balance-region-size-scheduler"
+	// BalanceRegionSizeType is balance region size scheduler type.
+	BalanceRegionSizeType = "balance-region-size"
+)
+
+type balanceRegionSizeSchedulerConfig struct {
+	sync.RWMutex
+	storage *core.Storage
+
+	Name           string          `json:"name"`
+	Ranges         []core.KeyRange `json:"ranges"`
+	ToleranceRatio float64         `json:"tolerance-ratio"`
+}
+
+func (conf *balanceRegionSizeSchedulerConfig) EncodeConfig() ([]byte, error) {
+	conf.RLock()
+	defer conf.RUnlock()
+	return schedule.EncodeConfig(conf)
+}
+
+func (conf *balanceRegionSizeSchedulerConfig) getToleranceRatio() float64 {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.ToleranceRatio
+}
+
+func (conf *balanceRegionSizeSchedulerConfig) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	router := mux.NewRouter()
+	router.HandleFunc("/list", conf.handleGetConfig).Methods("GET")
+	router.HandleFunc("/config", conf.handleSetConfig).Methods("POST")
+	router.ServeHTTP(w, r)
+}
+
+func (conf *balanceRegionSizeSchedulerConfig) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	conf.RLock()
+	defer conf.RUnlock()
+	rd := render.New(render.Options{IndentJSON: true})
+	rd.JSON(w, http.StatusOK, conf)
+}
+
+func (conf *balanceRegionSizeSchedulerConfig) handleSetConfig(w http.ResponseWriter, r *http.Request) {
+	rd := render.New(render.Options{IndentJSON: true})
+	var input map[string]interface{}
+	if err := apiutil.ReadJSONRespondError(rd, w, r.Body, &input); err != nil {
+		return
+	}
+	ratio, ok := input["tolerance-ratio"].(float64)
+	if !ok {
+		rd.JSON(w, http.StatusBadRequest, "config item not found")
+		return
+	}
+	if ratio < 0 || ratio >= 1 {
+		rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("tolerance-ratio should be in [0, 1), got %v", ratio))
+		return
+	}
+
+	conf.Lock()
+	defer conf.Unlock()
+	old := conf.ToleranceRatio
+	conf.ToleranceRatio = ratio
+	if err := conf.persistLocked(); err != nil {
+		conf.ToleranceRatio = old // revert
+		rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rd.JSON(w, http.StatusOK, "success")
+}
+
+func (conf *balanceRegionSizeSchedulerConfig) persistLocked() error {
+	data, err := schedule.EncodeConfig(conf)
+	if err != nil {
+		return err
+	}
+	return conf.storage.SaveScheduleConfig(conf.Name, data)
+}
+
+// balanceRegionSizeScheduler balances the total region sizes of the stores,
+// rather than the region scores the balance region scheduler balances, so
+// that the stores hold about the same bytes when the region sizes vary a lot.
+// It moves the larger regions first, and never moves a region which would
+// make the target store larger than the source store. It can not run together
+// with the balance region scheduler, which would fight with it.
+type balanceRegionSizeScheduler struct {
+	*BaseScheduler
+	conf    *balanceRegionSizeSchedulerConfig
+	filters []filter.Filter
+}
+
+// newBalanceRegionSizeScheduler creates a scheduler that tends to keep the
+// region sizes of the stores balanced.
+func newBalanceRegionSizeScheduler(opController *schedule.OperatorController, conf *balanceRegionSizeSchedulerConfig) schedule.Scheduler {
+	s := &balanceRegionSizeScheduler{
+		BaseScheduler: NewBaseScheduler(opController),
+		conf:          conf,
+	}
+	s.filters = []filter.Filter{
+		&filter.StoreStateFilter{ActionScope: s.GetName(), MoveRegion: true},
+		filter.NewSpecialUseFilter(s.GetName()),
+	}
+	return s
+}
+
+func (s *balanceRegionSizeScheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.conf.ServeHTTP(w, r)
+}
+
+func (s *balanceRegionSizeScheduler) GetName() string {
+	return s.conf.Name
+}
+
+func (s *balanceRegionSizeScheduler) GetType() string {
+	return BalanceRegionSizeType
+}
+
+func (s *balanceRegionSizeScheduler) EncodeConfig() ([]byte, error) {
+	return s.conf.EncodeConfig()
+}
+
+func (s *balanceRegionSizeScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
+	allowed := s.OpController.OperatorCount(operator.OpRegion) < cluster.GetOpts().GetRegionScheduleLimit()
+	if !allowed {
+		operator.OperatorLimitCounter.WithLabelValues(s.GetType(), operator.OpRegion.String()).Inc()
+	}
+	return allowed
+}
+
+func (s *balanceRegionSizeScheduler) Schedule(cluster opt.Cluster) []*operator.Operator {
+	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+	stores := filter.SelectSourceStores(cluster.GetStores(), s.filters, cluster.GetOpts())
+	opInfluence := s.OpController.GetOpInfluence(cluster)
+	regionSize := func(storeID uint64) int64 {
+		return cluster.GetStore(storeID).GetRegionSize() + opInfluence.GetStoreInfluence(storeID).RegionSize
+	}
+	sort.Slice(stores, func(i, j int) bool {
+		if iSize, jSize := regionSize(stores[i].GetID()), regionSize(stores[j].GetID()); iSize != jSize {
+			return iSize > jSize
+		}
+		return stores[i].GetID() < stores[j].GetID()
+	})
+
+	for _, source := range stores {
+		for _, region := range s.sampleRegions(cluster, source.GetID()) {
+			if op := s.transferPeer(cluster, region, source, regionSize); op != nil {
+				op.Counters = append(op.Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-operator"))
+				return []*operator.Operator{op}
+			}
+		}
+	}
+	return nil
+}
+
+// sampleRegions picks some distinct regions with a peer in the store, and
+// returns them from the largest to the smallest.
+func (s *balanceRegionSizeScheduler) sampleRegions(cluster opt.Cluster, storeID uint64) []*core.RegionInfo {
+	sampled := make(map[uint64]*core.RegionInfo)
+	notSampled := func(region *core.RegionInfo) bool {
+		_, ok := sampled[region.GetID()]
+		return !ok
+	}
+	for i := 0; i < balanceRegionSizeRetryLimit; i++ {
+		schedulerCounter.WithLabelValues(s.GetName(), "total").Inc()
+		region := cluster.RandFollowerRegion(storeID, s.conf.Ranges, opt.HealthRegion(cluster), opt.ReplicatedRegion(cluster), notSampled)
+		if region == nil {
+			region = cluster.RandLeaderRegion(storeID, s.conf.Ranges, opt.HealthRegion(cluster), opt.ReplicatedRegion(cluster), notSampled)
+		}
+		if region == nil {
+			// The random picks may all hit the regions sampled already.
+			if len(sampled) > 0 {
+				continue
+			}
+			schedulerCounter.WithLabelValues(s.GetName(), "no-region").Inc()
+			break
+		}
+		if cluster.IsRegionHot(region) {
+			schedulerCounter.WithLabelValues(s.GetName(), "region-hot").Inc()
+			continue
+		}
+		sampled[region.GetID()] = region
+	}
+	regions := make([]*core.RegionInfo, 0, len(sampled))
+	for _, region := range sampled {
+		regions = append(regions, region)
+	}
+	sort.Slice(regions, func(i, j int) bool {
+		if regions[i].GetApproximateSize() != regions[j].GetApproximateSize() {
+			return regions[i].GetApproximateSize() > regions[j].GetApproximateSize()
+		}
+		return regions[i].GetID() < regions[j].GetID()
+	})
+	return regions
+}
+
+// transferPeer moves the peer in the source store to the store with the least
+// region size, if the difference of the region sizes between them exceeds the
+// tolerance and moving the region does not overshoot.
+func (s *balanceRegionSizeScheduler) transferPeer(cluster opt.Cluster, region *core.RegionInfo, source *core.StoreInfo, regionSize func(uint64) int64) *operator.Operator {
+	filters := []filter.Filter{
+		filter.NewExcludedFilter(s.GetName(), nil, region.GetStoreIds()),
+		filter.NewPlacementSafeguard(s.GetName(), cluster, region, source),
+		filter.NewSpecialUseFilter(s.GetName()),
+		&filter.StoreStateFilter{ActionScope: s.GetName(), MoveRegion: true},
+	}
+	candidates := filter.NewCandidates(cluster.GetStores()).
+		FilterTarget(cluster.GetOpts(), filters...).Stores
+	if len(candidates) == 0 {
+		schedulerCounter.WithLabelValues(s.GetName(), "no-target").Inc()
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if iSize, jSize := regionSize(candidates[i].GetID()), regionSize(candidates[j].GetID()); iSize != jSize {
+			return iSize < jSize
+		}
+		return candidates[i].GetID() < candidates[j].GetID()
+	})
+	target := candidates[0]
+
+	sourceID, targetID := source.GetID(), target.GetID()
+	sourceSize, targetSize := regionSize(sourceID), regionSize(targetID)
+	diff := sourceSize - targetSize
+	if float64(diff) <= float64(sourceSize)*s.conf.getToleranceRatio() {
+		schedulerCounter.WithLabelValues(s.GetName(), "skip").Inc()
+		return nil
+	}
+	// Moving the region makes the target store larger than the source store.
+	if 2*region.GetApproximateSize() > diff {
+		schedulerCounter.WithLabelValues(s.GetName(), "overshoot").Inc()
+		return nil
+	}
+
+	oldPeer := region.GetStorePeer(sourceID)
+	newPeer := &metapb.Peer{StoreId: targetID, Role: oldPeer.GetRole()}
+	op, err := operator.CreateMovePeerOperator(BalanceRegionSizeType, cluster, region, operator.OpRegion, sourceID, newPeer)
+	if err != nil {
+		log.Debug("fail to create balance region size operator", zap.Uint64("region-id", region.GetID()), errs.ZapError(err))
+		schedulerCounter.WithLabelValues(s.GetName(), "create-operator-fail").Inc()
+		return nil
+	}
+	sourceLabel := strconv.FormatUint(sourceID, 10)
+	targetLabel := strconv.FormatUint(targetID, 10)
+	op.FinishedCounters = append(op.FinishedCounters,
+		balanceDirectionCounter.WithLabelValues(s.GetName(), sourceLabel, targetLabel),
+	)
+	op.AdditionalInfos["sourceSize"] = strconv.FormatInt(sourceSize, 10)
+	op.AdditionalInfos["targetSize"] = strconv.FormatInt(targetSize, 10)
+	return op
+}
+
+// exclusiveSchedulerTypes are the types of the schedulers which can not run
+// together, for they balance the same thing by different measures.
+var exclusiveSchedulerTypes = map[string][]string{
+	BalanceRegionType:     {BalanceRegionSizeType},
+	BalanceRegionSizeType: {BalanceRegionType},
+}
+
+// IsExclusive returns true if the schedulers of the two types can not run
+// together.
+func IsExclusive(typ1, typ2 string) bool {
+	for _, typ := range exclusiveSchedulerTypes[typ1] {
+		if typ == typ2 {
+			return true
+		}
+	}
+	return false
+}
@@ -255,6 +255,68 @@ func (s *testBalanceLeaderSchedulerSuite) TestBalanceLimit(c *C) {
 	c.Check(s.schedule(), NotNil)
 }
 
+func (s *testBalanceLeaderSchedulerSuite) TestTieBreak(c *C) {
+	// Stores:     1    2    3    4
+	// Leaders:    16   16   0    0
+	// Region1:    L    F    F    F
+	// Region2:    F    L    F    F
+	s.tc.AddLeaderStore(1, 16)
+	s.tc.AddLeaderStore(2, 16)
+	s.tc.AddLeaderStore(3, 0)
+	s.tc.AddLeaderStore(4, 0)
+	s.tc.AddLeaderRegion(1, 1, 2, 3, 4)
+	s.tc.AddLeaderRegion(2, 2, 1, 3, 4)
+	// The stores with the same score are picked by store ID.
+	for i := 0; i < 10; i++ {
+		testutil.CheckTransferLeader(c, s.schedule()[0], operator.OpKind(0), 1, 3)
+	}
+}
+
+func (s *testBalanceLeaderSchedulerSuite) TestExplanations(c *C) {
+	// Stores:     1    2    3    4
+	// Leaders:    16   0    0    0
+	// Region1:    L    F    F    F
+	s.tc.AddLeaderStore(1, 16)
+	s.tc.AddLeaderStore(2, 0)
+	s.tc.AddLeaderStore(3, 0)
+	s.tc.AddLeaderStore(4, 0)
+	s.tc.AddLeaderRegion(1, 1, 2, 3, 4)
+	explainer := s.lb.(*balanceLeaderScheduler).explainer
+
+	// Nothing is recorded before the explanations are enabled.
+	c.Assert(s.schedule(), NotNil)
+	c.Assert(explainer.list(), HasLen, 0)
+
+	explainer.setEnabled(true)
+	c.Assert(s.schedule(), NotNil)
+	explanations := explainer.list()
+	c.Assert(explanations, HasLen, 1)
+	c.Assert(explanations[0].RegionID, Equals, uint64(1))
+	c.Assert(explanations[0].SourceStore, Equals, uint64(1))
+	c.Assert(explanations[0].TargetStore, Equals, uint64(2))
+	c.Assert(explanations[0].SourceScore > explanations[0].TargetScore, IsTrue)
+	c.Assert(explanations[0].Decision, Equals, ExplanationDecisionBalance)
+
+	// The leaders are balanced enough, so the scheduler skips.
+	s.tc.UpdateLeaderCount(1, 1)
+	c.Assert(s.schedule(), IsNil)
+	explanations = explainer.list()
+	c.Assert(len(explanations) > 1, IsTrue)
+	c.Assert(explanations[len(explanations)-1].Decision, Equals, ExplanationDecisionSkip)
+
+	// Only the recent explanations are kept, from the oldest to the latest.
+	for i := 0; i < maxScheduleExplanations+10; i++ {
+		explainer.record(&ScheduleExplanation{RegionID: uint64(i)})
+	}
+	explanations = explainer.list()
+	c.Assert(explanations, HasLen, maxScheduleExplanations)
+	c.Assert(explanations[0].RegionID, Equals, uint64(10))
+	c.Assert(explanations[maxScheduleExplanations-1].RegionID, Equals, uint64(maxScheduleExplanations+9))
+
+	explainer.setEnabled(false)
+	c.Assert(explainer.list(), HasLen, 0)
+}
+
 func (s *testBalanceLeaderSchedulerSuite) TestBalanceLeaderSchedulePolicy(c *C) {
 	// Stores:          1       2       3       4
 	// Leader Count:    10      10      10      10
@@ -989,6 +1051,116 @@ func (s *testBalanceRegionSchedulerSuite) TestEmptyRegion(c *C) {
 	c.Assert(operators, IsNil)
 }
 
+var _ = Suite(&testBalanceLearnerSchedulerSuite{})
+
+type testBalanceLearnerSchedulerSuite struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *testBalanceLearnerSchedulerSuite) SetUpSuite(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+}
+
+func (s *testBalanceLearnerSchedulerSuite) TearDownSuite(c *C) {
+	s.cancel()
+}
+
+func (s *testBalanceLearnerSchedulerSuite) TestBalance(c *C) {
+	opt := config.NewTestOptions()
+	opt.SetPlacementRuleEnabled(false)
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	tc.DisableFeature(versioninfo.JointConsensus)
+	tc.SetMaxReplicas(3)
+	oc := schedule.NewOperatorController(s.ctx, nil, nil)
+
+	sb, err := schedule.CreateScheduler(BalanceLearnerType, oc, core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(BalanceLearnerType, []string{"", ""}))
+	c.Assert(err, IsNil)
+	c.Assert(sb.IsScheduleAllowed(tc), IsTrue)
+
+	for i := uint64(1); i <= 5; i++ {
+		tc.AddRegionStore(i, 10)
+	}
+	// The learners in store 4 have caught up, and are left to the learner checker.
+	for i := uint64(1); i <= 3; i++ {
+		tc.AddRegionWithLearner(i, 1, []uint64{2, 3}, []uint64{4})
+	}
+	c.Assert(sb.Schedule(tc), HasLen, 0)
+
+	// The learners which are catching up are moved to the store with the least learners.
+	for i := uint64(1); i <= 3; i++ {
+		region := tc.GetRegion(i)
+		tc.PutRegion(region.Clone(core.WithPendingPeers([]*metapb.Peer{region.GetStoreLearner(4)})))
+	}
+	tc.AddRegionWithLearner(4, 1, []uint64{2, 3}, []uint64{5})
+	testutil.CheckTransferLearner(c, sb.Schedule(tc)[0], operator.OpRegion, 4, 5)
+
+	// The learner counts are close enough.
+	tc.AddRegionWithLearner(5, 1, []uint64{2, 3}, []uint64{5})
+	c.Assert(sb.Schedule(tc), HasLen, 0)
+}
+
+var _ = Suite(&testBalanceRegionSizeSchedulerSuite{})
+
+type testBalanceRegionSizeSchedulerSuite struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *testBalanceRegionSizeSchedulerSuite) SetUpSuite(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+}
+
+func (s *testBalanceRegionSizeSchedulerSuite) TearDownSuite(c *C) {
+	s.cancel()
+}
+
+func (s *testBalanceRegionSizeSchedulerSuite) TestBalance(c *C) {
+	opt := config.NewTestOptions()
+	opt.SetPlacementRuleEnabled(false)
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	tc.DisableFeature(versioninfo.JointConsensus)
+	tc.SetMaxReplicas(3)
+	oc := schedule.NewOperatorController(s.ctx, nil, nil)
+
+	sb, err := schedule.CreateScheduler(BalanceRegionSizeType, oc, core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(BalanceRegionSizeType, []string{"", ""}))
+	c.Assert(err, IsNil)
+	c.Assert(sb.IsScheduleAllowed(tc), IsTrue)
+
+	// The region sizes of the stores are 3840MB, 1920MB, 1920MB and 960MB.
+	tc.AddRegionStore(1, 40)
+	tc.AddRegionStore(2, 20)
+	tc.AddRegionStore(3, 20)
+	tc.AddRegionStore(4, 10)
+	putRegion := func(id uint64, size int64) {
+		region := tc.AddLeaderRegion(id, 1, 2, 3)
+		tc.PutRegion(region.Clone(core.SetApproximateSize(size)))
+	}
+
+	// Moving the region makes store 4 larger than store 1.
+	putRegion(1, 1500)
+	c.Assert(sb.Schedule(tc), HasLen, 0)
+
+	// The largest region which does not overshoot is moved.
+	putRegion(2, 100)
+	putRegion(3, 1000)
+	for i := 0; i < 10; i++ {
+		op := sb.Schedule(tc)[0]
+		testutil.CheckTransferPeer(c, op, operator.OpKind(0), 1, 4)
+		c.Assert(op.RegionID(), Equals, uint64(3))
+	}
+
+	// The region sizes are close enough.
+	tc.AddRegionStore(4, 38)
+	putRegion(4, 90)
+	c.Assert(sb.Schedule(tc), HasLen, 0)
+	// Unless the tolerance is lowered.
+	sb.(*balanceRegionSizeScheduler).conf.ToleranceRatio = 0.01
+	op := sb.Schedule(tc)[0]
+	testutil.CheckTransferPeer(c, op, operator.OpKind(0), 1, 4)
+	c.Assert(op.RegionID(), Equals, uint64(4))
+}
+
 var _ = Suite(&testRandomMergeSchedulerSuite{})
 
 type testRandomMergeSchedulerSuite struct {
@@ -0,0 +1,239 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"sort"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/filter"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/tikv/pd/server/schedule/placement"
+	"go.uber.org/zap"
+)
+
+const (
+	// BalanceZoneRegionName is balance zone region scheduler name.
+	BalanceZoneRegionName = "balance-zone-region-scheduler"
+	// BalanceZoneRegionType is balance zone region scheduler type.
+	BalanceZoneRegionType = "balance-zone-region"
+
+	// defaultBalanceZoneLabel is the default label key which the zones are grouped by.
+	defaultBalanceZoneLabel = "zone"
+	// balanceZoneRegionRetryLimit is the limit to retry schedule for selected source store.
+	balanceZoneRegionRetryLimit = 10
+)
+
+func init() {
+	schedule.RegisterSliceDecoderBuilder(BalanceZoneRegionType, func(args []string) schedule.ConfigDecoder {
+		return func(v interface{}) error {
+			if len(args) > 1 {
+				return errs.ErrSchedulerConfig.FastGenByArgs("label-key")
+			}
+			conf, ok := v.(*balanceZoneRegionSchedulerConfig)
+			if !ok {
+				return errs.ErrScheduleConfigNotExist.FastGenByArgs()
+			}
+			conf.LabelKey = defaultBalanceZoneLabel
+			if len(args) == 1 && args[0] != "" {
+				conf.LabelKey = args[0]
+			}
+			conf.Name = BalanceZoneRegionName
+			return nil
+		}
+	})
+
+	schedule.RegisterScheduler(BalanceZoneRegionType, func(opController *schedule.OperatorController, storage *core.Storage, decoder schedule.ConfigDecoder) (schedule.Scheduler, error) {
+		conf := &balanceZoneRegionSchedulerConfig{LabelKey: defaultBalanceZoneLabel}
+		if err := decoder(conf); err != nil {
+			return nil, err
+		}
+		return newBalanceZoneRegionScheduler(opController, conf), nil
+	})
+}
+
+type balanceZoneRegionSchedulerConfig struct {
+	Name     string `json:"name"`
+	LabelKey string `json:"label-key"`
+}
+
+// balanceZoneRegionScheduler balances the region count among the zones, which
+// are the groups of stores with the same value of the label key. It moves a
+// peer from the zone with the most regions to the one with the fewest, as long
+// as the move doesn't break the placement of the region.
+type balanceZoneRegionScheduler struct {
+	*BaseScheduler
+	conf    *balanceZoneRegionSchedulerConfig
+	filters []filter.Filter
+}
+
+// zoneRegions is the region count of a zone.
+type zoneRegions struct {
+	zone   string
+	count  int64
+	stores []*core.StoreInfo
+}
+
+// newBalanceZoneRegionScheduler creates a scheduler that tends to keep regions
+// on each zone balanced.
+func newBalanceZoneRegionScheduler(opController *schedule.OperatorController, conf *balanceZoneRegionSchedulerConfig) schedule.Scheduler {
+	s := &balanceZoneRegionScheduler{
+		BaseScheduler: NewBaseScheduler(opController),
+		conf:          conf,
+	}
+	s.filters = []filter.Filter{
+		&filter.StoreStateFilter{ActionScope: s.GetName(), MoveRegion: true},
+		filter.NewSpecialUseFilter(s.GetName()),
+	}
+	return s
+}
+
+func (s *balanceZoneRegionScheduler) GetName() string {
+	return s.conf.Name
+}
+
+func (s *balanceZoneRegionScheduler) GetType() string {
+	return BalanceZoneRegionType
+}
+
+func (s *balanceZoneRegionScheduler) EncodeConfig() ([]byte, error) {
+	return schedule.EncodeConfig(s.conf)
+}
+
+func (s *balanceZoneRegionScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
+	allowed := s.OpController.OperatorCount(operator.OpRegion) < cluster.GetOpts().GetRegionScheduleLimit()
+	if !allowed {
+		operator.OperatorLimitCounter.WithLabelValues(s.GetType(), operator.OpRegion.String()).Inc()
+	}
+	return allowed
+}
+
+func (s *balanceZoneRegionScheduler) Schedule(cluster opt.Cluster) []*operator.Operator {
+	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+	zones := s.summaryZones(cluster)
+	if len(zones) < 2 {
+		schedulerCounter.WithLabelValues(s.GetName(), "no-zone").Inc()
+		return nil
+	}
+	source := zones[0]
+	for i := len(zones) - 1; i > 0; i-- {
+		target := zones[i]
+		// Moving a region doesn't make the zones more balanced.
+		if source.count-target.count <= 1 {
+			break
+		}
+		if op := s.transferPeer(cluster, source, target); op != nil {
+			op.Counters = append(op.Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-operator"))
+			return []*operator.Operator{op}
+		}
+	}
+	schedulerCounter.WithLabelValues(s.GetName(), "skip").Inc()
+	return nil
+}
+
+// summaryZones returns the zones sorted by the region count in descending order.
+// The stores without the label are ignored.
+func (s *balanceZoneRegionScheduler) summaryZones(cluster opt.Cluster) []*zoneRegions {
+	opInfluence := s.OpController.GetOpInfluence(cluster)
+	zones := make(map[string]*zoneRegions)
+	for _, store := range filter.SelectSourceStores(cluster.GetStores(), s.filters, cluster.GetOpts()) {
+		zone := store.GetLabelValue(s.conf.LabelKey)
+		if zone == "" {
+			continue
+		}
+		z, ok := zones[zone]
+		if !ok {
+			z = &zoneRegions{zone: zone}
+			zones[zone] = z
+		}
+		z.count += int64(store.GetRegionCount()) + opInfluence.GetStoreInfluence(store.GetID()).RegionCount
+		z.stores = append(z.stores, store)
+	}
+	ret := make([]*zoneRegions, 0, len(zones))
+	for _, z := range zones {
+		sort.Slice(z.stores, func(i, j int) bool {
+			if z.stores[i].GetRegionCount() != z.stores[j].GetRegionCount() {
+				return z.stores[i].GetRegionCount() > z.stores[j].GetRegionCount()
+			}
+			return z.stores[i].GetID() < z.stores[j].GetID()
+		})
+		ret = append(ret, z)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].count == ret[j].count {
+			return ret[i].zone < ret[j].zone
+		}
+		return ret[i].count > ret[j].count
+	})
+	return ret
+}
+
+// transferPeer moves a peer of a region in the source zone to the target zone.
+func (s *balanceZoneRegionScheduler) transferPeer(cluster opt.Cluster, source, target *zoneRegions) *operator.Operator {
+	zoneFilter := filter.NewLabelConstaintFilter(s.GetName(), []placement.LabelConstraint{
+		{Key: s.conf.LabelKey, Op: placement.In, Values: []string{target.zone}},
+	})
+	ranges := []core.KeyRange{core.NewKeyRange("", "")}
+	for _, store := range source.stores {
+		for i := 0; i < balanceZoneRegionRetryLimit; i++ {
+			schedulerCounter.WithLabelValues(s.GetName(), "total").Inc()
+			region := cluster.RandFollowerRegion(store.GetID(), ranges, opt.HealthRegion(cluster), opt.ReplicatedRegion(cluster))
+			if region == nil {
+				region = cluster.RandLeaderRegion(store.GetID(), ranges, opt.HealthRegion(cluster), opt.ReplicatedRegion(cluster))
+			}
+			if region == nil {
+				schedulerCounter.WithLabelValues(s.GetName(), "no-region").Inc()
+				break
+			}
+			if cluster.IsRegionHot(region) {
+				schedulerCounter.WithLabelValues(s.GetName(), "region-hot").Inc()
+				continue
+			}
+			filters := []filter.Filter{
+				zoneFilter,
+				filter.NewExcludedFilter(s.GetName(), nil, region.GetStoreIds()),
+				filter.NewPlacementSafeguard(s.GetName(), cluster, region, store),
+				filter.NewSpecialUseFilter(s.GetName()),
+				&filter.StoreStateFilter{ActionScope: s.GetName(), MoveRegion: true},
+			}
+			candidates := filter.NewCandidates(cluster.GetStores()).
+				FilterTarget(cluster.GetOpts(), filters...).
+				Sort(filter.RegionScoreComparer(cluster.GetOpts()))
+			if len(candidates.Stores) == 0 {
+				schedulerCounter.WithLabelValues(s.GetName(), "no-replacement").Inc()
+				continue
+			}
+			oldPeer := region.GetStorePeer(store.GetID())
+			newPeer := &metapb.Peer{StoreId: candidates.Stores[0].GetID(), Role: oldPeer.Role}
+			op, err := operator.CreateMovePeerOperator(BalanceZoneRegionType, cluster, region, operator.OpRegion, store.GetID(), newPeer)
+			if err != nil {
+				log.Debug("fail to create balance zone region operator", errs.ZapError(err))
+				schedulerCounter.WithLabelValues(s.GetName(), "create-operator-fail").Inc()
+				continue
+			}
+			log.Debug("balance zone region",
+				zap.Uint64("region-id", region.GetID()),
+				zap.String("source-zone", source.zone),
+				zap.String("target-zone", target.zone))
+			return op
+		}
+	}
+	return nil
+}
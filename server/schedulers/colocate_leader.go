@@ -0,0 +1,307 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/filter"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/unrolled/render"
+	"go.uber.org/zap"
+)
+
+func init() {
+	schedule.RegisterSliceDecoderBuilder(ColocateLeaderType, func(args []string) schedule.ConfigDecoder {
+		return func(v interface{}) error {
+			conf, ok := v.(*colocateLeaderSchedulerConfig)
+			if !ok {
+				return errs.ErrScheduleConfigNotExist.FastGenByArgs()
+			}
+			ranges, err := getKeyRanges(args)
+			if err != nil {
+				return err
+			}
+			conf.Ranges = ranges
+			conf.MaxLeaderSkew = defaultColocateLeaderMaxSkew
+			conf.Name = ColocateLeaderName
+			return nil
+		}
+	})
+	schedule.RegisterScheduler(ColocateLeaderType, func(opController *schedule.OperatorController, storage *core.Storage, decoder schedule.ConfigDecoder) (schedule.Scheduler, error) {
+		conf := &colocateLeaderSchedulerConfig{storage: storage}
+		if err := decoder(conf); err != nil {
+			return nil, err
+		}
+		return newColocateLeaderScheduler(opController, conf), nil
+	})
+}
+
+const (
+	// colocateLeaderScanLimit is the number of the regions scanned in a round,
+	// which are checked pair by pair.
+	colocateLeaderScanLimit = 128
+	// defaultColocateLeaderMaxSkew is the default ratio of the average leader
+	// count, by which a store may hold more leaders than the average.
+	defaultColocateLeaderMaxSkew = 0.1
+	// ColocateLeaderName is colocate leader scheduler name.
+	ColocateLeaderName = "colocate-leader-scheduler"
+	// ColocateLeaderType is colocate leader scheduler type.
+	ColocateLeaderType = "colocate-leader"
+)
+
+type colocateLeaderSchedulerConfig struct {
+	sync.RWMutex
+	storage *core.Storage
+
+	Name   string          `json:"name"`
+	Ranges []core.KeyRange `json:"ranges"`
+	// MaxLeaderSkew bounds the leaders of a store to (1 + MaxLeaderSkew) times
+	// the average leader count, beyond which no leader is colocated to it.
+	MaxLeaderSkew float64 `json:"max-leader-skew"`
+}
+
+func (conf *colocateLeaderSchedulerConfig) EncodeConfig() ([]byte, error) {
+	conf.RLock()
+	defer conf.RUnlock()
+	return schedule.EncodeConfig(conf)
+}
+
+func (conf *colocateLeaderSchedulerConfig) getMaxLeaderSkew() float64 {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.MaxLeaderSkew
+}
+
+func (conf *colocateLeaderSchedulerConfig) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	router := mux.NewRouter()
+	router.HandleFunc("/list", conf.handleGetConfig).Methods("GET")
+	router.HandleFunc("/config", conf.handleSetConfig).Methods("POST")
+	router.ServeHTTP(w, r)
+}
+
+func (conf *colocateLeaderSchedulerConfig) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	conf.RLock()
+	defer conf.RUnlock()
+	rd := render.New(render.Options{IndentJSON: true})
+	rd.JSON(w, http.StatusOK, conf)
+}
+
+func (conf *colocateLeaderSchedulerConfig) handleSetConfig(w http.ResponseWriter, r *http.Request) {
+	rd := render.New(render.Options{IndentJSON: true})
+	var input map[string]interface{}
+	if err := apiutil.ReadJSONRespondError(rd, w, r.Body, &input); err != nil {
+		return
+	}
+	skew, ok := input["max-leader-skew"].(float64)
+	if !ok {
+		rd.JSON(w, http.StatusBadRequest, "config item not found")
+		return
+	}
+	if skew < 0 {
+		rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("max-leader-skew should be non-negative, got %v", skew))
+		return
+	}
+
+	conf.Lock()
+	defer conf.Unlock()
+	old := conf.MaxLeaderSkew
+	conf.MaxLeaderSkew = skew
+	if err := conf.persistLocked(); err != nil {
+		conf.MaxLeaderSkew = old // revert
+		rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rd.JSON(w, http.StatusOK, "success")
+}
+
+func (conf *colocateLeaderSchedulerConfig) persistLocked() error {
+	data, err := schedule.EncodeConfig(conf)
+	if err != nil {
+		return err
+	}
+	return conf.storage.SaveScheduleConfig(conf.Name, data)
+}
+
+// colocateLeaderScheduler transfers the leaders of the adjacent regions in the
+// key ranges to the same store, so that a range scan across the regions is
+// served by fewer stores. A region always follows the leader store of the
+// region before it, which makes the runs of the colocated leaders grow from
+// left to right instead of fighting with each other. No leader is transferred
+// to a store holding more leaders than the max leader skew allows, which keeps
+// the scheduler from undoing the balance of the leaders too much.
+type colocateLeaderScheduler struct {
+	*BaseScheduler
+	conf    *colocateLeaderSchedulerConfig
+	filters []filter.Filter
+	// rangeIndex and startKey are where the next round of scanning starts.
+	rangeIndex int
+	startKey   []byte
+}
+
+// newColocateLeaderScheduler creates a scheduler that colocates the leaders of
+// the adjacent regions.
+func newColocateLeaderScheduler(opController *schedule.OperatorController, conf *colocateLeaderSchedulerConfig) schedule.Scheduler {
+	s := &colocateLeaderScheduler{
+		BaseScheduler: NewBaseScheduler(opController),
+		conf:          conf,
+	}
+	s.filters = []filter.Filter{
+		&filter.StoreStateFilter{ActionScope: s.GetName(), TransferLeader: true},
+		filter.NewSpecialUseFilter(s.GetName()),
+	}
+	return s
+}
+
+func (s *colocateLeaderScheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.conf.ServeHTTP(w, r)
+}
+
+func (s *colocateLeaderScheduler) GetName() string {
+	return s.conf.Name
+}
+
+func (s *colocateLeaderScheduler) GetType() string {
+	return ColocateLeaderType
+}
+
+func (s *colocateLeaderScheduler) EncodeConfig() ([]byte, error) {
+	return s.conf.EncodeConfig()
+}
+
+func (s *colocateLeaderScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
+	allowed := s.OpController.OperatorCount(operator.OpLeader) < cluster.GetOpts().GetLeaderScheduleLimit()
+	if !allowed {
+		operator.OperatorLimitCounter.WithLabelValues(s.GetType(), operator.OpLeader.String()).Inc()
+	}
+	return allowed
+}
+
+func (s *colocateLeaderScheduler) Schedule(cluster opt.Cluster) []*operator.Operator {
+	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+	regions := s.scanRegions(cluster)
+	if len(regions) < 2 {
+		schedulerCounter.WithLabelValues(s.GetName(), "no-region").Inc()
+		return nil
+	}
+
+	opInfluence := s.OpController.GetOpInfluence(cluster)
+	leaderCount := func(storeID uint64) int64 {
+		return int64(cluster.GetStore(storeID).GetLeaderCount()) + opInfluence.GetStoreInfluence(storeID).LeaderCount
+	}
+	var totalLeaders, upStores int64
+	for _, store := range cluster.GetStores() {
+		if store.IsUp() {
+			totalLeaders += leaderCount(store.GetID())
+			upStores++
+		}
+	}
+	if upStores == 0 {
+		return nil
+	}
+	maxLeaders := float64(totalLeaders) / float64(upStores) * (1 + s.conf.getMaxLeaderSkew())
+
+	for i := 1; i < len(regions); i++ {
+		prev, region := regions[i-1], regions[i]
+		if !bytes.Equal(prev.GetEndKey(), region.GetStartKey()) {
+			continue
+		}
+		sourceID, targetID := region.GetLeader().GetStoreId(), prev.GetLeader().GetStoreId()
+		if sourceID == 0 || targetID == 0 || sourceID == targetID {
+			continue
+		}
+		if float64(leaderCount(targetID)+1) > maxLeaders {
+			schedulerCounter.WithLabelValues(s.GetName(), "skew").Inc()
+			continue
+		}
+		if op := s.transferLeader(cluster, region, sourceID, targetID); op != nil {
+			op.Counters = append(op.Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-operator"))
+			return []*operator.Operator{op}
+		}
+	}
+	return nil
+}
+
+// scanRegions scans the next batch of regions in the key ranges. The last
+// region of a batch is scanned again in the next round, so that it is paired
+// with the region after it.
+func (s *colocateLeaderScheduler) scanRegions(cluster opt.Cluster) []*core.RegionInfo {
+	ranges := s.conf.Ranges
+	if len(ranges) == 0 {
+		return nil
+	}
+	if s.rangeIndex >= len(ranges) {
+		s.rangeIndex, s.startKey = 0, nil
+	}
+	keyRange := ranges[s.rangeIndex]
+	startKey := s.startKey
+	if startKey == nil {
+		startKey = keyRange.StartKey
+	}
+	regions := cluster.ScanRegions(startKey, keyRange.EndKey, colocateLeaderScanLimit)
+	if len(regions) < colocateLeaderScanLimit {
+		s.rangeIndex, s.startKey = s.rangeIndex+1, nil
+	} else {
+		s.startKey = regions[len(regions)-1].GetStartKey()
+	}
+	return regions
+}
+
+// transferLeader transfers the leader of the region to the target store, which
+// holds a healthy follower of it.
+func (s *colocateLeaderScheduler) transferLeader(cluster opt.Cluster, region *core.RegionInfo, sourceID, targetID uint64) *operator.Operator {
+	if !opt.IsRegionHealthy(cluster, region) {
+		schedulerCounter.WithLabelValues(s.GetName(), "unhealthy-region").Inc()
+		return nil
+	}
+	if s.OpController.GetOperator(region.GetID()) != nil {
+		schedulerCounter.WithLabelValues(s.GetName(), "operator-exists").Inc()
+		return nil
+	}
+	target := cluster.GetStore(targetID)
+	if target == nil || region.GetStoreVoter(targetID) == nil {
+		schedulerCounter.WithLabelValues(s.GetName(), "no-follower").Inc()
+		return nil
+	}
+	filters := s.filters
+	if f := filter.NewPlacementLeaderSafeguard(s.GetName(), cluster, region, cluster.GetStore(sourceID)); f != nil {
+		filters = append(filters[:len(filters):len(filters)], f)
+	}
+	if len(filter.NewCandidates([]*core.StoreInfo{target}).FilterTarget(cluster.GetOpts(), filters...).Stores) == 0 {
+		schedulerCounter.WithLabelValues(s.GetName(), "no-target-store").Inc()
+		return nil
+	}
+	op, err := operator.CreateTransferLeaderOperator(ColocateLeaderType, cluster, region, sourceID, targetID, operator.OpLeader)
+	if err != nil {
+		log.Debug("fail to create colocate leader operator", zap.Uint64("region-id", region.GetID()), errs.ZapError(err))
+		schedulerCounter.WithLabelValues(s.GetName(), "create-operator-fail").Inc()
+		return nil
+	}
+	op.FinishedCounters = append(op.FinishedCounters,
+		balanceDirectionCounter.WithLabelValues(s.GetName(), strconv.FormatUint(sourceID, 10), strconv.FormatUint(targetID, 10)),
+	)
+	return op
+}
@@ -0,0 +1,258 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/filter"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/unrolled/render"
+	"go.uber.org/zap"
+)
+
+const (
+	// DrainStoreName is drain store scheduler name.
+	DrainStoreName = "drain-store-scheduler"
+	// DrainStoreType is drain store scheduler type.
+	DrainStoreType = "drain-store"
+	// drainStoreRetryLimit is the number of regions tried in a single schedule.
+	drainStoreRetryLimit = 10
+)
+
+func init() {
+	schedule.RegisterSliceDecoderBuilder(DrainStoreType, func(args []string) schedule.ConfigDecoder {
+		return func(v interface{}) error {
+			if len(args) != 1 {
+				return errs.ErrSchedulerConfig.FastGenByArgs("id")
+			}
+			conf, ok := v.(*drainStoreSchedulerConfig)
+			if !ok {
+				return errs.ErrScheduleConfigNotExist.FastGenByArgs()
+			}
+			id, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return errs.ErrStrconvParseUint.Wrap(err).FastGenWithCause()
+			}
+			conf.StoreID = id
+			return nil
+		}
+	})
+
+	schedule.RegisterScheduler(DrainStoreType, func(opController *schedule.OperatorController, storage *core.Storage, decoder schedule.ConfigDecoder) (schedule.Scheduler, error) {
+		conf := &drainStoreSchedulerConfig{cluster: opController.GetCluster()}
+		if err := decoder(conf); err != nil {
+			return nil, err
+		}
+		return newDrainStoreScheduler(opController, conf), nil
+	})
+}
+
+type drainStoreSchedulerConfig struct {
+	cluster opt.Cluster
+	StoreID uint64 `json:"store-id"`
+}
+
+func (conf *drainStoreSchedulerConfig) getSchedulerName() string {
+	return fmt.Sprintf("%s-%d", DrainStoreName, conf.StoreID)
+}
+
+// drainStoreProgress is the progress of draining a store.
+type drainStoreProgress struct {
+	StoreID        uint64 `json:"store-id"`
+	PeersRemaining int    `json:"peers-remaining"`
+}
+
+func (conf *drainStoreSchedulerConfig) getProgress() *drainStoreProgress {
+	return &drainStoreProgress{
+		StoreID:        conf.StoreID,
+		PeersRemaining: conf.cluster.GetStoreRegionCount(conf.StoreID),
+	}
+}
+
+// drainStoreScheduler moves all peers out of a store, and removes itself once
+// the store holds no peer.
+type drainStoreScheduler struct {
+	*BaseScheduler
+	name    string
+	conf    *drainStoreSchedulerConfig
+	handler http.Handler
+}
+
+// newDrainStoreScheduler creates an admin scheduler that moves all peers out
+// of a store.
+func newDrainStoreScheduler(opController *schedule.OperatorController, conf *drainStoreSchedulerConfig) schedule.Scheduler {
+	return &drainStoreScheduler{
+		BaseScheduler: NewBaseScheduler(opController),
+		name:          conf.getSchedulerName(),
+		conf:          conf,
+		handler:       newDrainStoreHandler(conf),
+	}
+}
+
+func (s *drainStoreScheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+func (s *drainStoreScheduler) GetName() string {
+	return s.name
+}
+
+func (s *drainStoreScheduler) GetType() string {
+	return DrainStoreType
+}
+
+func (s *drainStoreScheduler) EncodeConfig() ([]byte, error) {
+	return schedule.EncodeConfig(s.conf)
+}
+
+func (s *drainStoreScheduler) Cleanup(cluster opt.Cluster) {
+	drainStorePeersGauge.DeleteLabelValues(strconv.FormatUint(s.conf.StoreID, 10))
+}
+
+// IsScheduleAllowed uses the replica schedule limit, since draining a store is
+// restoring the replicas of the regions just like the offline store handling.
+func (s *drainStoreScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
+	allowed := s.OpController.OperatorCount(operator.OpReplica) < cluster.GetOpts().GetReplicaScheduleLimit()
+	if !allowed {
+		operator.OperatorLimitCounter.WithLabelValues(s.GetType(), operator.OpReplica.String()).Inc()
+	}
+	return allowed
+}
+
+func (s *drainStoreScheduler) Schedule(cluster opt.Cluster) []*operator.Operator {
+	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+	storeID := s.conf.StoreID
+	source := cluster.GetStore(storeID)
+	remaining := cluster.GetStoreRegionCount(storeID)
+	if source == nil || source.IsTombstone() || remaining == 0 {
+		s.finish(cluster)
+		return nil
+	}
+	drainStorePeersGauge.WithLabelValues(strconv.FormatUint(storeID, 10)).Set(float64(remaining))
+
+	ranges := []core.KeyRange{core.NewKeyRange("", "")}
+	for i := 0; i < drainStoreRetryLimit; i++ {
+		// Pick the followers first, so that the leaders are transferred
+		// when there is nothing else to move.
+		region := cluster.RandFollowerRegion(storeID, ranges, opt.HealthAllowPending(cluster))
+		if region == nil {
+			region = cluster.RandLeaderRegion(storeID, ranges, opt.HealthAllowPending(cluster))
+		}
+		if region == nil {
+			region = cluster.RandLearnerRegion(storeID, ranges, opt.HealthAllowPending(cluster))
+		}
+		if region == nil {
+			schedulerCounter.WithLabelValues(s.GetName(), "no-region").Inc()
+			return nil
+		}
+		// The region is left to the operator in flight, which may be created by
+		// the replica checker for the same peer.
+		if s.OpController.GetOperator(region.GetID()) != nil {
+			schedulerCounter.WithLabelValues(s.GetName(), "operator-exists").Inc()
+			continue
+		}
+		if op := s.drainRegion(cluster, region, source); op != nil {
+			op.Counters = append(op.Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-operator"))
+			return []*operator.Operator{op}
+		}
+	}
+	return nil
+}
+
+// drainRegion transfers the leader out of the store if possible, or moves the
+// peer in the store to another one.
+func (s *drainStoreScheduler) drainRegion(cluster opt.Cluster, region *core.RegionInfo, source *core.StoreInfo) *operator.Operator {
+	opts := cluster.GetOpts()
+	if region.GetLeader().GetStoreId() == source.GetID() {
+		filters := []filter.Filter{&filter.StoreStateFilter{ActionScope: s.GetName(), TransferLeader: true}}
+		if f := filter.NewPlacementLeaderSafeguard(s.GetName(), cluster, region, source); f != nil {
+			filters = append(filters, f)
+		}
+		target := filter.NewCandidates(cluster.GetFollowerStores(region)).
+			FilterTarget(opts, filters...).
+			RandomPick()
+		if target != nil {
+			op, err := operator.CreateTransferLeaderOperator(DrainStoreType, cluster, region, source.GetID(), target.GetID(), operator.OpLeader)
+			if err != nil {
+				log.Debug("fail to create drain store operator", errs.ZapError(err))
+				return nil
+			}
+			return op
+		}
+	}
+
+	filters := []filter.Filter{
+		filter.NewExcludedFilter(s.GetName(), nil, region.GetStoreIds()),
+		filter.NewPlacementSafeguard(s.GetName(), cluster, region, source),
+		filter.NewSpecialUseFilter(s.GetName()),
+		&filter.StoreStateFilter{ActionScope: s.GetName(), MoveRegion: true},
+	}
+	target := filter.NewCandidates(cluster.GetStores()).
+		FilterTarget(opts, filters...).
+		Sort(filter.RegionScoreComparer(opts)).
+		PickFirst()
+	if target == nil {
+		schedulerCounter.WithLabelValues(s.GetName(), "no-target").Inc()
+		return nil
+	}
+	oldPeer := region.GetStorePeer(source.GetID())
+	newPeer := &metapb.Peer{StoreId: target.GetID(), Role: oldPeer.GetRole()}
+	op, err := operator.CreateMovePeerOperator(DrainStoreType, cluster, region, operator.OpReplica, source.GetID(), newPeer)
+	if err != nil {
+		log.Debug("fail to create drain store operator", errs.ZapError(err))
+		return nil
+	}
+	return op
+}
+
+// finish removes the scheduler once the store holds no peer.
+func (s *drainStoreScheduler) finish(cluster opt.Cluster) {
+	log.Info("store is drained, remove the scheduler",
+		zap.String("scheduler", s.GetName()),
+		zap.Uint64("store-id", s.conf.StoreID))
+	drainStorePeersGauge.WithLabelValues(strconv.FormatUint(s.conf.StoreID, 10)).Set(0)
+	if err := cluster.RemoveScheduler(s.GetName()); err != nil {
+		log.Warn("fail to remove the drain store scheduler", zap.String("scheduler", s.GetName()), errs.ZapError(err))
+	}
+}
+
+type drainStoreHandler struct {
+	rd     *render.Render
+	config *drainStoreSchedulerConfig
+}
+
+func (handler *drainStoreHandler) ListConfig(w http.ResponseWriter, r *http.Request) {
+	handler.rd.JSON(w, http.StatusOK, handler.config.getProgress())
+}
+
+func newDrainStoreHandler(config *drainStoreSchedulerConfig) http.Handler {
+	h := &drainStoreHandler{
+		config: config,
+		rd:     render.New(render.Options{IndentJSON: true}),
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/list", h.ListConfig).Methods("GET")
+	return router
+}
@@ -18,6 +18,7 @@ import (
 	"net/http"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pingcap/errors"
@@ -31,6 +32,7 @@ import (
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/opt"
 	"github.com/unrolled/render"
+	"go.uber.org/zap"
 )
 
 const (
@@ -47,7 +49,7 @@ const (
 func init() {
 	schedule.RegisterSliceDecoderBuilder(EvictLeaderType, func(args []string) schedule.ConfigDecoder {
 		return func(v interface{}) error {
-			if len(args) != 1 {
+			if len(args) != 1 && len(args) != 2 {
 				return errs.ErrSchedulerConfig.FastGenByArgs("id")
 			}
 			conf, ok := v.(*evictLeaderSchedulerConfig)
@@ -59,17 +61,28 @@ func init() {
 			if err != nil {
 				return errs.ErrStrconvParseUint.Wrap(err).FastGenWithCause()
 			}
-			ranges, err := getKeyRanges(args[1:])
+			deadline, err := getEvictLeaderDeadline(args[1:])
+			if err != nil {
+				return err
+			}
+			ranges, err := getKeyRanges(nil)
 			if err != nil {
 				return err
 			}
 			conf.StoreIDWithRanges[id] = ranges
+			if !deadline.IsZero() {
+				conf.StoreIDWithDeadline[id] = deadline
+			}
 			return nil
 		}
 	})
 
 	schedule.RegisterScheduler(EvictLeaderType, func(opController *schedule.OperatorController, storage *core.Storage, decoder schedule.ConfigDecoder) (schedule.Scheduler, error) {
-		conf := &evictLeaderSchedulerConfig{StoreIDWithRanges: make(map[uint64][]core.KeyRange), storage: storage}
+		conf := &evictLeaderSchedulerConfig{
+			StoreIDWithRanges:   make(map[uint64][]core.KeyRange),
+			StoreIDWithDeadline: make(map[uint64]time.Time),
+			storage:             storage,
+		}
 		if err := decoder(conf); err != nil {
 			return nil, err
 		}
@@ -82,11 +95,32 @@ type evictLeaderSchedulerConfig struct {
 	mu                sync.RWMutex
 	storage           *core.Storage
 	StoreIDWithRanges map[uint64][]core.KeyRange `json:"store-id-ranges"`
-	cluster           opt.Cluster
+	// StoreIDWithDeadline is the deadlines of the stores evicted with a timeout.
+	// Such a store is removed once it holds no leader or its deadline passes,
+	// and the scheduler removes itself along with its last store.
+	StoreIDWithDeadline map[uint64]time.Time `json:"store-id-deadline,omitempty"`
+	cluster             opt.Cluster
 }
 
-func (conf *evictLeaderSchedulerConfig) BuildWithArgs(args []string) error {
-	if len(args) != 1 {
+// getEvictLeaderDeadline returns the deadline of the timeout in the optional
+// argument, which is zero if there is no timeout.
+func getEvictLeaderDeadline(args []string) (time.Time, error) {
+	if len(args) == 0 {
+		return time.Time{}, nil
+	}
+	timeout, err := time.ParseDuration(args[0])
+	if err != nil || timeout <= 0 {
+		return time.Time{}, errs.ErrSchedulerConfig.FastGenByArgs("timeout")
+	}
+	return time.Now().Add(timeout), nil
+}
+
+// BuildWithArgs evicts the leaders of the store in args[0] within the key
+// ranges in the rest of args, which are all the keys if there is none. The
+// store is evicted until the timeout passes unless the timeout is empty, and
+// the deadline set before is dropped then.
+func (conf *evictLeaderSchedulerConfig) BuildWithArgs(args []string, timeout string) error {
+	if len(args) == 0 {
 		return errs.ErrSchedulerConfig.FastGenByArgs("id")
 	}
 
@@ -94,6 +128,12 @@ func (conf *evictLeaderSchedulerConfig) BuildWithArgs(args []string) error {
 	if err != nil {
 		return errs.ErrStrconvParseUint.Wrap(err).FastGenWithCause()
 	}
+	var deadline time.Time
+	if timeout != "" {
+		if deadline, err = getEvictLeaderDeadline([]string{timeout}); err != nil {
+			return err
+		}
+	}
 	ranges, err := getKeyRanges(args[1:])
 	if err != nil {
 		return err
@@ -101,6 +141,11 @@ func (conf *evictLeaderSchedulerConfig) BuildWithArgs(args []string) error {
 	conf.mu.Lock()
 	defer conf.mu.Unlock()
 	conf.StoreIDWithRanges[id] = ranges
+	if deadline.IsZero() {
+		delete(conf.StoreIDWithDeadline, id)
+	} else {
+		conf.StoreIDWithDeadline[id] = deadline
+	}
 	return nil
 }
 
@@ -108,7 +153,8 @@ func (conf *evictLeaderSchedulerConfig) Clone() *evictLeaderSchedulerConfig {
 	conf.mu.RLock()
 	defer conf.mu.RUnlock()
 	return &evictLeaderSchedulerConfig{
-		StoreIDWithRanges: conf.StoreIDWithRanges,
+		StoreIDWithRanges:   conf.StoreIDWithRanges,
+		StoreIDWithDeadline: conf.StoreIDWithDeadline,
 	}
 }
 
@@ -148,6 +194,7 @@ func (conf *evictLeaderSchedulerConfig) removeStore(id uint64) (succ bool, last
 	succ, last = false, false
 	if exists {
 		delete(conf.StoreIDWithRanges, id)
+		delete(conf.StoreIDWithDeadline, id)
 		conf.cluster.ResumeLeaderTransfer(id)
 		succ = true
 		last = len(conf.StoreIDWithRanges) == 0
@@ -155,11 +202,40 @@ func (conf *evictLeaderSchedulerConfig) removeStore(id uint64) (succ bool, last
 	return succ, last
 }
 
-func (conf *evictLeaderSchedulerConfig) resetStore(id uint64, keyRange []core.KeyRange) {
+func (conf *evictLeaderSchedulerConfig) resetStore(id uint64, keyRange []core.KeyRange, deadline time.Time) {
 	conf.mu.Lock()
 	defer conf.mu.Unlock()
 	conf.cluster.PauseLeaderTransfer(id)
 	conf.StoreIDWithRanges[id] = keyRange
+	if !deadline.IsZero() {
+		conf.StoreIDWithDeadline[id] = deadline
+	}
+}
+
+func (conf *evictLeaderSchedulerConfig) getDeadlineByID(id uint64) time.Time {
+	conf.mu.RLock()
+	defer conf.mu.RUnlock()
+	return conf.StoreIDWithDeadline[id]
+}
+
+// getFinishedStores returns the stores evicted with a timeout which hold no
+// leader in their ranges or whose deadlines have passed.
+func (conf *evictLeaderSchedulerConfig) getFinishedStores(cluster opt.Cluster, now time.Time) []uint64 {
+	conf.mu.RLock()
+	defer conf.mu.RUnlock()
+	var finished []uint64
+	for id, deadline := range conf.StoreIDWithDeadline {
+		if now.After(deadline) {
+			log.Warn("evicting leaders times out, stop evicting the store", zap.Uint64("store-id", id))
+			finished = append(finished, id)
+			continue
+		}
+		if cluster.RandLeaderRegion(id, conf.StoreIDWithRanges[id]) == nil {
+			log.Info("store holds no leader, stop evicting the store", zap.Uint64("store-id", id))
+			finished = append(finished, id)
+		}
+	}
+	return finished
 }
 
 func (conf *evictLeaderSchedulerConfig) getKeyRangesByID(id uint64) []core.KeyRange {
@@ -237,12 +313,38 @@ func (s *evictLeaderScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
 
 func (s *evictLeaderScheduler) Schedule(cluster opt.Cluster) []*operator.Operator {
 	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+	if s.removeFinishedStores(cluster) {
+		return nil
+	}
 	s.conf.mu.RLock()
 	defer s.conf.mu.RUnlock()
 
 	return scheduleEvictLeaderBatch(s.GetName(), cluster, s.conf.StoreIDWithRanges, EvictLeaderBatchSize)
 }
 
+// removeFinishedStores removes the stores evicted with a timeout once they
+// hold no leader or their deadlines pass. It returns true if the scheduler is
+// removed along with its last store.
+func (s *evictLeaderScheduler) removeFinishedStores(cluster opt.Cluster) bool {
+	for _, id := range s.conf.getFinishedStores(cluster, time.Now()) {
+		succ, last := s.conf.removeStore(id)
+		if !succ {
+			continue
+		}
+		if err := s.conf.Persist(); err != nil {
+			log.Warn("fail to persist the evict leader scheduler config", zap.Uint64("store-id", id), errs.ZapError(err))
+		}
+		if last {
+			if err := cluster.RemoveScheduler(EvictLeaderName); err != nil {
+				log.Warn("fail to remove the evict leader scheduler", errs.ZapError(err))
+			}
+			schedulerCounter.WithLabelValues(s.GetName(), "finished").Inc()
+			return true
+		}
+	}
+	return false
+}
+
 func uniqueAppendOperator(dst []*operator.Operator, src ...*operator.Operator) []*operator.Operator {
 	regionIDs := make(map[uint64]struct{})
 	for i := range dst {
@@ -328,7 +430,7 @@ func (handler *evictLeaderHandler) UpdateConfig(w http.ResponseWriter, r *http.R
 		return
 	}
 	var args []string
-	var exists bool
+	var exists, paused bool
 	var id uint64
 	idFloat, ok := input["store_id"].(float64)
 	if ok {
@@ -340,19 +442,26 @@ func (handler *evictLeaderHandler) UpdateConfig(w http.ResponseWriter, r *http.R
 				handler.rd.JSON(w, http.StatusInternalServerError, err.Error())
 				return
 			}
+			paused = true
 		}
 		handler.config.mu.RUnlock()
 		args = append(args, strconv.FormatUint(id, 10))
 	}
 
-	ranges, ok := (input["ranges"]).([]string)
-	if ok {
+	if ranges, ok := (input["ranges"]).([]string); ok {
 		args = append(args, ranges...)
 	} else if exists {
 		args = append(args, handler.config.getRanges(id)...)
 	}
+	timeout, _ := input["timeout"].(string)
 
-	handler.config.BuildWithArgs(args)
+	if err := handler.config.BuildWithArgs(args, timeout); err != nil {
+		if paused {
+			handler.config.cluster.ResumeLeaderTransfer(id)
+		}
+		handler.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	err := handler.config.Persist()
 	if err != nil {
 		handler.config.removeStore(id)
@@ -377,11 +486,12 @@ func (handler *evictLeaderHandler) DeleteConfig(w http.ResponseWriter, r *http.R
 
 	var resp interface{}
 	keyRanges := handler.config.getKeyRangesByID(id)
+	deadline := handler.config.getDeadlineByID(id)
 	succ, last := handler.config.removeStore(id)
 	if succ {
 		err = handler.config.Persist()
 		if err != nil {
-			handler.config.resetStore(id, keyRanges)
+			handler.config.resetStore(id, keyRanges, deadline)
 			handler.rd.JSON(w, http.StatusInternalServerError, err.Error())
 			return
 		}
@@ -390,7 +500,7 @@ func (handler *evictLeaderHandler) DeleteConfig(w http.ResponseWriter, r *http.R
 				if errors.ErrorEqual(err, errs.ErrSchedulerNotFound.FastGenByArgs()) {
 					handler.rd.JSON(w, http.StatusNotFound, err.Error())
 				} else {
-					handler.config.resetStore(id, keyRanges)
+					handler.config.resetStore(id, keyRanges, deadline)
 					handler.rd.JSON(w, http.StatusInternalServerError, err.Error())
 				}
 				return
@@ -25,6 +25,7 @@ import (
 	"github.com/tikv/pd/server/schedule"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/tikv/pd/server/statistics"
 	"go.uber.org/zap"
 )
 
@@ -33,9 +34,6 @@ const (
 	EvictSlowStoreName = "evict-slow-store-scheduler"
 	// EvictSlowStoreType is evict leader scheduler type.
 	EvictSlowStoreType = "evict-slow-store"
-
-	slowStoreEvictThreshold   = 100
-	slowStoreRecoverThreshold = 1
 )
 
 func init() {
@@ -91,7 +89,8 @@ func (conf *evictSlowStoreSchedulerConfig) getSchedulerName() string {
 
 type evictSlowStoreScheduler struct {
 	*BaseScheduler
-	conf *evictSlowStoreSchedulerConfig
+	conf     *evictSlowStoreSchedulerConfig
+	detector *statistics.SlowStoreDetector
 }
 
 func (s *evictSlowStoreScheduler) GetName() string {
@@ -149,6 +148,7 @@ func (s *evictSlowStoreScheduler) Schedule(cluster opt.Cluster) []*operator.Oper
 	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
 	var ops []*operator.Operator
 
+	opts := cluster.GetOpts()
 	evictedStores := s.conf.EvictedStores
 	if len(evictedStores) != 0 {
 		store := cluster.GetStore(evictedStores[0])
@@ -156,11 +156,9 @@ func (s *evictSlowStoreScheduler) Schedule(cluster opt.Cluster) []*operator.Oper
 			// Previous slow store had been removed, remove the sheduler and check
 			// slow node next time.
 			log.Info("slow store has been removed",
-				zap.Uint64("store-id", store.GetID()))
-		} else if store.GetSlowScore() <= slowStoreRecoverThreshold {
-			log.Info("slow store has been recovered",
-				zap.Uint64("store-id", store.GetID()))
-		} else {
+				zap.Uint64("store-id", evictedStores[0]))
+			s.detector.Forget(evictedStores[0])
+		} else if !s.detector.IsRecovered(store, opts.GetSlowStoreRecoverThreshold(), opts.GetSlowStoreRecoveryDuration()) {
 			return s.schedulerEvictLeader(cluster)
 		}
 		err := s.conf.Persist()
@@ -184,7 +182,7 @@ func (s *evictSlowStoreScheduler) Schedule(cluster opt.Cluster) []*operator.Oper
 		}
 
 		// If there is only one slow store, evict leaders from that store.
-		if len(slowStores) == 1 && slowStores[0].GetSlowScore() >= slowStoreEvictThreshold {
+		if len(slowStores) == 1 && s.detector.IsSlow(slowStores[0], opts.GetSlowStoreEvictThreshold()) {
 			store := slowStores[0]
 			log.Info("detected slow store, start to evict leaders",
 				zap.Uint64("store-id", store.GetID()))
@@ -213,6 +211,7 @@ func newEvictSlowStoreScheduler(opController *schedule.OperatorController, conf
 	s := &evictSlowStoreScheduler{
 		BaseScheduler: base,
 		conf:          conf,
+		detector:      statistics.NewSlowStoreDetector(),
 	}
 	return s
 }
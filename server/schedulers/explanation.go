@@ -0,0 +1,134 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/unrolled/render"
+)
+
+// maxScheduleExplanations is the number of the recent explanations a scheduler keeps.
+const maxScheduleExplanations = 256
+
+// The decisions of a balance scheduler on a pair of stores.
+const (
+	ExplanationDecisionBalance = "balance"
+	ExplanationDecisionSkip    = "skip"
+)
+
+// ScheduleExplanation explains why a balance scheduler decides to move a
+// region from the source store to the target store, or declines to, by the
+// scores of the stores after the move.
+type ScheduleExplanation struct {
+	Time        time.Time `json:"time"`
+	RegionID    uint64    `json:"region-id"`
+	SourceStore uint64    `json:"source-store"`
+	SourceScore float64   `json:"source-score"`
+	TargetStore uint64    `json:"target-store"`
+	TargetScore float64   `json:"target-score"`
+	Decision    string    `json:"decision"`
+}
+
+// explanationRecorder keeps the recent explanations of a scheduler, at most
+// maxScheduleExplanations of them. It records nothing unless it is enabled,
+// which is the default.
+type explanationRecorder struct {
+	sync.RWMutex
+	enabled      bool
+	explanations []*ScheduleExplanation
+	// next is the index to put the next explanation at once the explanations are full.
+	next int
+}
+
+func newExplanationRecorder() *explanationRecorder {
+	return &explanationRecorder{}
+}
+
+func (r *explanationRecorder) isEnabled() bool {
+	r.RLock()
+	defer r.RUnlock()
+	return r.enabled
+}
+
+// setEnabled enables or disables the recorder. The explanations are dropped
+// when it is disabled.
+func (r *explanationRecorder) setEnabled(enabled bool) {
+	r.Lock()
+	defer r.Unlock()
+	r.enabled = enabled
+	if !enabled {
+		r.explanations, r.next = nil, 0
+	}
+}
+
+func (r *explanationRecorder) record(explanation *ScheduleExplanation) {
+	r.Lock()
+	defer r.Unlock()
+	if !r.enabled {
+		return
+	}
+	if len(r.explanations) < maxScheduleExplanations {
+		r.explanations = append(r.explanations, explanation)
+		return
+	}
+	r.explanations[r.next] = explanation
+	r.next = (r.next + 1) % maxScheduleExplanations
+}
+
+// list returns the explanations from the oldest to the latest.
+func (r *explanationRecorder) list() []*ScheduleExplanation {
+	r.RLock()
+	defer r.RUnlock()
+	explanations := make([]*ScheduleExplanation, 0, len(r.explanations))
+	explanations = append(explanations, r.explanations[r.next:]...)
+	return append(explanations, r.explanations[:r.next]...)
+}
+
+func (r *explanationRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	router := mux.NewRouter()
+	router.HandleFunc("/explanations", r.handleGetExplanations).Methods("GET")
+	router.HandleFunc("/explanations", r.handleSetEnabled).Methods("POST")
+	router.ServeHTTP(w, req)
+}
+
+type scheduleExplanations struct {
+	Enabled      bool                   `json:"enabled"`
+	Explanations []*ScheduleExplanation `json:"explanations"`
+}
+
+func (r *explanationRecorder) handleGetExplanations(w http.ResponseWriter, req *http.Request) {
+	rd := render.New(render.Options{IndentJSON: true})
+	rd.JSON(w, http.StatusOK, &scheduleExplanations{Enabled: r.isEnabled(), Explanations: r.list()})
+}
+
+func (r *explanationRecorder) handleSetEnabled(w http.ResponseWriter, req *http.Request) {
+	rd := render.New(render.Options{IndentJSON: true})
+	var input map[string]interface{}
+	if err := apiutil.ReadJSONRespondError(rd, w, req.Body, &input); err != nil {
+		return
+	}
+	enabled, ok := input["enabled"].(bool)
+	if !ok {
+		rd.JSON(w, http.StatusBadRequest, "config item not found")
+		return
+	}
+	r.setEnabled(enabled)
+	rd.JSON(w, http.StatusOK, "success")
+}
@@ -600,32 +600,52 @@ func (bs *balanceSolver) filterHotPeers() []*statistics.HotPeerStat {
 	return ret
 }
 
+// sortHotPeers picks at most maxPeerNum peers among the ones ranked by the first
+// and the second priorities, in proportion to the rank weights of the two kinds.
 func (bs *balanceSolver) sortHotPeers(ret []*statistics.HotPeerStat, maxPeerNum int) map[*statistics.HotPeerStat]struct{} {
-	firstSort := make([]*statistics.HotPeerStat, len(ret))
-	copy(firstSort, ret)
-	sort.Slice(firstSort, func(i, j int) bool {
-		k := getRegionStatKind(bs.rwTy, bs.firstPriority)
-		return firstSort[i].GetLoad(k) > firstSort[j].GetLoad(k)
-	})
-	secondSort := make([]*statistics.HotPeerStat, len(ret))
-	copy(secondSort, ret)
-	sort.Slice(secondSort, func(i, j int) bool {
-		k := getRegionStatKind(bs.rwTy, bs.secondPriority)
-		return secondSort[i].GetLoad(k) > secondSort[j].GetLoad(k)
-	})
+	dims := []int{bs.firstPriority, bs.secondPriority}
+	ranked := make([][]*statistics.HotPeerStat, len(dims))
+	weights := make([]float64, len(dims))
+	for i, dim := range dims {
+		k := getRegionStatKind(bs.rwTy, dim)
+		peers := make([]*statistics.HotPeerStat, len(ret))
+		copy(peers, ret)
+		sort.Slice(peers, func(i, j int) bool {
+			return peers[i].GetLoad(k) > peers[j].GetLoad(k)
+		})
+		ranked[i] = peers
+		weights[i] = bs.sche.conf.GetRankWeight(k)
+	}
+	return pickHotPeers(ranked, weights, maxPeerNum)
+}
+
+// pickHotPeers picks at most maxPeerNum distinct peers from the ranked lists
+// with the smooth weighted round-robin, so a list with twice the weight of the
+// other gets twice the turns. The lists with the same weight take turns,
+// starting from the first one, and a list weighted zero only gets its turns
+// once the others run out.
+func pickHotPeers(ranked [][]*statistics.HotPeerStat, weights []float64, maxPeerNum int) map[*statistics.HotPeerStat]struct{} {
 	union := make(map[*statistics.HotPeerStat]struct{}, maxPeerNum)
+	current := make([]float64, len(ranked))
 	for len(union) < maxPeerNum {
-		for len(firstSort) > 0 {
-			peer := firstSort[0]
-			firstSort = firstSort[1:]
-			if _, ok := union[peer]; !ok {
-				union[peer] = struct{}{}
-				break
+		pick, total := -1, 0.0
+		for i := range ranked {
+			if len(ranked[i]) == 0 {
+				continue
 			}
+			current[i] += weights[i]
+			total += weights[i]
+			if pick < 0 || current[i] > current[pick] {
+				pick = i
+			}
+		}
+		if pick < 0 {
+			break
 		}
-		for len(union) < maxPeerNum && len(secondSort) > 0 {
-			peer := secondSort[0]
-			secondSort = secondSort[1:]
+		current[pick] -= total
+		for len(ranked[pick]) > 0 {
+			peer := ranked[pick][0]
+			ranked[pick] = ranked[pick][1:]
 			if _, ok := union[peer]; !ok {
 				union[peer] = struct{}{}
 				break
@@ -1117,7 +1137,8 @@ func (bs *balanceSolver) buildOperator() (op *operator.Operator, infl *Influence
 		balanceDirectionCounter.WithLabelValues(bs.sche.GetName(), sourceLabel, targetLabel))
 	op.Counters = append(op.Counters,
 		schedulerCounter.WithLabelValues(bs.sche.GetName(), "new-operator"),
-		schedulerCounter.WithLabelValues(bs.sche.GetName(), bs.opTy.String()))
+		schedulerCounter.WithLabelValues(bs.sche.GetName(), bs.opTy.String()),
+		hotFlowOperatorCounter.WithLabelValues(bs.sche.GetName(), bs.rwTy.FlowKind().String()))
 
 	infl = &Influence{
 		Loads: append(bs.cur.srcPeerStat.Loads[:0:0], bs.cur.srcPeerStat.Loads...),
@@ -1209,6 +1230,18 @@ func (rw rwType) String() string {
 	}
 }
 
+// FlowKind returns the flow kind which the balance is based on.
+func (rw rwType) FlowKind() statistics.FlowKind {
+	switch rw {
+	case read:
+		return statistics.ReadFlow
+	case write:
+		return statistics.WriteFlow
+	default:
+		return statistics.UnknownFlow
+	}
+}
+
 type opType int
 
 const (
@@ -17,6 +17,7 @@ package schedulers
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"reflect"
@@ -80,6 +81,7 @@ func initHotRegionScheduleConfig() *hotRegionSchedulerConfig {
 		DstToleranceRatio:      1.05, // Tolerate 5% difference
 		StrictPickingStore:     true,
 		EnableForTiFlash:       true,
+		RankWeights:            defaultRankWeights(),
 	}
 	cfg.apply(defaultConfig)
 	return cfg
@@ -105,9 +107,21 @@ func (conf *hotRegionSchedulerConfig) getValidConf() *hotRegionSchedulerConfig {
 		WritePeerPriorities:    adjustConfig(conf.lastQuerySupported, conf.WritePeerPriorities, getWritePeerPriorities),
 		StrictPickingStore:     conf.StrictPickingStore,
 		EnableForTiFlash:       conf.EnableForTiFlash,
+		RankWeights:            conf.RankWeights,
 	}
 }
 
+// defaultRankWeights gives the same weight to all the region statistics kinds,
+// so that the hot peers are picked alternately from the ones ranked by each
+// priority.
+func defaultRankWeights() map[string]float64 {
+	weights := make(map[string]float64, statistics.RegionStatCount)
+	for k := statistics.RegionStatKind(0); k < statistics.RegionStatCount; k++ {
+		weights[k.String()] = 1
+	}
+	return weights
+}
+
 type hotRegionSchedulerConfig struct {
 	sync.RWMutex
 	storage            *core.Storage
@@ -138,6 +152,11 @@ type hotRegionSchedulerConfig struct {
 
 	// Separately control whether to start hotspot scheduling for TiFlash
 	EnableForTiFlash bool `json:"enable-for-tiflash,string"`
+
+	// RankWeights are the weights of the region statistics kinds, e.g. write_keys,
+	// when the hot peers are picked from the ones ranked by each priority. A kind
+	// with twice the weight of the other gets twice the share of the picked peers.
+	RankWeights map[string]float64 `json:"rank-weights"`
 }
 
 func (conf *hotRegionSchedulerConfig) EncodeConfig() ([]byte, error) {
@@ -272,6 +291,17 @@ func (conf *hotRegionSchedulerConfig) GetWritePeerPriorities() []string {
 	return conf.WritePeerPriorities
 }
 
+// GetRankWeight returns the weight of the region statistics kind when picking
+// the hot peers. A kind without a configured weight is weighted 1.
+func (conf *hotRegionSchedulerConfig) GetRankWeight(k statistics.RegionStatKind) float64 {
+	conf.RLock()
+	defer conf.RUnlock()
+	if weight, ok := conf.RankWeights[k.String()]; ok {
+		return weight
+	}
+	return 1
+}
+
 func (conf *hotRegionSchedulerConfig) IsStrictPickingStoreEnabled() bool {
 	conf.RLock()
 	defer conf.RUnlock()
@@ -308,6 +338,14 @@ func (conf *hotRegionSchedulerConfig) handleSetConfig(w http.ResponseWriter, r *
 		rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if err := conf.validateLocked(); err != nil {
+		// the weights are merged into the map by the unmarshal, so it is
+		// dropped before restoring the old config.
+		conf.RankWeights = nil
+		_ = json.Unmarshal(oldc, conf)
+		rd.Text(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	newc, _ := json.Marshal(conf)
 	if !bytes.Equal(oldc, newc) {
 		conf.persistLocked()
@@ -334,6 +372,40 @@ func (conf *hotRegionSchedulerConfig) handleSetConfig(w http.ResponseWriter, r *
 	rd.Text(w, http.StatusBadRequest, "config item not found")
 }
 
+func (conf *hotRegionSchedulerConfig) validateLocked() error {
+	positive := false
+	for k := statistics.RegionStatKind(0); k < statistics.RegionStatCount; k++ {
+		weight, ok := conf.RankWeights[k.String()]
+		if !ok {
+			weight = 1
+		}
+		if weight < 0 {
+			return fmt.Errorf("rank-weights of %s should be non-negative, got %v", k, weight)
+		}
+		if weight > 0 {
+			positive = true
+		}
+	}
+	for name := range conf.RankWeights {
+		if !isRegionStatKindName(name) {
+			return fmt.Errorf("rank-weights of unknown kind %s", name)
+		}
+	}
+	if !positive {
+		return fmt.Errorf("rank-weights should have at least one positive weight")
+	}
+	return nil
+}
+
+func isRegionStatKindName(name string) bool {
+	for k := statistics.RegionStatKind(0); k < statistics.RegionStatCount; k++ {
+		if k.String() == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (conf *hotRegionSchedulerConfig) persistLocked() error {
 	data, err := schedule.EncodeConfig(conf)
 	if err != nil {
@@ -1629,6 +1629,52 @@ func (s *testHotCacheSuite) TestSortHotPeer(c *C) {
 
 	u = leaderSolver.sortHotPeers(hotPeers, 2)
 	checkSortResult(c, []uint64{1, 2}, u)
+
+	// the peers ranked by query are left out once its weight is zero.
+	hb.conf.RankWeights[statistics.RegionReadQuery.String()] = 0
+	u = leaderSolver.sortHotPeers(hotPeers, 2)
+	checkSortResult(c, []uint64{2, 3}, u)
+}
+
+func (s *testHotCacheSuite) TestPickHotPeersByWeight(c *C) {
+	peers := make([]*statistics.HotPeerStat, 6)
+	for i := range peers {
+		peers[i] = &statistics.HotPeerStat{RegionID: uint64(i + 1)}
+	}
+	ranked := func() [][]*statistics.HotPeerStat {
+		return [][]*statistics.HotPeerStat{
+			{peers[0], peers[1], peers[2]},
+			{peers[3], peers[4], peers[5]},
+		}
+	}
+	// the same weights take turns.
+	checkSortResult(c, []uint64{1, 4, 2, 5}, pickHotPeers(ranked(), []float64{1, 1}, 4))
+	// the second list gets twice the turns.
+	checkSortResult(c, []uint64{4, 1, 5, 6}, pickHotPeers(ranked(), []float64{1, 2}, 4))
+	// the list weighted zero gets its turns once the other runs out.
+	checkSortResult(c, []uint64{1, 2, 3, 4}, pickHotPeers(ranked(), []float64{1, 0}, 4))
+	checkSortResult(c, []uint64{1, 2, 3, 4, 5, 6}, pickHotPeers(ranked(), []float64{1, 2}, 10))
+}
+
+func (s *testHotCacheSuite) TestRankWeightsConfig(c *C) {
+	conf := initHotRegionScheduleConfig()
+	c.Assert(conf.validateLocked(), IsNil)
+	c.Assert(conf.GetRankWeight(statistics.RegionWriteKeys), Equals, 1.0)
+
+	conf.RankWeights = map[string]float64{"write_keys": 3}
+	c.Assert(conf.validateLocked(), IsNil)
+	c.Assert(conf.GetRankWeight(statistics.RegionWriteKeys), Equals, 3.0)
+	c.Assert(conf.GetRankWeight(statistics.RegionWriteBytes), Equals, 1.0)
+
+	conf.RankWeights = map[string]float64{"write_keys": -1}
+	c.Assert(conf.validateLocked(), NotNil)
+	conf.RankWeights = map[string]float64{"foo": 1}
+	c.Assert(conf.validateLocked(), NotNil)
+	conf.RankWeights = make(map[string]float64)
+	for k := statistics.RegionStatKind(0); k < statistics.RegionStatCount; k++ {
+		conf.RankWeights[k.String()] = 0
+	}
+	c.Assert(conf.validateLocked(), NotNil)
 }
 
 func checkSortResult(c *C, regions []uint64, hotPeers map[*statistics.HotPeerStat]struct{}) {
@@ -80,6 +80,14 @@ var hotSchedulerResultCounter = prometheus.NewCounterVec(
 		Help:      "Counter of hot region scheduler.",
 	}, []string{"type", "store"})
 
+var hotFlowOperatorCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pd",
+		Subsystem: "scheduler",
+		Name:      "hot_region_flow_operator",
+		Help:      "Counter of operators created by hot region schedulers for each flow kind.",
+	}, []string{"type", "flow"})
+
 var balanceDirectionCounter = prometheus.NewCounterVec(
 	prometheus.CounterOpts{
 		Namespace: "pd",
@@ -120,6 +128,14 @@ var hotPendingStatus = prometheus.NewGaugeVec(
 		Help:      "Counter of direction of balance related schedulers.",
 	}, []string{"type", "source", "target"})
 
+var drainStorePeersGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "pd",
+		Subsystem: "scheduler",
+		Name:      "drain_store_peers",
+		Help:      "Number of the peers remaining in the store being drained.",
+	}, []string{"store"})
+
 func init() {
 	prometheus.MustRegister(schedulerCounter)
 	prometheus.MustRegister(schedulerStatus)
@@ -128,10 +144,12 @@ func init() {
 	prometheus.MustRegister(balanceRegionCounter)
 	prometheus.MustRegister(hotSchedulerResultCounter)
 	prometheus.MustRegister(hotDirectionCounter)
+	prometheus.MustRegister(hotFlowOperatorCounter)
 	prometheus.MustRegister(balanceDirectionCounter)
 	prometheus.MustRegister(scatterRangeLeaderCounter)
 	prometheus.MustRegister(scatterRangeRegionCounter)
 	prometheus.MustRegister(opInfluenceStatus)
 	prometheus.MustRegister(tolerantResourceStatus)
 	prometheus.MustRegister(hotPendingStatus)
+	prometheus.MustRegister(drainStorePeersGauge)
 }
@@ -0,0 +1,228 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/tikv/pd/server/statistics"
+	"go.uber.org/zap"
+)
+
+const (
+	// PreventiveSplitName is preventive split scheduler name.
+	PreventiveSplitName = "preventive-split-scheduler"
+	// PreventiveSplitType is preventive split scheduler type.
+	PreventiveSplitType = "preventive-split"
+
+	defaultPreventiveSplitLowRatio  = 0.8
+	defaultPreventiveSplitHighRatio = 1.5
+	defaultPreventiveSplitCooldown  = 10 * time.Minute
+)
+
+func init() {
+	schedule.RegisterSliceDecoderBuilder(PreventiveSplitType, func(args []string) schedule.ConfigDecoder {
+		return func(v interface{}) error {
+			conf, ok := v.(*preventiveSplitSchedulerConfig)
+			if !ok {
+				return errs.ErrScheduleConfigNotExist.FastGenByArgs()
+			}
+			if len(args) != 0 && len(args) != 2 {
+				return errs.ErrSchedulerConfig.FastGenByArgs("ratio")
+			}
+			if len(args) == 2 {
+				low, err := strconv.ParseFloat(args[0], 64)
+				if err != nil {
+					return errs.ErrStrconvParseFloat.Wrap(err).FastGenWithCause()
+				}
+				high, err := strconv.ParseFloat(args[1], 64)
+				if err != nil {
+					return errs.ErrStrconvParseFloat.Wrap(err).FastGenWithCause()
+				}
+				if low <= 0 || low > high {
+					return errs.ErrSchedulerConfig.FastGenByArgs("ratio")
+				}
+				conf.LowRatio, conf.HighRatio = low, high
+			}
+			conf.Name = PreventiveSplitName
+			return nil
+		}
+	})
+
+	schedule.RegisterScheduler(PreventiveSplitType, func(opController *schedule.OperatorController, storage *core.Storage, decoder schedule.ConfigDecoder) (schedule.Scheduler, error) {
+		conf := &preventiveSplitSchedulerConfig{
+			LowRatio:  defaultPreventiveSplitLowRatio,
+			HighRatio: defaultPreventiveSplitHighRatio,
+			Cooldown:  typeutil.NewDuration(defaultPreventiveSplitCooldown),
+		}
+		if err := decoder(conf); err != nil {
+			return nil, err
+		}
+		return newPreventiveSplitScheduler(opController, conf), nil
+	})
+}
+
+type preventiveSplitSchedulerConfig struct {
+	Name string `json:"name"`
+	// LowRatio and HighRatio bound the near-hot band, which is the ratio of the
+	// write load of a region to the hot threshold of its store.
+	LowRatio  float64 `json:"low-ratio"`
+	HighRatio float64 `json:"high-ratio"`
+	// Cooldown is the time a split region is not split again.
+	Cooldown typeutil.Duration `json:"cooldown"`
+	// MinRegionSize is the minimum size in MB of the regions after split. It
+	// is the max merge region size if not set, so that the split regions are
+	// not merged back.
+	MinRegionSize int64 `json:"min-region-size"`
+}
+
+// preventiveSplitScheduler splits the regions whose write load is climbing
+// toward the hot threshold before they become hotspots, so that the future
+// load is spread over more regions. The confirmed hot regions are left to the
+// hot region scheduler.
+type preventiveSplitScheduler struct {
+	*BaseScheduler
+	conf *preventiveSplitSchedulerConfig
+
+	mu sync.Mutex
+	// splitAt records when the split operator of the region is created.
+	splitAt map[uint64]time.Time
+}
+
+// newPreventiveSplitScheduler creates a scheduler that splits the near-hot regions.
+func newPreventiveSplitScheduler(opController *schedule.OperatorController, conf *preventiveSplitSchedulerConfig) schedule.Scheduler {
+	return &preventiveSplitScheduler{
+		BaseScheduler: NewBaseScheduler(opController),
+		conf:          conf,
+		splitAt:       make(map[uint64]time.Time),
+	}
+}
+
+func (s *preventiveSplitScheduler) GetName() string {
+	return s.conf.Name
+}
+
+func (s *preventiveSplitScheduler) GetType() string {
+	return PreventiveSplitType
+}
+
+func (s *preventiveSplitScheduler) EncodeConfig() ([]byte, error) {
+	return schedule.EncodeConfig(s.conf)
+}
+
+func (s *preventiveSplitScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
+	allowed := s.OpController.OperatorCount(operator.OpSplit) < cluster.GetOpts().GetRegionScheduleLimit()
+	if !allowed {
+		operator.OperatorLimitCounter.WithLabelValues(s.GetType(), operator.OpSplit.String()).Inc()
+	}
+	return allowed
+}
+
+func (s *preventiveSplitScheduler) Schedule(cluster opt.Cluster) []*operator.Operator {
+	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for id, t := range s.splitAt {
+		if now.Sub(t) >= s.conf.Cooldown.Duration {
+			delete(s.splitAt, id)
+		}
+	}
+
+	minRegionSize := s.conf.MinRegionSize
+	if minRegionSize <= 0 {
+		minRegionSize = int64(cluster.GetOpts().GetMaxMergeRegionSize())
+	}
+	for _, stat := range s.nearHotPeers(cluster) {
+		if _, ok := s.splitAt[stat.RegionID]; ok {
+			schedulerCounter.WithLabelValues(s.GetName(), "in-cooldown").Inc()
+			continue
+		}
+		region := cluster.GetRegion(stat.RegionID)
+		if region == nil || !opt.IsRegionHealthy(cluster, region) || cluster.IsRegionHot(region) {
+			continue
+		}
+		// Both halves should be no smaller than the minimum region size.
+		if region.GetApproximateSize() < 2*minRegionSize {
+			schedulerCounter.WithLabelValues(s.GetName(), "region-too-small").Inc()
+			continue
+		}
+		op, err := operator.CreateSplitRegionOperator(PreventiveSplitType, region, 0, pdpb.CheckPolicy_APPROXIMATE, nil)
+		if err != nil {
+			log.Debug("fail to create preventive split operator", errs.ZapError(err))
+			schedulerCounter.WithLabelValues(s.GetName(), "create-operator-fail").Inc()
+			continue
+		}
+		log.Debug("split near-hot region",
+			zap.Uint64("region-id", region.GetID()),
+			zap.Float64s("loads", stat.GetLoads()),
+			zap.Float64s("thresholds", stat.GetThresholds()))
+		s.splitAt[region.GetID()] = now
+		op.Counters = append(op.Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-operator"))
+		return []*operator.Operator{op}
+	}
+	schedulerCounter.WithLabelValues(s.GetName(), "skip").Inc()
+	return nil
+}
+
+// nearHotPeers returns a write peer for each region whose load ratio is within
+// the near-hot band, sorted by the ratio in descending order.
+func (s *preventiveSplitScheduler) nearHotPeers(cluster opt.Cluster) []*statistics.HotPeerStat {
+	var peers []*statistics.HotPeerStat
+	ratios := make(map[uint64]float64)
+	for _, stats := range cluster.AllRegionWriteStats() {
+		for _, stat := range stats {
+			if _, ok := ratios[stat.RegionID]; ok {
+				continue
+			}
+			ratio := writeLoadRatio(stat)
+			if ratio < s.conf.LowRatio || ratio > s.conf.HighRatio {
+				continue
+			}
+			ratios[stat.RegionID] = ratio
+			peers = append(peers, stat)
+		}
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return ratios[peers[i].RegionID] > ratios[peers[j].RegionID]
+	})
+	return peers
+}
+
+// writeLoadRatio returns the highest ratio of the loads of the peer to the hot thresholds.
+func writeLoadRatio(stat *statistics.HotPeerStat) float64 {
+	loads, thresholds := stat.GetLoads(), stat.GetThresholds()
+	var ratio float64
+	for i := range loads {
+		if i >= len(thresholds) || thresholds[i] <= 0 {
+			continue
+		}
+		if r := loads[i] / thresholds[i]; r > ratio {
+			ratio = r
+		}
+	}
+	return ratio
+}
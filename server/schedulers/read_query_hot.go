@@ -0,0 +1,211 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/filter"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/tikv/pd/server/statistics"
+)
+
+const (
+	// ReadQueryHotName is read query hot scheduler name.
+	ReadQueryHotName = "read-query-hot-scheduler"
+	// ReadQueryHotType is read query hot scheduler type.
+	ReadQueryHotType = "read-query-hot"
+
+	// defaultReadQueryHotMargin is the default ratio of the source store's read query rate
+	// by which the source store must exceed the target store.
+	defaultReadQueryHotMargin = 0.1
+)
+
+func init() {
+	schedule.RegisterSliceDecoderBuilder(ReadQueryHotType, func(args []string) schedule.ConfigDecoder {
+		return func(v interface{}) error {
+			conf, ok := v.(*readQueryHotSchedulerConfig)
+			if !ok {
+				return errs.ErrScheduleConfigNotExist.FastGenByArgs()
+			}
+			conf.Margin = defaultReadQueryHotMargin
+			if len(args) == 1 {
+				margin, err := strconv.ParseFloat(args[0], 64)
+				if err != nil {
+					return errs.ErrStrconvParseFloat.Wrap(err).FastGenWithCause()
+				}
+				if margin < 0 || margin >= 1 {
+					return errs.ErrSchedulerConfig.FastGenByArgs("margin")
+				}
+				conf.Margin = margin
+			}
+			conf.Name = ReadQueryHotName
+			return nil
+		}
+	})
+
+	schedule.RegisterScheduler(ReadQueryHotType, func(opController *schedule.OperatorController, storage *core.Storage, decoder schedule.ConfigDecoder) (schedule.Scheduler, error) {
+		conf := &readQueryHotSchedulerConfig{Margin: defaultReadQueryHotMargin}
+		if err := decoder(conf); err != nil {
+			return nil, err
+		}
+		return newReadQueryHotScheduler(opController, conf), nil
+	})
+}
+
+type readQueryHotSchedulerConfig struct {
+	Name   string  `json:"name"`
+	Margin float64 `json:"margin"`
+}
+
+// readQueryHotScheduler balances the read query rate among stores by
+// transferring the leaders of hot read regions. A leader is only moved
+// when the read query rate of the two stores differ by more than the margin,
+// and the move doesn't make the target store hotter than the source store,
+// so that a region won't bounce between two stores.
+type readQueryHotScheduler struct {
+	*BaseScheduler
+	conf *readQueryHotSchedulerConfig
+}
+
+// newReadQueryHotScheduler creates a scheduler that balances the read query hotspots.
+func newReadQueryHotScheduler(opController *schedule.OperatorController, conf *readQueryHotSchedulerConfig) schedule.Scheduler {
+	return &readQueryHotScheduler{
+		BaseScheduler: NewBaseScheduler(opController),
+		conf:          conf,
+	}
+}
+
+func (s *readQueryHotScheduler) GetName() string {
+	return s.conf.Name
+}
+
+func (s *readQueryHotScheduler) GetType() string {
+	return ReadQueryHotType
+}
+
+func (s *readQueryHotScheduler) EncodeConfig() ([]byte, error) {
+	return schedule.EncodeConfig(s.conf)
+}
+
+func (s *readQueryHotScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
+	hotRegionAllowed := s.OpController.OperatorCount(operator.OpHotRegion) < cluster.GetOpts().GetHotRegionScheduleLimit()
+	leaderAllowed := s.OpController.OperatorCount(operator.OpLeader) < cluster.GetOpts().GetLeaderScheduleLimit()
+	if !hotRegionAllowed {
+		operator.OperatorLimitCounter.WithLabelValues(s.GetType(), operator.OpHotRegion.String()).Inc()
+	}
+	if !leaderAllowed {
+		operator.OperatorLimitCounter.WithLabelValues(s.GetType(), operator.OpLeader.String()).Inc()
+	}
+	return hotRegionAllowed && leaderAllowed
+}
+
+func (s *readQueryHotScheduler) Schedule(cluster opt.Cluster) []*operator.Operator {
+	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+	loadDetail := summaryStoresLoad(
+		summaryStoreInfos(cluster),
+		cluster.GetStoresLoads(),
+		cluster.RegionReadStats(),
+		cluster.GetOpts().IsTraceRegionFlow(),
+		read, core.LeaderKind)
+
+	sources := make([]*storeLoadDetail, 0, len(loadDetail))
+	for _, detail := range loadDetail {
+		if len(detail.HotPeers) > 0 {
+			sources = append(sources, detail)
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		return storeQueryRate(sources[i]) > storeQueryRate(sources[j])
+	})
+	for _, src := range sources {
+		if op := s.scheduleFromStore(cluster, src, loadDetail); op != nil {
+			return []*operator.Operator{op}
+		}
+	}
+	schedulerCounter.WithLabelValues(s.GetName(), "skip").Inc()
+	return nil
+}
+
+// scheduleFromStore tries to transfer the leader of the hottest read region of
+// the source store to the coldest follower store which is allowed by the margin.
+func (s *readQueryHotScheduler) scheduleFromStore(cluster opt.Cluster, src *storeLoadDetail, loadDetail map[uint64]*storeLoadDetail) *operator.Operator {
+	srcRate := storeQueryRate(src)
+	peers := append(src.HotPeers[:0:0], src.HotPeers...)
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].GetLoad(statistics.RegionReadQuery) > peers[j].GetLoad(statistics.RegionReadQuery)
+	})
+	for _, peer := range peers {
+		region := cluster.GetRegion(peer.RegionID)
+		if region == nil || len(region.GetDownPeers()) != 0 || len(region.GetPendingPeers()) != 0 {
+			continue
+		}
+		if region.GetLeader().GetStoreId() != src.getID() {
+			continue
+		}
+		regionRate := peer.GetLoad(statistics.RegionReadQuery)
+		if regionRate <= 0 {
+			continue
+		}
+		filters := []filter.Filter{
+			&filter.StoreStateFilter{ActionScope: s.GetName(), TransferLeader: true},
+			filter.NewSpecialUseFilter(s.GetName(), filter.SpecialUseHotRegion),
+		}
+		if leaderFilter := filter.NewPlacementLeaderSafeguard(s.GetName(), cluster, region, src.Info.Store); leaderFilter != nil {
+			filters = append(filters, leaderFilter)
+		}
+
+		var dst *storeLoadDetail
+		for _, follower := range region.GetFollowers() {
+			detail, ok := loadDetail[follower.GetStoreId()]
+			if !ok || !filter.Target(cluster.GetOpts(), detail.Info.Store, filters) {
+				continue
+			}
+			dstRate := storeQueryRate(detail)
+			// the difference is within the margin, or the target store will be
+			// hotter than the source store after the transfer.
+			if srcRate-dstRate <= s.conf.Margin*srcRate || dstRate+regionRate > srcRate-regionRate {
+				continue
+			}
+			if dst == nil || dstRate < storeQueryRate(dst) {
+				dst = detail
+			}
+		}
+		if dst == nil {
+			continue
+		}
+		op, err := operator.CreateTransferLeaderOperator("transfer-read-query-hot-leader", cluster, region, src.getID(), dst.getID(), operator.OpHotRegion|operator.OpLeader)
+		if err != nil {
+			log.Debug("fail to create transfer leader operator", errs.ZapError(err))
+			continue
+		}
+		op.SetPriorityLevel(core.HighPriority)
+		op.Counters = append(op.Counters,
+			schedulerCounter.WithLabelValues(s.GetName(), "new-operator"),
+			hotFlowOperatorCounter.WithLabelValues(s.GetName(), statistics.QueryFlow.String()))
+		return op
+	}
+	return nil
+}
+
+func storeQueryRate(detail *storeLoadDetail) float64 {
+	return detail.LoadPred.Current.Loads[statistics.QueryDim]
+}
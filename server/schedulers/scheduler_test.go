@@ -17,6 +17,7 @@ package schedulers
 import (
 	"context"
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -27,6 +28,7 @@ import (
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/kv"
 	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/hbstream"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/opt"
 	"github.com/tikv/pd/server/schedule/placement"
@@ -268,6 +270,80 @@ func (s *testEvictLeaderSuite) TestEvictLeader(c *C) {
 	testutil.CheckTransferLeader(c, op[0], operator.OpLeader, 1, 2)
 }
 
+func (s *testEvictLeaderSuite) TestEvictLeaderWithTimeout(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(ctx, opt)
+	tc.AddLeaderStore(1, 0)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderStore(3, 0)
+	tc.AddLeaderRegion(1, 1, 2)
+	tc.AddLeaderRegion(2, 2, 1)
+
+	oc := schedule.NewOperatorController(ctx, tc, nil)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	_, err := schedule.CreateScheduler(EvictLeaderType, oc, storage, schedule.ConfigSliceDecoder(EvictLeaderType, []string{"1", "-1m"}))
+	c.Assert(err, NotNil)
+	sl, err := schedule.CreateScheduler(EvictLeaderType, oc, storage, schedule.ConfigSliceDecoder(EvictLeaderType, []string{"1", "1h"}))
+	c.Assert(err, IsNil)
+	conf := sl.(*evictLeaderScheduler).conf
+	c.Assert(conf.BuildWithArgs([]string{"3"}, "1h"), IsNil)
+	c.Assert(conf.BuildWithArgs([]string{"2"}, ""), IsNil)
+	c.Assert(conf.StoreIDWithDeadline, HasLen, 2)
+	ops := sl.Schedule(tc)
+	c.Assert(ops, HasLen, 2)
+
+	// Store 3 holds no leader, so it is no longer evicted.
+	c.Assert(conf.StoreIDWithRanges, HasLen, 2)
+	c.Assert(conf.StoreIDWithDeadline, HasLen, 1)
+
+	// Store 1 is no longer evicted once its deadline passes, but store 2,
+	// which is evicted without a timeout, still is.
+	conf.StoreIDWithDeadline[1] = time.Now().Add(-time.Second)
+	ops = sl.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	testutil.CheckTransferLeader(c, ops[0], operator.OpLeader, 2, 1)
+	c.Assert(conf.StoreIDWithRanges, HasLen, 1)
+	c.Assert(conf.StoreIDWithDeadline, HasLen, 0)
+
+	// The scheduler is done along with its last store.
+	c.Assert(conf.BuildWithArgs([]string{"2"}, "1h"), IsNil)
+	tc.AddLeaderRegion(2, 1, 2)
+	c.Assert(sl.Schedule(tc), HasLen, 0)
+	c.Assert(conf.StoreIDWithRanges, HasLen, 0)
+}
+
+func (s *testEvictLeaderSuite) TestReAddEvictLeaderStore(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(ctx, opt)
+	sl, err := schedule.CreateScheduler(EvictLeaderType, schedule.NewOperatorController(ctx, tc, nil), core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(EvictLeaderType, []string{"1", "1h"}))
+	c.Assert(err, IsNil)
+	conf := sl.(*evictLeaderScheduler).conf
+	c.Assert(conf.StoreIDWithDeadline, HasLen, 1)
+
+	// Re-adding the store without a timeout drops its deadline.
+	c.Assert(conf.BuildWithArgs([]string{"1", "a", "b"}, ""), IsNil)
+	c.Assert(conf.StoreIDWithDeadline, HasLen, 0)
+	c.Assert(conf.StoreIDWithRanges[1], DeepEquals, []core.KeyRange{core.NewKeyRange("a", "b")})
+
+	// Re-adding it with a timeout keeps its ranges.
+	c.Assert(conf.BuildWithArgs(append([]string{"1"}, conf.getRanges(1)...), "1h"), IsNil)
+	c.Assert(conf.StoreIDWithDeadline, HasLen, 1)
+	c.Assert(conf.StoreIDWithRanges[1], DeepEquals, []core.KeyRange{core.NewKeyRange("a", "b")})
+
+	// A single range key is not taken as a timeout.
+	c.Assert(conf.BuildWithArgs([]string{"2", "1h"}, ""), IsNil)
+	c.Assert(conf.StoreIDWithDeadline, HasLen, 1)
+	c.Assert(conf.StoreIDWithRanges[2], DeepEquals, []core.KeyRange{core.NewKeyRange("", "")})
+
+	c.Assert(conf.BuildWithArgs(nil, ""), NotNil)
+	c.Assert(conf.BuildWithArgs([]string{"3"}, "-1m"), NotNil)
+	c.Assert(conf.StoreIDWithRanges, HasLen, 2)
+}
+
 func (s *testEvictLeaderSuite) TestEvictLeaderWithUnhealthyPeer(c *C) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -653,3 +729,298 @@ func (s *testEvictSlowStoreSuite) TestEvictSlowStore(c *C) {
 	op = bs.Schedule(tc)
 	testutil.CheckTransferLeader(c, op[0], operator.OpLeader, 2, 1)
 }
+
+func (s *testEvictSlowStoreSuite) TestEvictSlowStoreRecovery(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(ctx, opt)
+	tc.SetSlowStoreThresholds(90, 10)
+	tc.SetSlowStoreRecoveryDuration(100 * time.Millisecond)
+	tc.AddLeaderStore(1, 0)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderRegion(1, 1, 2)
+
+	oc := schedule.NewOperatorController(ctx, nil, nil)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	es, err := schedule.CreateScheduler(EvictSlowStoreType, oc, storage, schedule.ConfigSliceDecoder(EvictSlowStoreType, []string{}))
+	c.Assert(err, IsNil)
+	setSlowScore := func(score uint64) {
+		tc.PutStore(tc.GetStore(1).Clone(func(store *core.StoreInfo) {
+			store.GetStoreStats().SlowScore = score
+		}))
+	}
+
+	// the slow score is below the evict threshold.
+	setSlowScore(85)
+	c.Assert(es.Schedule(tc), HasLen, 0)
+	setSlowScore(90)
+	testutil.CheckTransferLeader(c, es.Schedule(tc)[0], operator.OpLeader, 1, 2)
+	c.Assert(tc.GetStore(1).EvictedAsSlowStore(), IsTrue)
+
+	// the store isn't recovered until its slow score stays normal for the recovery duration.
+	setSlowScore(10)
+	c.Assert(es.Schedule(tc), HasLen, 1)
+	time.Sleep(50 * time.Millisecond)
+	setSlowScore(20)
+	c.Assert(es.Schedule(tc), HasLen, 1)
+	setSlowScore(5)
+	c.Assert(es.Schedule(tc), HasLen, 1)
+	time.Sleep(50 * time.Millisecond)
+	c.Assert(es.Schedule(tc), HasLen, 1)
+	c.Assert(tc.GetStore(1).EvictedAsSlowStore(), IsTrue)
+	time.Sleep(100 * time.Millisecond)
+	c.Assert(es.Schedule(tc), HasLen, 0)
+	c.Assert(tc.GetStore(1).EvictedAsSlowStore(), IsFalse)
+}
+
+var _ = Suite(&testReadQueryHotSuite{})
+
+type testReadQueryHotSuite struct{}
+
+func (s *testReadQueryHotSuite) TestSchedule(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	statistics.Denoising = false
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(ctx, opt)
+	tc.SetHotRegionCacheHitsThreshold(0)
+	tc.AddRegionStore(1, 20)
+	tc.AddRegionStore(2, 20)
+	tc.AddRegionStore(3, 20)
+
+	rq, err := schedule.CreateScheduler(ReadQueryHotType, schedule.NewOperatorController(ctx, nil, nil), core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(ReadQueryHotType, []string{"0.1"}))
+	c.Assert(err, IsNil)
+
+	tc.UpdateStorageReadQuery(1, 10500*statistics.StoreHeartBeatReportInterval)
+	tc.UpdateStorageReadQuery(2, 10000*statistics.StoreHeartBeatReportInterval)
+	tc.UpdateStorageReadQuery(3, 9000*statistics.StoreHeartBeatReportInterval)
+	addRegionInfo(tc, read, []testRegionInfo{
+		{1, []uint64{1, 2, 3}, 0, 0, 500},
+	})
+	// transfer the leader to the coldest follower store.
+	ops := rq.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	testutil.CheckTransferLeader(c, ops[0], operator.OpHotRegion, 1, 3)
+
+	// the difference of read query rate is within the margin.
+	tc.UpdateStorageReadQuery(1, 10000*statistics.StoreHeartBeatReportInterval)
+	tc.UpdateStorageReadQuery(2, 9500*statistics.StoreHeartBeatReportInterval)
+	tc.UpdateStorageReadQuery(3, 9200*statistics.StoreHeartBeatReportInterval)
+	c.Assert(rq.Schedule(tc), HasLen, 0)
+
+	// the target store will be hotter than the source store after the transfer.
+	tc = mockcluster.NewCluster(ctx, opt)
+	tc.SetHotRegionCacheHitsThreshold(0)
+	tc.AddRegionStore(1, 20)
+	tc.AddRegionStore(2, 20)
+	tc.AddRegionStore(3, 20)
+	tc.UpdateStorageReadQuery(1, 10500*statistics.StoreHeartBeatReportInterval)
+	tc.UpdateStorageReadQuery(2, 9000*statistics.StoreHeartBeatReportInterval)
+	tc.UpdateStorageReadQuery(3, 9000*statistics.StoreHeartBeatReportInterval)
+	addRegionInfo(tc, read, []testRegionInfo{
+		{1, []uint64{1, 2, 3}, 0, 0, 1000},
+	})
+	c.Assert(rq.Schedule(tc), HasLen, 0)
+
+	// invalid margin.
+	_, err = schedule.CreateScheduler(ReadQueryHotType, schedule.NewOperatorController(ctx, nil, nil), core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(ReadQueryHotType, []string{"1.5"}))
+	c.Assert(err, NotNil)
+}
+
+var _ = Suite(&testBalanceZoneRegionSuite{})
+
+type testBalanceZoneRegionSuite struct{}
+
+func (s *testBalanceZoneRegionSuite) TestSchedule(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(ctx, opt)
+	tc.DisableFeature(versioninfo.JointConsensus)
+	rule := &placement.Rule{GroupID: "pd", ID: "default", Role: placement.Voter, Count: 1}
+	tc.RuleManager.SetRule(rule)
+	tc.AddLabelsStore(1, 10, map[string]string{"zone": "z1", "rack": "r1"})
+	tc.AddLabelsStore(2, 10, map[string]string{"zone": "z1", "rack": "r2"})
+	tc.AddLabelsStore(3, 12, map[string]string{"zone": "z2", "rack": "r2"})
+	tc.AddLabelsStore(4, 2, map[string]string{"zone": "z3", "rack": "r2"})
+	tc.AddLeaderRegion(1, 1)
+
+	oc := schedule.NewOperatorController(ctx, nil, nil)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	sb, err := schedule.CreateScheduler(BalanceZoneRegionType, oc, storage, schedule.ConfigSliceDecoder(BalanceZoneRegionType, []string{}))
+	c.Assert(err, IsNil)
+	c.Assert(sb.IsScheduleAllowed(tc), IsTrue)
+	// move the region from the zone with the most regions to the one with the fewest.
+	ops := sb.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	testutil.CheckTransferPeer(c, ops[0], operator.OpRegion, 1, 4)
+
+	// the placement rule doesn't allow the region to be placed in z3.
+	rule.LabelConstraints = []placement.LabelConstraint{{Key: "zone", Op: placement.NotIn, Values: []string{"z3"}}}
+	tc.RuleManager.SetRule(rule)
+	ops = sb.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	testutil.CheckTransferPeer(c, ops[0], operator.OpRegion, 1, 3)
+	rule.LabelConstraints = nil
+	tc.RuleManager.SetRule(rule)
+
+	// the zones are balanced by another label key.
+	sb, err = schedule.CreateScheduler(BalanceZoneRegionType, oc, storage, schedule.ConfigSliceDecoder(BalanceZoneRegionType, []string{"rack"}))
+	c.Assert(err, IsNil)
+	// no region in r2 can be moved.
+	c.Assert(sb.Schedule(tc), HasLen, 0)
+	tc.UpdateRegionCount(1, 40)
+	ops = sb.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	testutil.CheckTransferPeer(c, ops[0], operator.OpRegion, 1, 4)
+}
+
+var _ = Suite(&testPreventiveSplitSuite{})
+
+type testPreventiveSplitSuite struct{}
+
+func (s *testPreventiveSplitSuite) TestSchedule(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(ctx, opt)
+	tc.SetHotRegionCacheHitsThreshold(100)
+	tc.SetMaxMergeRegionSize(20)
+	for i := uint64(1); i <= 3; i++ {
+		tc.AddRegionStore(i, 10)
+	}
+	interval := uint64(statistics.WriteReportInterval)
+	// region 1 is near hot, region 2 is much hotter and region 3 is too small.
+	tc.AddLeaderRegionWithWriteInfo(1, 1, 1200*interval, 0, 0, interval, []uint64{2, 3})
+	tc.AddLeaderRegionWithWriteInfo(2, 1, 5000*interval, 0, 0, interval, []uint64{2, 3})
+	tc.AddLeaderRegionWithWriteInfo(3, 1, 1300*interval, 0, 0, interval, []uint64{2, 3})
+	for id, size := range map[uint64]int64{1: 100, 2: 100, 3: 30} {
+		tc.PutRegion(tc.GetRegion(id).Clone(core.SetApproximateSize(size)))
+	}
+
+	oc := schedule.NewOperatorController(ctx, nil, nil)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	_, err := schedule.CreateScheduler(PreventiveSplitType, oc, storage, schedule.ConfigSliceDecoder(PreventiveSplitType, []string{"2", "1"}))
+	c.Assert(err, NotNil)
+	sb, err := schedule.CreateScheduler(PreventiveSplitType, oc, storage, schedule.ConfigSliceDecoder(PreventiveSplitType, []string{}))
+	c.Assert(err, IsNil)
+	c.Assert(sb.IsScheduleAllowed(tc), IsTrue)
+	ops := sb.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	c.Assert(ops[0].RegionID(), Equals, uint64(1))
+	c.Assert(ops[0].Kind()&operator.OpSplit, Not(Equals), operator.OpKind(0))
+	c.Assert(ops[0].Step(0).(operator.SplitRegion).Policy, Equals, pdpb.CheckPolicy_APPROXIMATE)
+
+	// region 1 is in cooldown.
+	c.Assert(sb.Schedule(tc), HasLen, 0)
+
+	// region 3 can be split with a smaller minimum region size.
+	tc.SetMaxMergeRegionSize(10)
+	ops = sb.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	c.Assert(ops[0].RegionID(), Equals, uint64(3))
+
+	// the confirmed hot region is left to the hot region scheduler.
+	tc.SetHotRegionCacheHitsThreshold(0)
+	sb, err = schedule.CreateScheduler(PreventiveSplitType, oc, storage, schedule.ConfigSliceDecoder(PreventiveSplitType, []string{}))
+	c.Assert(err, IsNil)
+	c.Assert(sb.Schedule(tc), HasLen, 0)
+}
+
+var _ = Suite(&testDrainStoreSuite{})
+
+type testDrainStoreSuite struct{}
+
+func (s *testDrainStoreSuite) TestDrainStore(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(ctx, opt)
+	tc.DisableFeature(versioninfo.JointConsensus)
+	for i := uint64(1); i <= 4; i++ {
+		tc.AddRegionStore(i, 0)
+	}
+	tc.AddLeaderRegion(1, 1, 2, 3)
+	tc.AddLeaderRegion(2, 2, 1, 3)
+
+	stream := hbstream.NewTestHeartbeatStreams(ctx, tc.ID, tc, false /* no need to run */)
+	oc := schedule.NewOperatorController(ctx, tc, stream)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	_, err := schedule.CreateScheduler(DrainStoreType, oc, storage, schedule.ConfigSliceDecoder(DrainStoreType, []string{}))
+	c.Assert(err, NotNil)
+	sd, err := schedule.CreateScheduler(DrainStoreType, oc, storage, schedule.ConfigSliceDecoder(DrainStoreType, []string{"1"}))
+	c.Assert(err, IsNil)
+	c.Assert(sd.GetName(), Equals, "drain-store-scheduler-1")
+	c.Assert(sd.IsScheduleAllowed(tc), IsTrue)
+
+	// The follower is moved first.
+	ops := sd.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	testutil.CheckTransferPeer(c, ops[0], operator.OpReplica, 1, 4)
+
+	// The region with an operator in flight is skipped.
+	c.Assert(oc.AddOperator(ops[0]), IsTrue)
+	c.Assert(sd.Schedule(tc), HasLen, 0)
+	oc.RemoveOperator(ops[0])
+
+	// The leader is transferred before the peer is moved.
+	tc.AddLeaderRegion(2, 2, 3, 4)
+	ops = sd.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	testutil.CheckTransferLeaderFrom(c, ops[0], operator.OpLeader, 1)
+
+	// It is done once the store holds no peer.
+	tc.AddLeaderRegion(1, 2, 3, 4)
+	c.Assert(tc.GetStoreRegionCount(1), Equals, 0)
+	c.Assert(sd.Schedule(tc), HasLen, 0)
+}
+
+var _ = Suite(&testColocateLeaderSuite{})
+
+type testColocateLeaderSuite struct{}
+
+func (s *testColocateLeaderSuite) TestColocateLeader(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(ctx, opt)
+	for i := uint64(1); i <= 3; i++ {
+		tc.AddLeaderStore(i, 10)
+	}
+	tc.AddLeaderRegionWithRange(1, "a", "b", 1, 2, 3)
+	tc.AddLeaderRegionWithRange(2, "b", "c", 2, 1, 3)
+	tc.AddLeaderRegionWithRange(3, "c", "d", 2, 1, 3)
+	tc.AddLeaderRegionWithRange(4, "d", "", 3, 1, 2)
+
+	oc := schedule.NewOperatorController(ctx, tc, nil)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	sd, err := schedule.CreateScheduler(ColocateLeaderType, oc, storage, schedule.ConfigSliceDecoder(ColocateLeaderType, []string{"", ""}))
+	c.Assert(err, IsNil)
+	c.Assert(sd.IsScheduleAllowed(tc), IsTrue)
+
+	// The region follows the leader store of the region before it.
+	ops := sd.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	testutil.CheckTransferLeader(c, ops[0], operator.OpLeader, 2, 1)
+
+	// No leader is transferred to the store holding too many leaders.
+	tc.AddLeaderStore(1, 20)
+	ops = sd.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	testutil.CheckTransferLeader(c, ops[0], operator.OpLeader, 3, 2)
+	tc.AddLeaderStore(2, 20)
+	c.Assert(sd.Schedule(tc), HasLen, 0)
+	sd.(*colocateLeaderScheduler).conf.MaxLeaderSkew = 1
+	ops = sd.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	testutil.CheckTransferLeader(c, ops[0], operator.OpLeader, 2, 1)
+
+	// Only the regions in the key ranges are colocated.
+	sd, err = schedule.CreateScheduler(ColocateLeaderType, oc, storage, schedule.ConfigSliceDecoder(ColocateLeaderType, []string{"a", "c"}))
+	c.Assert(err, IsNil)
+	tc.AddLeaderStore(1, 10)
+	tc.AddLeaderStore(2, 10)
+	tc.AddLeaderRegionWithRange(2, "b", "c", 1, 2, 3)
+	c.Assert(sd.Schedule(tc), HasLen, 0)
+}
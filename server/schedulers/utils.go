@@ -53,6 +53,9 @@ type balancePlan struct {
 
 	sourceScore float64
 	targetScore float64
+
+	// explainer records the scores of the stores and the decisions if it is enabled.
+	explainer *explanationRecorder
 }
 
 func newBalancePlan(kind core.ScheduleKind, cluster opt.Cluster, opInfluence operator.OpInfluence) *balancePlan {
@@ -124,6 +127,7 @@ func (p *balancePlan) shouldBalance(scheduleName string) bool {
 	}
 	// Make sure after move, source score is still greater than target score.
 	shouldBalance := p.sourceScore > p.targetScore
+	p.explain(shouldBalance)
 
 	if !shouldBalance {
 		log.Debug("skip balance "+p.kind.Resource.String(),
@@ -138,6 +142,25 @@ func (p *balancePlan) shouldBalance(scheduleName string) bool {
 	return shouldBalance
 }
 
+func (p *balancePlan) explain(shouldBalance bool) {
+	if p.explainer == nil || !p.explainer.isEnabled() {
+		return
+	}
+	decision := ExplanationDecisionSkip
+	if shouldBalance {
+		decision = ExplanationDecisionBalance
+	}
+	p.explainer.record(&ScheduleExplanation{
+		Time:        time.Now(),
+		RegionID:    p.region.GetID(),
+		SourceStore: p.SourceStoreID(),
+		SourceScore: p.sourceScore,
+		TargetStore: p.TargetStoreID(),
+		TargetScore: p.targetScore,
+		Decision:    decision,
+	})
+}
+
 func (p *balancePlan) getTolerantResource() int64 {
 	if p.kind.Resource == core.LeaderKind && p.kind.Policy == core.ByCount {
 		return int64(p.tolerantSizeRatio)
@@ -792,3 +815,33 @@ func (q *retryQuota) GC(keepStores []*core.StoreInfo) {
 		}
 	}
 }
+
+// lowChurnSensitiveTypes are the types of the schedulers which only improve
+// the balance or the locality of the cluster, and are held back during a
+// low-churn window. The schedulers moving the leaders or the regions out of
+// the stores on purpose, such as evict-leader, are not among them.
+var lowChurnSensitiveTypes = map[string]struct{}{
+	BalanceLeaderType:     {},
+	BalanceLearnerType:    {},
+	BalanceRegionType:     {},
+	BalanceRegionSizeType: {},
+	BalanceZoneRegionType: {},
+	ColocateLeaderType:    {},
+	HotRegionType:         {},
+	HotReadRegionType:     {},
+	HotWriteRegionType:    {},
+	ReadQueryHotType:      {},
+	PreventiveSplitType:   {},
+	RandomMergeType:       {},
+	ScatterRangeType:      {},
+	ShuffleHotRegionType:  {},
+	ShuffleLeaderType:     {},
+	ShuffleRegionType:     {},
+}
+
+// IsLowChurnSensitive returns true if the schedulers of the type are held back
+// during a low-churn window.
+func IsLowChurnSensitive(typ string) bool {
+	_, ok := lowChurnSensitiveTypes[typ]
+	return ok
+}
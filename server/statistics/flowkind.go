@@ -14,6 +14,8 @@
 
 package statistics
 
+import "math"
+
 // FlowKind is a identify Flow types.
 type FlowKind uint32
 
@@ -21,6 +23,13 @@ type FlowKind uint32
 const (
 	WriteFlow FlowKind = iota
 	ReadFlow
+	// QueryFlow is a virtual flow which combines the query statistics of
+	// read and write flows.
+	QueryFlow
+
+	// UnknownFlow is returned by FlowKindOf if the statistics kind is not
+	// owned by any flow.
+	UnknownFlow FlowKind = math.MaxUint32
 )
 
 func (k FlowKind) String() string {
@@ -29,17 +38,47 @@ func (k FlowKind) String() string {
 		return "write"
 	case ReadFlow:
 		return "read"
+	case QueryFlow:
+		return "query"
 	}
 	return "unimplemented"
 }
 
-// RegionStats returns hot items according to kind
+// RegionStats returns hot items according to kind, including the registered
+// region statistics kinds of the flow.
 func (k FlowKind) RegionStats() []RegionStatKind {
+	builtin := k.builtinRegionStats()
+	registered := registeredRegionStats(k)
+	if len(registered) == 0 {
+		return builtin
+	}
+	return append(append(builtin[:0:0], builtin...), registered...)
+}
+
+// builtinRegionStats returns the built-in region statistics kinds of the flow.
+// They are the dimensions tracked by the hot cache, in the order of ByteDim,
+// KeyDim and QueryDim.
+func (k FlowKind) builtinRegionStats() []RegionStatKind {
 	switch k {
 	case WriteFlow:
 		return []RegionStatKind{RegionWriteBytes, RegionWriteKeys, RegionWriteQuery}
 	case ReadFlow:
 		return []RegionStatKind{RegionReadBytes, RegionReadKeys, RegionReadQuery}
+	case QueryFlow:
+		return []RegionStatKind{RegionReadQuery, RegionWriteQuery}
 	}
 	return nil
 }
+
+// FlowKindOf returns the flow kind which the region statistics kind belongs
+// to. The virtual QueryFlow is not taken into account.
+func FlowKindOf(k RegionStatKind) FlowKind {
+	for _, kind := range []FlowKind{WriteFlow, ReadFlow} {
+		for _, statKind := range kind.RegionStats() {
+			if statKind == k {
+				return kind
+			}
+		}
+	}
+	return UnknownFlow
+}
@@ -16,6 +16,7 @@ package statistics
 
 import (
 	"context"
+	"time"
 
 	"github.com/tikv/pd/server/core"
 )
@@ -26,26 +27,43 @@ var Denoising = true
 
 const queueCap = 20000
 
+// HotCacheConfig is the config of the hot cache. It is read by the cache
+// before running each task, so that a change of it never waits in the queues,
+// which drop the tasks once they are full.
+type HotCacheConfig interface {
+	GetHotRegionQueryThreshold() float64
+	GetHotRegionRollingWindowSize() int
+	GetHotRegionCacheHitsThreshold() int
+	GetHotRegionTransitionHysteresis() time.Duration
+}
+
 // HotCache is a cache hold hot regions.
 type HotCache struct {
 	ctx            context.Context
 	quit           <-chan struct{}
+	opt            HotCacheConfig
 	readFlowQueue  chan FlowItemTask
 	writeFlowQueue chan FlowItemTask
 	writeFlow      *hotPeerCache
 	readFlow       *hotPeerCache
+	listeners      *hotRegionListeners
 }
 
-// NewHotCache creates a new hot spot cache.
-func NewHotCache(ctx context.Context, quit <-chan struct{}) *HotCache {
+// NewHotCache creates a new hot spot cache. The config is optional, and the
+// cache keeps its default settings if it is nil.
+func NewHotCache(ctx context.Context, quit <-chan struct{}, opt HotCacheConfig) *HotCache {
 	w := &HotCache{
 		ctx:            ctx,
 		quit:           quit,
+		opt:            opt,
 		readFlowQueue:  make(chan FlowItemTask, queueCap),
 		writeFlowQueue: make(chan FlowItemTask, queueCap),
 		writeFlow:      NewHotPeerCache(WriteFlow),
 		readFlow:       NewHotPeerCache(ReadFlow),
+		listeners:      &hotRegionListeners{},
 	}
+	w.writeFlow.transitions.listeners = w.listeners
+	w.readFlow.transitions.listeners = w.listeners
 	go w.updateItems(w.readFlowQueue, w.runReadTask)
 	go w.updateItems(w.writeFlowQueue, w.runWriteTask)
 	return w
@@ -54,12 +72,14 @@ func NewHotCache(ctx context.Context, quit <-chan struct{}) *HotCache {
 // CheckWritePeerSync checks the write status, returns update items.
 // This is used for mockcluster.
 func (w *HotCache) CheckWritePeerSync(peer *core.PeerInfo, region *core.RegionInfo) *HotPeerStat {
+	w.applyConfig(w.writeFlow)
 	return w.writeFlow.CheckPeerFlow(peer, region)
 }
 
 // CheckReadPeerSync checks the read status, returns update items.
 // This is used for mockcluster.
 func (w *HotCache) CheckReadPeerSync(peer *core.PeerInfo, region *core.RegionInfo) *HotPeerStat {
+	w.applyConfig(w.readFlow)
 	return w.readFlow.CheckPeerFlow(peer, region)
 }
 
@@ -111,10 +131,45 @@ func (w *HotCache) RegionStats(kind FlowKind, minHotDegree int) map[uint64][]*Ho
 			return nil
 		}
 		return task.waitRet(w.ctx, w.quit)
+	case QueryFlow:
+		return w.queryRegionStats(minHotDegree)
 	}
 	return nil
 }
 
+// RegionStatsByKind returns the hot peers which are hot in the dimension of the
+// given kind, ranked by the load of the dimension for each store.
+func (w *HotCache) RegionStatsByKind(k RegionStatKind, minHotDegree int) map[uint64][]*HotPeerStat {
+	return RankHotPeers(w.RegionStats(FlowKindOf(k), minHotDegree), k)
+}
+
+// GetHotRegionsByKind returns the hot regions whose rate of any region
+// statistics kind of the flow reaches minRate, with the rates of all the kinds
+// and the ones exceeding minRate. All the peers in the cache are taken into
+// account no matter how many times they have been hot.
+func (w *HotCache) GetHotRegionsByKind(kind FlowKind, minRate float64) []*HotRegionRate {
+	return FilterHotRegionsByRate(w.RegionStats(kind, 0), kind, minRate)
+}
+
+// queryRegionStats collects the hot peers of both read and write flows whose
+// query load reaches the query threshold.
+func (w *HotCache) queryRegionStats(minHotDegree int) map[uint64][]*HotPeerStat {
+	res := make(map[uint64][]*HotPeerStat)
+	for _, kind := range []FlowKind{ReadFlow, WriteFlow} {
+		for storeID, stats := range w.RegionStats(kind, minHotDegree) {
+			if _, ok := res[storeID]; !ok {
+				res[storeID] = make([]*HotPeerStat, 0, len(stats))
+			}
+			for _, stat := range stats {
+				if stat.isQueryHot() {
+					res[storeID] = append(res[storeID], stat)
+				}
+			}
+		}
+	}
+	return res
+}
+
 // HotRegionsFromStore picks hot region in specify store.
 func (w *HotCache) HotRegionsFromStore(storeID uint64, kind FlowKind, minHotDegree int) []*HotPeerStat {
 	if stats, ok := w.RegionStats(kind, minHotDegree)[storeID]; ok && len(stats) > 0 {
@@ -143,6 +198,12 @@ func (w *HotCache) CollectMetrics() {
 	w.CheckReadAsync(readMetricsTask)
 }
 
+// SubscribeHotRegionTransition adds a listener which is notified when a region
+// turns hot or cold in the read or write flow.
+func (w *HotCache) SubscribeHotRegionTransition(listener HotRegionListener) {
+	w.listeners.add(listener)
+}
+
 // ResetMetrics resets the hot cache metrics.
 func (w *HotCache) ResetMetrics() {
 	hotCacheStatusGauge.Reset()
@@ -197,6 +258,7 @@ func (w *HotCache) updateItems(queue <-chan FlowItemTask, runTask func(task Flow
 func (w *HotCache) runReadTask(task FlowItemTask) {
 	if task != nil {
 		// TODO: do we need a run-task timeout to protect the queue won't be stucked by a task?
+		w.applyConfig(w.readFlow)
 		task.runTask(w.readFlow)
 		hotCacheFlowQueueStatusGauge.WithLabelValues(ReadFlow.String()).Set(float64(len(w.readFlowQueue)))
 	}
@@ -205,11 +267,27 @@ func (w *HotCache) runReadTask(task FlowItemTask) {
 func (w *HotCache) runWriteTask(task FlowItemTask) {
 	if task != nil {
 		// TODO: do we need a run-task timeout to protect the queue won't be stucked by a task?
+		w.applyConfig(w.writeFlow)
 		task.runTask(w.writeFlow)
 		hotCacheFlowQueueStatusGauge.WithLabelValues(WriteFlow.String()).Set(float64(len(w.writeFlowQueue)))
 	}
 }
 
+// applyConfig applies the config to the flow before it runs a task.
+func (w *HotCache) applyConfig(flow *hotPeerCache) {
+	if w.opt == nil {
+		return
+	}
+	flow.queryThreshold = w.opt.GetHotRegionQueryThreshold()
+	// The existing peers keep their recent averages when their windows are
+	// resized by the next update.
+	if size := w.opt.GetHotRegionRollingWindowSize(); size > 0 {
+		flow.rollingWindowSize = size
+	}
+	flow.transitions.minHotDegree = w.opt.GetHotRegionCacheHitsThreshold()
+	flow.transitions.hysteresis = w.opt.GetHotRegionTransitionHysteresis()
+}
+
 func update(item *HotPeerStat, flow *hotPeerCache) {
 	flow.Update(item)
 	if item.IsNeedDelete() {
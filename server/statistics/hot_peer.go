@@ -37,10 +37,10 @@ type dimStat struct {
 	LastAverage *movingaverage.AvgOverTime // it's used to obtain the average speed in last second as instantaneous speed.
 }
 
-func newDimStat(typ RegionStatKind, reportInterval time.Duration) *dimStat {
+func newDimStat(typ RegionStatKind, reportInterval time.Duration, windowSize int) *dimStat {
 	return &dimStat{
 		typ:         typ,
-		Rolling:     movingaverage.NewTimeMedian(DefaultAotSize, rollingWindowsSize, reportInterval),
+		Rolling:     movingaverage.NewTimeMedian(DefaultAotSize, windowSize, reportInterval),
 		LastAverage: movingaverage.NewAvgOverTime(reportInterval),
 	}
 }
@@ -70,6 +70,11 @@ func (d *dimStat) Get() float64 {
 	return d.Rolling.Get()
 }
 
+// slope returns the relative slope of the averages of the recent periods.
+func (d *dimStat) slope() float64 {
+	return relativeSlope(d.Rolling.Records())
+}
+
 // HotPeerStat records each hot peer's statistics
 type HotPeerStat struct {
 	StoreID  uint64 `json:"store_id"`
@@ -85,6 +90,9 @@ type HotPeerStat struct {
 
 	// rolling statistics, recording some recently added records.
 	rollingLoads []*dimStat
+	// slopes are the relative slopes of the loads kept by the clone, which has
+	// no rolling statistics.
+	slopes []float64
 
 	// LastUpdateTime used to calculate average write
 	LastUpdateTime time.Time `json:"last_update_time"`
@@ -153,6 +161,13 @@ func (stat *HotPeerStat) IsNew() bool {
 
 // GetLoad returns denoised load if possible.
 func (stat *HotPeerStat) GetLoad(k RegionStatKind) float64 {
+	if k >= RegionStatCount {
+		loads := make([]float64, RegionStatCount)
+		for i := RegionStatKind(0); i < RegionStatCount; i++ {
+			loads[i] = stat.GetLoad(i)
+		}
+		return ComputeRegionStat(k, loads)
+	}
 	if len(stat.rollingLoads) > int(k) {
 		return math.Round(stat.rollingLoads[int(k)].Get())
 	}
@@ -161,7 +176,7 @@ func (stat *HotPeerStat) GetLoad(k RegionStatKind) float64 {
 
 // GetLoads returns denoised load if possible.
 func (stat *HotPeerStat) GetLoads() []float64 {
-	regionStats := stat.Kind.RegionStats()
+	regionStats := stat.Kind.builtinRegionStats()
 	loads := make([]float64, len(regionStats))
 	for i, k := range regionStats {
 		loads[i] = stat.GetLoad(k)
@@ -169,6 +184,49 @@ func (stat *HotPeerStat) GetLoads() []float64 {
 	return loads
 }
 
+// GetLoadSlopes returns the relative slope of each load in the order of
+// GetLoads, which is how much the load changes per rolling period relative to
+// the load, fitted from the recent periods in the rolling window. It is 0 if
+// there are not enough periods to tell.
+func (stat *HotPeerStat) GetLoadSlopes() []float64 {
+	if stat.rollingLoads == nil && stat.slopes != nil {
+		return stat.slopes
+	}
+	slopes := make([]float64, len(stat.Kind.builtinRegionStats()))
+	for i := range slopes {
+		if i < len(stat.rollingLoads) {
+			slopes[i] = stat.rollingLoads[i].slope()
+		}
+	}
+	return slopes
+}
+
+// GetLoadTrend returns the trend of the load of the dim. The load whose
+// relative slope is within the tolerance is taken as flat.
+func (stat *HotPeerStat) GetLoadTrend(dim int, tolerance float64) Trend {
+	slopes := stat.GetLoadSlopes()
+	if dim < 0 || dim >= len(slopes) {
+		return TrendFlat
+	}
+	return trendOf(slopes[dim], tolerance)
+}
+
+// GetFlowTrend returns the trend of the flow of the peer. The flow is
+// increasing if any of its loads is increasing, and decreasing if none is
+// increasing but some is decreasing.
+func (stat *HotPeerStat) GetFlowTrend(tolerance float64) Trend {
+	trend := TrendFlat
+	for _, slope := range stat.GetLoadSlopes() {
+		switch trendOf(slope, tolerance) {
+		case TrendIncreasing:
+			return TrendIncreasing
+		case TrendDecreasing:
+			trend = TrendDecreasing
+		}
+	}
+	return trend
+}
+
 // GetThresholds returns thresholds
 func (stat *HotPeerStat) GetThresholds() []float64 {
 	return stat.thresholds
@@ -181,6 +239,7 @@ func (stat *HotPeerStat) Clone() *HotPeerStat {
 	for i := RegionStatKind(0); i < RegionStatCount; i++ {
 		ret.Loads[i] = stat.GetLoad(i) // replace with denoised loads
 	}
+	ret.slopes = stat.GetLoadSlopes()
 	ret.rollingLoads = nil
 	return &ret
 }
@@ -191,6 +250,28 @@ func (stat *HotPeerStat) isFullAndHot() bool {
 	})
 }
 
+// isQueryHot returns true if the query load of the peer reaches its threshold.
+func (stat *HotPeerStat) isQueryHot() bool {
+	if len(stat.thresholds) <= QueryDim {
+		return false
+	}
+	k := RegionReadQuery
+	if stat.Kind == WriteFlow {
+		k = RegionWriteQuery
+	}
+	return stat.GetLoad(k) >= stat.thresholds[QueryDim]
+}
+
+// isHotIn returns true if the load of the given kind reaches the threshold of its dimension.
+func (stat *HotPeerStat) isHotIn(k RegionStatKind) bool {
+	for dim, statKind := range stat.Kind.builtinRegionStats() {
+		if statKind == k {
+			return dim < len(stat.thresholds) && stat.GetLoad(k) >= stat.thresholds[dim]
+		}
+	}
+	return false
+}
+
 func (stat *HotPeerStat) clearLastAverage() {
 	for _, l := range stat.rollingLoads {
 		l.clearLastAverage()
@@ -35,7 +35,9 @@ const (
 	// ReadReportInterval indicates the interval between read stats report
 	ReadReportInterval = StoreHeartBeatReportInterval
 
-	rollingWindowsSize = 5
+	// DefaultHotRollingWindowSize is the default number of the averages which the
+	// rolling loads of a hot peer take the median of.
+	DefaultHotRollingWindowSize = 5
 
 	// HotRegionReportMinInterval is used for the simulator and test
 	HotRegionReportMinInterval = 3
@@ -61,6 +63,13 @@ type hotPeerCache struct {
 	inheritItem        map[uint64]*HotPeerStat        // regionID -> HotPeerStat
 	topNTTL            time.Duration
 	reportIntervalSecs int
+	// queryThreshold is the query rate above which a peer is always hot.
+	// It is disabled if it is 0.
+	queryThreshold float64
+	// rollingWindowSize is the number of the averages which the rolling loads take the median of.
+	rollingWindowSize int
+	// transitions notifies the listeners when the regions turn hot or cold.
+	transitions *hotTransitions
 }
 
 // NewHotPeerCache creates a hotPeerCache
@@ -71,6 +80,9 @@ func NewHotPeerCache(kind FlowKind) *hotPeerCache {
 		storesOfRegion: make(map[uint64]map[uint64]struct{}),
 		regionsOfStore: make(map[uint64]map[uint64]struct{}),
 		inheritItem:    make(map[uint64]*HotPeerStat),
+
+		rollingWindowSize: DefaultHotRollingWindowSize,
+		transitions:       newHotTransitions(&hotRegionListeners{}),
 	}
 	if kind == WriteFlow {
 		c.reportIntervalSecs = WriteReportInterval
@@ -108,6 +120,7 @@ func (f *hotPeerCache) Update(item *HotPeerStat) {
 		f.putItem(item)
 		item.Log("region heartbeat update", log.Debug)
 	}
+	f.transitions.track(f, item.RegionID, time.Now())
 }
 
 func (f *hotPeerCache) collectPeerMetrics(loads []float64, interval uint64) {
@@ -116,7 +129,7 @@ func (f *hotPeerCache) collectPeerMetrics(loads []float64, interval uint64) {
 		return
 	}
 	// TODO: use unified metrics. (keep backward compatibility at the same time)
-	for _, k := range f.kind.RegionStats() {
+	for _, k := range f.kind.builtinRegionStats() {
 		switch k {
 		case RegionReadBytes:
 			readByteHist.Observe(loads[int(k)])
@@ -267,20 +280,29 @@ func (f *hotPeerCache) getOldHotPeerStat(regionID, storeID uint64) *HotPeerStat
 }
 
 func (f *hotPeerCache) calcHotThresholds(storeID uint64) []float64 {
-	statKinds := f.kind.RegionStats()
+	statKinds := f.kind.builtinRegionStats()
 	mins := make([]float64, len(statKinds))
 	for i, k := range statKinds {
 		mins[i] = minHotThresholds[k]
 	}
 	tn, ok := f.peersOfStore[storeID]
 	if !ok || tn.Len() < TopNN {
-		return mins
+		return f.adjustQueryThreshold(mins)
 	}
 	ret := make([]float64, len(statKinds))
 	for i := range ret {
 		ret[i] = math.Max(tn.GetTopNMin(i).(*HotPeerStat).GetLoad(statKinds[i])*HotThresholdRatio, mins[i])
 	}
-	return ret
+	return f.adjustQueryThreshold(ret)
+}
+
+// adjustQueryThreshold makes sure that the query threshold does not exceed
+// the configured one, so that a peer with high query rate is always hot.
+func (f *hotPeerCache) adjustQueryThreshold(thresholds []float64) []float64 {
+	if f.queryThreshold > 0 && len(thresholds) > QueryDim {
+		thresholds[QueryDim] = math.Min(thresholds[QueryDim], f.queryThreshold)
+	}
+	return thresholds
 }
 
 // gets the storeIDs, including old region and new region
@@ -370,16 +392,21 @@ func (f *hotPeerCache) isRegionHotWithPeer(region *core.RegionInfo, peer *metapb
 }
 
 func (f *hotPeerCache) getDefaultTimeMedian() *movingaverage.TimeMedian {
-	return movingaverage.NewTimeMedian(DefaultAotSize, rollingWindowsSize, time.Duration(f.reportIntervalSecs)*time.Second)
+	return movingaverage.NewTimeMedian(DefaultAotSize, DefaultHotRollingWindowSize, time.Duration(f.reportIntervalSecs)*time.Second)
 }
 
 func (f *hotPeerCache) updateHotPeerStat(newItem, oldItem *HotPeerStat, deltaLoads []float64, interval time.Duration) *HotPeerStat {
-	regionStats := f.kind.RegionStats()
+	regionStats := f.kind.builtinRegionStats()
 	if oldItem == nil {
 		return f.updateNewHotPeerStat(newItem, deltaLoads, interval)
 	}
 
 	newItem.rollingLoads = oldItem.rollingLoads
+	// The window is resized by the next sample after it is changed, keeping
+	// the recent samples.
+	for _, l := range newItem.rollingLoads {
+		l.Rolling.Resize(f.rollingWindowSize)
+	}
 
 	if newItem.justTransferLeader {
 		newItem.lastTransferLeaderTime = time.Now()
@@ -429,7 +456,7 @@ func (f *hotPeerCache) updateHotPeerStat(newItem, oldItem *HotPeerStat, deltaLoa
 }
 
 func (f *hotPeerCache) updateNewHotPeerStat(newItem *HotPeerStat, deltaLoads []float64, interval time.Duration) *HotPeerStat {
-	regionStats := f.kind.RegionStats()
+	regionStats := f.kind.builtinRegionStats()
 	if interval == 0 {
 		return nil
 	}
@@ -445,7 +472,7 @@ func (f *hotPeerCache) updateNewHotPeerStat(newItem *HotPeerStat, deltaLoads []f
 	newItem.isNew = true
 	newItem.rollingLoads = make([]*dimStat, len(regionStats))
 	for i, k := range regionStats {
-		ds := newDimStat(k, time.Duration(newItem.hotStatReportInterval())*time.Second)
+		ds := newDimStat(k, time.Duration(newItem.hotStatReportInterval())*time.Second, f.rollingWindowSize)
 		ds.Add(deltaLoads[k], interval)
 		if ds.isFull() {
 			ds.clearLastAverage()
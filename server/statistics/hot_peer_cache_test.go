@@ -15,6 +15,7 @@
 package statistics
 
 import (
+	"context"
 	"math/rand"
 	"testing"
 	"time"
@@ -293,6 +294,206 @@ func (t *testHotPeerCache) TestThresholdWithUpdateHotPeerStat(c *C) {
 	t.testMetrics(c, 1., byteRate, expectThreshold)
 }
 
+func (t *testHotPeerCache) TestQueryThreshold(c *C) {
+	cache := NewHotPeerCache(ReadFlow)
+	storeID := uint64(1)
+	thresholds := cache.calcHotThresholds(storeID)
+	c.Assert(thresholds[QueryDim], Equals, minHotThresholds[RegionReadQuery])
+
+	// The configured query threshold takes effect only if it is smaller.
+	cache.queryThreshold = minHotThresholds[RegionReadQuery] * 2
+	thresholds = cache.calcHotThresholds(storeID)
+	c.Assert(thresholds[QueryDim], Equals, minHotThresholds[RegionReadQuery])
+	cache.queryThreshold = minHotThresholds[RegionReadQuery] / 2
+	thresholds = cache.calcHotThresholds(storeID)
+	c.Assert(thresholds[QueryDim], Equals, minHotThresholds[RegionReadQuery]/2)
+	c.Assert(thresholds[ByteDim], Equals, minHotThresholds[RegionReadBytes])
+
+	// A peer with tiny byte and key rates is hot because of its query rate.
+	newItem := &HotPeerStat{
+		Kind:       cache.kind,
+		StoreID:    storeID,
+		RegionID:   1,
+		thresholds: thresholds,
+		Loads:      make([]float64, DimLen),
+	}
+	deltaLoads := make([]float64, RegionStatCount)
+	deltaLoads[RegionReadQuery] = minHotThresholds[RegionReadQuery] * 0.75 * ReadReportInterval
+	c.Assert(cache.updateHotPeerStat(newItem, nil, deltaLoads, ReadReportInterval*time.Second), NotNil)
+}
+
+type testHotCacheConfig struct {
+	queryThreshold    float64
+	rollingWindowSize int
+	minHotDegree      int
+	hysteresis        time.Duration
+}
+
+func (cfg *testHotCacheConfig) GetHotRegionQueryThreshold() float64 {
+	return cfg.queryThreshold
+}
+
+func (cfg *testHotCacheConfig) GetHotRegionRollingWindowSize() int {
+	return cfg.rollingWindowSize
+}
+
+func (cfg *testHotCacheConfig) GetHotRegionCacheHitsThreshold() int {
+	return cfg.minHotDegree
+}
+
+func (cfg *testHotCacheConfig) GetHotRegionTransitionHysteresis() time.Duration {
+	return cfg.hysteresis
+}
+
+func (t *testHotPeerCache) TestHotCacheConfig(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := &testHotCacheConfig{queryThreshold: 10, rollingWindowSize: 2, minHotDegree: 3, hysteresis: time.Minute}
+	cache := NewHotCache(ctx, nil, opt)
+
+	// The config is applied before running any task, without being queued.
+	c.Assert(cache.RegionStats(ReadFlow, 0), HasLen, 0)
+	c.Assert(cache.readFlow.queryThreshold, Equals, 10.0)
+	c.Assert(cache.readFlow.rollingWindowSize, Equals, 2)
+	c.Assert(cache.readFlow.transitions.minHotDegree, Equals, 3)
+	c.Assert(cache.readFlow.transitions.hysteresis, Equals, time.Minute)
+	opt.queryThreshold = 20
+	c.Assert(cache.RegionStats(WriteFlow, 0), HasLen, 0)
+	c.Assert(cache.writeFlow.queryThreshold, Equals, 20.0)
+
+	// An invalid rolling window size is ignored.
+	opt.rollingWindowSize = 0
+	c.Assert(cache.RegionStats(ReadFlow, 0), HasLen, 0)
+	c.Assert(cache.readFlow.rollingWindowSize, Equals, 2)
+}
+
+func (t *testHotPeerCache) TestRollingWindowSize(c *C) {
+	cache := NewHotPeerCache(ReadFlow)
+	interval := 2 * ReadReportInterval * time.Second
+	update := func(item *HotPeerStat, rate float64) *HotPeerStat {
+		newItem := &HotPeerStat{thresholds: []float64{0.0, 0.0, 0.0}, Kind: ReadFlow}
+		deltaLoads := make([]float64, RegionStatCount)
+		deltaLoads[RegionReadBytes] = rate * interval.Seconds()
+		return cache.updateHotPeerStat(newItem, item, deltaLoads, interval)
+	}
+	var item *HotPeerStat
+	for _, rate := range []float64{10, 10, 10, 100, 100} {
+		item = update(item, rate)
+	}
+	c.Assert(item.rollingLoads[0].Get(), Equals, 10.0)
+
+	// A smaller window keeps the recent samples and reacts faster.
+	cache.rollingWindowSize = 2
+	item = update(item, 10)
+	c.Assert(item.rollingLoads[0].Get(), Equals, 55.0)
+
+	// The new peers use the window too.
+	newItem := update(nil, 10)
+	newItem = update(newItem, 100)
+	newItem = update(newItem, 100)
+	c.Assert(newItem.rollingLoads[0].Get(), Equals, 100.0)
+}
+
+func (t *testHotPeerCache) TestHotRegionTransition(c *C) {
+	type transition struct {
+		regionID uint64
+		hot      bool
+	}
+	var transitions []transition
+	cache := NewHotPeerCache(WriteFlow)
+	cache.transitions.listeners.add(func(regionID uint64, kind FlowKind, hot bool) {
+		c.Assert(kind, Equals, WriteFlow)
+		transitions = append(transitions, transition{regionID, hot})
+	})
+	cache.transitions.minHotDegree, cache.transitions.hysteresis = 3, time.Minute
+	put := func(regionID, storeID uint64, hotDegree int, now time.Time) {
+		cache.putItem(&HotPeerStat{
+			Kind:      WriteFlow,
+			StoreID:   storeID,
+			RegionID:  regionID,
+			HotDegree: hotDegree,
+			Loads:     make([]float64, RegionStatCount),
+		})
+		cache.transitions.track(cache, regionID, now)
+	}
+
+	// A region flapping within the hysteresis is not notified.
+	now := time.Now()
+	put(1, 1, 3, now)
+	put(1, 1, 4, now.Add(30*time.Second))
+	put(1, 1, 2, now.Add(40*time.Second))
+	c.Assert(transitions, HasLen, 0)
+
+	// A region is hot once any of its peers stays hot for the hysteresis.
+	put(1, 2, 3, now.Add(50*time.Second))
+	put(1, 2, 4, now.Add(time.Minute))
+	c.Assert(transitions, HasLen, 0)
+	put(1, 2, 5, now.Add(110*time.Second))
+	c.Assert(transitions, DeepEquals, []transition{{1, true}})
+	put(1, 2, 6, now.Add(3*time.Minute))
+	c.Assert(transitions, HasLen, 1)
+
+	// A region turning cold is confirmed by the updates of the other regions.
+	put(1, 2, 2, now.Add(4*time.Minute))
+	put(2, 1, 1, now.Add(5*time.Minute))
+	c.Assert(transitions, DeepEquals, []transition{{1, true}, {1, false}})
+	c.Assert(cache.transitions.regions, HasLen, 0)
+
+	// The regions are notified at once without the hysteresis.
+	cache.transitions.hysteresis = 0
+	item := &HotPeerStat{Kind: WriteFlow, StoreID: 1, RegionID: 2, HotDegree: 3, Loads: make([]float64, RegionStatCount)}
+	cache.Update(item)
+	c.Assert(transitions[2:], DeepEquals, []transition{{2, true}})
+	item.needDelete = true
+	cache.Update(item)
+	c.Assert(transitions[3:], DeepEquals, []transition{{2, false}})
+}
+
+func (t *testHotPeerCache) TestLoadTrend(c *C) {
+	cache := NewHotPeerCache(ReadFlow)
+	interval := 2 * ReadReportInterval * time.Second
+	update := func(item *HotPeerStat, rate float64) *HotPeerStat {
+		newItem := &HotPeerStat{thresholds: []float64{0.0, 0.0, 0.0}, Kind: ReadFlow, Loads: make([]float64, RegionStatCount)}
+		deltaLoads := make([]float64, RegionStatCount)
+		deltaLoads[RegionReadBytes] = rate * interval.Seconds()
+		deltaLoads[RegionReadKeys] = 10 * interval.Seconds()
+		return cache.updateHotPeerStat(newItem, item, deltaLoads, interval)
+	}
+
+	// Not enough periods to tell.
+	item := update(nil, 10)
+	c.Assert(item.GetLoadSlopes(), DeepEquals, []float64{0, 0, 0})
+	c.Assert(item.GetFlowTrend(DefaultTrendTolerance), Equals, TrendFlat)
+
+	for _, rate := range []float64{20, 30, 40} {
+		item = update(item, rate)
+	}
+	// The rates are 10, 20, 30, 40, whose slope is 10 and median is 25.
+	slopes := item.GetLoadSlopes()
+	c.Assert(slopes, HasLen, 3)
+	c.Assert(slopes[ByteDim], Equals, 0.4)
+	c.Assert(slopes[KeyDim], Equals, 0.0)
+	c.Assert(item.GetLoadTrend(ByteDim, DefaultTrendTolerance), Equals, TrendIncreasing)
+	c.Assert(item.GetLoadTrend(KeyDim, DefaultTrendTolerance), Equals, TrendFlat)
+	c.Assert(item.GetLoadTrend(DimLen, DefaultTrendTolerance), Equals, TrendFlat)
+	c.Assert(item.GetFlowTrend(DefaultTrendTolerance), Equals, TrendIncreasing)
+	c.Assert(item.GetFlowTrend(0.5), Equals, TrendFlat)
+	// The clone keeps the slopes.
+	c.Assert(item.Clone().GetLoadSlopes(), DeepEquals, slopes)
+
+	// A spike doesn't make the flat load increasing.
+	for _, rate := range []float64{40, 40, 40, 40, 200, 40, 40, 40, 40} {
+		item = update(item, rate)
+	}
+	c.Assert(item.GetFlowTrend(DefaultTrendTolerance), Equals, TrendFlat)
+
+	for _, rate := range []float64{30, 20, 10} {
+		item = update(item, rate)
+	}
+	c.Assert(item.GetFlowTrend(DefaultTrendTolerance), Equals, TrendDecreasing)
+	c.Assert(TrendDecreasing.String(), Equals, "decreasing")
+}
+
 func (t *testHotPeerCache) testMetrics(c *C, interval, byteRate, expectThreshold float64) {
 	cache := NewHotPeerCache(ReadFlow)
 	storeID := uint64(1)
@@ -362,3 +563,87 @@ func BenchmarkCheckRegionFlow(b *testing.B) {
 		}
 	}
 }
+
+func (t *testHotPeerCache) TestRankHotPeers(c *C) {
+	newPeer := func(regionID uint64, bytes, keys float64) *HotPeerStat {
+		loads := make([]float64, RegionStatCount)
+		loads[RegionWriteBytes] = bytes
+		loads[RegionWriteKeys] = keys
+		return &HotPeerStat{
+			Kind:       WriteFlow,
+			StoreID:    1,
+			RegionID:   regionID,
+			Loads:      loads,
+			thresholds: []float64{1024, 32, 32},
+		}
+	}
+	stats := map[uint64][]*HotPeerStat{
+		1: {
+			newPeer(1, 4096, 1),  // hot only in bytes
+			newPeer(2, 10, 100),  // hot only in keys
+			newPeer(3, 2048, 64), // hot in both
+		},
+		2: {},
+	}
+
+	keys := RankHotPeers(stats, RegionWriteKeys)
+	c.Assert(keys, HasLen, 2)
+	c.Assert(keys[2], HasLen, 0)
+	c.Assert(keys[1], HasLen, 2)
+	c.Assert(keys[1][0].RegionID, Equals, uint64(2))
+	c.Assert(keys[1][1].RegionID, Equals, uint64(3))
+
+	bytes := RankHotPeers(stats, RegionWriteBytes)
+	c.Assert(bytes[1], HasLen, 2)
+	c.Assert(bytes[1][0].RegionID, Equals, uint64(1))
+	c.Assert(bytes[1][1].RegionID, Equals, uint64(3))
+
+	// a kind of another flow picks nothing.
+	c.Assert(RankHotPeers(stats, RegionReadKeys)[1], HasLen, 0)
+	// the stats are not modified.
+	c.Assert(stats[1], HasLen, 3)
+	c.Assert(stats[1][0].RegionID, Equals, uint64(1))
+}
+
+func (t *testHotPeerCache) TestFilterHotRegionsByRate(c *C) {
+	newPeer := func(kind FlowKind, storeID, regionID uint64, loads map[RegionStatKind]float64) *HotPeerStat {
+		stat := &HotPeerStat{
+			Kind:     kind,
+			StoreID:  storeID,
+			RegionID: regionID,
+			Loads:    make([]float64, RegionStatCount),
+		}
+		for k, load := range loads {
+			stat.Loads[k] = load
+		}
+		return stat
+	}
+	stats := map[uint64][]*HotPeerStat{
+		1: {
+			newPeer(WriteFlow, 1, 1, map[RegionStatKind]float64{RegionWriteBytes: 4096, RegionWriteKeys: 10}),
+			newPeer(WriteFlow, 1, 2, map[RegionStatKind]float64{RegionWriteBytes: 100, RegionWriteKeys: 2000}),
+		},
+		2: {
+			// the rate of a region is the highest one among its peers.
+			newPeer(WriteFlow, 2, 1, map[RegionStatKind]float64{RegionWriteBytes: 1024, RegionWriteKeys: 1200}),
+			newPeer(WriteFlow, 2, 3, map[RegionStatKind]float64{RegionWriteBytes: 10, RegionWriteKeys: 10}),
+		},
+	}
+
+	res := FilterHotRegionsByRate(stats, WriteFlow, 1000)
+	c.Assert(res, HasLen, 2)
+	c.Assert(res[0].RegionID, Equals, uint64(1))
+	c.Assert(res[0].Rates[RegionWriteBytes], Equals, 4096.0)
+	c.Assert(res[0].Rates[RegionWriteKeys], Equals, 1200.0)
+	c.Assert(res[0].Exceeded, DeepEquals, []RegionStatKind{RegionWriteBytes, RegionWriteKeys})
+	c.Assert(res[1].RegionID, Equals, uint64(2))
+	c.Assert(res[1].Exceeded, DeepEquals, []RegionStatKind{RegionWriteKeys})
+
+	c.Assert(FilterHotRegionsByRate(stats, WriteFlow, 5000), HasLen, 0)
+	// a peer only reports the rates of its own flow.
+	stats[3] = []*HotPeerStat{newPeer(ReadFlow, 3, 3, map[RegionStatKind]float64{RegionReadQuery: 500, RegionWriteQuery: 900})}
+	res = FilterHotRegionsByRate(stats, QueryFlow, 400)
+	c.Assert(res, HasLen, 1)
+	c.Assert(res[0].RegionID, Equals, uint64(3))
+	c.Assert(res[0].Exceeded, DeepEquals, []RegionStatKind{RegionReadQuery})
+}
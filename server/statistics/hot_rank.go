@@ -0,0 +1,93 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import "sort"
+
+// RankHotPeers picks the peers which are hot in the dimension of the given kind,
+// and sorts the peers of each store by the load of the kind in descending order.
+// Unlike the aggregated hot stats, a peer which is only hot in another dimension
+// is left out, so that a scheduler can focus on one kind of hotspot, e.g. the
+// write keys hotspots caused by many small writes. The given stats are not modified.
+func RankHotPeers(stats map[uint64][]*HotPeerStat, k RegionStatKind) map[uint64][]*HotPeerStat {
+	res := make(map[uint64][]*HotPeerStat, len(stats))
+	for storeID, peers := range stats {
+		ranked := make([]*HotPeerStat, 0, len(peers))
+		for _, peer := range peers {
+			if peer.isHotIn(k) {
+				ranked = append(ranked, peer)
+			}
+		}
+		sort.SliceStable(ranked, func(i, j int) bool {
+			return ranked[i].GetLoad(k) > ranked[j].GetLoad(k)
+		})
+		res[storeID] = ranked
+	}
+	return res
+}
+
+// HotRegionRate is the rates of a hot region in the region statistics kinds of
+// a flow.
+type HotRegionRate struct {
+	RegionID uint64
+	// Rates is the rate of the region in each region statistics kind of the flow.
+	Rates map[RegionStatKind]float64
+	// Exceeded are the kinds whose rates reach the minimum rate, in the order
+	// of the region statistics kinds of the flow.
+	Exceeded []RegionStatKind
+}
+
+// FilterHotRegionsByRate picks the regions whose rate of any region statistics
+// kind of the flow reaches minRate, sorted by the region ID. The rate of a
+// region is the highest one among its hot peers. A peer only reports the rates
+// of the kinds belonging to its own flow, which matters for the QueryFlow.
+func FilterHotRegionsByRate(stats map[uint64][]*HotPeerStat, kind FlowKind, minRate float64) []*HotRegionRate {
+	statKinds := kind.RegionStats()
+	rates := make(map[uint64]map[RegionStatKind]float64)
+	for _, peers := range stats {
+		for _, peer := range peers {
+			r, ok := rates[peer.RegionID]
+			if !ok {
+				r = make(map[RegionStatKind]float64, len(statKinds))
+				rates[peer.RegionID] = r
+			}
+			for _, k := range statKinds {
+				if FlowKindOf(k) != peer.Kind {
+					continue
+				}
+				if load := peer.GetLoad(k); load > r[k] {
+					r[k] = load
+				}
+			}
+		}
+	}
+	res := make([]*HotRegionRate, 0, len(rates))
+	for regionID, r := range rates {
+		var exceeded []RegionStatKind
+		for _, k := range statKinds {
+			if r[k] >= minRate {
+				exceeded = append(exceeded, k)
+			}
+		}
+		if len(exceeded) == 0 {
+			continue
+		}
+		res = append(res, &HotRegionRate{RegionID: regionID, Rates: r, Exceeded: exceeded})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].RegionID < res[j].RegionID
+	})
+	return res
+}
@@ -0,0 +1,130 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultHotRegionTransitionMinHotDegree is the hot degree a peer needs to
+// make its region hot before it is configured.
+const DefaultHotRegionTransitionMinHotDegree = 3
+
+// HotRegionListener is called when a region turns hot, or turns cold if hot is
+// false, in the flow of the kind. A region is hot if any of its peers is hot.
+// It is called in the goroutine updating the hot cache, so it must not block.
+type HotRegionListener func(regionID uint64, kind FlowKind, hot bool)
+
+// hotRegionListeners are the listeners shared by the flows of a hot cache.
+type hotRegionListeners struct {
+	sync.RWMutex
+	listeners []HotRegionListener
+}
+
+func (l *hotRegionListeners) add(listener HotRegionListener) {
+	l.Lock()
+	defer l.Unlock()
+	l.listeners = append(l.listeners, listener)
+}
+
+func (l *hotRegionListeners) notify(regionID uint64, kind FlowKind, hot bool) {
+	l.RLock()
+	defer l.RUnlock()
+	for _, listener := range l.listeners {
+		listener(regionID, kind, hot)
+	}
+}
+
+// hotTransitionState is the hot state of a region last notified, along with
+// since when the region has been in the opposite state.
+type hotTransitionState struct {
+	hot bool
+	// changingSince is zero if the region is in the state notified.
+	changingSince time.Time
+}
+
+// hotTransitions tracks whether the regions of a flow are hot, and notifies the
+// listeners once a region stays hot or cold for the hysteresis, so that a
+// region flapping between hot and cold is not notified again and again.
+type hotTransitions struct {
+	minHotDegree int
+	hysteresis   time.Duration
+	// regions are the regions which are hot or are changing.
+	regions   map[uint64]*hotTransitionState
+	listeners *hotRegionListeners
+}
+
+func newHotTransitions(listeners *hotRegionListeners) *hotTransitions {
+	return &hotTransitions{
+		minHotDegree: DefaultHotRegionTransitionMinHotDegree,
+		regions:      make(map[uint64]*hotTransitionState),
+		listeners:    listeners,
+	}
+}
+
+// track updates the state of the region, and then confirms the changing
+// regions whose hysteresis has passed, as the regions turning cold may not be
+// updated anymore.
+func (t *hotTransitions) track(f *hotPeerCache, regionID uint64, now time.Time) {
+	t.update(f, regionID, now)
+	for id, state := range t.regions {
+		if id != regionID && !state.changingSince.IsZero() {
+			t.update(f, id, now)
+		}
+	}
+}
+
+func (t *hotTransitions) update(f *hotPeerCache, regionID uint64, now time.Time) {
+	hot := f.isRegionHot(regionID, t.minHotDegree)
+	state, ok := t.regions[regionID]
+	if !ok {
+		if !hot {
+			return
+		}
+		state = &hotTransitionState{}
+		t.regions[regionID] = state
+	}
+	if state.hot == hot {
+		state.changingSince = time.Time{}
+	} else {
+		if state.changingSince.IsZero() {
+			state.changingSince = now
+		}
+		if now.Sub(state.changingSince) >= t.hysteresis {
+			state.hot, state.changingSince = hot, time.Time{}
+			t.listeners.notify(regionID, f.kind, hot)
+		}
+	}
+	if !state.hot && state.changingSince.IsZero() {
+		delete(t.regions, regionID)
+	}
+}
+
+// isRegionHot returns true if any peer of the region in the cache is hot.
+func (f *hotPeerCache) isRegionHot(regionID uint64, minHotDegree int) bool {
+	for storeID := range f.storesOfRegion[regionID] {
+		peers, ok := f.peersOfStore[storeID]
+		if !ok {
+			continue
+		}
+		if stat := peers.Get(regionID); stat != nil {
+			if peer := stat.(*HotPeerStat); peer.HotDegree >= minHotDegree && !peer.inCold {
+				return true
+			}
+		}
+	}
+	return false
+}
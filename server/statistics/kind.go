@@ -44,6 +44,9 @@ func (k RegionStatKind) String() string {
 	case RegionWriteQuery:
 		return "write_query"
 	}
+	if name, ok := registeredRegionStatName(k); ok {
+		return name
+	}
 	return "unknown RegionStatKind"
 }
 
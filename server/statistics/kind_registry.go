@@ -0,0 +1,110 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"sync"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+// RegionStatComputer computes a derived statistics value from the loads of a
+// region, which are indexed by the built-in RegionStatKind.
+type RegionStatComputer func(loads []float64) float64
+
+type registeredRegionStat struct {
+	name    string
+	flow    FlowKind
+	compute RegionStatComputer
+}
+
+// firstRegisteredRegionStat is the first registered kind. It is kept apart from
+// the built-in kinds, so that adding a built-in kind doesn't renumber the
+// registered ones.
+const firstRegisteredRegionStat RegionStatKind = 1 << 10
+
+var (
+	registryMu sync.RWMutex
+	// registeredStats holds the registered kinds, the i-th of which is firstRegisteredRegionStat+i.
+	registeredStats []registeredRegionStat
+)
+
+// RegisterRegionStatKind registers a derived region statistics kind of the flow
+// and returns it. The kind is included in the RegionStats of the flow, but it is
+// not tracked by the hot cache. It should be called in init() func of package.
+func RegisterRegionStatKind(name string, flow FlowKind, compute RegionStatComputer) RegionStatKind {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if compute == nil || flow.builtinRegionStats() == nil {
+		log.Fatal("invalid region stat kind", zap.String("name", name), zap.Stringer("flow", flow))
+	}
+	for k := RegionStatKind(0); k < RegionStatCount; k++ {
+		if k.String() == name {
+			log.Fatal("duplicated region stat kind", zap.String("name", name))
+		}
+	}
+	for _, stat := range registeredStats {
+		if stat.name == name {
+			log.Fatal("duplicated region stat kind", zap.String("name", name))
+		}
+	}
+	registeredStats = append(registeredStats, registeredRegionStat{name: name, flow: flow, compute: compute})
+	return firstRegisteredRegionStat + RegionStatKind(len(registeredStats)-1)
+}
+
+// ComputeRegionStat returns the value of the statistics kind given the loads of
+// a region, which are indexed by the built-in RegionStatKind.
+func ComputeRegionStat(k RegionStatKind, loads []float64) float64 {
+	if k >= 0 && k < RegionStatCount {
+		if int(k) < len(loads) {
+			return loads[k]
+		}
+		return 0
+	}
+	stat, ok := getRegisteredRegionStat(k)
+	if !ok {
+		return 0
+	}
+	return stat.compute(loads)
+}
+
+func getRegisteredRegionStat(k RegionStatKind) (registeredRegionStat, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	i := int(k - firstRegisteredRegionStat)
+	if i < 0 || i >= len(registeredStats) {
+		return registeredRegionStat{}, false
+	}
+	return registeredStats[i], true
+}
+
+func registeredRegionStatName(k RegionStatKind) (string, bool) {
+	stat, ok := getRegisteredRegionStat(k)
+	return stat.name, ok
+}
+
+// registeredRegionStats returns the registered kinds of the flow.
+func registeredRegionStats(flow FlowKind) []RegionStatKind {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	var kinds []RegionStatKind
+	for i, stat := range registeredStats {
+		if stat.flow == flow {
+			kinds = append(kinds, firstRegisteredRegionStat+RegionStatKind(i))
+		}
+	}
+	return kinds
+}
@@ -66,3 +66,72 @@ func (s *testRegionInfoSuite) TestGetLoads(c *C) {
 	c.Assert(float64(regionA.GetKeysWritten()), Equals, loads[RegionWriteKeys])
 	c.Assert(float64(regionA.GetWriteQueryNum()), Equals, loads[RegionWriteQuery])
 }
+
+func (s *testRegionInfoSuite) TestQueryFlow(c *C) {
+	c.Assert(QueryFlow.String(), Equals, "query")
+	c.Assert(QueryFlow.RegionStats(), DeepEquals, []RegionStatKind{RegionReadQuery, RegionWriteQuery})
+
+	stat := &HotPeerStat{
+		Kind:       WriteFlow,
+		Loads:      make([]float64, RegionStatCount),
+		thresholds: []float64{1024, 32, 32},
+	}
+	c.Assert(stat.isQueryHot(), IsFalse)
+	stat.Loads[RegionWriteQuery] = 64
+	c.Assert(stat.isQueryHot(), IsTrue)
+	stat.Kind = ReadFlow
+	c.Assert(stat.isQueryHot(), IsFalse)
+}
+
+func (s *testRegionInfoSuite) TestFlowKindOf(c *C) {
+	for _, kind := range []FlowKind{WriteFlow, ReadFlow} {
+		for _, k := range kind.RegionStats() {
+			c.Assert(FlowKindOf(k), Equals, kind)
+		}
+	}
+	c.Assert(FlowKindOf(RegionStatCount), Equals, UnknownFlow)
+	c.Assert(UnknownFlow.String(), Equals, "unimplemented")
+	c.Assert(UnknownFlow.RegionStats(), IsNil)
+}
+
+// unregisterRegionStatKind drops the registered kind and the ones registered
+// after it, so that the kinds registered by a test don't leak into the others.
+func unregisterRegionStatKind(k RegionStatKind) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if i := int(k - firstRegisteredRegionStat); i >= 0 && i < len(registeredStats) {
+		registeredStats = registeredStats[:i]
+	}
+}
+
+func (s *testRegionInfoSuite) TestRegisterRegionStatKind(c *C) {
+	k := RegisterRegionStatKind("write_bytes_per_key", WriteFlow, func(loads []float64) float64 {
+		if loads[RegionWriteKeys] == 0 {
+			return 0
+		}
+		return loads[RegionWriteBytes] / loads[RegionWriteKeys]
+	})
+	defer unregisterRegionStatKind(k)
+	c.Assert(k >= RegionStatCount, IsTrue)
+	c.Assert(k.String(), Equals, "write_bytes_per_key")
+	c.Assert(WriteFlow.RegionStats(), DeepEquals, []RegionStatKind{RegionWriteBytes, RegionWriteKeys, RegionWriteQuery, k})
+	c.Assert(ReadFlow.RegionStats(), DeepEquals, []RegionStatKind{RegionReadBytes, RegionReadKeys, RegionReadQuery})
+	c.Assert(FlowKindOf(k), Equals, WriteFlow)
+	c.Assert((k + 1).String(), Equals, "unknown RegionStatKind")
+
+	loads := make([]float64, RegionStatCount)
+	loads[RegionWriteBytes], loads[RegionWriteKeys] = 1024, 4
+	c.Assert(ComputeRegionStat(k, loads), Equals, 256.0)
+	c.Assert(ComputeRegionStat(RegionWriteKeys, loads), Equals, 4.0)
+	c.Assert(ComputeRegionStat(k+1, loads), Equals, 0.0)
+
+	// the hot peer computes the registered kind from its denoised loads.
+	stat := &HotPeerStat{Kind: WriteFlow, Loads: loads}
+	c.Assert(stat.GetLoad(k), Equals, 256.0)
+	c.Assert(stat.GetLoads(), HasLen, len(WriteFlow.builtinRegionStats()))
+
+	unregisterRegionStatKind(k)
+	c.Assert(k.String(), Equals, "unknown RegionStatKind")
+	c.Assert(WriteFlow.RegionStats(), DeepEquals, []RegionStatKind{RegionWriteBytes, RegionWriteKeys, RegionWriteQuery})
+	c.Assert(ComputeRegionStat(k, loads), Equals, 0.0)
+}
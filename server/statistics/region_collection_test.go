@@ -284,3 +284,39 @@ func (t *testRegionStatisticsSuite) TestRegionLabelIsolationLevel(c *C) {
 		c.Assert(labelLevelStats.labelCounter[i], Equals, res)
 	}
 }
+
+func (t *testRegionStatisticsSuite) TestReplicaSpread(c *C) {
+	zones := map[uint64]string{1: "z1", 2: "z1", 3: "z2", 4: "z3"}
+	stores := make([]*core.StoreInfo, 0, len(zones))
+	for id := uint64(1); id <= 4; id++ {
+		stores = append(stores, core.NewStoreInfo(&metapb.Store{Id: id}, core.SetStoreLabels([]*metapb.StoreLabel{{Key: "zone", Value: zones[id]}})))
+	}
+	newRegion := func(id uint64, storeIDs ...uint64) *core.RegionInfo {
+		peers := make([]*metapb.Peer, 0, len(storeIDs))
+		for _, storeID := range storeIDs {
+			peers = append(peers, &metapb.Peer{Id: id*10 + storeID, StoreId: storeID})
+		}
+		return core.NewRegionInfo(&metapb.Region{Id: id, Peers: peers}, peers[0])
+	}
+	spreadStats := NewReplicaSpreadStatistics()
+	// The voters of region 1 are on different zones, but two voters of
+	// region 2 are on z1 while z3 is available.
+	spreadStats.Observe(newRegion(1, 1, 3, 4), stores, []string{"zone"})
+	spreadStats.Observe(newRegion(2, 1, 2, 3), stores, []string{"zone"})
+
+	spreads := spreadStats.GetLabelSpreads(stores, []string{"zone"})
+	c.Assert(spreads, HasLen, 1)
+	c.Assert(spreads[0].Key, Equals, "zone")
+	c.Assert(spreads[0].Voters, DeepEquals, map[string]int{"z1": 3, "z2": 2, "z3": 1})
+	c.Assert(spreads[0].MaxVoters, DeepEquals, map[int]int{1: 1, 2: 1})
+	c.Assert(spreads[0].PoorlySpreadRegions, DeepEquals, []uint64{2})
+
+	// Two voters on z1 is the best spread when there are only two zones.
+	spreads = spreadStats.GetLabelSpreads(stores[:3], []string{"zone"})
+	c.Assert(spreads[0].PoorlySpreadRegions, HasLen, 0)
+
+	spreadStats.ClearDefunctRegion(2)
+	spreads = spreadStats.GetLabelSpreads(stores, []string{"zone"})
+	c.Assert(spreads[0].Voters, DeepEquals, map[string]int{"z1": 1, "z2": 1, "z3": 1})
+	c.Assert(spreads[0].PoorlySpreadRegions, HasLen, 0)
+}
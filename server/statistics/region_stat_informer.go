@@ -19,9 +19,15 @@ import "github.com/tikv/pd/server/core"
 // RegionStatInformer provides access to a shared informer of statistics.
 type RegionStatInformer interface {
 	IsRegionHot(region *core.RegionInfo) bool
+	// HasHotPeer returns true if any peer of the region is hot, no matter how
+	// many times it has been hot.
+	HasHotPeer(region *core.RegionInfo) bool
 	// RegionWriteStats return the storeID -> write stat of peers on this store.
 	// The result only includes peers that are hot enough.
 	RegionWriteStats() map[uint64][]*HotPeerStat
+	// AllRegionWriteStats return the storeID -> write stat of peers on this store.
+	// Unlike RegionWriteStats, the result also includes peers that are not hot enough yet.
+	AllRegionWriteStats() map[uint64][]*HotPeerStat
 	// RegionReadStats return the storeID -> read stat of peers on this store.
 	// The result only includes peers that are hot enough.
 	RegionReadStats() map[uint64][]*HotPeerStat
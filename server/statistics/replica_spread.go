@@ -0,0 +1,129 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"sort"
+
+	"github.com/tikv/pd/server/core"
+)
+
+// ReplicaSpreadStatistics records how the voters of each region are spread over
+// the values of the location labels. Unlike the placement rules, which only
+// require the replicas to be isolated at some level, it tells whether the
+// voters are spread as evenly as the label values in the cluster allow.
+type ReplicaSpreadStatistics struct {
+	// regionSpreads maps the region ID to the voter count of each label value,
+	// grouped by the label key.
+	regionSpreads map[uint64]map[string]map[string]int
+}
+
+// NewReplicaSpreadStatistics creates a new ReplicaSpreadStatistics.
+func NewReplicaSpreadStatistics() *ReplicaSpreadStatistics {
+	return &ReplicaSpreadStatistics{
+		regionSpreads: make(map[uint64]map[string]map[string]int),
+	}
+}
+
+// Observe records how the voters of the region are spread over the values of
+// the labels. The voters on the stores without a label are counted under the
+// empty value.
+func (s *ReplicaSpreadStatistics) Observe(region *core.RegionInfo, stores []*core.StoreInfo, labels []string) {
+	storeMap := make(map[uint64]*core.StoreInfo, len(stores))
+	for _, store := range stores {
+		storeMap[store.GetID()] = store
+	}
+	spreads := make(map[string]map[string]int, len(labels))
+	for _, label := range labels {
+		values := make(map[string]int)
+		for _, voter := range region.GetVoters() {
+			if store, ok := storeMap[voter.GetStoreId()]; ok {
+				values[store.GetLabelValue(label)]++
+			}
+		}
+		spreads[label] = values
+	}
+	s.regionSpreads[region.GetID()] = spreads
+}
+
+// ClearDefunctRegion is used to handle the overlap region.
+func (s *ReplicaSpreadStatistics) ClearDefunctRegion(regionID uint64) {
+	delete(s.regionSpreads, regionID)
+}
+
+// LabelSpread is the distribution of the voters over the values of a label key.
+type LabelSpread struct {
+	Key string `json:"key"`
+	// Voters is the total number of voters on each label value.
+	Voters map[string]int `json:"voters"`
+	// MaxVoters maps the max number of voters of a region on a single label
+	// value to the number of such regions.
+	MaxVoters map[int]int `json:"max-voters"`
+	// PoorlySpreadRegions are the IDs of the regions which put more voters on a
+	// label value than needed, given the label values of the stores.
+	PoorlySpreadRegions []uint64 `json:"poorly-spread-regions"`
+}
+
+// GetLabelSpreads returns the spread of the voters over each label key. The
+// label values available for the regions are taken from the stores, which are
+// usually all the stores which may hold replicas.
+func (s *ReplicaSpreadStatistics) GetLabelSpreads(stores []*core.StoreInfo, labels []string) []*LabelSpread {
+	ret := make([]*LabelSpread, 0, len(labels))
+	for _, label := range labels {
+		available := make(map[string]struct{})
+		for _, store := range stores {
+			if value := store.GetLabelValue(label); value != "" {
+				available[value] = struct{}{}
+			}
+		}
+		spread := &LabelSpread{
+			Key:       label,
+			Voters:    make(map[string]int),
+			MaxVoters: make(map[int]int),
+		}
+		for regionID, spreads := range s.regionSpreads {
+			values, ok := spreads[label]
+			if !ok {
+				continue
+			}
+			var voters, maxVoters int
+			for value, count := range values {
+				spread.Voters[value] += count
+				voters += count
+				if value != "" && count > maxVoters {
+					maxVoters = count
+				}
+			}
+			spread.MaxVoters[maxVoters]++
+			if maxVoters > idealMaxVoters(voters, len(available)) {
+				spread.PoorlySpreadRegions = append(spread.PoorlySpreadRegions, regionID)
+			}
+		}
+		sort.Slice(spread.PoorlySpreadRegions, func(i, j int) bool {
+			return spread.PoorlySpreadRegions[i] < spread.PoorlySpreadRegions[j]
+		})
+		ret = append(ret, spread)
+	}
+	return ret
+}
+
+// idealMaxVoters returns the number of voters on the most crowded label value
+// when the voters are spread over the values as evenly as possible.
+func idealMaxVoters(voters, values int) int {
+	if values == 0 || voters == 0 {
+		return voters
+	}
+	return (voters + values - 1) / values
+}
@@ -0,0 +1,96 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+)
+
+// SlowStoreDetector detects the slow stores by the slow score reported in the
+// store heartbeats, which reflects the latency of the store. A slow store is
+// only considered recovered after its slow score stays normal for a while, so
+// that a store whose latency fluctuates doesn't get its leaders back too early.
+type SlowStoreDetector struct {
+	mu sync.Mutex
+	// recoveringSince records when each store started to look normal again.
+	recoveringSince map[uint64]time.Time
+}
+
+// NewSlowStoreDetector creates a SlowStoreDetector.
+func NewSlowStoreDetector() *SlowStoreDetector {
+	return &SlowStoreDetector{
+		recoveringSince: make(map[uint64]time.Time),
+	}
+}
+
+// IsSlow returns true if the slow score of the store reaches the threshold.
+func (d *SlowStoreDetector) IsSlow(store *core.StoreInfo, evictThreshold uint64) bool {
+	score := store.GetSlowScore()
+	if score < evictThreshold {
+		return false
+	}
+	log.Info("store is detected as slow",
+		zap.Uint64("store-id", store.GetID()),
+		zap.Uint64("slow-score", score),
+		zap.Uint64("threshold", evictThreshold))
+	return true
+}
+
+// IsRecovered returns true if the slow score of the store has stayed no more than
+// the threshold for at least the recovery duration.
+func (d *SlowStoreDetector) IsRecovered(store *core.StoreInfo, recoverThreshold uint64, recoveryDuration time.Duration) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	storeID, score := store.GetID(), store.GetSlowScore()
+	since, recovering := d.recoveringSince[storeID]
+	if score > recoverThreshold {
+		if recovering {
+			log.Info("slow store stops recovering",
+				zap.Uint64("store-id", storeID),
+				zap.Uint64("slow-score", score),
+				zap.Duration("recovered", time.Since(since)))
+			delete(d.recoveringSince, storeID)
+		}
+		return false
+	}
+	if !recovering {
+		since = time.Now()
+		d.recoveringSince[storeID] = since
+		log.Info("slow store starts recovering",
+			zap.Uint64("store-id", storeID),
+			zap.Uint64("slow-score", score),
+			zap.Duration("recovery-duration", recoveryDuration))
+	}
+	if time.Since(since) < recoveryDuration {
+		return false
+	}
+	delete(d.recoveringSince, storeID)
+	log.Info("slow store has been recovered",
+		zap.Uint64("store-id", storeID),
+		zap.Uint64("slow-score", score))
+	return true
+}
+
+// Forget drops the recovery state of the store.
+func (d *SlowStoreDetector) Forget(storeID uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.recoveringSince, storeID)
+}
@@ -77,3 +77,91 @@ func (t *testStoreStatisticsSuite) TestStoreStatistics(c *C) {
 	c.Assert(stats.LabelCounter["host:h2"], Equals, 4)
 	c.Assert(stats.LabelCounter["zone:unknown"], Equals, 2)
 }
+
+func (t *testStoreStatisticsSuite) TestStoreFlowStats(c *C) {
+	newRegion := func(id, leaderStore uint64, opts ...core.RegionCreateOption) *core.RegionInfo {
+		leader := &metapb.Peer{Id: id*10 + leaderStore, StoreId: leaderStore}
+		opts = append(opts, core.SetReportInterval(10))
+		return core.NewRegionInfo(&metapb.Region{Id: id, Peers: []*metapb.Peer{leader}}, leader, opts...)
+	}
+	stats := NewStoreFlowStats()
+	stats.Observe(newRegion(1, 1, core.SetWrittenBytes(1000), core.SetWrittenKeys(100), core.SetReadBytes(500)))
+	stats.Observe(newRegion(2, 1, core.SetWrittenBytes(2000)))
+	stats.Observe(newRegion(3, 2, core.SetReadBytes(3000)))
+
+	c.Assert(stats.GetStoreFlowByKind(1, WriteFlow), DeepEquals, map[RegionStatKind]float64{
+		RegionWriteBytes: 300, RegionWriteKeys: 10, RegionWriteQuery: 0,
+	})
+	c.Assert(stats.GetStoreFlowByKind(1, ReadFlow)[RegionReadBytes], Equals, float64(50))
+	c.Assert(stats.GetStoreFlowByKind(2, ReadFlow)[RegionReadBytes], Equals, float64(300))
+	c.Assert(stats.GetStoreFlowByKind(3, ReadFlow)[RegionReadBytes], Equals, float64(0))
+
+	// The flow moves along with the leader.
+	stats.observe(newRegion(2, 2, core.SetWrittenBytes(1000)), time.Now().Add(10*time.Second))
+	c.Assert(stats.GetStoreFlowByKind(1, WriteFlow)[RegionWriteBytes], Equals, float64(100))
+	c.Assert(stats.GetStoreFlowByKind(2, WriteFlow)[RegionWriteBytes], Equals, float64(100))
+
+	stats.ClearDefunctRegion(1)
+	c.Assert(stats.GetStoreFlowByKind(1, WriteFlow)[RegionWriteBytes], Equals, float64(0))
+	c.Assert(stats.GetStoreFlowByKind(1, ReadFlow)[RegionReadBytes], Equals, float64(0))
+}
+
+func (t *testStoreStatisticsSuite) TestGetHotStores(c *C) {
+	newRegion := func(id, leaderStore uint64, opts ...core.RegionCreateOption) *core.RegionInfo {
+		leader := &metapb.Peer{Id: id*10 + leaderStore, StoreId: leaderStore}
+		opts = append(opts, core.SetReportInterval(10))
+		return core.NewRegionInfo(&metapb.Region{Id: id, Peers: []*metapb.Peer{leader}}, leader, opts...)
+	}
+	stats := NewStoreFlowStats()
+	stats.Observe(newRegion(1, 1, core.SetWrittenBytes(3000), core.SetReadBytes(500)))
+	stats.Observe(newRegion(2, 2, core.SetWrittenKeys(1000), core.SetReadBytes(3000)))
+	stats.Observe(newRegion(3, 3, core.SetWrittenBytes(500), core.SetReadBytes(500)))
+	// No threshold is set.
+	c.Assert(stats.GetHotStores(WriteFlow), HasLen, 0)
+
+	stats.SetHotStoreThresholds(WriteFlow, []float64{200, 100, 0})
+	stats.SetHotStoreThresholds(ReadFlow, []float64{200, 0, 0})
+	c.Assert(stats.GetHotStores(WriteFlow), DeepEquals, []uint64{1, 2})
+	// Store 1 is write-hot but read-cold.
+	c.Assert(stats.GetHotStores(ReadFlow), DeepEquals, []uint64{2})
+	c.Assert(stats.GetHotStores(QueryFlow), HasLen, 0)
+
+	stats.SetHotStoreThresholds(WriteFlow, []float64{0, 100})
+	c.Assert(stats.GetHotStores(WriteFlow), DeepEquals, []uint64{2})
+}
+
+func (t *testStoreStatisticsSuite) TestStoreFlowInterval(c *C) {
+	newRegion := func(leaderStore, interval uint64) *core.RegionInfo {
+		leader := &metapb.Peer{Id: 10 + leaderStore, StoreId: leaderStore}
+		return core.NewRegionInfo(&metapb.Region{Id: 1, Peers: []*metapb.Peer{leader}}, leader,
+			core.SetReportInterval(interval), core.SetWrittenBytes(6000))
+	}
+	stats := NewStoreFlowStats()
+	now := time.Now()
+	// The flow of an unknown interval is ignored.
+	c.Assert(stats.observe(newRegion(1, 0), now), Equals, uint64(0))
+	c.Assert(stats.GetStoreFlowByKind(1, WriteFlow)[RegionWriteBytes], Equals, float64(0))
+	c.Assert(stats.observe(newRegion(1, 60), now), Equals, uint64(60))
+	c.Assert(stats.GetStoreFlowByKind(1, WriteFlow)[RegionWriteBytes], Equals, float64(100))
+
+	// A missed heartbeat is covered by the reported interval.
+	now = now.Add(120 * time.Second)
+	c.Assert(stats.observe(newRegion(1, 120), now), Equals, uint64(120))
+	c.Assert(stats.GetStoreFlowByKind(1, WriteFlow)[RegionWriteBytes], Equals, float64(50))
+
+	// The time since the last sample is used if the reported interval is implausible.
+	now = now.Add(60 * time.Second)
+	c.Assert(stats.observe(newRegion(1, 0), now), Equals, uint64(60))
+	now = now.Add(30 * time.Second)
+	c.Assert(stats.observe(newRegion(1, uint64(now.Unix())), now), Equals, uint64(30))
+	c.Assert(stats.GetStoreFlowByKind(1, WriteFlow)[RegionWriteBytes], Equals, float64(200))
+
+	// The first report of a new leader starts long before it becomes the leader.
+	now = now.Add(60 * time.Second)
+	c.Assert(stats.observe(newRegion(2, 3600), now), Equals, uint64(60))
+	c.Assert(stats.GetStoreFlowByKind(1, WriteFlow)[RegionWriteBytes], Equals, float64(0))
+	c.Assert(stats.GetStoreFlowByKind(2, WriteFlow)[RegionWriteBytes], Equals, float64(100))
+	// The rate doesn't spike even if the leader moves right after the last sample.
+	c.Assert(stats.observe(newRegion(3, 1), now), Equals, uint64(minFlowInterval))
+	c.Assert(stats.GetStoreFlowByKind(3, WriteFlow)[RegionWriteBytes], Equals, float64(2000))
+}
@@ -0,0 +1,207 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tikv/pd/server/core"
+)
+
+const (
+	// minFlowInterval is the shortest interval in seconds which a rate is
+	// computed over if the interval is not reported, so that a heartbeat soon
+	// after the previous one doesn't make the rate spike.
+	minFlowInterval = HotRegionReportMinInterval
+	// maxFlowInterval is the longest plausible report interval in seconds. A
+	// longer one is likely to start from a missing or stale timestamp.
+	maxFlowInterval = 5 * 60
+)
+
+// regionFlow is the latest flow of a region, which is counted by the store of
+// its leader.
+type regionFlow struct {
+	storeID uint64
+	rates   []float64
+	// sampledAt is when the flow is observed.
+	sampledAt time.Time
+}
+
+// flowInterval returns the interval in seconds which the flow reported by the
+// store is accumulated over, or 0 if it is unknown. The reported interval is
+// used unless it is implausible, or the leader moves to the store since the
+// last sample, as the first report of a new leader may start long before it
+// becomes the leader. The time since the last sample is used instead then.
+func flowInterval(reported uint64, last *regionFlow, storeID uint64, now time.Time) uint64 {
+	plausible := reported > 0 && reported <= maxFlowInterval
+	if last == nil {
+		if !plausible {
+			return 0
+		}
+		return reported
+	}
+	if last.storeID == storeID && plausible {
+		return reported
+	}
+	interval := uint64(now.Sub(last.sampledAt) / time.Second)
+	if plausible && reported < interval {
+		interval = reported
+	}
+	if interval < minFlowInterval {
+		interval = minFlowInterval
+	}
+	return interval
+}
+
+// StoreFlowStats sums the flow of the regions reported by region heartbeats
+// for the stores of their leaders, so that the read and write flows of the
+// stores can be compared.
+type StoreFlowStats struct {
+	sync.RWMutex
+	regionFlows map[uint64]regionFlow
+	// storeFlows is the total rates of the leaders on each store, indexed by
+	// the built-in RegionStatKind.
+	storeFlows map[uint64][]float64
+	// hotThresholds is the rate of each built-in RegionStatKind which makes a
+	// store hot in the flow of the kind. 0 means the kind is ignored.
+	hotThresholds [RegionStatCount]float64
+}
+
+// NewStoreFlowStats creates a new StoreFlowStats.
+func NewStoreFlowStats() *StoreFlowStats {
+	return &StoreFlowStats{
+		regionFlows: make(map[uint64]regionFlow),
+		storeFlows:  make(map[uint64][]float64),
+	}
+}
+
+// Observe replaces the flow of the region with the one in the heartbeat, and
+// returns the interval in seconds which the flow is accumulated over, so that
+// the other statistics compute the rates in the same way. The heartbeat without
+// a leader is ignored, and its report interval is returned as is. The heartbeat
+// of an unknown interval is ignored too.
+func (s *StoreFlowStats) Observe(region *core.RegionInfo) uint64 {
+	return s.observe(region, time.Now())
+}
+
+func (s *StoreFlowStats) observe(region *core.RegionInfo, now time.Time) uint64 {
+	reportInterval := region.GetInterval()
+	reported := reportInterval.GetEndTimestamp() - reportInterval.GetStartTimestamp()
+	if region.GetLeader() == nil {
+		return reported
+	}
+	storeID := region.GetLeader().GetStoreId()
+	s.Lock()
+	defer s.Unlock()
+	var last *regionFlow
+	if flow, ok := s.regionFlows[region.GetID()]; ok {
+		last = &flow
+	}
+	interval := flowInterval(reported, last, storeID, now)
+	if interval == 0 {
+		return 0
+	}
+	rates := region.GetLoads()
+	for i := range rates {
+		rates[i] /= float64(interval)
+	}
+	s.removeLocked(region.GetID())
+	flow := regionFlow{storeID: storeID, rates: rates, sampledAt: now}
+	s.regionFlows[region.GetID()] = flow
+	total, ok := s.storeFlows[flow.storeID]
+	if !ok {
+		total = make([]float64, RegionStatCount)
+		s.storeFlows[flow.storeID] = total
+	}
+	for i, rate := range rates {
+		total[i] += rate
+	}
+	return interval
+}
+
+// ClearDefunctRegion is used to handle the overlap region.
+func (s *StoreFlowStats) ClearDefunctRegion(regionID uint64) {
+	s.Lock()
+	defer s.Unlock()
+	s.removeLocked(regionID)
+}
+
+func (s *StoreFlowStats) removeLocked(regionID uint64) {
+	flow, ok := s.regionFlows[regionID]
+	if !ok {
+		return
+	}
+	delete(s.regionFlows, regionID)
+	total := s.storeFlows[flow.storeID]
+	for i, rate := range flow.rates {
+		total[i] -= rate
+	}
+}
+
+// GetStoreFlowByKind returns the total rates of the leaders on the store for
+// each built-in region statistics kind of the flow.
+func (s *StoreFlowStats) GetStoreFlowByKind(storeID uint64, kind FlowKind) map[RegionStatKind]float64 {
+	s.RLock()
+	defer s.RUnlock()
+	total := s.storeFlows[storeID]
+	ret := make(map[RegionStatKind]float64)
+	for _, k := range kind.builtinRegionStats() {
+		var rate float64
+		if int(k) < len(total) {
+			rate = total[k]
+		}
+		ret[k] = rate
+	}
+	return ret
+}
+
+// SetHotStoreThresholds sets the rates which make a store hot in the flow. The
+// thresholds are in the order of the built-in region statistics kinds of the
+// flow, i.e. ByteDim, KeyDim and QueryDim for the read and write flows. The
+// virtual QueryFlow shares the query thresholds of the read and write flows.
+func (s *StoreFlowStats) SetHotStoreThresholds(kind FlowKind, thresholds []float64) {
+	s.Lock()
+	defer s.Unlock()
+	for i, k := range kind.builtinRegionStats() {
+		var threshold float64
+		if i < len(thresholds) {
+			threshold = thresholds[i]
+		}
+		s.hotThresholds[k] = threshold
+	}
+}
+
+// GetHotStores returns the stores whose total rate of the leaders reaches the
+// threshold of any built-in region statistics kind of the flow, sorted by the
+// store ID. Only the rates of the flow are taken into account, so that a store
+// which is hot in reading is not taken as hot in writing.
+func (s *StoreFlowStats) GetHotStores(kind FlowKind) []uint64 {
+	s.RLock()
+	defer s.RUnlock()
+	var ret []uint64
+	for storeID, total := range s.storeFlows {
+		for _, k := range kind.builtinRegionStats() {
+			threshold := s.hotThresholds[k]
+			if threshold > 0 && int(k) < len(total) && total[k] >= threshold {
+				ret = append(ret, storeID)
+				break
+			}
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import "github.com/montanaflynn/stats"
+
+// DefaultTrendTolerance is the relative slope of the load within which the
+// load is taken as flat.
+const DefaultTrendTolerance = 0.05
+
+// Trend is the direction the load of a region moves in.
+type Trend int
+
+// Trends of the load.
+const (
+	TrendFlat Trend = iota
+	TrendIncreasing
+	TrendDecreasing
+)
+
+func (t Trend) String() string {
+	switch t {
+	case TrendFlat:
+		return "flat"
+	case TrendIncreasing:
+		return "increasing"
+	case TrendDecreasing:
+		return "decreasing"
+	}
+	return "unknown"
+}
+
+// trendOf returns the trend of the relative slope.
+func trendOf(slope, tolerance float64) Trend {
+	switch {
+	case slope > tolerance:
+		return TrendIncreasing
+	case slope < -tolerance:
+		return TrendDecreasing
+	}
+	return TrendFlat
+}
+
+// relativeSlope returns the slope of the samples divided by their median,
+// which is the change of the load per sample relative to the load. The slope
+// is the median of the slopes between each pair of the samples, so that a
+// spike of the load doesn't make a trend. It is 0 if there are fewer than 2
+// samples or the median is 0.
+func relativeSlope(samples []float64) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+	median, _ := stats.Median(samples)
+	if median <= 0 {
+		return 0
+	}
+	slopes := make([]float64, 0, len(samples)*(len(samples)-1)/2)
+	for i := range samples {
+		for j := i + 1; j < len(samples); j++ {
+			slopes = append(slopes, (samples[j]-samples[i])/float64(j-i))
+		}
+	}
+	slope, _ := stats.Median(slopes)
+	return slope / median
+}
@@ -505,7 +505,14 @@ func NewPlacementRulesCommand() *cobra.Command {
 	ruleBundleSave.Flags().String("in", "rules.json", "the file contains all group configs and all rules")
 	ruleBundleSave.Flags().Bool("partial", false, "do not drop all old configurations, partial update")
 	ruleBundle.AddCommand(ruleBundleGet, ruleBundleSet, ruleBundleDelete, ruleBundleLoad, ruleBundleSave)
-	c.AddCommand(enable, disable, show, load, save, ruleGroup, ruleBundle)
+	simulate := &cobra.Command{
+		Use:   "simulate",
+		Short: "estimate the effect of all group configs and rules from file on regions without saving them",
+		Run:   simulateRuleBundle,
+	}
+	simulate.Flags().String("in", "rules.json", "the file contains all group configs and all rules")
+	simulate.Flags().Bool("partial", false, "do not drop all old configurations, partial update")
+	c.AddCommand(enable, disable, show, load, save, ruleGroup, ruleBundle, simulate)
 	return c
 }
 
@@ -800,3 +807,28 @@ func saveRuleBundle(cmd *cobra.Command, args []string) {
 
 	cmd.Println(res)
 }
+
+func simulateRuleBundle(cmd *cobra.Command, args []string) {
+	var file string
+	if f := cmd.Flag("in"); f != nil {
+		file = f.Value.String()
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+
+	path := ruleBundlePrefix + "?simulate=true"
+	if ok, _ := cmd.Flags().GetBool("partial"); ok {
+		path += "&partial=true"
+	}
+
+	res, err := doRequest(cmd, path, http.MethodPost, WithBody("application/json", bytes.NewReader(content)))
+	if err != nil {
+		cmd.Printf("failed to simulate rule bundles %s: %s\n", content, err)
+		return
+	}
+
+	cmd.Println(res)
+}
@@ -133,10 +133,14 @@ func NewAddSchedulerCommand() *cobra.Command {
 	c.AddCommand(NewScatterRangeSchedulerCommand())
 	c.AddCommand(NewBalanceLeaderSchedulerCommand())
 	c.AddCommand(NewBalanceRegionSchedulerCommand())
+	c.AddCommand(NewBalanceRegionSizeSchedulerCommand())
+	c.AddCommand(NewBalanceLearnerSchedulerCommand())
 	c.AddCommand(NewBalanceHotRegionSchedulerCommand())
 	c.AddCommand(NewRandomMergeSchedulerCommand())
 	c.AddCommand(NewLabelSchedulerCommand())
 	c.AddCommand(NewEvictSlowStoreSchedulerCommand())
+	c.AddCommand(NewDrainStoreSchedulerCommand())
+	c.AddCommand(NewColocateLeaderSchedulerCommand())
 	return c
 }
 
@@ -153,13 +157,59 @@ func NewGrantLeaderSchedulerCommand() *cobra.Command {
 // NewEvictLeaderSchedulerCommand returns a command to add a evict-leader-scheduler.
 func NewEvictLeaderSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
-		Use:   "evict-leader-scheduler <store_id>",
+		Use:   "evict-leader-scheduler <store_id> [--timeout=<duration>]",
 		Short: "add a scheduler to evict leader from a store",
 		Run:   addSchedulerForStoreCommandFunc,
 	}
+	c.Flags().String("timeout", "", "stop evicting the store once it holds no leader or the timeout fires, such as 10m")
 	return c
 }
 
+// NewDrainStoreSchedulerCommand returns a command to add a drain-store-scheduler.
+func NewDrainStoreSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "drain-store-scheduler <store_id>",
+		Short: "add a scheduler to move all peers out of a store",
+		Run:   addSchedulerForStoreCommandFunc,
+	}
+	return c
+}
+
+// NewColocateLeaderSchedulerCommand returns a command to add a colocate-leader-scheduler.
+func NewColocateLeaderSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "colocate-leader-scheduler [--format=raw|encode|hex] [<start_key> <end_key>]",
+		Short: "add a scheduler to transfer the leaders of adjacent regions to the same store",
+		Run:   addColocateLeaderSchedulerCommandFunc,
+	}
+	c.Flags().String("format", "hex", "the key format")
+	return c
+}
+
+func addColocateLeaderSchedulerCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 && len(args) != 2 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	input := make(map[string]interface{})
+	input["name"] = cmd.Name()
+	if len(args) == 2 {
+		startKey, err := parseKey(cmd.Flags(), args[0])
+		if err != nil {
+			cmd.Println("Error: ", err)
+			return
+		}
+		endKey, err := parseKey(cmd.Flags(), args[1])
+		if err != nil {
+			cmd.Println("Error: ", err)
+			return
+		}
+		input["start_key"] = url.QueryEscape(startKey)
+		input["end_key"] = url.QueryEscape(endKey)
+	}
+	postJSON(cmd, schedulersPrefix, input)
+}
+
 func checkSchedulerExist(cmd *cobra.Command, schedulerName string) (bool, error) {
 	r, err := doRequest(cmd, schedulersPrefix, http.MethodGet)
 	if err != nil {
@@ -204,6 +254,9 @@ func addSchedulerForStoreCommandFunc(cmd *cobra.Command, args []string) {
 		input := make(map[string]interface{})
 		input["name"] = cmd.Name()
 		input["store_id"] = storeID
+		if timeout, _ := cmd.Flags().GetString("timeout"); timeout != "" {
+			input["timeout"] = timeout
+		}
 		postJSON(cmd, schedulersPrefix, input)
 	}
 }
@@ -288,6 +341,26 @@ func NewBalanceRegionSchedulerCommand() *cobra.Command {
 	return c
 }
 
+// NewBalanceRegionSizeSchedulerCommand returns a command to add a balance-region-size-scheduler.
+func NewBalanceRegionSizeSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "balance-region-size-scheduler",
+		Short: "add a scheduler to balance region sizes between stores",
+		Run:   addSchedulerCommandFunc,
+	}
+	return c
+}
+
+// NewBalanceLearnerSchedulerCommand returns a command to add a balance-learner-scheduler.
+func NewBalanceLearnerSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "balance-learner-scheduler",
+		Short: "add a scheduler to balance learners between stores",
+		Run:   addSchedulerCommandFunc,
+	}
+	return c
+}
+
 // NewBalanceHotRegionSchedulerCommand returns a command to add a balance-hot-region-scheduler.
 func NewBalanceHotRegionSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
@@ -413,6 +486,8 @@ func NewConfigSchedulerCommand() *cobra.Command {
 		newConfigGrantLeaderCommand(),
 		newConfigHotRegionCommand(),
 		newConfigShuffleRegionCommand(),
+		newConfigBalanceRegionSizeCommand(),
+		newConfigColocateLeaderCommand(),
 	)
 	return c
 }
@@ -434,6 +509,40 @@ func newConfigHotRegionCommand() *cobra.Command {
 	return c
 }
 
+func newConfigBalanceRegionSizeCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "balance-region-size-scheduler",
+		Short: "balance-region-size-scheduler config",
+		Run:   listSchedulerConfigCommandFunc,
+	}
+	c.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "list the config item",
+		Run:   listSchedulerConfigCommandFunc})
+	c.AddCommand(&cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "set the config item",
+		Run:   func(cmd *cobra.Command, args []string) { postSchedulerConfigCommandFunc(cmd, c.Name(), args) }})
+	return c
+}
+
+func newConfigColocateLeaderCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "colocate-leader-scheduler",
+		Short: "colocate-leader-scheduler config",
+		Run:   listSchedulerConfigCommandFunc,
+	}
+	c.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "list the config item",
+		Run:   listSchedulerConfigCommandFunc})
+	c.AddCommand(&cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "set the config item",
+		Run:   func(cmd *cobra.Command, args []string) { postSchedulerConfigCommandFunc(cmd, c.Name(), args) }})
+	return c
+}
+
 func newConfigEvictLeaderCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "evict-leader-scheduler",
@@ -501,6 +610,9 @@ func addStoreToSchedulerConfig(cmd *cobra.Command, schedulerName string, args []
 	input := make(map[string]interface{})
 	input["name"] = schedulerName
 	input["store_id"] = storeID
+	if timeout, _ := cmd.Flags().GetString("timeout"); timeout != "" {
+		input["timeout"] = timeout
+	}
 
 	postJSON(cmd, path.Join(schedulerConfigPrefix, schedulerName, "config"), input)
 }
@@ -565,6 +677,23 @@ func postSchedulerConfigCommandFunc(cmd *cobra.Command, schedulerName string, ar
 			cmd.Println("priorities shouldn't be repeated")
 			return
 		}
+	} else if schedulerName == "balance-hot-region-scheduler" && key == "rank-weights" {
+		// e.g. write_keys=2,write_bytes=1
+		weights := make(map[string]float64)
+		for _, item := range strings.Split(value, ",") {
+			kv := strings.SplitN(item, "=", 2)
+			if len(kv) != 2 {
+				cmd.Println("rank-weights should be in the form of kind=weight,kind=weight")
+				return
+			}
+			weight, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				cmd.Println(fmt.Sprintf("invalid weight of %s: %s", kv[0], kv[1]))
+				return
+			}
+			weights[kv[0]] = weight
+		}
+		input[key] = weights
 	} else {
 		input[key] = val
 	}